@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/fpl"
+	"github.com/mick4711/moh/negotiate"
+)
+
+// requests kept for the admin dashboard
+const recentRequestsKept = 100
+
+// access to /admin, set at startup. It needs basic auth with ADMIN_USER and ADMIN_PASSWORD and is 404 without them
+var adminAccess struct {
+	user     string
+	password string
+	refresh  []refreshSource // refreshed by the dashboard's refresh button
+}
+
+// the outcome messages of the dashboard actions by their ?done= value
+var adminDone = map[string]string{"refreshed": "Data refreshed", "cleared": "Cache cleared"}
+
+// a request in the admin dashboard's recent requests
+type requestRecord struct {
+	Time     time.Time     `json:"time"`
+	ID       string        `json:"id"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	Status   int           `json:"status"`
+	Duration time.Duration `json:"duration"`
+}
+
+// the most recent requests in a fixed size ring, safe for concurrent use
+type requestLog struct {
+	mu      sync.Mutex
+	records []requestRecord
+	next    int // where the next record goes once the ring is full
+}
+
+func newRequestLog(size int) *requestLog {
+	return &requestLog{records: make([]requestRecord, 0, size)}
+}
+
+// the requests recorded by the access log, unsampled
+var recentRequests = newRequestLog(recentRequestsKept)
+
+func (l *requestLog) add(record requestRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.records) < cap(l.records) {
+		l.records = append(l.records, record)
+		return
+	}
+
+	l.records[l.next] = record
+	l.next = (l.next + 1) % len(l.records)
+}
+
+// the recorded requests, most recent first
+func (l *requestLog) recent() []requestRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	recent := make([]requestRecord, 0, len(l.records))
+	for i := len(l.records) - 1; i >= 0; i-- {
+		recent = append(recent, l.records[(l.next+i)%len(l.records)])
+	}
+
+	return recent
+}
+
+// the admin dashboard
+type adminPage struct {
+	Version  string                   `json:"version"`
+	Done     string                   `json:"-"`
+	Quota    *cann.Quota              `json:"quota"` // nil until football-data reports it
+	Caches   map[string][]cache.Entry `json:"caches"`
+	Requests []requestRecord          `json:"requests"`
+}
+
+// shows the cache contents, the football-data quota and the recent requests, as json for json clients
+func adminHandler(w http.ResponseWriter, req *http.Request) {
+	if !adminAllowed(w, req) {
+		return
+	}
+
+	page := adminPage{Version: version, Done: adminDone[req.URL.Query().Get("done")], Quota: cann.UpstreamQuota(),
+		Caches: cann.CacheEntries(), Requests: recentRequests.recent()}
+	maps.Copy(page.Caches, fpl.CacheEntries())
+
+	w.Header().Set("Cache-Control", "no-store")
+
+	if negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			slog.WarnContext(req.Context(), "error writing admin dashboard", "err", err)
+		}
+
+		return
+	}
+
+	templ, err := pageTemplate(adminTemplate, "AdminTemplate.html")
+	if err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("admin dashboard unavailable, error reading admin template: %w", err))
+		return
+	}
+
+	var body bytes.Buffer
+	if err := templ.Execute(&body, page); err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("admin dashboard unavailable, error executing admin template: %w", err))
+		return
+	}
+
+	if _, err := body.WriteTo(w); err != nil {
+		slog.WarnContext(req.Context(), "error writing admin dashboard", "err", err)
+	}
+}
+
+// fetches the standings and FPL points again now, as the background refresher does
+func adminRefreshHandler(w http.ResponseWriter, req *http.Request) {
+	if !adminAllowed(w, req) || !adminSameOrigin(w, req) {
+		return
+	}
+
+	var errs []error
+
+	for _, source := range adminAccess.refresh {
+		if _, err := source.refresh(req.Context()); err != nil {
+			errs = append(errs, fmt.Errorf("%s refresh failed: %w", source.event, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		errorpage.Write(w, req, http.StatusBadGateway, err)
+		return
+	}
+
+	slog.InfoContext(req.Context(), "data refreshed from the admin dashboard")
+	http.Redirect(w, req, "/admin?done=refreshed", http.StatusSeeOther)
+}
+
+// empties the standings and FPL caches so the next requests fetch from the upstream apis
+func adminClearCacheHandler(w http.ResponseWriter, req *http.Request) {
+	if !adminAllowed(w, req) || !adminSameOrigin(w, req) {
+		return
+	}
+
+	cann.ClearCache()
+	fpl.ClearCache()
+
+	slog.InfoContext(req.Context(), "cache cleared from the admin dashboard")
+	http.Redirect(w, req, "/admin?done=cleared", http.StatusSeeOther)
+}
+
+// true for requests with the admin credentials, otherwise writes a 404 when the dashboard is off or a 401
+// asking for them
+func adminAllowed(w http.ResponseWriter, req *http.Request) bool {
+	if adminAccess.password == "" {
+		http.NotFound(w, req)
+		return false
+	}
+
+	user, password, ok := req.BasicAuth()
+	if ok && subtle.ConstantTimeCompare([]byte(user), []byte(adminAccess.user)) == 1 &&
+		subtle.ConstantTimeCompare([]byte(password), []byte(adminAccess.password)) == 1 {
+		return true
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="moh admin", charset="UTF-8"`)
+	errorpage.Write(w, req, http.StatusUnauthorized, errors.New("the admin user name and password are required"))
+
+	return false
+}
+
+// true unless the browser says the request came from another site, so a page elsewhere can't post to the
+// dashboard with the remembered credentials. Otherwise writes a 403
+func adminSameOrigin(w http.ResponseWriter, req *http.Request) bool {
+	site := req.Header.Get("Sec-Fetch-Site")
+	if site == "same-origin" || site == "none" {
+		return true
+	}
+
+	origin := req.Header.Get("Origin")
+	if site == "" && origin == "" {
+		return true // not sent by a browser
+	}
+
+	if parsed, err := url.Parse(origin); site == "" && err == nil && parsed.Host == req.Host {
+		return true
+	}
+
+	errorpage.Write(w, req, http.StatusForbidden, errors.New("admin actions must be posted from the admin dashboard"))
+
+	return false
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/cann"
+)
+
+func TestRequestLog(t *testing.T) {
+	log := newRequestLog(3)
+
+	if got := log.recent(); len(got) != 0 {
+		t.Errorf("recent() on an empty log = %v, want none", got)
+	}
+
+	for status := 201; status <= 205; status++ {
+		log.add(requestRecord{Status: status})
+	}
+
+	var got []int
+	for _, record := range log.recent() {
+		got = append(got, record.Status)
+	}
+
+	if want := []int{205, 204, 203}; !reflect.DeepEqual(got, want) {
+		t.Errorf("recent() statuses = %v, want the last 3 most recent first %v", got, want)
+	}
+}
+
+func TestAdminDashboard(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	standings, err := os.ReadFile("cann/standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Requests-Available-Minute", "9")
+		w.Header().Set("X-RequestCounter-Reset", "42")
+		_, _ = w.Write(standings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	cann.Configure(cann.Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second})
+
+	saved := adminAccess
+	defer func() { adminAccess = saved }()
+
+	adminAccess.user, adminAccess.password = "mick", "secret"
+	adminAccess.refresh = []refreshSource{{event: "standings", refresh: func(ctx context.Context) (string, error) {
+		return cann.Refresh(ctx, "PL")
+	}}}
+
+	tests := []struct {
+		name       string
+		method     string
+		url        string
+		password   string
+		site       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{"no credentials", http.MethodGet, "/admin", "", "", adminHandler, http.StatusUnauthorized, "user name and password"},
+		{"wrong password", http.MethodGet, "/admin", "guess", "", adminHandler, http.StatusUnauthorized, "user name and password"},
+		{"refresh", http.MethodPost, "/admin/refresh", "secret", "same-origin", adminRefreshHandler, http.StatusSeeOther, ""},
+		{"dashboard", http.MethodGet, "/admin?done=refreshed", "secret", "", adminHandler, http.StatusOK,
+			"<p><strong>Data refreshed</strong></p>"},
+		{"cached standings", http.MethodGet, "/admin", "secret", "", adminHandler, http.StatusOK,
+			"<td>" + ts.URL + "/competitions/PL/standings</td>"},
+		{"quota", http.MethodGet, "/admin", "secret", "", adminHandler, http.StatusOK, "9 requests available"},
+		{"json", http.MethodGet, "/admin?format=json", "secret", "", adminHandler, http.StatusOK, `"quota":{"available":9`},
+		{"cross site clear", http.MethodPost, "/admin/clear-cache", "secret", "cross-site", adminClearCacheHandler, http.StatusForbidden, ""},
+		{"clear", http.MethodPost, "/admin/clear-cache", "secret", "same-origin", adminClearCacheHandler, http.StatusSeeOther, ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.url, http.NoBody)
+		if test.password != "" {
+			req.SetBasicAuth("mick", test.password)
+		}
+
+		if test.site != "" {
+			req.Header.Set("Sec-Fetch-Site", test.site)
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		test.handler(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus || !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("%s: %s %s status = %d body = %s, want %d with %s", test.name, test.method, test.url, w.Code, w.Body, test.wantStatus, test.wantBody)
+		}
+	}
+
+	if entries := cann.CacheEntries()["standings"]; len(entries) != 0 {
+		t.Errorf("standings cache after clearing = %+v, want empty", entries)
+	}
+}
+
+func TestAdminSameOrigin(t *testing.T) {
+	tests := []struct {
+		site   string
+		origin string
+		want   bool
+	}{
+		{"", "", true},
+		{"same-origin", "", true},
+		{"none", "", true},
+		{"cross-site", "", false},
+		{"same-site", "", false},
+		{"", "http://example.com", true},
+		{"", "https://attacker.example", false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/admin/refresh", http.NoBody)
+		req.Header.Set("Sec-Fetch-Site", test.site)
+		req.Header.Set("Origin", test.origin)
+
+		if got := adminSameOrigin(httptest.NewRecorder(), req); got != test.want {
+			t.Errorf("adminSameOrigin(Sec-Fetch-Site %q, Origin %q) = %t, want %t", test.site, test.origin, got, test.want)
+		}
+	}
+}
+
+func TestAdminOff(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/admin", http.NoBody)
+	req.SetBasicAuth("", "")
+
+	w := httptest.NewRecorder()
+	adminHandler(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("adminHandler() without ADMIN_PASSWORD status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/fpl"
+)
+
+// upstream data sources of the pages
+const (
+	sourceFootballData = "football-data"
+	sourceFPL          = "fpl"
+	sourceLocal        = "local" // served from configuration, always available
+)
+
+// a page in the /api listing
+type apiRoute struct {
+	Path    string   `json:"path"`
+	Title   string   `json:"title"`
+	Params  []string `json:"params"`
+	Source  string   `json:"source"`
+	Healthy bool     `json:"healthy"` // the last fetch from the source succeeded
+}
+
+// query parameters and data source of each page, for the /api listing and the OpenAPI document
+var apiDescriptions = map[string]struct {
+	params []string
+	source string
+}{
+	"/cann":          {cann.QueryParams(), sourceFootballData},
+	"/cann/gaps":     {[]string{"comp"}, sourceFootballData},
+	"/cann.svg":      {[]string{"a11y", "comp", "rowsort", "teams", "theme"}, sourceFootballData},
+	"/compare":       {[]string{"away", "comp", "format", "home"}, sourceFootballData},
+	"/fixtures":      {[]string{"comp", "format", "projection", "results"}, sourceFootballData},
+	"/fixtures.ics":  {[]string{"comp", "team"}, sourceFootballData},
+	"/fpl":           {[]string{"fields", "format", "league", "page", "pageSize"}, sourceFPL},
+	"/fpl/bootstrap": {nil, sourceFPL},
+	"/fpl/live":      {[]string{"league"}, sourceFPL},
+	"/fpl/summary":   {[]string{"league"}, sourceFPL},
+	"/huxley":        {[]string{"format"}, sourceLocal},
+	"/scorers":       {[]string{"comp", "format", "sort"}, sourceFootballData},
+}
+
+// lists the pages linked from the home page with their query parameters and source health as json.
+// Health comes from the recorded fetch outcomes, the upstreams aren't called
+func apiHandler(w http.ResponseWriter, req *http.Request) {
+	footballData, fplStatus := cann.UpstreamStatus(), fpl.UpstreamStatus()
+	healthy := map[string]bool{sourceFootballData: footballData.Healthy, sourceFPL: fplStatus.Healthy, sourceLocal: true}
+
+	routes := make([]apiRoute, 0, len(homeLinks))
+	for _, link := range homeLinks {
+		description := apiDescriptions[link.URL]
+		routes = append(routes, apiRoute{Path: link.URL, Title: link.Title, Params: description.params,
+			Source: description.source, Healthy: healthy[description.source]})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	response := map[string]any{"routes": routes, "sources": map[string]any{sourceFootballData: footballData, sourceFPL: fplStatus}}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.WarnContext(req.Context(), "error writing api response", "err", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/mick4711/moh/config"
+)
+
+func TestAPIHandler(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	homeLinks = linksFor(enabledRoutes(config.Load()))
+	defer func() { homeLinks = nil }()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	apiHandler(w, httptest.NewRequest(http.MethodGet, "/api", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var got struct {
+		Routes []map[string]json.RawMessage `json:"routes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("apiHandler() body %q, err = %v", w.Body, err)
+	}
+
+	want := map[string]string{"/cann": `"football-data"`, "/cann.svg": `"football-data"`, "/compare": `"football-data"`, "/fixtures": `"football-data"`, "/fixtures.ics": `"football-data"`, "/scorers": `"football-data"`, "/fpl": `"fpl"`, "/fpl/live": `"fpl"`, "/fpl/summary": `"fpl"`, "/huxley": `"local"`}
+	if len(got.Routes) != len(want) {
+		t.Fatalf("apiHandler() routes = %d, want %d", len(got.Routes), len(want))
+	}
+
+	for _, route := range got.Routes {
+		var path string
+		if err := json.Unmarshal(route["path"], &path); err != nil {
+			t.Fatal(err)
+		}
+
+		if string(route["source"]) != want[path] {
+			t.Errorf("apiHandler() %s source = %s, want %s", path, route["source"], want[path])
+		}
+
+		if healthy := string(route["healthy"]); healthy != "true" && healthy != "false" {
+			t.Errorf("apiHandler() %s healthy = %q, want a boolean", path, healthy)
+		}
+
+		var params []string
+		if err := json.Unmarshal(route["params"], &params); err != nil || len(params) == 0 {
+			t.Errorf("apiHandler() %s params = %s, want its query parameters", path, route["params"])
+		}
+
+		if path == "/cann" && !slices.Contains(params, "comp") {
+			t.Errorf("apiHandler() /cann params = %v, want comp listed", params)
+		}
+	}
+}
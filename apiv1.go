@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// path prefix of the versioned json api
+const apiV1Prefix = "/api/v1"
+
+// OpenAPI summary of each page served as json under /api/v1, keyed by the page path
+var apiV1Summaries = map[string]string{
+	"/cann":          "Cann table, teams grouped by points",
+	"/cann/gaps":     "Points gap of each team to the team above it",
+	"/compare":       "Head to head comparison of two teams",
+	"/fixtures":      "Upcoming fixtures with the points at stake",
+	"/fpl":           "FPL managers' gameweek points",
+	"/fpl/bootstrap": "FPL reference data",
+	"/fpl/live":      "FPL managers' provisional live points",
+	"/fpl/summary":   "FPL managers' captains, chips and transfers",
+	"/scorers":       "Top scorers",
+}
+
+// serves the page's json under /api/v1 whatever the Accept header or ?format=, so errors have the
+// {"status", "title", "error"} envelope of errorpage too
+func apiV1(page http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept", "application/json")
+
+		query := req.URL.Query()
+		query.Set("format", "json")
+		req.URL.RawQuery = query.Encode()
+
+		page(w, req)
+	}
+}
+
+// the OpenAPI document of the enabled /api/v1 routes, set at startup
+var openAPIDocument []byte
+
+// an OpenAPI 3 document, only the parts the api uses
+type openAPI struct {
+	OpenAPI    string                         `json:"openapi"`
+	Info       openAPIInfo                    `json:"info"`
+	Paths      map[string]map[string]apiOp    `json:"paths"`
+	Components map[string]map[string]apiValue `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type apiOp struct {
+	Summary    string              `json:"summary"`
+	Parameters []apiParam          `json:"parameters,omitempty"`
+	Responses  map[string]apiValue `json:"responses"`
+}
+
+type apiParam struct {
+	Name   string   `json:"name"`
+	In     string   `json:"in"`
+	Schema apiValue `json:"schema"`
+}
+
+// a free form part of the document, e.g. a schema or response
+type apiValue map[string]any
+
+// the OpenAPI document of the enabled /api/v1 routes with their query parameters, every route responds with json
+// or the error envelope
+func newOpenAPIDocument(enabled []route) ([]byte, error) {
+	errorResponse := apiValue{"description": "error", "content": apiValue{"application/json": apiValue{"schema": apiValue{"$ref": "#/components/schemas/Error"}}}}
+
+	document := openAPI{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "moh", Version: "1"},
+		Paths:   map[string]map[string]apiOp{},
+		Components: map[string]map[string]apiValue{"schemas": {"Error": {
+			"type":     "object",
+			"required": []string{"status", "title", "error"},
+			"properties": apiValue{
+				"status": apiValue{"type": "integer"},
+				"title":  apiValue{"type": "string", "description": "the status text e.g. Bad Request"},
+				"error":  apiValue{"type": "string"},
+			},
+		}}},
+	}
+
+	for _, r := range enabled {
+		page, ok := strings.CutPrefix(r.path(), apiV1Prefix)
+		summary, described := apiV1Summaries[page]
+
+		if !ok || !described {
+			continue
+		}
+
+		op := apiOp{Summary: summary, Responses: map[string]apiValue{
+			"200":     {"description": "json", "content": apiValue{"application/json": apiValue{}}},
+			"default": errorResponse,
+		}}
+
+		for _, name := range apiDescriptions[page].params {
+			if name != "format" {
+				op.Parameters = append(op.Parameters, apiParam{Name: name, In: "query", Schema: apiValue{"type": "string"}})
+			}
+		}
+
+		document.Paths[r.path()] = map[string]apiOp{"get": op}
+	}
+
+	return json.MarshalIndent(document, "", "  ") //nolint:wrapcheck // the document is static
+}
+
+// serves the OpenAPI document of the /api/v1 routes
+func openAPIHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(openAPIDocument); err != nil {
+		slog.WarnContext(req.Context(), "error writing openapi document", "err", err)
+	}
+}
+
+// whether the url path is in the versioned json api
+func isAPIV1(path string) bool {
+	return path == apiV1Prefix || strings.HasPrefix(path, apiV1Prefix+"/")
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mick4711/moh/config"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/negotiate"
+)
+
+func TestAPIV1(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	page := apiV1(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("fail") == "1" {
+			errorpage.Write(w, req, http.StatusBadRequest, errors.New("invalid fail"))
+			return
+		}
+
+		_, _ = w.Write([]byte(negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) + " " + req.URL.RawQuery)) //nolint:errcheck // test handler
+	})
+
+	tests := []struct {
+		url      string
+		accept   string
+		wantCode int
+		wantBody string
+	}{
+		{"/api/v1/cann?comp=SA", "text/html", http.StatusOK, "json comp=SA&format=json"},
+		{"/api/v1/cann?format=html", "", http.StatusOK, "json format=json"},
+		{"/api/v1/cann?fail=1", "text/html", http.StatusBadRequest, `{"status":400,"title":"Bad Request","error":"invalid fail"}`},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+		req.Header.Set("Accept", test.accept)
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		page(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantCode || strings.TrimSpace(w.Body.String()) != test.wantBody {
+			t.Errorf("apiV1(%s) = %d %q, want %d %q", test.url, w.Code, w.Body, test.wantCode, test.wantBody)
+		}
+
+		if got := req.URL.RawQuery; strings.Contains(got, "format=json") {
+			t.Errorf("apiV1(%s) changed the caller's request query to %q", test.url, got)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	t.Setenv("DISABLED_ROUTES", "/api/v1/fpl/bootstrap")
+
+	document, err := newOpenAPIDocument(enabledRoutes(config.Load()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	openAPIDocument = document
+	defer func() { openAPIDocument = nil }()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	openAPIHandler(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var got struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Get struct {
+				Summary    string `json:"summary"`
+				Parameters []struct {
+					Name string `json:"name"`
+				} `json:"parameters"`
+				Responses map[string]json.RawMessage `json:"responses"`
+			} `json:"get"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.OpenAPI != "3.0.3" {
+		t.Fatalf("openAPIHandler() body %q, err = %v", w.Body, err)
+	}
+
+	want := []string{"/api/v1/cann", "/api/v1/cann/gaps", "/api/v1/compare", "/api/v1/fixtures", "/api/v1/fpl", "/api/v1/fpl/live",
+		"/api/v1/fpl/summary", "/api/v1/scorers"}
+	paths := make([]string, 0, len(got.Paths))
+	for path := range got.Paths {
+		paths = append(paths, path)
+	}
+
+	if slices.Sort(paths); !slices.Equal(paths, want) {
+		t.Errorf("openAPIHandler() paths = %v, want the enabled /api/v1 routes %v", paths, want)
+	}
+
+	scorers := got.Paths["/api/v1/scorers"].Get
+	names := make([]string, 0, len(scorers.Parameters))
+	for _, param := range scorers.Parameters {
+		names = append(names, param.Name)
+	}
+
+	if !slices.Equal(names, []string{"comp", "sort"}) || scorers.Summary != "Top scorers" || scorers.Responses["default"] == nil {
+		t.Errorf("openAPIHandler() /api/v1/scorers = %+v, want comp and sort parameters and the error response", scorers)
+	}
+}
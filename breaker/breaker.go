@@ -0,0 +1,114 @@
+// circuit breaker for upstream apis, after repeated failures requests are refused for a cooldown
+// instead of waiting on an api that is down, then a single trial request decides whether to close again
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+const (
+	DefaultThreshold = 5
+	DefaultCooldown  = 30 * time.Second
+)
+
+// ErrOpen is returned by Allow while the circuit is open
+var ErrOpen = errors.New("circuit open, upstream failing")
+
+type state int
+
+const (
+	closed state = iota
+	open
+	halfOpen // cooldown over, one trial request is in flight
+)
+
+// the circuit state name reported by State
+func (s state) String() string {
+	switch s {
+	case open:
+		return "open"
+	case halfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// A Breaker opens after threshold consecutive failures and refuses requests until the cooldown has passed,
+// safe for concurrent use
+type Breaker struct {
+	mu        sync.Mutex
+	clk       clock.Clock
+	threshold int
+	cooldown  time.Duration
+	state     state
+	failures  int
+	openedAt  time.Time
+}
+
+// New returns a closed breaker, the defaults are used for a threshold or cooldown that isn't positive
+func New(threshold int, cooldown time.Duration, clk clock.Clock) *Breaker {
+	if threshold < 1 {
+		threshold = DefaultThreshold
+	}
+
+	if cooldown <= 0 {
+		cooldown = DefaultCooldown
+	}
+
+	if clk == nil {
+		clk = clock.Real{}
+	}
+
+	return &Breaker{clk: clk, threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports whether a request may be sent, ErrOpen while open. Once the cooldown has passed one trial
+// request is allowed, the others are refused until its outcome is recorded
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case open:
+		if clock.Since(b.clk, b.openedAt) < b.cooldown {
+			return ErrOpen
+		}
+
+		b.state = halfOpen
+
+		return nil
+	case halfOpen:
+		return ErrOpen
+	default:
+		return nil
+	}
+}
+
+// Record the outcome of an allowed request, a success closes the circuit, a failed trial reopens it
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		b.state, b.failures = closed, 0
+		return
+	}
+
+	b.failures++
+	if b.state == halfOpen || b.failures >= b.threshold {
+		b.state, b.openedAt = open, b.clk.Now()
+	}
+}
+
+// State is "closed", "open" or "half-open" while a trial request is in flight, for health reporting
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state.String()
+}
@@ -0,0 +1,80 @@
+package breaker
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestBreaker(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fake := clock.NewFake(time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC))
+	b := New(3, time.Minute, fake)
+
+	steps := []struct {
+		name      string
+		advance   time.Duration
+		record    []bool // outcomes recorded before checking Allow
+		wantAllow bool
+	}{
+		{"closed", 0, nil, true},
+		{"below the threshold", 0, []bool{true, true}, true},
+		{"success resets the count", 0, []bool{false, true, true}, true},
+		{"threshold reached", 0, []bool{true}, false},
+		{"within the cooldown", 59 * time.Second, nil, false},
+		{"trial after the cooldown", time.Second, nil, true},
+		{"one trial at a time", 0, nil, false},
+		{"failed trial reopens", 0, []bool{true}, false},
+		{"second trial", time.Minute, nil, true},
+		{"successful trial closes", 0, []bool{false}, true},
+	}
+
+	for _, step := range steps {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		fake.Advance(step.advance)
+
+		for _, failed := range step.record {
+			b.Record(failed)
+		}
+
+		err := b.Allow()
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if allowed := err == nil; allowed != step.wantAllow {
+			t.Fatalf("%s: Allow() = %v, want allowed %t", step.name, err, step.wantAllow)
+		}
+
+		if err != nil && !errors.Is(err, ErrOpen) {
+			t.Errorf("%s: Allow() = %v, want ErrOpen", step.name, err)
+		}
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	b := New(0, 0, nil)
+
+	if b.threshold != DefaultThreshold || b.cooldown != DefaultCooldown {
+		t.Errorf("New(0, 0, nil) threshold, cooldown = %d, %s, want %d, %s", b.threshold, b.cooldown, DefaultThreshold, DefaultCooldown)
+	}
+}
+
+func TestState(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC))
+	b := New(1, time.Minute, fake)
+
+	states := []string{b.State()}
+
+	b.Record(true)
+	states = append(states, b.State())
+
+	fake.Advance(time.Minute)
+	_ = b.Allow() //nolint:errcheck // the trial request
+	states = append(states, b.State())
+
+	if want := []string{"closed", "open", "half-open"}; strings.Join(states, ",") != strings.Join(want, ",") {
+		t.Errorf("State() = %v, want %v", states, want)
+	}
+}
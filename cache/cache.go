@@ -0,0 +1,169 @@
+// in-memory cache of upstream response bodies keyed by request parameters.
+// The number of entries is capped, once the cap is reached the least-recently-used entry is evicted.
+package cache
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+const DefaultMaxEntries = 32
+
+// Stats contains the cache counters reported by the debug endpoint
+type Stats struct {
+	Entries    int   `json:"entries"`
+	MaxEntries int   `json:"maxEntries"`
+	Hits       int64 `json:"hits"`
+	Misses     int64 `json:"misses"`
+	Evictions  int64 `json:"evictions"`
+}
+
+// An Entry describes a cached value for the admin dashboard
+type Entry struct {
+	Key        string        `json:"key"`
+	Size       int           `json:"size"` // bytes
+	Age        time.Duration `json:"-"`
+	AgeSeconds int           `json:"ageSeconds"`
+	Expired    bool          `json:"expired"` // older than the ttl, only served as a stale copy
+}
+
+// A Cache holds response bodies for a fixed time-to-live, safe for concurrent use
+type Cache struct {
+	mu         sync.Mutex
+	clock      clock.Clock
+	ttl        time.Duration
+	maxEntries int
+	items      map[string]*entry
+	uses       int64 // incremented on every access, used to find the least-recently-used entry
+	stats      Stats
+}
+
+type entry struct {
+	value    []byte
+	fetched  time.Time
+	lastUsed int64
+}
+
+// New returns an empty cache, a maxEntries value < 1 means the default cap is used
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return NewWithClock(ttl, maxEntries, clock.Real{})
+}
+
+// NewWithClock returns an empty cache that ages its entries by clk
+func NewWithClock(ttl time.Duration, maxEntries int, clk clock.Clock) *Cache {
+	if maxEntries < 1 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	return &Cache{
+		clock:      clk,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		items:      make(map[string]*entry),
+	}
+}
+
+// Get returns the value for key if present and younger than the ttl
+func (c *Cache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok || clock.Since(c.clock, item.fetched) > c.ttl {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.uses++
+	item.lastUsed = c.uses
+	c.stats.Hits++
+
+	return item.value, true
+}
+
+// GetStale returns the value for key and when it was stored, regardless of the ttl
+func (c *Cache) GetStale(key string) ([]byte, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+
+	c.uses++
+	item.lastUsed = c.uses
+
+	return item.value, item.fetched, true
+}
+
+// Set stores value for key, evicting the least-recently-used entry if the cache is full
+func (c *Cache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[key]; !ok && len(c.items) >= c.maxEntries {
+		c.evictOldest()
+	}
+
+	c.uses++
+	c.items[key] = &entry{value: value, fetched: c.clock.Now(), lastUsed: c.uses}
+}
+
+// remove the least-recently-used entry, caller must hold the lock
+func (c *Cache) evictOldest() {
+	var (
+		oldestKey  string
+		oldestUsed int64 = -1
+	)
+
+	for key, item := range c.items {
+		if oldestUsed < 0 || item.lastUsed < oldestUsed {
+			oldestKey, oldestUsed = key, item.lastUsed
+		}
+	}
+
+	delete(c.items, oldestKey)
+	c.stats.Evictions++
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.items)
+	stats.MaxEntries = c.maxEntries
+
+	return stats
+}
+
+// Entries describes the cached values ordered by key, without counting as uses
+func (c *Cache) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := make([]Entry, 0, len(c.items))
+
+	for key, item := range c.items {
+		age := clock.Since(c.clock, item.fetched).Round(time.Second)
+		entries = append(entries, Entry{Key: key, Size: len(item.value), Age: age, AgeSeconds: int(age.Seconds()), Expired: age > c.ttl})
+	}
+
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.Key, b.Key) })
+
+	return entries
+}
+
+// Clear removes every entry, the counters are kept
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.items)
+}
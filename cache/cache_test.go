@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	c := New(time.Minute, 2)
+	c.Set("a", []byte("1"))
+	c.Set("b", []byte("2"))
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	c.Set("c", []byte("3"))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if _, ok := c.Get("a"); ok {
+		t.Error(`Get("a") found, want oldest entry evicted`)
+	}
+
+	for _, key := range []string{"b", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf(`Get(%q) not found, want cached`, key)
+		}
+	}
+
+	if stats := c.Stats(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("Stats() = %+v, want 1 eviction and 2 entries", stats)
+	}
+}
+
+func TestGetRefreshesRecency(t *testing.T) {
+	c := New(time.Minute, 2)
+	c.Set("a", []byte("1"))
+	c.Set("b", []byte("2"))
+	c.Get("a") // "b" is now the least recently used
+	c.Set("c", []byte("3"))
+
+	if _, ok := c.Get("b"); ok {
+		t.Error(`Get("b") found, want least-recently-used entry evicted`)
+	}
+
+	if _, ok := c.Get("a"); !ok {
+		t.Error(`Get("a") not found, want recently used entry kept`)
+	}
+}
+
+func TestGetExpired(t *testing.T) {
+	c := New(-time.Second, 2)
+	c.Set("a", []byte("1"))
+
+	if _, ok := c.Get("a"); ok {
+		t.Error(`Get("a") found, want expired entry treated as a miss`)
+	}
+
+	if stats := c.Stats(); stats.Misses != 1 {
+		t.Errorf("Stats().Misses = %v, want 1", stats.Misses)
+	}
+}
+
+func TestGetStale(t *testing.T) {
+	c := New(-time.Second, 2)
+	c.Set("a", []byte("1"))
+
+	if value, fetched, ok := c.GetStale("a"); !ok || string(value) != "1" || fetched.IsZero() {
+		t.Errorf(`GetStale("a") = %q, %v, %v, want expired value with its fetch time`, value, fetched, ok)
+	}
+
+	if _, _, ok := c.GetStale("b"); ok {
+		t.Error(`GetStale("b") found, want missing key not found`)
+	}
+}
+
+func TestGetExpiresOnClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC))
+	c := NewWithClock(time.Minute, 2, fake)
+	c.Set("a", []byte("1"))
+
+	fake.Advance(time.Minute)
+
+	if _, ok := c.Get("a"); !ok {
+		t.Error(`Get("a") at the ttl not found, want it cached`)
+	}
+
+	fake.Advance(time.Second)
+
+	if _, ok := c.Get("a"); ok {
+		t.Error(`Get("a") past the ttl found, want it expired`)
+	}
+}
+
+func TestEntriesAndClear(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fake := clock.NewFake(time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC))
+	c := NewWithClock(time.Minute, 4, fake)
+	c.Set("b", []byte("old"))
+	fake.Advance(90 * time.Second)
+	c.Set("a", []byte("new value"))
+	fake.Advance(10 * time.Second)
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := c.Entries()
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := []Entry{{Key: "a", Size: 9, Age: 10 * time.Second, AgeSeconds: 10}, {Key: "b", Size: 3, Age: 100 * time.Second, AgeSeconds: 100, Expired: true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() = %+v, want %+v", got, want)
+	}
+
+	if body, err := json.Marshal(got[0]); err != nil || string(body) != `{"key":"a","size":9,"ageSeconds":10,"expired":false}` {
+		t.Errorf("Entries() json = %s, %v, want the age in seconds", body, err)
+	}
+
+	c.Clear()
+
+	if entries, stats := c.Entries(), c.Stats(); len(entries) != 0 || stats.Entries != 0 {
+		t.Errorf("after Clear() Entries() = %+v, Stats() = %+v, want none", entries, stats)
+	}
+}
@@ -0,0 +1,312 @@
+package cann
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// An AlertRule fires when the team, by TLA, moves into the zone between standings fetches
+type AlertRule struct {
+	Team string `json:"team"`
+	Zone Zone   `json:"zone"`
+}
+
+// An Alert is a team of interest moving into a zone, posted to the webhooks
+type Alert struct {
+	TeamID           int    `json:"teamId"`
+	Team             string `json:"team"`
+	TLA              string `json:"tla"`
+	Zone             Zone   `json:"zone"`
+	PreviousZone     Zone   `json:"previousZone"`
+	Position         int    `json:"position"`
+	PreviousPosition int    `json:"previousPosition"`
+	Points           Points `json:"points"`
+}
+
+// An alertTarget is a webhook the alerts are posted to, in the payload its chat service expects
+type alertTarget struct {
+	format string // slack, discord, telegram or webhook
+	url    string
+	chatID string // telegram chat the bot posts to, taken from the url's chat_id
+}
+
+// the payload formats named by an ALERT_WEBHOOKS prefix e.g. "discord=https://...", without one the generic webhook
+// json is posted
+var alertFormats = []string{"slack", "discord", "telegram"}
+
+// the configured alert rules and the webhooks they are posted to, set by Configure
+var (
+	alertRules   []AlertRule
+	alertTargets []alertTarget
+)
+
+// the zone and league position of each team of interest last seen for each target url keyed by team ID, so
+// fetches seeing the same standings again don't repeat an alert. Teams are recorded silently the first time
+// they're seen. Reset by Configure
+var alerted = struct {
+	sync.Mutex
+	teams map[string]map[int]alertedTeam
+}{teams: make(map[string]map[int]alertedTeam)}
+
+// where a team of interest was when it was last seen for a target
+type alertedTeam struct {
+	zone     Zone
+	position int
+}
+
+// webhooks in flight, they aren't tied to the request that fetched the standings
+var alerting sync.WaitGroup
+
+// parse the teams of interest and the zones to alert on from ALERT_TEAMS e.g. [{"team": "TOT", "zone": "relegation"}]
+func parseAlertRules(value string) []AlertRule {
+	if value == "" {
+		return nil
+	}
+
+	var rules []AlertRule
+	if err := json.Unmarshal([]byte(value), &rules); err != nil {
+		slog.Warn("invalid ALERT_TEAMS ignored", "err", err)
+		return nil
+	}
+
+	return rules
+}
+
+// parse an ALERT_WEBHOOKS entry, an http(s) url optionally prefixed with its payload format. Telegram urls are the
+// bot's sendMessage method with the chat_id parameter
+func parseAlertTarget(entry string) (alertTarget, error) {
+	target := alertTarget{format: "webhook", url: entry}
+
+	if format, rest, ok := strings.Cut(entry, "="); ok && slices.Contains(alertFormats, format) {
+		target.format, target.url = format, rest
+	}
+
+	parsed, err := url.Parse(target.url)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return alertTarget{}, fmt.Errorf("invalid alert webhook %q, must be an http or https url", redactURL(target.url))
+	}
+
+	if target.format == "telegram" {
+		query := parsed.Query()
+		if target.chatID = query.Get("chat_id"); target.chatID == "" {
+			return alertTarget{}, fmt.Errorf("telegram webhook %q needs a chat_id parameter", redactURL(target.url))
+		}
+
+		query.Del("chat_id")
+		parsed.RawQuery = query.Encode()
+		target.url = parsed.String()
+	}
+
+	return target, nil
+}
+
+// the scheme and host of a webhook url for errors, the path and query hold its secret
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "[REDACTED]"
+	}
+
+	return parsed.Scheme + "://" + parsed.Host + "/[REDACTED]"
+}
+
+// set the alert rules and parse the webhooks, invalid webhooks are logged and left out
+func configureAlerts(teams string, webhooks []string) {
+	alertRules = parseAlertRules(teams)
+	alertTargets = nil
+
+	for _, webhook := range webhooks {
+		target, err := parseAlertTarget(webhook)
+		if err != nil {
+			slog.Warn("ALERT_WEBHOOKS entry ignored", "err", err)
+			continue
+		}
+
+		alertTargets = append(alertTargets, target)
+	}
+
+	alerted.Lock()
+	alerted.teams = make(map[string]map[int]alertedTeam)
+	alerted.Unlock()
+}
+
+// post the teams of interest that moved into a rule's zone of the competition since they were last seen to each
+// target, in the background, best effort. Failed alerts are sent again on the next fetch that still sees the move
+func checkAlerts(comp string, standings []byte) {
+	if len(alertTargets) == 0 || len(alertRules) == 0 {
+		return
+	}
+
+	table, err := parseStandings(standings)
+	if err != nil {
+		return
+	}
+
+	for _, target := range alertTargets {
+		alerts := zoneTransitions(comp, target.url, table, alertRules)
+		if len(alerts) == 0 {
+			continue
+		}
+
+		alerting.Add(1)
+
+		go func() {
+			defer alerting.Done()
+
+			if err := postAlerts(context.Background(), target, alerts); err != nil {
+				slog.Warn("alert webhook failed", "format", target.format, "err", err)
+				forgetAlerts(target.url, alerts)
+			}
+		}()
+	}
+}
+
+// alerts for the teams of interest that moved into a rule's zone of the competition since they were last seen for
+// the target url, recorded as seen now so a fetch while they're posted doesn't send them again
+func zoneTransitions(comp, targetURL string, table []TableRow, rules []AlertRule) []Alert {
+	alerted.Lock()
+	defer alerted.Unlock()
+
+	teams, ok := alerted.teams[targetURL]
+	if !ok {
+		teams = make(map[int]alertedTeam)
+		alerted.teams[targetURL] = teams
+	}
+
+	var alerts []Alert
+
+	for _, row := range table {
+		ruleZones := make(map[Zone]bool)
+		for _, rule := range rules {
+			if strings.EqualFold(rule.Team, row.Team.TLA) {
+				ruleZones[rule.Zone] = true
+			}
+		}
+
+		if len(ruleZones) == 0 {
+			continue
+		}
+
+		zone := zoneFor(comp, row.Position, len(table))
+		before, seen := teams[row.Team.ID]
+		teams[row.Team.ID] = alertedTeam{zone: zone, position: row.Position}
+
+		if !seen || before.zone == zone || !ruleZones[zone] {
+			continue
+		}
+
+		alerts = append(alerts, Alert{
+			TeamID:           row.Team.ID,
+			Team:             row.Team.ShortName,
+			TLA:              row.Team.TLA,
+			Zone:             zone,
+			PreviousZone:     before.zone,
+			Position:         row.Position,
+			PreviousPosition: before.position,
+			Points:           row.Points,
+		})
+	}
+
+	return alerts
+}
+
+// put back where the teams of alerts that couldn't be posted were, unless a later fetch has moved them again
+func forgetAlerts(targetURL string, alerts []Alert) {
+	alerted.Lock()
+	defer alerted.Unlock()
+
+	teams := alerted.teams[targetURL]
+	for _, alert := range alerts {
+		if teams[alert.TeamID].position == alert.Position {
+			teams[alert.TeamID] = alertedTeam{zone: alert.PreviousZone, position: alert.PreviousPosition}
+		}
+	}
+}
+
+// the zones as they read in an alert message
+var zoneNames = map[Zone]string{
+	ZoneChampionsLeague: "the Champions League places",
+	ZoneEuropa:          "the Europa places",
+	ZoneMidTable:        "mid-table",
+	ZoneRelegation:      "the relegation zone",
+}
+
+// e.g. "Tottenham down to 18th from 17th, into the relegation zone (24 pts)"
+func (a Alert) message() string {
+	direction := "up"
+	if a.Position > a.PreviousPosition {
+		direction = "down"
+	}
+
+	return fmt.Sprintf("%s %s to %s from %s, into %s (%d pts)", a.Team, direction, ordinal(a.Position), ordinal(a.PreviousPosition),
+		zoneNames[a.Zone], a.Points)
+}
+
+// the json posted to the target, the text field each chat service reads, the generic webhook also gets the alerts
+func alertPayload(target alertTarget, alerts []Alert) any {
+	lines := make([]string, 0, len(alerts))
+	for _, alert := range alerts {
+		lines = append(lines, alert.message())
+	}
+
+	text := strings.Join(lines, "\n")
+
+	switch target.format {
+	case "slack":
+		return map[string]string{"text": text}
+	case "discord":
+		return map[string]string{"content": text}
+	case "telegram":
+		return map[string]string{"chat_id": target.chatID, "text": text}
+	default:
+		return struct {
+			Text   string  `json:"text"`
+			Alerts []Alert `json:"alerts"`
+		}{text, alerts}
+	}
+}
+
+// post the alerts to the target within the upstream deadline
+func postAlerts(ctx context.Context, target alertTarget, alerts []Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, upstreamTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(alertPayload(target, alerts))
+	if err != nil {
+		return fmt.Errorf("error marshalling alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("error creating %s alert request: %w", target.format, err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// the url error repeats the webhook url and its secret
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return fmt.Errorf("error posting %s alerts to %s: %w", target.format, redactURL(target.url), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%s alert webhook response status not OK: %v", target.format, resp.StatusCode)
+	}
+
+	return nil
+}
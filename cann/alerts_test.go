@@ -0,0 +1,261 @@
+package cann
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// standings json for a 20 team table with the team with teamID at position, the other teams fill the remaining places
+func standingsWithTeamAt(t *testing.T, teamID, position int) []byte {
+	t.Helper()
+
+	table := make([]TableRow, 0, 20)
+	other := 1
+
+	for pos := 1; pos <= 20; pos++ {
+		points := Points(60 - 2*pos)
+
+		if pos == position {
+			table = append(table, TableRow{Team: Team{ID: teamID, ShortName: "Tottenham", TLA: "TOT"}, Position: pos, Played: 30, Points: points})
+			continue
+		}
+
+		table = append(table, TableRow{Team: Team{ID: other, TLA: "T" + string(rune('A'+other))}, Position: pos, Played: 30, Points: points})
+		other++
+	}
+
+	body, err := json.Marshal(DataResponse{Standings: []Standings{{Table: table}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+func TestAlertOnZoneTransition(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	received := make(chan []byte, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var payload json.RawMessage
+		if err := json.NewDecoder(req.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+
+		received <- payload
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer webhook.Close()
+
+	var requests atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		position := 17
+		if requests.Add(1) > 1 {
+			position = 18 // into the relegation zone on the refresh
+		}
+
+		_, _ = w.Write(standingsWithTeamAt(t, 73, position)) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: 50 * time.Millisecond, UpstreamTimeout: 5 * time.Second,
+		AlertTeams: `[{"team": "tot", "zone": "relegation"}]`, AlertWebhooks: []string{webhook.URL}})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	if _, _, err := getStandings(context.Background(), defaultCompetition); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond) // expire the cached copy
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	if _, _, err := getStandings(context.Background(), defaultCompetition); err != nil {
+		t.Fatal(err)
+	}
+
+	alerting.Wait()
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	select {
+	case payload := <-received:
+		var got struct {
+			Alerts []Alert `json:"alerts"`
+		}
+
+		if err := json.Unmarshal(payload, &got); err != nil {
+			t.Fatal(err)
+		}
+
+		want := Alert{TeamID: 73, Team: "Tottenham", TLA: "TOT", Zone: ZoneRelegation, PreviousZone: ZoneMidTable, Position: 18, PreviousPosition: 17, Points: 24}
+		if len(got.Alerts) != 1 || got.Alerts[0] != want {
+			t.Errorf("webhook alerts = %+v, want [%+v]", got.Alerts, want)
+		}
+	default:
+		t.Fatal("webhook wasn't posted on the zone transition")
+	}
+}
+
+func TestZoneTransitionsIgnoresOtherZones(t *testing.T) {
+	tests := []struct {
+		comp               string
+		previous, position int
+		rule               AlertRule
+	}{
+		{"PL", 5, 6, AlertRule{Team: "TOT", Zone: ZoneRelegation}},       // Europa to mid-table
+		{"ELC", 5, 4, AlertRule{Team: "TOT", Zone: ZoneChampionsLeague}}, // no European places in the Championship
+		{"FL1", 3, 4, AlertRule{Team: "TOT", Zone: ZoneChampionsLeague}}, // out of the Ligue 1 Champions League places
+		{"DED", 4, 3, AlertRule{Team: "TOT", Zone: ZoneChampionsLeague}}, // Europa places in the Eredivisie
+		{"PL", 17, 17, AlertRule{Team: "TOT", Zone: ZoneMidTable}},       // the same standings again
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		previous, err := parseStandings(standingsWithTeamAt(t, 73, test.previous))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		current, err := parseStandings(standingsWithTeamAt(t, 73, test.position))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		configureAlerts("", nil)
+		rules := []AlertRule{test.rule}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		first := zoneTransitions(test.comp, "https://example.com/hook", previous, rules)
+		got := zoneTransitions(test.comp, "https://example.com/hook", current, rules)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if len(first) != 0 || len(got) != 0 {
+			t.Errorf("zoneTransitions(%s) from %d to %d = %+v then %+v, want no alerts for a move into a zone without a rule",
+				test.comp, test.previous, test.position, first, got)
+		}
+	}
+}
+
+func TestAlertTargets(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	var (
+		mu       sync.Mutex
+		received = make(map[string][]string) // payloads by path
+		failing  = true                      // the generic webhook fails the first time
+	)
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if req.URL.Path == "/hook" && failing {
+			failing = false
+
+			w.WriteHeader(http.StatusBadGateway)
+
+			return
+		}
+
+		received[req.URL.Path] = append(received[req.URL.Path], string(body))
+	}))
+	defer webhook.Close()
+
+	Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: time.Second, AlertTeams: `[{"team": "tot", "zone": "relegation"}]`,
+		AlertWebhooks: []string{"slack=" + webhook.URL + "/slack", "discord=" + webhook.URL + "/discord",
+			"telegram=" + webhook.URL + "/bot123:abc/sendMessage?chat_id=42", webhook.URL + "/hook"}})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	for _, position := range []int{17, 18, 18, 18} { // first seen, relegated, then the same standings fetched twice
+		checkAlerts(defaultCompetition, standingsWithTeamAt(t, 73, position))
+		alerting.Wait()
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	message := "Tottenham down to 18th from 17th, into the relegation zone (24 pts)"
+	want := map[string][]string{
+		"/slack":                  {`{"text":"` + message + `"}`},
+		"/discord":                {`{"content":"` + message + `"}`},
+		"/bot123:abc/sendMessage": {`{"chat_id":"42","text":"` + message + `"}`},
+		"/hook": {`{"text":"` + message + `","alerts":[{"teamId":73,"team":"Tottenham","tla":"TOT","zone":"relegation",` +
+			`"previousZone":"mid-table","position":18,"previousPosition":17,"points":24}]}`}, // sent again by the fetch after it failed
+	}
+
+	if !reflect.DeepEqual(received, want) {
+		t.Errorf("alerts = %v, want each target alerted once %v", received, want)
+	}
+}
+
+func TestAlertMessage(t *testing.T) {
+	tests := []struct {
+		position, previous int
+		zone               Zone
+		want               string
+	}{
+		{4, 6, ZoneChampionsLeague, "Tottenham up to 4th from 6th, into the Champions League places (24 pts)"},
+		{5, 4, ZoneEuropa, "Tottenham down to 5th from 4th, into the Europa places (24 pts)"},
+		{17, 18, ZoneMidTable, "Tottenham up to 17th from 18th, into mid-table (24 pts)"},
+		{18, 17, ZoneRelegation, "Tottenham down to 18th from 17th, into the relegation zone (24 pts)"},
+	}
+
+	for _, test := range tests {
+		alert := Alert{Team: "Tottenham", Position: test.position, PreviousPosition: test.previous, Points: 24, Zone: test.zone}
+
+		if got := alert.message(); got != test.want {
+			t.Errorf("message() from %d to %d = %q, want %q", test.previous, test.position, got, test.want)
+		}
+	}
+}
+
+func TestParseAlertTarget(t *testing.T) {
+	tests := []struct {
+		entry   string
+		want    alertTarget
+		wantErr bool
+	}{
+		{"https://example.com/hook", alertTarget{format: "webhook", url: "https://example.com/hook"}, false},
+		{"https://example.com/hook?a=b", alertTarget{format: "webhook", url: "https://example.com/hook?a=b"}, false},
+		{"discord=https://discord.com/api/webhooks/1/x", alertTarget{format: "discord", url: "https://discord.com/api/webhooks/1/x"}, false},
+		{"telegram=https://api.telegram.org/bot1:x/sendMessage?chat_id=-42",
+			alertTarget{format: "telegram", url: "https://api.telegram.org/bot1:x/sendMessage", chatID: "-42"}, false},
+		{"telegram=https://api.telegram.org/bot1:x/sendMessage", alertTarget{}, true},
+		{"teams=https://example.com/hook", alertTarget{}, true},
+		{"ftp://example.com/hook", alertTarget{}, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseAlertTarget(test.entry)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("parseAlertTarget(%q) = %+v, %v, want %+v with error %t", test.entry, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestPostAlertsRedactsWebhookURL(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := httptest.NewServer(http.NotFoundHandler())
+	target := alertTarget{format: "webhook", url: ts.URL + "/services/super-secret-token"}
+	ts.Close() // refuse the connection
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	err := postAlerts(context.Background(), target, []Alert{{TLA: "TOT", Zone: ZoneRelegation}})
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err == nil || strings.Contains(err.Error(), "super-secret-token") || !strings.Contains(err.Error(), "/[REDACTED]") {
+		t.Errorf("postAlerts() err = %v, want the webhook url redacted", err)
+	}
+}
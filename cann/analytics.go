@@ -0,0 +1,142 @@
+package cann
+
+import (
+	"cmp"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+)
+
+const (
+	defaultMinMatchdays = 5 // derived metrics such as projections are meaningless in the first few matchdays
+	pointsForWin        = 3
+)
+
+// games each team plays in a season per competition, override with environment variable SEASON_GAMES e.g. {"PL": 38}
+var seasonGames = map[string]int{
+	"PL":  38,
+	"ELC": 46,
+	"BL1": 34,
+	"SA":  38,
+	"PD":  38,
+	"FL1": 34,
+	"DED": 34,
+	"PPL": 34,
+}
+
+// the minimum matchday for derived metrics, set by Configure
+var minMatchdays = defaultMinMatchdays
+
+// games each team plays in a season by competition code from SEASON_GAMES, set by Configure
+var configuredSeasonGames map[string]int
+
+// parse the minimum matchday for derived metrics from MIN_MATCHDAYS, falls back to the default
+func parseMinMatchdays(value string) int {
+	if value == "" {
+		return defaultMinMatchdays
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		slog.Warn("invalid MIN_MATCHDAYS, using the default", "value", value, "default", defaultMinMatchdays)
+		return defaultMinMatchdays
+	}
+
+	return n
+}
+
+// parse the games each team plays by competition code from SEASON_GAMES e.g. {"PL": 38}, none when invalid
+func parseSeasonGames(value string) map[string]int {
+	if value == "" {
+		return nil
+	}
+
+	var games map[string]int
+	if err := json.Unmarshal([]byte(value), &games); err != nil {
+		slog.Warn("invalid SEASON_GAMES ignored", "err", err)
+		return nil
+	}
+
+	return games
+}
+
+// games each team plays in a season for a competition, from SEASON_GAMES, the defaults,
+// or assuming every team plays every other team home and away
+func totalGames(comp string, tableSize int) int {
+	if n, ok := configuredSeasonGames[comp]; ok && n > 0 {
+		return n
+	}
+
+	if n, ok := seasonGames[comp]; ok {
+		return n
+	}
+
+	return 2 * (tableSize - 1)
+}
+
+// games the team has left from its own games played, so teams with games in hand have more remaining
+func remainingGames(row TableRow, totalGames int) int {
+	return max(totalGames-row.Played, 0)
+}
+
+// the most points the team can finish the season with
+func maxPoints(row TableRow, totalGames int) Points {
+	return row.Points + Points(pointsForWin*remainingGames(row, totalGames))
+}
+
+// the current matchday reported by the season, or the most games played by any team if the season omits it
+func currentMatchday(dataResponse DataResponse) int {
+	if matchday := dataResponse.Season.CurrentMatchday; matchday > 0 {
+		return matchday
+	}
+
+	var matchday int
+	for _, row := range dataResponse.Standings[0].Table {
+		matchday = max(matchday, row.Played)
+	}
+
+	return matchday
+}
+
+// projected end of season points at the team's current points per game
+func projectedPoints(row TableRow, totalGames int) Points {
+	if row.Played == 0 {
+		return 0
+	}
+
+	return Points(math.Round(float64(row.Points) / float64(row.Played) * float64(totalGames)))
+}
+
+// recompute each team's points from wins and draws with winPoints for a win, then re-rank the table
+// by points and goal difference, keeping the original order for teams still level
+func recomputePoints(standingsTable []TableRow, winPoints Points) []TableRow {
+	recomputed := slices.Clone(standingsTable)
+	for i := range recomputed {
+		recomputed[i].Points = Points(recomputed[i].Won)*winPoints + Points(recomputed[i].Draw)
+	}
+
+	return rerank(recomputed)
+}
+
+// sort the table by points and goal difference, keeping the original order for teams still level, and renumber positions
+func rerank(standingsTable []TableRow) []TableRow {
+	slices.SortStableFunc(standingsTable, func(a, b TableRow) int {
+		if a.Points != b.Points {
+			return cmp.Compare(b.Points, a.Points)
+		}
+
+		if a.GoalDiff != b.GoalDiff {
+			return cmp.Compare(b.GoalDiff, a.GoalDiff)
+		}
+
+		return cmp.Compare(a.Position, b.Position)
+	})
+
+	for i := range standingsTable {
+		standingsTable[i].Position = i + 1
+	}
+
+	return standingsTable
+}
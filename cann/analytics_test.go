@@ -0,0 +1,130 @@
+package cann
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// standings json for a table of size teams at a matchday
+func testStandings(t *testing.T, size, matchday int) []byte {
+	t.Helper()
+
+	table := testTable(size)
+	for i := range table {
+		table[i].Played = matchday
+	}
+
+	standings, err := json.Marshal(DataResponse{Season: Season{CurrentMatchday: matchday}, Standings: []Standings{{Table: table}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return standings
+}
+
+func TestComputeGapsDerivedMetrics(t *testing.T) {
+	tests := []struct {
+		matchday    int
+		showDerived bool
+	}{
+		{1, false},
+		{4, false},
+		{5, true},
+		{30, true},
+	}
+
+	for _, test := range tests {
+		got, err := computeGaps(testStandings(t, 20, test.matchday), "PL", defaultMinMatchdays)
+		if err != nil {
+			t.Fatalf("computeGaps() err = %v, want nil", err)
+		}
+
+		if shown := got.Gaps[0].Projected != nil; shown != test.showDerived {
+			t.Errorf("computeGaps() matchday %d projections shown = %v, want %v", test.matchday, shown, test.showDerived)
+		}
+
+		if hasNote := got.Note != ""; hasNote == test.showDerived {
+			t.Errorf("computeGaps() matchday %d note = %q, want note only when projections are suppressed", test.matchday, got.Note)
+		}
+	}
+}
+
+func TestProjectedPoints(t *testing.T) {
+	if got := projectedPoints(TableRow{Played: 19, Points: 40}, 38); got != 80 {
+		t.Errorf("projectedPoints() = %v, want 80", got)
+	}
+
+	if got := projectedPoints(TableRow{}, 38); got != 0 {
+		t.Errorf("projectedPoints() no games played = %v, want 0", got)
+	}
+}
+
+func TestRemainingMaxPoints(t *testing.T) {
+	table := []TableRow{
+		{Position: 1, Played: 30, Points: 70},
+		{Position: 2, Played: 28, Points: 66}, // two games in hand
+		{Position: 3, Played: 38, Points: 75}, // season finished
+	}
+
+	want := []struct {
+		remaining int
+		maxPoints Points
+	}{
+		{8, 94},
+		{10, 96},
+		{0, 75},
+	}
+
+	games := totalGames("PL", len(table))
+	for i, row := range table {
+		if remaining, maxPts := remainingGames(row, games), maxPoints(row, games); remaining != want[i].remaining || maxPts != want[i].maxPoints {
+			t.Errorf("position %d remaining = %d maxPoints = %d, want %d %d", row.Position, remaining, maxPts, want[i].remaining, want[i].maxPoints)
+		}
+	}
+}
+
+func TestTotalGames(t *testing.T) {
+	if got := totalGames("ELC", 24); got != 46 {
+		t.Errorf(`totalGames("ELC") = %d, want 46`, got)
+	}
+
+	if got := totalGames("XYZ", 10); got != 18 {
+		t.Errorf(`totalGames("XYZ", 10) = %d, want 18 from the table size`, got)
+	}
+
+	configuredSeasonGames = parseSeasonGames(`{"PL": 36}`)
+	defer func() { configuredSeasonGames = nil }()
+
+	if got := totalGames("PL", 20); got != 36 {
+		t.Errorf(`totalGames("PL") with SEASON_GAMES = %d, want 36`, got)
+	}
+}
+
+func TestRecomputePoints(t *testing.T) {
+	table := []TableRow{
+		{Team: Team{TLA: "AAA"}, Position: 1, Won: 10, Draw: 0, Lost: 10, Points: 30, GoalDiff: 5},
+		{Team: Team{TLA: "BBB"}, Position: 2, Won: 8, Draw: 5, Lost: 7, Points: 29, GoalDiff: 3},
+		{Team: Team{TLA: "CCC"}, Position: 3, Won: 6, Draw: 9, Lost: 5, Points: 27, GoalDiff: 0},
+	}
+
+	got := recomputePoints(table, 2)
+
+	want := []struct {
+		tla    string
+		points Points
+	}{
+		{"BBB", 21}, // 8*2 + 5
+		{"CCC", 21}, // 6*2 + 9, level on points, lower goal difference
+		{"AAA", 20}, // 10*2 + 0
+	}
+
+	for i, row := range got {
+		if row.Team.TLA != want[i].tla || row.Points != want[i].points || row.Position != i+1 {
+			t.Errorf("recomputePoints() position %d = %s %d pts, want %s %d pts", row.Position, row.Team.TLA, row.Points, want[i].tla, want[i].points)
+		}
+	}
+
+	if table[0].Points != 30 {
+		t.Errorf("recomputePoints() altered the fetched table, points = %d, want 30", table[0].Points)
+	}
+}
@@ -0,0 +1,60 @@
+package cann
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mick4711/moh/stale"
+)
+
+// response header reporting whether the data was a cache hit, a miss fetched from the upstream or an expired copy
+const cacheHeader = "X-Cache"
+
+// where a cached resource came from
+type cacheStatus string
+
+const (
+	cacheHit   cacheStatus = "HIT"
+	cacheMiss  cacheStatus = "MISS"
+	cacheStale cacheStatus = "STALE"
+)
+
+// while the upstream is failing, marks a response with the competition's stale standings with the Warning and Age
+// headers and describes them for the banner. nil when the response isn't marked stale
+func markStale(w http.ResponseWriter, comp string) *stale.Data {
+	if w.Header().Get(cacheHeader) != string(cacheStale) || !upstream.failing() {
+		return nil
+	}
+
+	fetched, ok := staleSince(comp)
+	if !ok {
+		w.Header().Set("Warning", stale.Warning)
+		return nil
+	}
+
+	data := stale.New(fetched, clk.Now())
+	data.Mark(w.Header())
+
+	return data
+}
+
+// when the stale standings served were fetched, from the cached copy or else the latest saved snapshot
+func staleSince(comp string) (time.Time, bool) {
+	if _, fetched, ok := standingsCache.GetStale(standingsURL(comp)); ok {
+		return fetched, true
+	}
+
+	_, saved, ok := latestSnapshot(comp)
+
+	return saved, ok
+}
+
+// whether the request asks for the standings to be refetched with ?refresh=1, bypassing the cached copy
+func forceRefresh(req *http.Request) bool {
+	return req.URL.Query().Get("refresh") == "1"
+}
+
+// mark a response with where its data came from
+func markCache(w http.ResponseWriter, status cacheStatus) {
+	w.Header().Set(cacheHeader, string(status))
+}
@@ -0,0 +1,62 @@
+package cann
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheStatusAndRefresh(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url          string
+		wantStatus   cacheStatus
+		wantRequests int
+	}{
+		{"/cann?format=json", cacheMiss, 1},
+		{"/cann?format=json", cacheHit, 1},
+		{"/cann/gaps", cacheHit, 1},
+		{"/cann?format=json&refresh=1", cacheMiss, 2},
+		{"/cann?format=json", cacheHit, 2},
+		{"/cann/gaps?refresh=1", cacheMiss, 3},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+
+		if strings.HasPrefix(test.url, "/cann/gaps") {
+			Gaps(w, req)
+		} else {
+			GenerateTable(w, req)
+		}
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != http.StatusOK || w.Header().Get(cacheHeader) != string(test.wantStatus) {
+			t.Errorf("%s status = %d %s = %q, want 200 %q", test.url, w.Code, cacheHeader, w.Header().Get(cacheHeader), test.wantStatus)
+		}
+
+		if requests != test.wantRequests {
+			t.Errorf("%s upstream requests = %d, want %d", test.url, requests, test.wantRequests)
+		}
+	}
+}
@@ -4,36 +4,239 @@
 package cann
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mick4711/moh/breaker"
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/conditional"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/i18n"
+	"github.com/mick4711/moh/metrics"
+	"github.com/mick4711/moh/negotiate"
+	"github.com/mick4711/moh/snapshot"
+	"github.com/mick4711/moh/stale"
+)
+
+const (
+	defaultBaseURL         = "http://api.football-data.org/v4"
+	defaultTTL             = 60 * time.Second
+	defaultUpstreamTimeout = 5 * time.Second
+)
+
+// Settings contains the upstream and cache configuration
+type Settings struct {
+	BaseURL         string        // api.football-data.org base url
+	APIToken        string        // football-data.org X-Auth-Token, upstream fetches fail when empty
+	TTL             time.Duration // standings cache time-to-live
+	ScorersTTL      time.Duration // cache time-to-live of the responses fetched with Get, defaultScorersTTL when 0
+	MaxEntries      int           // standings cache size
+	UpstreamTimeout time.Duration // deadline for each upstream fetch, independent of the server write timeout
+	Odds            OddsProvider  // title and relegation probabilities, none are shown when nil
+	Clock           clock.Clock   // the current time, the system clock when nil
+	HTTPClient      HTTPClient    // sends the outbound requests, http.DefaultClient when nil
+	RetryAttempts   int           // attempts per upstream fetch including the first, defaultRetryAttempts when 0
+
+	BreakerThreshold int           // consecutive failed fetches that open the circuit, breaker.DefaultThreshold when 0
+	BreakerCooldown  time.Duration // time the circuit stays open before a trial fetch, breaker.DefaultCooldown when 0
+
+	StaleWhileRevalidate bool          // serve expired cached copies immediately while refreshing them in the background
+	FreshnessCheck       bool          // note when recently finished matches aren't in the standings yet
+	UpdatingTTL          time.Duration // shorter standings cache lifetime while they are updating, 0 keeps TTL
+
+	Metrics *metrics.Registry // registry for the fetch and cache metrics, unregistered when nil
+
+	TemplateDir string // re-read the Cann table templates from this directory on every render, the compiled in copies when empty
+	SnapshotDir string // save the standings here after every fetch for ?date= and the movement indicator, off when empty
+
+	WarmMaxAge time.Duration // standings refreshed in the background are served from the cache for up to this long
+
+	RowSort string // order of the teams within a row without ?rowsort=, goal difference, goals scored and name when empty
+
+	PointsAdjustments string   // json map of team ID to deduction and reason shown as footnotes, none when empty
+	MinMatchdays      string   // matchday the derived metrics are shown from, defaultMinMatchdays when empty
+	SeasonGames       string   // json map of competition code to games each team plays, overriding the defaults
+	XGSourceURL       string   // expected goals for the ?xg=1 table, the xG table is unavailable when empty
+	AlertTeams        string   // json list of the teams and zones to alert on e.g. [{"team": "TOT", "zone": "relegation"}]
+	AlertWebhooks     []string // webhook urls optionally prefixed slack=, discord= or telegram= for their payload, alerts are off when empty
+	DerbyPairs        string   // json list of rival team ID pairs e.g. [[57, 73], [64, 62]]
+	DerbyPoints       string   // points within which a derby pair is highlighted, defaultDerbyPoints when empty
+	EuropeanPlaces    string   // json map of competition code to the positions of each European competition
+}
+
+var (
+	baseURL         = defaultBaseURL
+	apiToken        string
+	upstreamTimeout = defaultUpstreamTimeout
+	standingsCache  = cache.New(defaultTTL, cache.DefaultMaxEntries) // standings response bodies keyed by request url
 )
 
+// the current time, set by Configure
+var clk clock.Clock = clock.Real{}
+
+// An HTTPClient sends requests, e.g. an *http.Client
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// sends the upstream, supplementary and webhook requests, set by Configure
+var httpClient HTTPClient = http.DefaultClient
+
+// Configure applies the settings, call before serving requests
+func Configure(settings Settings) {
+	clk = settings.Clock
+	if clk == nil {
+		clk = clock.Real{}
+	}
+
+	httpClient = settings.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	retryAttempts = settings.RetryAttempts
+	if retryAttempts < 1 {
+		retryAttempts = defaultRetryAttempts
+	}
+
+	baseURL = settings.BaseURL
+	apiToken = settings.APIToken
+	upstreamTimeout = settings.UpstreamTimeout
+	standingsCache = cache.NewWithClock(settings.TTL, settings.MaxEntries, clk)
+	cacheMaxAge = settings.TTL
+	historyCache = cache.NewWithClock(historyTTL, settings.MaxEntries, clk)
+
+	scorersTTL := settings.ScorersTTL
+	if scorersTTL == 0 {
+		scorersTTL = defaultScorersTTL
+	}
+
+	scorersCache = cache.NewWithClock(scorersTTL, settings.MaxEntries, clk)
+	oddsProvider = settings.Odds
+	staleWhileRevalidate = settings.StaleWhileRevalidate
+	freshnessCheck = settings.FreshnessCheck
+	updatingTTL = settings.UpdatingTTL
+	templateDir = settings.TemplateDir
+	warmMaxAge = settings.WarmMaxAge
+
+	rowSortCriteria = defaultRowSortCriteria
+	if settings.RowSort != "" {
+		if _, err := rowSortFor(settings.RowSort); err != nil {
+			slog.Warn("invalid CANN_ROW_SORT ignored", "err", err)
+		} else {
+			rowSortCriteria = settings.RowSort
+		}
+	}
+
+	pointsAdjustments = parsePointsAdjustments(settings.PointsAdjustments)
+	minMatchdays = parseMinMatchdays(settings.MinMatchdays)
+	configuredSeasonGames = parseSeasonGames(settings.SeasonGames)
+	xgSourceURL = settings.XGSourceURL
+	configureAlerts(settings.AlertTeams, settings.AlertWebhooks)
+	derbyPairs = parseDerbyPairs(settings.DerbyPairs)
+	derbyPoints = parseDerbyPoints(settings.DerbyPoints)
+	configuredEuropeanPlaces = parseEuropeanPlaces(settings.EuropeanPlaces)
+
+	backgroundRefreshes.Lock()
+	backgroundRefreshes.at = make(map[string]time.Time)
+	backgroundRefreshes.Unlock()
+
+	snapshots = nil
+	if settings.SnapshotDir != "" {
+		snapshots = snapshot.New(settings.SnapshotDir)
+	}
+
+	upstreamBreaker = breaker.New(settings.BreakerThreshold, settings.BreakerCooldown, clk)
+	upstream = &upstreamHealth{}
+	fetchDuration = newFetchDuration(settings.Metrics)
+	fetchErrors = newFetchErrors(settings.Metrics)
+	registerCacheMetrics(settings.Metrics)
+}
+
 type Points int
 
-// A Row contains the points and teams with those points
+// A Row contains the points and teams with those points, in json as {"points": 45, "teams": [...], "text": "..."}
 type Row struct {
-	Points Points
-	Teams  string
+	Points Points `json:"points"`
+	Teams  string `json:"text"` // the teams formatted for the page e.g. " - [1]Arsenal(20, +25)"
+
+	TeamDetails []RowTeam `json:"teams"` // the teams in Teams as structured fields, in the same order
+}
+
+// MarshalJSON writes a row without teams with an empty teams list rather than null
+func (r Row) MarshalJSON() ([]byte, error) {
+	type row Row // without the method
+
+	if r.TeamDetails == nil {
+		r.TeamDetails = []RowTeam{}
+	}
+
+	return json.Marshal(row(r)) //nolint:wrapcheck // a row always marshals
+}
+
+// A RowTeam is a team in a Cann table row
+type RowTeam struct {
+	Position     int        `json:"position"`
+	TeamID       int        `json:"teamId"`
+	Team         string     `json:"team"`
+	TLA          string     `json:"tla"`
+	Played       int        `json:"playedGames"`
+	GoalDiff     int        `json:"goalDifference"`
+	GoalsFor     int        `json:"goalsFor"`
+	GoalsAgainst int        `json:"goalsAgainst"`
+	Zone         Zone       `json:"zone"`               // from the position in the whole league table
+	Movement     int        `json:"movement,omitempty"` // league positions gained in the last week, lost when negative
+	Form         []string   `json:"form,omitempty"`     // last five results W, D or L, omitted with ?form=0
+	CrestURL     string     `json:"crestUrl,omitempty"` // team badge image from football-data, empty when not reported
+	Labels       string     `json:"labels,omitempty"`   // badges appended to the team in Teams e.g. "[CL]"
+	Stats        *TeamStats `json:"stats,omitempty"`    // pace analytics, only with ?stats=1
+}
+
+// e.g. "[3]Man City(19, +24)[CL]"
+func (t RowTeam) String() string {
+	return fmt.Sprintf("[%d]%s(%d, %+d)%s", t.Position, t.Team, t.Played, t.GoalDiff, t.Labels)
 }
 
 // A Team contains details for a team.
 type Team struct {
 	ID        int    `json:"id"`
 	ShortName string `json:"shortName"`
+	TLA       string `json:"tla"`
+	Crest     string `json:"crest,omitempty"` // badge image url
 }
 
 // A TableRow contains details for a standings table row.
 type TableRow struct {
-	Team     Team   `json:"team"`
-	Position int    `json:"position"`
-	Played   int    `json:"playedGames"`
-	Points   Points `json:"points"`
-	GoalDiff int    `json:"goalDifference"`
+	Team         Team   `json:"team"`
+	Position     int    `json:"position"`
+	Played       int    `json:"playedGames"`
+	Points       Points `json:"points"`
+	GoalDiff     int    `json:"goalDifference"`
+	GoalsFor     int    `json:"goalsFor"`
+	GoalsAgainst int    `json:"goalsAgainst"`
+	Won          int    `json:"won"`
+	Draw         int    `json:"draw"`
+	Lost         int    `json:"lost"`
+	Form         string `json:"form,omitempty"` // recent results e.g. "W,D,L,W,W", empty when not reported
+
+	// supplementary, nil unless an xG data source is configured
+	ExpectedGoalsFor     *float64 `json:"expectedGoalsFor,omitempty"`
+	ExpectedGoalsAgainst *float64 `json:"expectedGoalsAgainst,omitempty"`
 }
 
 // A Standings contains a table of Rows, i.e. teams and points.
@@ -41,113 +244,876 @@ type Standings struct {
 	Table []TableRow `json:"table"`
 }
 
+// A Season contains the current season details
+type Season struct {
+	StartDate       string `json:"startDate"` // yyyy-mm-dd
+	CurrentMatchday int    `json:"currentMatchday"`
+}
+
 // DataResponse contains the Standings
 type DataResponse struct {
+	Season    Season      `json:"season"`
 	Standings []Standings `json:"standings"`
+
+	LastUpdated string `json:"lastUpdated"` // RFC 3339 time football-data last updated the standings
+}
+
+// A Gap contains a team's points gap to the team immediately above it in the table
+type Gap struct {
+	Position int    `json:"position"`
+	TeamID   int    `json:"teamId"`
+	Team     string `json:"team"`
+	TLA      string `json:"tla"`
+	Points   Points `json:"points"`
+	Gap      Points `json:"gap"`
+
+	Remaining int    `json:"remaining"` // games left for this team
+	MaxPoints Points `json:"maxPoints"` // most points this team can finish with
+
+	Projected *Points `json:"projected,omitempty"` // derived, omitted before the minimum matchday
+}
+
+// GapsResponse contains the gaps and the matchday they were computed at
+type GapsResponse struct {
+	Matchday    int    `json:"matchday"`
+	Note        string `json:"note,omitempty"`
+	Gaps        []Gap  `json:"gaps"`
+	DataVersion string `json:"dataVersion,omitempty"`
+
+	Stale *stale.Data `json:"stale,omitempty"` // set when the last good standings are served while the upstream is failing
+}
+
+// An Adjustment is an informational points deduction for a team, the fetched points are not altered
+type Adjustment struct {
+	Deduction int    `json:"deduction"`
+	Reason    string `json:"reason"`
+}
+
+// options applied when generating the Cann table
+type options struct {
+	adjustments map[int]Adjustment // keyed by team ID
+	teams       map[string]bool    // selected team TLAs, all teams are shown when empty
+	europe      map[int]string     // European competition keyed by qualifying league position
+	derby       map[int]bool       // IDs of derby teams close in the standings
+	odds        map[int]Probabilities
+	lastSeason  map[int]Points    // points difference from the same matchday last season keyed by team ID
+	movement    map[int]int       // league positions gained in the last week keyed by team ID
+	stats       map[int]TeamStats // pace analytics keyed by team ID, left out when nil
+	hideForm    bool              // leave out the recent form badges
+	rowSort     rowSort           // order of the teams within a row, the configured criteria when nil
+	tableSize   int               // teams in the whole league table for zones, the table the Cann table is built from when 0
+}
+
+// data passed to the Cann template, Groups is only populated when the table is grouped by zone
+type cannPage struct {
+	Rows        []Row    `json:"rows,omitempty"`
+	Groups      []Group  `json:"groups,omitempty"`
+	Notes       []string `json:"notes,omitempty"`
+	Adjustments []string `json:"adjustments,omitempty"` // footnotes of the informational points adjustments
+	PreSeason   bool     `json:"preSeason,omitempty"`   // no games played, Rows lists the teams alphabetically
+
+	Stale *stale.Data `json:"stale,omitempty"` // set when the last good standings are served while the upstream is failing, the page banner
+
+	DataVersion   string `json:"dataVersion,omitempty"`   // hash of the standings the page was rendered from
+	LastRefreshed string `json:"lastRefreshed,omitempty"` // RFC 3339 time of the last background refresh of the standings
+	Permalink     string `json:"-"`                       // url of this view with every parameter explicit
+	Theme         Theme  `json:"-"`
+
+	Insights *Insights `json:"insights,omitempty"` // densest cluster and biggest gap of the whole table, not only the selected teams
+
+	Competition     string         `json:"competition"` // competition name e.g. "Premier League"
+	CompetitionCode string         `json:"-"`
+	Season          int            `json:"season,omitempty"` // start year of a past season, omitted for the current season
+	Seasons         []SeasonOption `json:"-"`                // the season selector
+
+	Lang string        `json:"-"` // language of the html page from ?lang= or Accept-Language
+	Text i18n.Messages `json:"-"` // the page text in Lang
+}
+
+const (
+	defaultCompetition = "PL"
+	maxRenderBody      = 1 << 20 // bytes
+)
+
+// competition codes supported by api.football-data.org free tier
+var competitions = map[string]string{
+	"PL":  "Premier League",
+	"ELC": "Championship",
+	"BL1": "Bundesliga",
+	"SA":  "Serie A",
+	"PD":  "Primera Division",
+	"FL1": "Ligue 1",
+	"DED": "Eredivisie",
+	"PPL": "Primeira Liga",
+}
+
+// friendly names accepted for the football-data.org competition codes, e.g. ?comp=championship
+var competitionAliases = map[string]string{
+	"premier-league":   "PL",
+	"championship":     "ELC",
+	"bundesliga":       "BL1",
+	"serie-a":          "SA",
+	"la-liga":          "PD",
+	"primera-division": "PD",
+	"ligue-1":          "FL1",
+	"eredivisie":       "DED",
+	"primeira-liga":    "PPL",
+}
+
+// fetches the standard table standings for the ?comp= competition, generates and outputs the Cann table
+func GenerateTable(w http.ResponseWriter, req *http.Request) {
+	comp, err := competition(req)
+	if err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	if historical(req) {
+		renderHistorical(w, req, comp)
+		return
+	}
+
+	season, err := seasonQuery(req)
+	if err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	if season != 0 {
+		renderSeason(w, req, comp, season)
+		return
+	}
+
+	standings, status, err := requestStandings(req, comp)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	markCache(w, status)
+
+	standings, notes := reconcileFreshness(req.Context(), comp, standings)
+	renderTable(w, req, comp, standings, notes...)
 }
 
-// fetches the standard table standings, generates and outputs the Cann table
-func GenerateTable(w http.ResponseWriter, _ *http.Request) {
-	standings, err := getStandings()
+// Render outputs the Cann table for a posted standings json body, bypassing the upstream fetch, for debugging payloads
+func Render(w http.ResponseWriter, req *http.Request) {
+	standings, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxRenderBody))
 	if err != nil {
-		returnError(err, w)
+		returnBadRequest(w, req, fmt.Errorf("error reading posted standings: %w", err))
+		return
+	}
+
+	if _, err := parseResponse(standings); err != nil {
+		returnBadRequest(w, req, err)
 		return
 	}
 
-	cannTable, err := generateCann(standings)
+	comp, err := competition(req)
 	if err != nil {
-		returnError(err, w)
+		returnBadRequest(w, req, err)
 		return
 	}
 
-	if err := writeResponse(w, cannTable); err != nil {
-		returnError(err, w)
+	renderTable(w, req, comp, standings)
+}
+
+// generates the Cann table from the competition's standings json and writes it as html, or json for ?format=json.
+// The notes are shown before the notes for the options
+func renderTable(w http.ResponseWriter, req *http.Request, comp string, standings []byte, notes ...string) {
+	standingsTable, err := parseStandings(standings)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	version := setDataVersion(w, standings)
+	pageTheme := theme(w, req)
+	season, _ := seasonQuery(req) //nolint:errcheck // checked by GenerateTable, posted standings are shown as the current season
+
+	// a past season's table or one as it stood earlier has no live scores or freshness banners
+	current := season == 0 && !historical(req)
+
+	var degraded *stale.Data
+	if current {
+		degraded = markStale(w, comp)
+	}
+
+	if isPreSeason(standingsTable) {
+		writePage(w, req, cannPage{Competition: competitions[comp], CompetitionCode: comp, Rows: preSeasonRows(standingsTable), Notes: []string{preSeasonNote},
+			PreSeason: true, Stale: degraded, DataVersion: version, Theme: pageTheme, Season: season, Seasons: seasonOptions(season)})
 		return
 	}
+
+	opts := options{adjustments: pointsAdjustments, teams: watchlist(w, req), europe: europeanPlaces(comp), movement: weeklyMovement(comp, standings),
+		hideForm: req.URL.Query().Get("form") == "0"}
+	page := cannPage{Competition: competitions[comp], CompetitionCode: comp, Notes: notes, Adjustments: adjustmentNotes(opts.adjustments), Stale: degraded, DataVersion: version,
+		Theme: pageTheme, Permalink: permalink(req, opts.teams, pageTheme.Name == a11yTheme.Name), Season: season, Seasons: seasonOptions(season)}
+
+	if current {
+		page.LastRefreshed = lastRefreshed(comp)
+	}
+
+	if req.URL.Query().Get("xg") == "1" {
+		var note string
+
+		standingsTable, note = xgTable(req.Context(), standingsTable)
+		page.Notes = append(page.Notes, note)
+	}
+
+	if req.URL.Query().Get("live") == "1" && current {
+		var note string
+
+		standingsTable, note = liveTable(req.Context(), comp, standingsTable)
+		page.Notes = append(page.Notes, note)
+	}
+
+	if req.URL.Query().Has("winpoints") {
+		winPoints, err := strconv.Atoi(req.URL.Query().Get("winpoints"))
+		if err != nil || (winPoints != 2 && winPoints != pointsForWin) {
+			returnBadRequest(w, req, fmt.Errorf("invalid winpoints %q, must be 2 or 3", req.URL.Query().Get("winpoints")))
+			return
+		}
+
+		if winPoints != pointsForWin {
+			standingsTable = recomputePoints(standingsTable, Points(winPoints))
+			page.Notes = append(page.Notes, fmt.Sprintf("Unofficial table: points recomputed with %d points for a win", winPoints))
+		}
+	}
+
+	if req.URL.Query().Get("stats") == "1" {
+		opts.stats = teamStats(standingsTable, totalGames(comp, len(standingsTable)), minMatchdays)
+	}
+
+	opts.derby = derbyWatch(standingsTable, derbyPairs, derbyPoints)
+	opts.odds = teamProbabilities(req.Context(), comp)
+	if opts.odds != nil {
+		logMissing(req.Context(), "odds", standingsTable, func(teamID int) bool { _, ok := opts.odds[teamID]; return ok })
+	}
+
+	if req.URL.Query().Get("compareLastSeason") == "1" {
+		var note string
+
+		opts.lastSeason, note = lastSeasonComparison(req.Context(), comp, standings)
+		page.Notes = append(page.Notes, note)
+
+		if opts.lastSeason != nil {
+			logMissing(req.Context(), "last season comparison", standingsTable, func(teamID int) bool { _, ok := opts.lastSeason[teamID]; return ok })
+		}
+	}
+
+	if opts.rowSort, err = rowSortFor(req.URL.Query().Get("rowsort")); err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	insights := tableInsights(standingsTable)
+	page.Insights = &insights
+
+	if req.URL.Query().Get("grouped") == "1" {
+		page.Groups = groupByZone(comp, standingsTable, opts)
+	} else {
+		page.Rows = buildCann(standingsTable, opts)
+	}
+
+	writePage(w, req, page)
 }
 
-// TODO display empty page template with error message
-func returnError(err error, w http.ResponseWriter) {
-	log.Printf("\n*********** FATAL ERROR ********** [%s]\n", err)
-	w.WriteHeader(http.StatusInternalServerError)
-	fmt.Fprintln(w, err)
+// writes the Cann page as html, the lite html for ?lite=1, or json for ?format=json or an Accept header preferring json.
+// The page is buffered so it can be tagged for conditional requests
+func writePage(w http.ResponseWriter, req *http.Request, page cannPage) {
+	if negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		body, err := encodeJSON(req, page)
+		if err != nil {
+			returnError(w, req, err)
+			return
+		}
+
+		writeCacheable(w, req, "application/json", body)
+
+		return
+	}
+
+	page.Lang = i18n.Language(w, req)
+	page.Text = i18n.Text(page.Lang)
+
+	templateFile := fullTemplate
+	if req.URL.Query().Get("lite") == "1" {
+		templateFile = liteTemplate
+	}
+
+	body, err := renderTemplate(page, templateFile)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	writeCacheable(w, req, "text/html; charset=utf-8", body)
 }
 
-// fetch standard table standings
-func getStandings() ([]byte, error) {
-	// configure request
-	url := `http://api.football-data.org/v4/competitions/PL/standings`
+// fetches the standard table standings and outputs the points gaps between consecutive teams as json
+func Gaps(w http.ResponseWriter, req *http.Request) {
+	comp, err := competition(req)
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusBadRequest, err)
+		return
+	}
 
-	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+	standings, status, err := requestStandings(req, comp)
 	if err != nil {
-		return nil, fmt.Errorf("error creating standings request: %w", err)
+		errorpage.JSON(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	markCache(w, status)
+
+	gaps, err := computeGaps(standings, comp, minMatchdays)
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	gaps.DataVersion = setDataVersion(w, standings)
+	gaps.Stale = markStale(w, comp)
+
+	body, err := encodeJSON(req, gaps)
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeCacheable(w, req, "application/json", body)
+}
+
+// write value to response as json, indented for ?pretty=1
+func writeJSON(w http.ResponseWriter, req *http.Request, value any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := encodeJSON(req, value)
+	if err != nil {
+		slog.ErrorContext(req.Context(), "error encoding json response", "err", err)
+		return
+	}
+
+	conditional.SetDataVersion(w.Header(), body)
+
+	if _, err := w.Write(body); err != nil {
+		slog.WarnContext(req.Context(), "error writing json response", "err", err)
+	}
+}
+
+// value as json, indented for ?pretty=1
+func encodeJSON(req *http.Request, value any) ([]byte, error) {
+	var body bytes.Buffer
+
+	encoder := json.NewEncoder(&body)
+	if req.URL.Query().Get("pretty") == "1" {
+		encoder.SetIndent("", "  ")
+	}
+
+	if err := encoder.Encode(value); err != nil {
+		return nil, fmt.Errorf("error encoding json: %w", err)
+	}
+
+	return body.Bytes(), nil
+}
+
+// informational points adjustments by team ID, set by Configure
+var pointsAdjustments map[int]Adjustment
+
+// parse informational points adjustments from POINTS_ADJUSTMENTS,
+// a json map of team ID to deduction and reason e.g. {"62": {"deduction": 6, "reason": "Everton, breach of PSR"}}
+func parsePointsAdjustments(value string) map[int]Adjustment {
+	if value == "" {
+		return nil
+	}
+
+	var adjustments map[int]Adjustment
+	if err := json.Unmarshal([]byte(value), &adjustments); err != nil {
+		slog.Warn("invalid POINTS_ADJUSTMENTS ignored", "err", err)
+		return nil
+	}
+
+	return adjustments
+}
+
+// footnotes explaining the points adjustments, ordered by team ID
+func adjustmentNotes(adjustments map[int]Adjustment) []string {
+	teamIDs := sortedKeys(adjustments)
+
+	notes := make([]string, 0, len(teamIDs))
+	for _, teamID := range teamIDs {
+		adjustment := adjustments[teamID]
+		notes = append(notes, fmt.Sprintf("%s %s", adjustment.label(), adjustment.Reason))
+	}
+
+	return notes
+}
+
+// keep only the selected teams, all teams are kept when none are selected
+func (o options) selectTeams(standingsTable []TableRow) []TableRow {
+	if len(o.teams) == 0 {
+		return standingsTable
+	}
+
+	var selected []TableRow
+
+	for _, row := range standingsTable {
+		if o.teams[row.Team.TLA] {
+			selected = append(selected, row)
+		}
+	}
+
+	return selected
+}
+
+// map keys in ascending order
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// label displayed next to the team, e.g. "(-6 pts)"
+func (a Adjustment) label() string {
+	return fmt.Sprintf("(-%d pts)", a.Deduction)
+}
+
+// get the requested competition code from the /cann/{competition} path, the comp query parameter or its long form competition,
+// as a code in any case or a friendly name e.g. "serie-a". Defaults to the Premier League
+func competition(req *http.Request) (string, error) {
+	comp := req.PathValue("competition")
+	if comp == "" {
+		comp = req.URL.Query().Get("comp")
+	}
+
+	if comp == "" {
+		comp = req.URL.Query().Get("competition")
+	}
+
+	if comp == "" {
+		return defaultCompetition, nil
+	}
+
+	if code, ok := competitionAliases[strings.ToLower(comp)]; ok {
+		return code, nil
+	}
+
+	if code := strings.ToUpper(comp); competitions[code] != "" {
+		return code, nil
+	}
+
+	return "", fmt.Errorf("unsupported competition: %q", comp)
+}
+
+// CacheStats returns the standings cache counters
+func CacheStats() cache.Stats {
+	return standingsCache.Stats()
+}
+
+// CacheEntries describes the cached standings, past seasons' standings and scorers keyed by cache name
+func CacheEntries() map[string][]cache.Entry {
+	return map[string][]cache.Entry{"standings": standingsCache.Entries(), "history": historyCache.Entries(), "scorers": scorersCache.Entries()}
+}
+
+// ClearCache empties the standings and scorers caches so the next requests fetch from the upstream api
+func ClearCache() {
+	standingsCache.Clear()
+	historyCache.Clear()
+	scorersCache.Clear()
+}
+
+// logs the error and responds 500 with the error page
+func returnError(w http.ResponseWriter, req *http.Request, err error) {
+	errorpage.Write(w, req, http.StatusInternalServerError, err)
+}
+
+// logs the error and responds 400 with the error page
+func returnBadRequest(w http.ResponseWriter, req *http.Request, err error) {
+	errorpage.Write(w, req, http.StatusBadRequest, err)
+}
+
+// fetch standard table standings for a competition code, with whether they came from the cache
+func getStandings(ctx context.Context, comp string) (standings []byte, status cacheStatus, err error) {
+	return getCached(ctx, "standings", standingsURL(comp))
+}
+
+// fetch the standings for the request, from the upstream api bypassing the cache when it has ?refresh=1. When the
+// fetch fails without a cached copy, e.g. after a restart, the latest saved snapshot is served as stale standings
+func requestStandings(req *http.Request, comp string) ([]byte, cacheStatus, error) {
+	var (
+		standings []byte
+		status    cacheStatus
+		err       error
+	)
+
+	if forceRefresh(req) {
+		standings, status, err = fetchOrStale(req.Context(), standingsCache, "standings", standingsURL(comp))
+	} else {
+		standings, status, err = getStandings(req.Context(), comp)
+	}
+
+	if err != nil {
+		if saved, at, ok := latestSnapshot(comp); ok {
+			slog.WarnContext(req.Context(), "serving the standings snapshot, fetch failed", "comp", comp, "saved", at.Format(time.RFC3339), "err", err)
+			return saved, cacheStale, nil
+		}
+	}
+
+	return standings, status, err
+}
+
+// football-data.org standings url for a competition code
+func standingsURL(comp string) string {
+	return fmt.Sprintf(`%s/competitions/%s/standings`, baseURL, comp)
+}
+
+// the competition whose current season standings are at the url, false for other resources and past seasons
+func currentStandingsComp(url string) (string, bool) {
+	for comp := range competitions {
+		if standingsURL(comp) == url {
+			return comp, true
+		}
+	}
+
+	return "", false
+}
+
+// get a resource from the standings cache or the upstream api
+func getCached(ctx context.Context, resource, url string) (body []byte, status cacheStatus, err error) {
+	return getCachedIn(ctx, standingsCache, resource, url)
+}
+
+// get a resource from the store or the upstream api, with whether it was a cache hit, miss or an expired copy.
+// With stale-while-revalidate an expired copy is returned immediately and refreshed in the background,
+// otherwise it is only returned if the fetch fails, e.g. when the upstream deadline is exceeded.
+func getCachedIn(ctx context.Context, store *cache.Cache, resource, url string) (body []byte, status cacheStatus, err error) {
+	if body, ok := store.Get(url); ok {
+		return body, cacheHit, nil
+	}
+
+	// the background refresh keeps it current
+	if body, fetched, ok := store.GetStale(url); ok && warm(url, fetched) {
+		return body, cacheHit, nil
+	}
+
+	if staleWhileRevalidate {
+		if body, _, ok := store.GetStale(url); ok {
+			revalidate(store, resource, url)
+			return body, cacheStale, nil
+		}
+	}
+
+	return fetchOrStale(ctx, store, resource, url)
+}
+
+// fetch a resource from the upstream api, falling back to an expired cached copy if the fetch fails
+func fetchOrStale(ctx context.Context, store *cache.Cache, resource, url string) ([]byte, cacheStatus, error) {
+	body, err := fetchInto(ctx, store, resource, url)
+	if err != nil {
+		if body, fetched, ok := store.GetStale(url); ok {
+			slog.WarnContext(ctx, "serving the cached copy, fetch failed", "resource", resource, "cached", fetched.Format(time.RFC3339), "err", err)
+			return body, cacheStale, nil
+		}
+
+		return nil, cacheMiss, err
+	}
+
+	return body, cacheMiss, nil
+}
+
+// fetch a resource from the upstream api within the upstream deadline and cache it in the store.
+// Nothing is sent while the circuit is open after repeated failures
+func fetchInto(ctx context.Context, store *cache.Cache, resource, url string) ([]byte, error) {
+	if err := upstreamBreaker.Allow(); err != nil {
+		return nil, fmt.Errorf("%s request not sent: %w", resource, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, upstreamTimeout)
+	defer cancel()
+
+	start := clk.Now()
+	body, err := fetchWithRetry(ctx, resource, url)
+	fetchDuration.Observe(clock.Since(clk, start).Seconds(), resource)
+	upstream.record(err)
+	upstreamBreaker.Record(upstreamFailure(err))
+
+	if err != nil {
+		fetchErrors.Inc(resource)
+		return nil, err
+	}
+
+	detectSchemaDrift(resource, body)
+
+	store.Set(url, body)
+	saveSnapshot(url, body)
+
+	if comp, ok := currentStandingsComp(url); ok && resource == "standings" {
+		checkAlerts(comp, body)
+	}
+
+	return body, nil
+}
+
+// request a resource from the upstream api
+func fetch(ctx context.Context, resource, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("error creating %s request: %w", resource, err)
 	}
 
 	// add API token to header
-	apiToken, ok := os.LookupEnv("API_TOKEN")
-	if !ok {
-		return nil, fmt.Errorf("environment variable -API_TOKEN- can not be read")
+	if apiToken == "" {
+		return nil, errNoAPIToken
 	}
 
 	req.Header.Add("X-Auth-Token", apiToken)
 
 	// get the response body
-	client := http.Client{}
+	resp, err := httpClient.Do(req)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%s request timed out after %s: %w", resource, upstreamTimeout, err)
+	}
 
-	resp, err := client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error requesting standings: %w", err)
+		return nil, fmt.Errorf("error requesting %s: %w", resource, err)
 	}
 	defer resp.Body.Close()
 
+	recordQuota(resp.Header)
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("standings response status not OK: %v", resp.StatusCode)
+		return nil, &statusError{resource: resource, status: resp.StatusCode, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), clk.Now())}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("error reading standings response: %w", err)
+		return nil, fmt.Errorf("error reading %s response: %w", resource, err)
 	}
 
 	return body, nil
 }
 
-// generate Cann table from standard standings table
-func generateCann(standings []byte) ([]Row, error) {
-	// unmarshall json standings into DataResponse slice of TableRows
+// unmarshall json standings into DataResponse
+func parseResponse(standings []byte) (DataResponse, error) {
 	var dataResponse DataResponse
 	if err := json.Unmarshal(standings, &dataResponse); err != nil {
-		return nil, fmt.Errorf("error unmarshalling json from standings response:%w", err)
+		return DataResponse{}, fmt.Errorf("error unmarshalling json from standings response:%w", err)
+	}
+
+	if len(dataResponse.Standings) == 0 {
+		return DataResponse{}, fmt.Errorf("standings response contains no standings")
+	}
+
+	if len(dataResponse.Standings[0].Table) == 0 {
+		return DataResponse{}, fmt.Errorf("standings response contains an empty table")
+	}
+
+	return dataResponse, nil
+}
+
+// unmarshall json standings into DataResponse and return the slice of TableRows
+func parseStandings(standings []byte) ([]TableRow, error) {
+	dataResponse, err := parseResponse(standings)
+	if err != nil {
+		return nil, err
+	}
+
+	return dataResponse.Standings[0].Table, nil
+}
+
+// compute the points gap from each team to the team immediately above it, the leader has a gap of 0.
+// Projected points are only included from the minimum matchday, before that a note explains their absence.
+func computeGaps(standings []byte, comp string, minMatchdays int) (GapsResponse, error) {
+	dataResponse, err := parseResponse(standings)
+	if err != nil {
+		return GapsResponse{}, err
 	}
 
 	standingsTable := dataResponse.Standings[0].Table
-	maxPoints := standingsTable[0].Points
-	minPoints := standingsTable[len(standingsTable)-1].Points
+	matchday := currentMatchday(dataResponse)
+	showDerived := matchday >= minMatchdays
+	games := totalGames(comp, len(standingsTable))
 
-	// generate an empty Cann table with the correct number of rows, set points values
-	cannTable := make([]Row, maxPoints-minPoints+1)
-	for i := range cannTable {
-		cannTable[i].Points = maxPoints - Points(i)
+	sorted := slices.Clone(standingsTable)
+	slices.SortStableFunc(sorted, func(a, b TableRow) int { return a.Position - b.Position })
+
+	gaps := make([]Gap, len(sorted))
+	for i, row := range sorted {
+		gaps[i] = Gap{
+			Position: row.Position,
+			TeamID:   row.Team.ID,
+			Team:     row.Team.ShortName,
+			TLA:      row.Team.TLA,
+			Points:   row.Points,
+
+			Remaining: remainingGames(row, games),
+			MaxPoints: maxPoints(row, games),
+		}
+
+		if i > 0 {
+			gaps[i].Gap = sorted[i-1].Points - row.Points
+		}
+
+		if showDerived {
+			projected := projectedPoints(row, games)
+			gaps[i].Projected = &projected
+		}
+	}
+
+	response := GapsResponse{Matchday: matchday, Gaps: gaps}
+	if !showDerived {
+		response.Note = fmt.Sprintf("insufficient data: projections are shown from matchday %d", minMatchdays)
 	}
 
-	const rowFormat = "[%d]%s(%d, %+d)"
+	return response, nil
+}
+
+// generate Cann table from standard standings table json
+func generateCann(standings []byte, opts options) ([]Row, error) {
+	standingsTable, err := parseStandings(standings)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCann(standingsTable, opts), nil
+}
+
+// build Cann table rows from the standings table, from the highest to the lowest points total in the table
+func buildCann(standingsTable []TableRow, opts options) []Row {
+	if opts.tableSize == 0 {
+		opts.tableSize = len(standingsTable)
+	}
+
+	standingsTable = opts.selectTeams(standingsTable)
+	if len(standingsTable) == 0 {
+		return nil
+	}
+
+	// the range is taken from every row, not the first and last, so a table out of points order can't index outside it
+	maxPoints, minPoints := standingsTable[0].Points, standingsTable[0].Points
+	for _, row := range standingsTable[1:] {
+		maxPoints, minPoints = max(maxPoints, row.Points), min(minPoints, row.Points)
+	}
+
+	sortRow := opts.rowSort
+	if sortRow == nil {
+		sortRow, _ = rowSortFor("") //nolint:errcheck // the configured criteria are checked by Configure
+	}
 
-	// loop thru standard table and assign team names and details to their point values in the Cann table
+	// collect the teams on each points value first, so teams sharing a row are ordered the same on every refresh
+	buckets := make([][]TableRow, maxPoints-minPoints+1)
 	for _, row := range standingsTable {
 		index := maxPoints - row.Points
-		rowData := fmt.Sprintf(rowFormat, row.Position, row.Team.ShortName, row.Played, row.GoalDiff)
-		cannTable[index].Teams += fmt.Sprintf(" - %v", rowData)
+		buckets[index] = append(buckets[index], row)
 	}
 
-	return cannTable, nil
+	// generate the Cann table with the correct number of rows, set points values
+	cannTable := make([]Row, len(buckets))
+	for i, bucket := range buckets {
+		slices.SortStableFunc(bucket, sortRow)
+
+		cannTable[i].Points = maxPoints - Points(i)
+		for _, row := range bucket {
+			addTeam(&cannTable[i], row, opts)
+		}
+	}
+
+	return cannTable
 }
 
-// write Cann table to response
-func writeResponse(w http.ResponseWriter, cannTable []Row) error {
-	cannTemplate := template.Must(template.ParseFiles("cann/CannTemplate.html"))
-	if err := cannTemplate.Execute(w, cannTable); err != nil {
-		return fmt.Errorf("error executing cannTemplate: %w", err)
+// append the team's details, zone and badges to the Cann table row
+func addTeam(cannRow *Row, row TableRow, opts options) {
+	team := rowTeam(row, opts)
+
+	cannRow.Teams += fmt.Sprintf(" - %v", team)
+	cannRow.TeamDetails = append(cannRow.TeamDetails, team)
+}
+
+// the team's details, zone and badges from its standings table row
+func rowTeam(row TableRow, opts options) RowTeam {
+	var labels string
+	if adjustment, ok := opts.adjustments[row.Team.ID]; ok {
+		labels += adjustment.label()
+	}
+
+	if europeanComp, ok := opts.europe[row.Position]; ok {
+		labels += europeanLabel(europeanComp)
+	}
+
+	if opts.derby[row.Team.ID] {
+		labels += derbyLabel
+	}
+
+	labels += xgLabel(row)
+	labels += opts.odds[row.Team.ID].label()
+	labels += lastSeasonLabel(opts.lastSeason, row.Team.ID)
+	labels += movementLabel(opts.movement, row.Team.ID)
+
+	team := RowTeam{
+		Position:     row.Position,
+		TeamID:       row.Team.ID,
+		Team:         row.Team.ShortName,
+		TLA:          row.Team.TLA,
+		Played:       row.Played,
+		GoalDiff:     row.GoalDiff,
+		GoalsFor:     row.GoalsFor,
+		GoalsAgainst: row.GoalsAgainst,
+		Zone:         placeZone(opts.europe, row.Position, opts.tableSize),
+		Movement:     opts.movement[row.Team.ID],
+		CrestURL:     row.Team.Crest,
+		Labels:       labels,
+	}
+
+	if !opts.hideForm {
+		team.Form = formResults(row.Form)
+	}
+
+	if stats, ok := opts.stats[row.Team.ID]; ok {
+		team.Stats = &stats
+	}
+
+	return team
+}
+
+// Cann table templates, the lite variant has no styling for slow connections and embeds
+const (
+	fullTemplate     = "CannTemplate.html"
+	liteTemplate     = "CannLiteTemplate.html"
+	fixturesTemplate = "FixturesTemplate.html"
+	imageTemplate    = "CannImage.svg"
+	compareTemplate  = "CompareTemplate.html"
+)
+
+//go:embed CannTemplate.html CannLiteTemplate.html FixturesTemplate.html CannImage.svg CompareTemplate.html
+var templateFS embed.FS
+
+// the Cann table, fixtures, image and compare templates compiled into the binary and parsed once at startup, named by file
+var cannTemplates = template.Must(template.ParseFS(templateFS, fullTemplate, liteTemplate, fixturesTemplate, imageTemplate, compareTemplate))
+
+// directory the Cann table templates are re-read from on every render for live editing, set by Configure.
+// The compiled in templates are used when empty
+var templateDir string
+
+// render the Cann table or fixtures html, buffered so nothing is written when the template fails
+func renderTemplate(page any, templateFile string) ([]byte, error) {
+	templates := cannTemplates
+	if templateDir != "" {
+		var err error
+		if templates, err = template.ParseFS(os.DirFS(templateDir), templateFile); err != nil {
+			return nil, fmt.Errorf("error reading cannTemplate: %w", err)
+		}
+	}
+
+	var body bytes.Buffer
+	if err := templates.ExecuteTemplate(&body, templateFile, page); err != nil {
+		return nil, fmt.Errorf("error executing cannTemplate: %w", err)
 	}
 
-	return nil
+	return body.Bytes(), nil
 }
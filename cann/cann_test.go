@@ -1,10 +1,16 @@
 package cann
 
 import (
+	"bytes"
+	"encoding/json"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGenerateCann(t *testing.T) {
@@ -14,13 +20,13 @@ func TestGenerateCann(t *testing.T) {
 	}
 
 	validCannTable := []Row{
-		{45, " - [1]Liverpool(20, -25)"},
-		{44, ""},
-		{43, ""},
-		{42, " - [2]Aston Villa(20, +16)"},
-		{41, ""},
-		{40, " - [3]Man City(19, +24) - [4]Arsenal(20, +17)"},
-		{39, " - [5]Tottenham(20, +13)"},
+		{45, " - [1]Liverpool(20, -25)[CL]", []RowTeam{{1, 64, "Liverpool", "LIV", 20, -25, 18, 43, ZoneChampionsLeague, 0, nil, "https://crests.football-data.org/64.png", "[CL]", nil}}},
+		{44, "", nil},
+		{43, "", nil},
+		{42, " - [2]Aston Villa(20, +16)[CL]", []RowTeam{{2, 58, "Aston Villa", "AVL", 20, 16, 43, 27, ZoneChampionsLeague, 0, nil, "https://crests.football-data.org/58.png", "[CL]", nil}}},
+		{41, "", nil},
+		{40, " - [3]Man City(19, +24)[CL] - [4]Arsenal(20, +17)[CL]", []RowTeam{{3, 65, "Man City", "MCI", 19, 24, 45, 21, ZoneChampionsLeague, 0, nil, "https://crests.football-data.org/65.png", "[CL]", nil}, {4, 57, "Arsenal", "ARS", 20, 17, 37, 20, ZoneChampionsLeague, 0, nil, "https://crests.football-data.org/57.png", "[CL]", nil}}},
+		{39, " - [5]Tottenham(20, +13)[EL]", []RowTeam{{5, 73, "Tottenham", "TOT", 20, 13, 42, 29, ZoneEuropa, 0, nil, "https://crests.football-data.org/73.svg", "[EL]", nil}}},
 	}
 
 	tests := []struct {
@@ -33,7 +39,7 @@ func TestGenerateCann(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		got, err := generateCann(test.input)
+		got, err := generateCann(test.input, options{europe: europeanPlaces("PL")})
 		if hasError := err != nil; hasError != test.hasError {
 			t.Errorf("generateCann()\n got err:%v, \nwant hasError:%v", err, test.hasError)
 		}
@@ -43,3 +49,356 @@ func TestGenerateCann(t *testing.T) {
 		}
 	}
 }
+
+func TestComputeGaps(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	want := []Gap{
+		{1, 64, "Liverpool", "LIV", 45, 0, 18, 99, nil},
+		{2, 58, "Aston Villa", "AVL", 42, 3, 18, 96, nil},
+		{3, 65, "Man City", "MCI", 40, 2, 19, 97, nil},
+		{4, 57, "Arsenal", "ARS", 40, 0, 18, 94, nil},
+		{5, 73, "Tottenham", "TOT", 39, 1, 18, 93, nil},
+	}
+
+	got, err := computeGaps(validStandings, "PL", 21)
+	if err != nil {
+		t.Fatalf("computeGaps() err = %v, want nil", err)
+	}
+
+	if !reflect.DeepEqual(got.Gaps, want) {
+		t.Errorf("computeGaps()\ngot :%#v, \nwant:%#v", got.Gaps, want)
+	}
+}
+
+func TestGenerateCannAdjustment(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	opts := options{adjustments: map[int]Adjustment{58: {Deduction: 6, Reason: "Aston Villa, test deduction"}}}
+
+	got, err := generateCann(validStandings, opts)
+	if err != nil {
+		t.Fatalf("generateCann() err = %v, want nil", err)
+	}
+
+	if want := " - [2]Aston Villa(20, +16)(-6 pts)"; got[3].Teams != want {
+		t.Errorf("generateCann() adjusted row = %q, want %q", got[3].Teams, want)
+	}
+
+	if got[3].Points != 42 {
+		t.Errorf("generateCann() adjusted row points = %v, want fetched points 42", got[3].Points)
+	}
+
+	if notes := adjustmentNotes(opts.adjustments); len(notes) != 1 || notes[0] != "(-6 pts) Aston Villa, test deduction" {
+		t.Errorf("adjustmentNotes() = %q, want single note for the configured team", notes)
+	}
+}
+
+func TestRender(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	w := httptest.NewRecorder()
+	Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?format=json", bytes.NewReader(validStandings)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Render() status = %d, want %d\n%s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var got cannPage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Rows) != 7 || got.Rows[5].Teams != " - [3]Man City(19, +24)[CL] - [4]Arsenal(20, +17)[CL]" {
+		t.Errorf("Render() rows = %#v, want the Cann table for the posted standings", got.Rows)
+	}
+}
+
+func TestRenderCrests(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	Render(w, httptest.NewRequest(http.MethodPost, "/debug/render", bytes.NewReader(validStandings)))
+
+	want := `<img class="crest" src="https://crests.football-data.org/73.svg" alt="" width="16" height="16"><span class="europa">[5]Tottenham`
+	if body := w.Body.String(); !strings.Contains(body, want) {
+		t.Errorf("Render() html = %s, want the crest before each team %s", body, want)
+	}
+}
+
+func TestRenderInvalidPayload(t *testing.T) {
+	for _, body := range []string{"", "not json", `{"standings": []}`, `{"standings": [{"table": []}]}`} {
+		w := httptest.NewRecorder()
+		Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?format=json", strings.NewReader(body)))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Render(%q) status = %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestGenerateTableEmptyStandings(t *testing.T) {
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`{"standings": []}`, "standings response contains no standings"},
+		{`{"standings": [{"table": []}]}`, "standings response contains an empty table"},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(test.body)) //nolint:errcheck // test server
+		}))
+
+		Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second})
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann", http.NoBody))
+
+		ts.Close()
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), test.want) {
+			t.Errorf("GenerateTable(%s) status = %d body = %q, want 500 with %q", test.body, w.Code, w.Body, test.want)
+		}
+	}
+}
+
+func TestBuildCannOutOfOrder(t *testing.T) {
+	table := []TableRow{
+		{Team: Team{ID: 1, ShortName: "AAA"}, Position: 1, Points: 10},
+		{Team: Team{ID: 2, ShortName: "BBB"}, Position: 2, Points: 12}, // e.g. after a points correction
+	}
+
+	got := buildCann(table, options{})
+
+	if len(got) != 3 || got[0].Points != 12 || got[2].Points != 10 {
+		t.Errorf("buildCann() out of points order = %+v, want rows from 12 to 10 points", got)
+	}
+
+	if single := buildCann(table[:1], options{}); len(single) != 1 || single[0].Teams != " - [1]AAA(0, +0)" {
+		t.Errorf("buildCann() single row = %+v, want one row with the team", single)
+	}
+}
+
+func TestGenerateTableCompetition(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = append(requested, req.URL.Path)
+
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url             string
+		wantStatus      int
+		wantPath        string
+		wantCompetition string
+	}{
+		{"/cann?format=json", http.StatusOK, "/competitions/PL/standings", "Premier League"},
+		{"/cann?format=json&competition=BL1", http.StatusOK, "/competitions/BL1/standings", "Bundesliga"},
+		{"/cann?format=json&comp=SA", http.StatusOK, "/competitions/SA/standings", "Serie A"},
+		{"/cann?format=json&comp=elc", http.StatusOK, "/competitions/ELC/standings", "Championship"},
+		{"/cann?format=json&comp=La-Liga", http.StatusOK, "/competitions/PD/standings", "Primera Division"},
+		{"/cann?format=json&competition=eredivisie", http.StatusOK, "/competitions/DED/standings", "Eredivisie"},
+		{"/cann?format=json&competition=XYZ", http.StatusBadRequest, "", ""},
+		{"/cann?format=json&competition=../PL", http.StatusBadRequest, "", ""},
+		{"/cann/fl1?format=json", http.StatusOK, "/competitions/FL1/standings", "Ligue 1"},
+		{"/cann/primeira-liga?format=json&comp=PL", http.StatusOK, "/competitions/PPL/standings", "Primeira Liga"},
+		{"/cann/XYZ?format=json", http.StatusBadRequest, "", ""},
+	}
+
+	// the competition can be a path parameter as routed by main
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /cann", GenerateTable)
+	mux.HandleFunc("GET /cann/{competition}", GenerateTable)
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		requested = nil
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("GenerateTable(%s) status = %d, want %d", test.url, w.Code, test.wantStatus)
+			continue
+		}
+
+		if test.wantStatus != http.StatusOK {
+			if len(requested) != 0 {
+				t.Errorf("GenerateTable(%s) requested %q, want no upstream request", test.url, requested)
+			}
+
+			continue
+		}
+
+		var got cannPage
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(requested) != 1 || requested[0] != test.wantPath || got.Competition != test.wantCompetition {
+			t.Errorf("GenerateTable(%s) requested %q competition %q, want %s %q", test.url, requested, got.Competition, test.wantPath, test.wantCompetition)
+		}
+	}
+}
+
+func TestGenerateTableJSONShape(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	req := httptest.NewRequest(http.MethodGet, "/cann", http.NoBody)
+	req.Header.Set("Accept", "application/json")
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	GenerateTable(w, req)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("GenerateTable() Content-Type = %q, want application/json", contentType)
+	}
+
+	var got struct {
+		Rows []map[string]json.RawMessage `json:"rows"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Rows) != 7 {
+		t.Fatalf("GenerateTable() rows = %d, want 7", len(got.Rows))
+	}
+
+	want := `[{"position":3,"teamId":65,"team":"Man City","tla":"MCI","playedGames":19,"goalDifference":24,"goalsFor":45,"goalsAgainst":21,"zone":"champions-league","crestUrl":"https://crests.football-data.org/65.png","labels":"[CL]"},` +
+		`{"position":4,"teamId":57,"team":"Arsenal","tla":"ARS","playedGames":20,"goalDifference":17,"goalsFor":37,"goalsAgainst":20,"zone":"champions-league","crestUrl":"https://crests.football-data.org/57.png","labels":"[CL]"}]`
+	if row := got.Rows[5]; string(row["points"]) != "40" || string(row["teams"]) != want {
+		t.Errorf("GenerateTable() 40 points row = %s %s, want 40 %s", row["points"], row["teams"], want)
+	}
+
+	if teams := string(got.Rows[1]["teams"]); teams != "[]" {
+		t.Errorf("GenerateTable() empty row teams = %s, want []", teams)
+	}
+}
+
+func TestRenderLanguage(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url            string
+		acceptLanguage string
+		wantBody       []string
+	}{
+		{"/debug/render", "", []string{`<html lang="en">`, "<h1> Premier League Cann table </h1>", "<th>Points</th>"}},
+		{"/debug/render", "pt-BR,pt;q=0.9", []string{`<html lang="pt">`, "<h1> Tabela Cann: Premier League </h1>", "<th>Pontos</th>"}},
+		{"/debug/render?lang=es&grouped=1", "pt-BR", []string{`<html lang="es">`, "<th>Puntos</th>", "Liga de Campeones</th>", "Liga Europa</th>"}},
+		{"/debug/render?lang=es&lite=1", "", []string{`<html lang="es">`, "<h1>Tabla Cann: Premier League</h1>"}},
+		{"/debug/render?lang=pt&format=json", "", []string{`"competition":"Premier League"`}},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, test.url, bytes.NewReader(validStandings))
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Render(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		for _, want := range test.wantBody {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("Render(%s) Accept-Language %q body = %s, want %s", test.url, test.acceptLanguage, w.Body, want)
+			}
+		}
+	}
+}
+
+func TestRenderNotesHeadings(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(adjustments map[int]Adjustment) { pointsAdjustments = adjustments }(pointsAdjustments)
+
+	const notesHeading, adjustmentsHeading = "<p>Notes</p>", "<p>Points adjustments are informational only"
+
+	tests := []struct {
+		url         string
+		adjustments map[int]Adjustment
+		want        []string
+		wantNot     []string
+	}{
+		{"/debug/render?winpoints=2", nil, []string{notesHeading, "points recomputed with 2 points"}, []string{adjustmentsHeading}},
+		{"/debug/render", map[int]Adjustment{58: {Deduction: 6, Reason: "test deduction"}}, []string{adjustmentsHeading, "(-6 pts) test deduction"},
+			[]string{notesHeading}},
+		{"/debug/render?winpoints=2&lang=es", map[int]Adjustment{58: {Deduction: 6, Reason: "test deduction"}},
+			[]string{"<p>Notas</p>", "<p>Los ajustes de puntos", "(-6 pts) test deduction"}, nil},
+	}
+
+	for _, test := range tests {
+		pointsAdjustments = test.adjustments
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Render(w, httptest.NewRequest(http.MethodPost, test.url, bytes.NewReader(validStandings)))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		for _, want := range test.want {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("Render(%s) body = %s, want %s", test.url, w.Body, want)
+			}
+		}
+
+		for _, unwanted := range test.wantNot {
+			if strings.Contains(w.Body.String(), unwanted) {
+				t.Errorf("Render(%s) body contains %s, want it left out", test.url, unwanted)
+			}
+		}
+	}
+}
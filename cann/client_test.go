@@ -0,0 +1,81 @@
+package cann
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// an HTTPClient answering every request with a fixed status and body
+type fixedClient struct {
+	status int
+	body   string
+	urls   []string
+}
+
+func (c *fixedClient) Do(req *http.Request) (*http.Response, error) {
+	c.urls = append(c.urls, req.URL.String())
+
+	return &http.Response{StatusCode: c.status, Body: io.NopCloser(strings.NewReader(c.body)), Request: req}, nil
+}
+
+func TestGenerateTableInjectedClient(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a TLS server only accepts its own client, so a successful fetch shows the injected client was used
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second, HTTPClient: ts.Client()})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann?format=json", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK {
+		t.Fatalf("GenerateTable() status = %d body = %s, want 200", w.Code, w.Body)
+	}
+
+	var got cannPage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Rows) != 7 || got.Rows[5].Teams != " - [3]Man City(19, +24)[CL] - [4]Arsenal(20, +17)[CL]" {
+		t.Errorf("GenerateTable() rows = %#v, want the Cann table for the fetched standings", got.Rows)
+	}
+}
+
+func TestGenerateTableInjectedClientNotOK(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	client := &fixedClient{status: http.StatusServiceUnavailable}
+
+	Configure(Settings{BaseURL: "http://upstream.test", APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second, HTTPClient: client, RetryAttempts: 1})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "standings response status not OK: 503") {
+		t.Errorf("GenerateTable() status = %d body = %q, want 500 with the upstream status", w.Code, w.Body)
+	}
+
+	if want := "http://upstream.test/competitions/PL/standings"; len(client.urls) != 1 || client.urls[0] != want {
+		t.Errorf("injected client requests = %q, want [%s]", client.urls, want)
+	}
+}
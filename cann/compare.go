@@ -0,0 +1,330 @@
+package cann
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mick4711/moh/negotiate"
+)
+
+const (
+	headToHeadLimit   = 10 // past meetings fetched, football-data.org returns the most recent first
+	recentFormMatches = 5
+)
+
+// trajectory chart dimensions in pixels
+const (
+	trajectoryWidth   = 600
+	trajectoryHeight  = 240
+	trajectoryPadding = 10
+)
+
+// A CompareTeam is a team's current season in the head-to-head comparison
+type CompareTeam struct {
+	RowTeam
+	Points     Points   `json:"points"`
+	Won        int      `json:"won"`
+	Draw       int      `json:"draw"`
+	Lost       int      `json:"lost"`
+	Trajectory []Points `json:"trajectory"` // points after each finished match in kick off order
+	RecentForm []string `json:"recentForm"` // results of the last five finished matches W, D or L, oldest first
+}
+
+// A Meeting is a past match between the two teams
+type Meeting struct {
+	Date        string `json:"date"` // yyyy-mm-dd
+	Competition string `json:"competition"`
+	Home        string `json:"home"`
+	Away        string `json:"away"`
+	Score       string `json:"score"`
+}
+
+// HeadToHead counts the results of the past meetings
+type HeadToHead struct {
+	HomeWins int `json:"homeWins"` // won by the ?home= team wherever it was played
+	Draws    int `json:"draws"`
+	AwayWins int `json:"awayWins"`
+}
+
+// A TrajectoryChart is both teams' points after each match drawn as svg lines, x is matches played and y points
+type TrajectoryChart struct {
+	Width, Height int
+	Home, Away    string // svg polyline points e.g. "10,230 40,190"
+	MaxPoints     Points
+}
+
+// the football-data.org head2head response, the matches are in any competition
+type headToHeadResponse struct {
+	Matches []struct {
+		Match
+		Competition struct {
+			Name string `json:"name"`
+		} `json:"competition"`
+	} `json:"matches"`
+}
+
+// data passed to the compare template, the teams are nil until both are chosen
+type comparePage struct {
+	Competition string           `json:"competition"`
+	Home        *CompareTeam     `json:"home,omitempty"`
+	Away        *CompareTeam     `json:"away,omitempty"`
+	Record      *HeadToHead      `json:"record,omitempty"`
+	Meetings    []Meeting        `json:"meetings,omitempty"` // most recent first
+	Notes       []string         `json:"notes,omitempty"`
+	Chart       *TrajectoryChart `json:"-"`
+	Teams       []Team           `json:"-"` // the team pickers, alphabetically
+	Code        string           `json:"-"` // competition code for the pickers
+	HomeTLA     string           `json:"-"`
+	AwayTLA     string           `json:"-"`
+}
+
+// Compare writes the current season stats, points trajectories, recent form and past meetings of the ?home= and
+// ?away= teams by their three letter codes side by side, as html or json for ?format=json. ?comp= chooses the
+// competition as for the Cann table. Without both teams it only offers the team pickers
+func Compare(w http.ResponseWriter, req *http.Request) {
+	comp, err := competition(req)
+	if err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	standings, _, err := requestStandings(req, comp)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	standingsTable, err := parseStandings(standings)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	query := req.URL.Query()
+	page := comparePage{Competition: competitions[comp], Teams: pickerTeams(standingsTable), Code: comp,
+		HomeTLA: strings.ToUpper(query.Get("home")), AwayTLA: strings.ToUpper(query.Get("away"))}
+
+	if page.HomeTLA != "" && page.AwayTLA != "" {
+		if err := compareTeams(req.Context(), &page, comp, standingsTable); err != nil {
+			returnBadRequest(w, req, err)
+			return
+		}
+	}
+
+	if negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		writeJSON(w, req, page)
+		return
+	}
+
+	body, err := renderTemplate(page, compareTemplate)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	writeCacheable(w, req, "text/html; charset=utf-8", body)
+}
+
+var errSameTeam = errors.New("choose two different teams to compare")
+
+// fill in the comparison of the two teams, an error for teams not in the standings. The matches are only needed
+// here, a failed fetch leaves out the trajectories, form and meetings with a note
+func compareTeams(ctx context.Context, page *comparePage, comp string, standingsTable []TableRow) error {
+	if page.HomeTLA == page.AwayTLA {
+		return errSameTeam
+	}
+
+	opts := options{adjustments: pointsAdjustments, europe: europeanPlaces(comp), tableSize: len(standingsTable), hideForm: true}
+
+	for _, side := range []struct {
+		tla  string
+		team **CompareTeam
+	}{{page.HomeTLA, &page.Home}, {page.AwayTLA, &page.Away}} {
+		i := slices.IndexFunc(standingsTable, func(row TableRow) bool { return row.Team.TLA == side.tla })
+		if i < 0 {
+			return fmt.Errorf("unknown team %q", side.tla)
+		}
+
+		row := standingsTable[i]
+		*side.team = &CompareTeam{RowTeam: rowTeam(row, opts), Points: row.Points, Won: row.Won, Draw: row.Draw, Lost: row.Lost}
+	}
+
+	matches, err := getMatches(ctx, comp, "")
+	if err != nil {
+		slog.WarnContext(ctx, "head to head matches unavailable", "err", err)
+		page.Notes = append(page.Notes, "Points trajectories, form and past meetings unavailable")
+
+		return nil
+	}
+
+	slices.SortStableFunc(matches, func(a, b Match) int { return cmp.Compare(a.UTCDate, b.UTCDate) })
+
+	for _, team := range []*CompareTeam{page.Home, page.Away} {
+		team.Trajectory, team.RecentForm = seasonResults(matches, team.TeamID)
+	}
+
+	page.Chart = trajectoryChart(page.Home.Trajectory, page.Away.Trajectory)
+
+	fixture := slices.IndexFunc(matches, func(match Match) bool {
+		return (match.HomeTeam.ID == page.Home.TeamID && match.AwayTeam.ID == page.Away.TeamID) ||
+			(match.HomeTeam.ID == page.Away.TeamID && match.AwayTeam.ID == page.Home.TeamID)
+	})
+	if fixture < 0 {
+		page.Notes = append(page.Notes, "No meeting this season to find past meetings from")
+		return nil
+	}
+
+	if page.Meetings, page.Record, err = pastMeetings(ctx, matches[fixture].ID, page.Home.TeamID); err != nil {
+		slog.WarnContext(ctx, "head to head meetings unavailable", "err", err)
+		page.Notes = append(page.Notes, "Past meetings unavailable")
+	}
+
+	return nil
+}
+
+// the team's points after each of its finished matches and its last five results, from the season's matches in
+// kick off order
+func seasonResults(matches []Match, teamID int) ([]Points, []string) {
+	var (
+		points     Points
+		trajectory []Points
+		results    []string
+	)
+
+	for _, match := range matches {
+		result := matchResult(match, teamID)
+		if result == "" {
+			continue
+		}
+
+		switch result {
+		case "W":
+			points += pointsForWin
+		case "D":
+			points++
+		}
+
+		trajectory = append(trajectory, points)
+		results = append(results, result)
+	}
+
+	return trajectory, results[max(len(results)-recentFormMatches, 0):]
+}
+
+// the team's result in a finished match W, D or L, empty when it didn't play in it or the match isn't finished
+func matchResult(match Match, teamID int) string {
+	if match.Status != "FINISHED" {
+		return ""
+	}
+
+	var goalsFor, goalsAgainst int
+
+	switch teamID {
+	case match.HomeTeam.ID:
+		goalsFor, goalsAgainst = match.Score.FullTime.Home, match.Score.FullTime.Away
+	case match.AwayTeam.ID:
+		goalsFor, goalsAgainst = match.Score.FullTime.Away, match.Score.FullTime.Home
+	default:
+		return ""
+	}
+
+	switch {
+	case goalsFor > goalsAgainst:
+		return "W"
+	case goalsFor == goalsAgainst:
+		return "D"
+	default:
+		return "L"
+	}
+}
+
+// the finished past meetings of the teams playing the match, most recent first, and their results for the home
+// team. Kept in the history cache as they only change after the teams meet again
+func pastMeetings(ctx context.Context, matchID, homeID int) ([]Meeting, *HeadToHead, error) {
+	url := fmt.Sprintf(`%s/matches/%d/head2head?limit=%d`, baseURL, matchID, headToHeadLimit)
+
+	body, _, err := getCachedIn(ctx, historyCache, "head2head", url)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var response headToHeadResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, nil, fmt.Errorf("error unmarshalling json from head2head response: %w", err)
+	}
+
+	var meetings []Meeting
+
+	record := &HeadToHead{}
+
+	for _, match := range response.Matches {
+		if match.Status != "FINISHED" {
+			continue
+		}
+
+		meetings = append(meetings, Meeting{Date: strings.SplitN(match.UTCDate, "T", 2)[0], Competition: match.Competition.Name,
+			Home: match.HomeTeam.ShortName, Away: match.AwayTeam.ShortName,
+			Score: fmt.Sprintf("%d-%d", match.Score.FullTime.Home, match.Score.FullTime.Away)})
+
+		switch matchResult(match.Match, homeID) {
+		case "W":
+			record.HomeWins++
+		case "D":
+			record.Draws++
+		case "L":
+			record.AwayWins++
+		}
+	}
+
+	slices.SortStableFunc(meetings, func(a, b Meeting) int { return cmp.Compare(b.Date, a.Date) })
+
+	return meetings, record, nil
+}
+
+// both trajectories scaled to the chart from 0 points before the first match, nil before either team has played
+func trajectoryChart(home, away []Points) *TrajectoryChart {
+	matches := max(len(home), len(away))
+	if matches == 0 {
+		return nil
+	}
+
+	chart := TrajectoryChart{Width: trajectoryWidth, Height: trajectoryHeight, MaxPoints: 1}
+	for _, points := range slices.Concat(home, away) {
+		chart.MaxPoints = max(chart.MaxPoints, points)
+	}
+
+	line := func(trajectory []Points) string {
+		points := make([]string, 0, len(trajectory)+1)
+
+		for i, total := range slices.Concat([]Points{0}, trajectory) {
+			x := trajectoryPadding + i*(trajectoryWidth-2*trajectoryPadding)/matches
+			y := trajectoryHeight - trajectoryPadding - int(total)*(trajectoryHeight-2*trajectoryPadding)/int(chart.MaxPoints)
+			points = append(points, fmt.Sprintf("%d,%d", x, y))
+		}
+
+		return strings.Join(points, " ")
+	}
+
+	chart.Home, chart.Away = line(home), line(away)
+
+	return &chart
+}
+
+// the teams in the standings alphabetically, for the team pickers
+func pickerTeams(standingsTable []TableRow) []Team {
+	teams := make([]Team, 0, len(standingsTable))
+	for _, row := range standingsTable {
+		teams = append(teams, row.Team)
+	}
+
+	slices.SortFunc(teams, func(a, b Team) int { return strings.Compare(a.ShortName, b.ShortName) })
+
+	return teams
+}
@@ -0,0 +1,118 @@
+package cann
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const compareMatchesJSON = `{"matches": [
+	{"id": 3, "utcDate": "2023-09-03T15:00:00Z", "status": "FINISHED", "homeTeam": {"id": 57, "shortName": "Arsenal", "tla": "ARS"},
+		"awayTeam": {"id": 64, "shortName": "Liverpool", "tla": "LIV"}, "score": {"fullTime": {"home": 1, "away": 1}}},
+	{"id": 1, "utcDate": "2023-08-12T15:00:00Z", "status": "FINISHED", "homeTeam": {"id": 57, "shortName": "Arsenal", "tla": "ARS"},
+		"awayTeam": {"id": 65, "shortName": "Man City", "tla": "MCI"}, "score": {"fullTime": {"home": 2, "away": 0}}},
+	{"id": 2, "utcDate": "2023-08-19T15:00:00Z", "status": "FINISHED", "homeTeam": {"id": 64, "shortName": "Liverpool", "tla": "LIV"},
+		"awayTeam": {"id": 65, "shortName": "Man City", "tla": "MCI"}, "score": {"fullTime": {"home": 0, "away": 3}}},
+	{"id": 99, "utcDate": "2024-03-31T15:30:00Z", "status": "TIMED", "homeTeam": {"id": 65, "shortName": "Man City", "tla": "MCI"},
+		"awayTeam": {"id": 57, "shortName": "Arsenal", "tla": "ARS"}, "score": {"fullTime": {"home": null, "away": null}}}
+]}`
+
+const headToHeadJSON = `{"matches": [
+	{"id": 1, "utcDate": "2023-08-12T15:00:00Z", "status": "FINISHED", "competition": {"name": "Premier League"},
+		"homeTeam": {"id": 57, "shortName": "Arsenal"}, "awayTeam": {"id": 65, "shortName": "Man City"}, "score": {"fullTime": {"home": 2, "away": 0}}},
+	{"id": 7, "utcDate": "2023-08-06T15:00:00Z", "status": "FINISHED", "competition": {"name": "FA Community Shield"},
+		"homeTeam": {"id": 57, "shortName": "Arsenal"}, "awayTeam": {"id": 65, "shortName": "Man City"}, "score": {"fullTime": {"home": 1, "away": 1}}},
+	{"id": 8, "utcDate": "2023-04-26T19:00:00Z", "status": "FINISHED", "competition": {"name": "Premier League"},
+		"homeTeam": {"id": 65, "shortName": "Man City"}, "awayTeam": {"id": 57, "shortName": "Arsenal"}, "score": {"fullTime": {"home": 4, "away": 1}}}
+]}`
+
+func TestCompare(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.RequestURI() {
+		case "/competitions/PL/standings":
+			_, _ = w.Write(validStandings) //nolint:errcheck // test server
+		case "/competitions/PL/matches":
+			_, _ = w.Write([]byte(compareMatchesJSON)) //nolint:errcheck // test server
+		case "/matches/1/head2head?limit=10": // the first meeting this season
+			_, _ = w.Write([]byte(headToHeadJSON)) //nolint:errcheck // test server
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url        string
+		wantStatus int
+		wantBody   []string
+	}{
+		{"/compare", http.StatusOK, []string{`<option value="ARS">Arsenal</option>`, `<button type="submit">Compare</button>`}},
+		{"/compare?home=ars&away=MCI", http.StatusOK, []string{`<option value="ARS" selected>Arsenal</option>`, "<th>Arsenal</th>",
+			"<td>12 / 4 / 4</td>", `<span class="form form-W">W</span><span class="form form-D">D</span>`, `<polyline points="10,230 300,65 590,10"`,
+			"<p>Arsenal 1 wins, 1 draws, Man City 1 wins</p>", "<td>2023-08-12</td>", "<td>FA Community Shield</td>", "<td>Man City 4-1 Arsenal</td>"}},
+		{"/compare?home=ARS&away=MCI&format=json", http.StatusOK, []string{`"tla":"ARS"`, `"points":40,"won":12,"draw":4,"lost":4,"trajectory":[3,4],"recentForm":["W","D"]`,
+			`"record":{"homeWins":1,"draws":1,"awayWins":1}`, `"meetings":[{"date":"2023-08-12","competition":"Premier League","home":"Arsenal","away":"Man City","score":"2-0"}`}},
+		{"/compare?home=LIV&away=AVL", http.StatusOK, []string{"No meeting this season to find past meetings from"}},
+		{"/compare?home=ARS&away=ars", http.StatusBadRequest, []string{"choose two different teams"}},
+		{"/compare?home=ARS&away=XXX", http.StatusBadRequest, []string{"unknown team &#34;XXX&#34;"}},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+		req.Header.Set("Accept", "text/html") // error messages as pages too
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Compare(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("Compare(%s) status = %d body = %s, want %d", test.url, w.Code, w.Body, test.wantStatus)
+			continue
+		}
+
+		for _, want := range test.wantBody {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("Compare(%s) body = %s, want %s", test.url, w.Body, want)
+			}
+		}
+	}
+}
+
+func TestSeasonResults(t *testing.T) {
+	matches := []Match{
+		{Status: "FINISHED", HomeTeam: Team{ID: 1}, AwayTeam: Team{ID: 2}},
+		{Status: "FINISHED", HomeTeam: Team{ID: 3}, AwayTeam: Team{ID: 1}},
+		{Status: "TIMED", HomeTeam: Team{ID: 1}, AwayTeam: Team{ID: 3}},
+	}
+	matches[1].Score.FullTime.Home, matches[1].Score.FullTime.Away = 0, 2
+
+	for range 5 {
+		match := Match{Status: "FINISHED", HomeTeam: Team{ID: 1}, AwayTeam: Team{ID: 4}}
+		match.Score.FullTime.Away = 1
+		matches = append(matches, match)
+	}
+
+	trajectory, form := seasonResults(matches, 1)
+
+	if want := []Points{1, 4, 4, 4, 4, 4, 4}; !reflect.DeepEqual(trajectory, want) {
+		t.Errorf("seasonResults() trajectory = %v, want %v", trajectory, want)
+	}
+
+	if want := []string{"L", "L", "L", "L", "L"}; !reflect.DeepEqual(form, want) {
+		t.Errorf("seasonResults() form = %v, want the last five %v", form, want)
+	}
+}
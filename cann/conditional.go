@@ -0,0 +1,48 @@
+package cann
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/mick4711/moh/conditional"
+)
+
+// lifetime browsers and CDNs may reuse a Cann page for, the standings cache lifetime set by Configure
+var cacheMaxAge = defaultTTL
+
+// when each url's page last changed, its Last-Modified time
+var pageChanges = conditional.NewChanges(maxTrackedPages)
+
+// urls whose Last-Modified times are tracked
+const maxTrackedPages = 1000
+
+// write a generated body with an ETag hashed from it, a Last-Modified time of when the url's page last changed and
+// a Cache-Control max-age of the standings cache lifetime. 304 Not Modified without a body when If-None-Match has
+// the ETag, or without If-None-Match when the page hasn't changed since If-Modified-Since. Stale copies are marked no-cache
+func writeCacheable(w http.ResponseWriter, req *http.Request, contentType string, body []byte) {
+	tag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	modified := pageChanges.Since(req.URL.RequestURI(), tag, clk.Now())
+
+	w.Header().Set("ETag", tag)
+	conditional.SetLastModified(w.Header(), modified)
+	w.Header().Add("Vary", "Cookie") // the a11y and watchlist cookies change the page
+
+	if w.Header().Get(cacheHeader) == string(cacheStale) {
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(cacheMaxAge.Seconds())))
+	}
+
+	if conditional.NotModified(req, tag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+
+	if _, err := w.Write(body); err != nil {
+		slog.WarnContext(req.Context(), "error writing response", "err", err)
+	}
+}
@@ -0,0 +1,83 @@
+package cann
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestGenerateTableConditional(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: 90 * time.Second, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	first := httptest.NewRecorder()
+	GenerateTable(first, httptest.NewRequest(http.MethodGet, "/cann", http.NoBody))
+
+	tag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || tag == "" || first.Header().Get("Cache-Control") != "max-age=90" {
+		t.Fatalf("GenerateTable() = %d ETag %q Cache-Control %q, want 200 with an ETag and max-age=90",
+			first.Code, tag, first.Header().Get("Cache-Control"))
+	}
+
+	modified, err := http.ParseTime(first.Header().Get("Last-Modified"))
+	if err != nil {
+		t.Fatalf("GenerateTable() Last-Modified = %q, want an http date", first.Header().Get("Last-Modified"))
+	}
+
+	tests := []struct {
+		url             string
+		ifNoneMatch     string
+		ifModifiedSince time.Time
+		wantStatus      int
+	}{
+		{"/cann", tag, time.Time{}, http.StatusNotModified},
+		{"/cann", `"other", ` + tag, time.Time{}, http.StatusNotModified},
+		{"/cann", `"other"`, time.Time{}, http.StatusOK},
+		{"/cann?format=json", tag, time.Time{}, http.StatusOK}, // a different representation has a different tag
+		{"/cann", "", modified, http.StatusNotModified},
+		{"/cann", "", modified.Add(-time.Second), http.StatusOK},
+		{"/cann", `"other"`, modified, http.StatusOK}, // If-None-Match wins
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+		if test.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+
+		if !test.ifModifiedSince.IsZero() {
+			req.Header.Set("If-Modified-Since", test.ifModifiedSince.UTC().Format(http.TimeFormat))
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		GenerateTable(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("GenerateTable(%s) If-None-Match %s If-Modified-Since %s status = %d, want %d", test.url, test.ifNoneMatch,
+				test.ifModifiedSince, w.Code, test.wantStatus)
+		}
+
+		if test.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("GenerateTable(%s) 304 body = %q, want empty", test.url, w.Body)
+		}
+
+		if test.wantStatus == http.StatusOK && w.Body.Len() == 0 {
+			t.Errorf("GenerateTable(%s) 200 body is empty, want the full table", test.url)
+		}
+	}
+}
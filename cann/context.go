@@ -0,0 +1,62 @@
+package cann
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/mick4711/moh/errorpage"
+)
+
+// static reference data per competition code, as of the season given in each entry
+//
+//go:embed context.json
+var contextData []byte
+
+// CompetitionContext is static background displayed alongside a competition's table, not live data
+type CompetitionContext struct {
+	Season             string `json:"season"`
+	ReigningChampion   string `json:"reigningChampion"`
+	MostTitles         Titles `json:"mostTitles"`
+	TypicalPointsToWin Points `json:"typicalPointsToWin"`
+}
+
+// Titles is the team with the most league titles
+type Titles struct {
+	Team   string `json:"team"`
+	Titles int    `json:"titles"`
+}
+
+// Context writes the static context for the competition in the comp query parameter as json,
+// 404 for a competition without context
+func Context(w http.ResponseWriter, req *http.Request) {
+	comp := req.URL.Query().Get("comp")
+	if comp == "" {
+		comp = defaultCompetition
+	}
+
+	contexts, err := competitionContexts()
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	compContext, ok := contexts[comp]
+	if !ok {
+		errorpage.JSON(w, req, http.StatusNotFound, fmt.Errorf("no context for competition: %q", comp))
+		return
+	}
+
+	writeJSON(w, req, compContext)
+}
+
+// parse the bundled context data keyed by competition code
+func competitionContexts() (map[string]CompetitionContext, error) {
+	var contexts map[string]CompetitionContext
+	if err := json.Unmarshal(contextData, &contexts); err != nil {
+		return nil, fmt.Errorf("error parsing competition context data: %w", err)
+	}
+
+	return contexts, nil
+}
@@ -0,0 +1,62 @@
+package cann
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mick4711/moh/conditional"
+)
+
+func TestContext(t *testing.T) {
+	tests := []struct {
+		url        string
+		wantStatus int
+		wantTeam   string
+	}{
+		{"/cann/context?comp=PL", http.StatusOK, "Manchester United"},
+		{"/cann/context", http.StatusOK, "Manchester United"},
+		{"/cann/context?comp=XYZ", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		Context(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		if w.Code != test.wantStatus {
+			t.Errorf("Context(%s) status = %d, want %d", test.url, w.Code, test.wantStatus)
+			continue
+		}
+
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+
+		var got CompetitionContext
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if got.MostTitles.Team != test.wantTeam || got.ReigningChampion == "" || got.TypicalPointsToWin == 0 {
+			t.Errorf("Context(%s) = %+v, want the PL context", test.url, got)
+		}
+
+		if version := w.Header().Get(conditional.DataVersionHeader); version != conditional.DataVersion(w.Body.Bytes()) {
+			t.Errorf("Context(%s) %s = %q, want the version of the json", test.url, conditional.DataVersionHeader, version)
+		}
+	}
+}
+
+func TestCompetitionContextsSupported(t *testing.T) {
+	contexts, err := competitionContexts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for comp := range contexts {
+		if _, ok := competitions[comp]; !ok {
+			t.Errorf("context for %q, not a supported competition", comp)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package cann
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strconv"
+)
+
+// derby pairs are within this many points of each other to be highlighted, override with DERBY_POINTS
+const defaultDerbyPoints = 3
+
+// label displayed next to a team whose derby rival is close in the standings
+const derbyLabel = "[derby watch]"
+
+// the configured local rivalries and the points within which they are highlighted, set by Configure
+var (
+	derbyPairs  [][2]int
+	derbyPoints Points = defaultDerbyPoints
+)
+
+// parse local rivalries as pairs of team IDs from DERBY_PAIRS e.g. [[57, 73], [64, 62]]
+func parseDerbyPairs(value string) [][2]int {
+	if value == "" {
+		return nil
+	}
+
+	var pairs [][2]int
+	if err := json.Unmarshal([]byte(value), &pairs); err != nil {
+		slog.Warn("invalid DERBY_PAIRS ignored", "err", err)
+		return nil
+	}
+
+	return pairs
+}
+
+// parse the points threshold within which a derby pair is highlighted from DERBY_POINTS
+func parseDerbyPoints(value string) Points {
+	if value == "" {
+		return defaultDerbyPoints
+	}
+
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 0 {
+		slog.Warn("invalid DERBY_POINTS ignored", "value", value)
+		return defaultDerbyPoints
+	}
+
+	return Points(threshold)
+}
+
+// IDs of the teams in a derby pair whose points are within threshold of each other,
+// pairs with a team missing from the table are ignored
+func derbyWatch(standingsTable []TableRow, pairs [][2]int, threshold Points) map[int]bool {
+	points := make(map[int]Points, len(standingsTable))
+	for _, row := range standingsTable {
+		points[row.Team.ID] = row.Points
+	}
+
+	watch := make(map[int]bool)
+
+	for _, pair := range pairs {
+		first, ok1 := points[pair[0]]
+		second, ok2 := points[pair[1]]
+
+		if !ok1 || !ok2 {
+			continue
+		}
+
+		if diff := first - second; diff <= threshold && -diff <= threshold {
+			watch[pair[0]] = true
+			watch[pair[1]] = true
+		}
+	}
+
+	return watch
+}
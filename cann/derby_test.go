@@ -0,0 +1,53 @@
+package cann
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDerbyWatch(t *testing.T) {
+	table := testTable(10) // team i has 11-i points
+	tests := []struct {
+		name      string
+		pairs     [][2]int
+		threshold Points
+		want      map[int]bool
+	}{
+		{"within threshold", [][2]int{{2, 4}}, 3, map[int]bool{2: true, 4: true}},
+		{"on threshold", [][2]int{{1, 4}}, 3, map[int]bool{1: true, 4: true}},
+		{"outside threshold", [][2]int{{1, 5}}, 3, map[int]bool{}},
+		{"team not in table", [][2]int{{1, 99}}, 3, map[int]bool{}},
+		{"multiple pairs", [][2]int{{1, 2}, {3, 9}, {9, 10}}, 1, map[int]bool{1: true, 2: true, 9: true, 10: true}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := derbyWatch(table, test.pairs, test.threshold); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("derbyWatch() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBuildCannDerbyBadge(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	Configure(Settings{DerbyPairs: "[[2, 3], [1, 8]]", DerbyPoints: "2"})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	table := testTable(8)
+	opts := options{derby: derbyWatch(table, derbyPairs, derbyPoints)}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	rows := buildCann(table, opts)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	for _, row := range rows {
+		badge := strings.Contains(row.Teams, derbyLabel)
+		wantBadge := strings.Contains(row.Teams, "team2") || strings.Contains(row.Teams, "team3")
+
+		if badge != wantBadge {
+			t.Errorf("buildCann() %d points row = %q, derby badge %v, want %v", row.Points, row.Teams, badge, wantBadge)
+		}
+	}
+}
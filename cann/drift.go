@@ -0,0 +1,117 @@
+package cann
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// the full football-data.org v4 standings response shape, including the fields the Cann table ignores,
+// decoded strictly to detect fields added upstream. Nested objects the app never reads aren't checked
+type standingsSchema struct {
+	Filters     any `json:"filters"`
+	Area        any `json:"area"`
+	Competition any `json:"competition"`
+	LastUpdated any `json:"lastUpdated"`
+	Season      struct {
+		ID              any `json:"id"`
+		StartDate       any `json:"startDate"`
+		EndDate         any `json:"endDate"`
+		CurrentMatchday any `json:"currentMatchday"`
+		Winner          any `json:"winner"`
+	} `json:"season"`
+	Standings []struct {
+		Stage any `json:"stage"`
+		Type  any `json:"type"`
+		Group any `json:"group"`
+		Table []struct {
+			Position int `json:"position"`
+			Team     struct {
+				ID        any `json:"id"`
+				Name      any `json:"name"`
+				ShortName any `json:"shortName"`
+				TLA       any `json:"tla"`
+				Crest     any `json:"crest"`
+			} `json:"team"`
+			PlayedGames    any `json:"playedGames"`
+			Form           any `json:"form"`
+			Won            any `json:"won"`
+			Draw           any `json:"draw"`
+			Lost           any `json:"lost"`
+			Points         any `json:"points"`
+			GoalsFor       any `json:"goalsFor"`
+			GoalsAgainst   any `json:"goalsAgainst"`
+			GoalDifference any `json:"goalDifference"`
+		} `json:"table"`
+	} `json:"standings"`
+}
+
+// strict schemas keyed by upstream resource name, resources without a schema aren't checked
+var schemas = map[string]func() any{
+	"standings": func() any { return &standingsSchema{} },
+}
+
+// schema_drift_total counter keyed by unknown field name
+var schemaDrift = struct {
+	sync.Mutex
+	total map[string]int64
+}{total: make(map[string]int64)}
+
+// strictly decodes a freshly fetched upstream body alongside the lenient decode used for rendering,
+// logging a warning and counting the field when the response has a field the schema doesn't know.
+// Runs once per upstream fetch rather than per request, so it is sampled by the cache TTL
+func detectSchemaDrift(resource string, body []byte) {
+	schema, ok := schemas[resource]
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(schema())
+	if err == nil {
+		return
+	}
+
+	field, ok := unknownField(err)
+	if !ok {
+		return // malformed payloads are reported by the lenient decode
+	}
+
+	slog.Warn("schema drift, unknown response field", "resource", resource, "field", field)
+
+	schemaDrift.Lock()
+	schemaDrift.total[field]++
+	schemaDrift.Unlock()
+}
+
+// the field name from a DisallowUnknownFields error, e.g. json: unknown field "crestUrl"
+func unknownField(err error) (string, bool) {
+	const prefix = "json: unknown field "
+
+	if !strings.HasPrefix(err.Error(), prefix) {
+		return "", false
+	}
+
+	return strings.Trim(strings.TrimPrefix(err.Error(), prefix), `"`), true
+}
+
+// SchemaDriftMetrics writes the schema_drift_total counters in the Prometheus text format
+func SchemaDriftMetrics() string {
+	schemaDrift.Lock()
+	defer schemaDrift.Unlock()
+
+	var metrics strings.Builder
+
+	metrics.WriteString("# TYPE schema_drift_total counter\n")
+
+	for _, field := range sortedKeys(schemaDrift.total) {
+		fmt.Fprintf(&metrics, "schema_drift_total{field=%q} %d\n", field, schemaDrift.total[field])
+	}
+
+	return metrics.String()
+}
@@ -0,0 +1,36 @@
+package cann
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDetectSchemaDrift(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaDrift.total = make(map[string]int64)
+
+	drifted := strings.Replace(string(validStandings), `"playedGames": 20,`, `"playedGames": 20, "xgFor": 31.5,`, 1)
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	detectSchemaDrift("standings", validStandings)
+	detectSchemaDrift("standings", []byte(drifted))
+	detectSchemaDrift("standings", []byte(drifted))
+	detectSchemaDrift("matches", []byte(`{"unknown": 1}`))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	got := SchemaDriftMetrics()
+
+	if want := `schema_drift_total{field="xgFor"} 2`; !strings.Contains(got, want) {
+		t.Errorf("SchemaDriftMetrics() = %q, want it to contain %q", got, want)
+	}
+
+	if strings.Count(got, "schema_drift_total{") != 1 {
+		t.Errorf("SchemaDriftMetrics() = %q, want only the drifted field", got)
+	}
+}
@@ -0,0 +1,24 @@
+package cann
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// debug logs the teams an enrichment step has no value for. Enrichment of the base standings is
+// per team, a team without a value is rendered with its base data and the rest are still enriched
+func logMissing(ctx context.Context, enrichment string, standingsTable []TableRow, has func(teamID int) bool) {
+	var missing []string
+
+	for _, row := range standingsTable {
+		if !has(row.Team.ID) {
+			missing = append(missing, row.Team.ShortName)
+		}
+	}
+
+	if len(missing) > 0 {
+		slog.DebugContext(ctx, "enrichment missing for teams", "enrichment", enrichment, "count", len(missing),
+			"teams", strings.Join(missing, ", "))
+	}
+}
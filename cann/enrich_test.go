@@ -0,0 +1,83 @@
+package cann
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderPartialEnrichment(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// xG for every team except Tottenham
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"64": {"xgFor": 40, "xgAgainst": 20}, "58": {"xgFor": 38, "xgAgainst": 25},
+			"65": {"xgFor": 45, "xgAgainst": 18}, "57": {"xgFor": 39, "xgAgainst": 19}}`)
+	}))
+	defer ts.Close()
+
+	var logs bytes.Buffer
+
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	xgSourceURL = ts.URL
+	defer func() { xgSourceURL = "" }()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?format=json&xg=1", bytes.NewReader(validStandings)))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var page cannPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		" - [1]Liverpool(20, -25)[CL](xG 40.0-20.0)",
+		" - [2]Aston Villa(20, +16)[CL](xG 38.0-25.0)",
+		" - [3]Man City(19, +24)[CL](xG 45.0-18.0) - [4]Arsenal(20, +17)[CL](xG 39.0-19.0)",
+		" - [5]Tottenham(20, +13)[EL]",
+	}
+
+	var got []string
+
+	for _, row := range page.Rows {
+		if row.Teams != "" {
+			got = append(got, row.Teams)
+		}
+	}
+
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Render() rows =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if !strings.Contains(logs.String(), "level=DEBUG msg=\"enrichment missing for teams\" enrichment=xG count=1 teams=Tottenham") {
+		t.Errorf("logs = %q, want the missing xG team at debug level", logs.String())
+	}
+}
+
+func TestLogMissingInfoLevel(t *testing.T) {
+	var logs bytes.Buffer
+
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	logMissing(context.Background(), "xG", testTable(2), func(int) bool { return false })
+
+	if logs.Len() != 0 {
+		t.Errorf("logs = %q, want nothing below debug level", logs.String())
+	}
+}
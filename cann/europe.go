@@ -0,0 +1,61 @@
+package cann
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+)
+
+// league positions granting each European competition for the current season by competition code, a competition
+// without places e.g. the Championship has no labels. Override per competition with
+// EUROPEAN_PLACES e.g. {"PL": {"CL": [1, 2, 3, 4, 5], "EL": [6], "ECL": [7]}}
+var defaultEuropeanPlaces = map[string]map[string][]int{
+	"PL":  {"CL": {1, 2, 3, 4}, "EL": {5}, "ECL": {6}},
+	"BL1": {"CL": {1, 2, 3, 4}, "EL": {5}, "ECL": {6}},
+	"SA":  {"CL": {1, 2, 3, 4}, "EL": {5}, "ECL": {6}},
+	"PD":  {"CL": {1, 2, 3, 4}, "EL": {5}, "ECL": {6}},
+	"FL1": {"CL": {1, 2, 3}, "EL": {4}, "ECL": {5}},
+	"DED": {"CL": {1, 2}, "EL": {3, 4}, "ECL": {5}},
+	"PPL": {"CL": {1, 2}, "EL": {3, 4}, "ECL": {5}},
+}
+
+// the competitions' places from EUROPEAN_PLACES, set by Configure
+var configuredEuropeanPlaces map[string]map[string][]int
+
+// parse the per competition overrides of the European places from EUROPEAN_PLACES, none when invalid
+func parseEuropeanPlaces(value string) map[string]map[string][]int {
+	if value == "" {
+		return nil
+	}
+
+	var configured map[string]map[string][]int
+	if err := json.Unmarshal([]byte(value), &configured); err != nil {
+		slog.Warn("invalid EUROPEAN_PLACES ignored", "err", err)
+		return nil
+	}
+
+	return configured
+}
+
+// map of league position to the European competition it qualifies for in the competition, empty without places
+func europeanPlaces(comp string) map[int]string {
+	places := defaultEuropeanPlaces[comp]
+	if compPlaces, ok := configuredEuropeanPlaces[comp]; ok {
+		places = compPlaces
+	}
+
+	byPosition := make(map[int]string)
+
+	for _, europeanComp := range sortedKeys(places) {
+		for _, position := range places[europeanComp] {
+			byPosition[position] = europeanComp
+		}
+	}
+
+	return byPosition
+}
+
+// label displayed next to a qualifying team, e.g. "[CL]"
+func europeanLabel(europeanComp string) string {
+	return fmt.Sprintf("[%s]", europeanComp)
+}
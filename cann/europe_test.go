@@ -0,0 +1,74 @@
+package cann
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEuropeanPlaces(t *testing.T) {
+	tests := []struct {
+		comp       string
+		configured string
+		want       map[int]string
+	}{
+		{"PL", "", map[int]string{1: "CL", 2: "CL", 3: "CL", 4: "CL", 5: "EL", 6: "ECL"}},
+		{"FL1", "", map[int]string{1: "CL", 2: "CL", 3: "CL", 4: "EL", 5: "ECL"}},
+		{"ELC", "", map[int]string{}},
+		{"PL", `{"PL": {"CL": [1, 2, 3, 4, 5], "EL": [6, 7], "ECL": [8]}}`, map[int]string{1: "CL", 2: "CL", 3: "CL", 4: "CL", 5: "CL", 6: "EL", 7: "EL", 8: "ECL"}},
+		{"BL1", `{"PL": {"CL": [1, 2, 3, 4, 5]}}`, map[int]string{1: "CL", 2: "CL", 3: "CL", 4: "CL", 5: "EL", 6: "ECL"}},
+		{"PL", `{"CL": [1, 2]}`, map[int]string{1: "CL", 2: "CL", 3: "CL", 4: "CL", 5: "EL", 6: "ECL"}},
+	}
+
+	defer func() { configuredEuropeanPlaces = nil }()
+
+	for _, test := range tests {
+		configuredEuropeanPlaces = parseEuropeanPlaces(test.configured)
+
+		if got := europeanPlaces(test.comp); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("europeanPlaces(%s) with %q = %v, want %v", test.comp, test.configured, got, test.want)
+		}
+	}
+}
+
+func TestRenderChampionshipWithoutEuropeanLabels(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	standings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?comp=ELC", bytes.NewReader(standings)))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK {
+		t.Fatalf("Render() ELC status = %d, want %d\n%s", w.Code, http.StatusOK, w.Body)
+	}
+
+	if body := w.Body.String(); !strings.Contains(body, "Championship") || strings.Contains(body, "[CL]") || strings.Contains(body, "[EL]") {
+		t.Errorf("Render() ELC body has European labels, want none\n%s", body)
+	}
+}
+
+func TestBuildCannEuropeanLabels(t *testing.T) {
+	got := buildCann(testTable(8), options{europe: map[int]string{1: "CL", 5: "EL", 6: "ECL"}})
+
+	want := map[Points]string{
+		8: " - [1]team1(10, +0)[CL]",
+		4: " - [5]team5(10, +0)[EL]",
+		3: " - [6]team6(10, +0)[ECL]",
+		2: " - [7]team7(10, +0)",
+	}
+
+	for _, row := range got {
+		if teams, ok := want[row.Points]; ok && row.Teams != teams {
+			t.Errorf("buildCann() %d points row = %q, want %q", row.Points, row.Teams, teams)
+		}
+	}
+}
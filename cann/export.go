@@ -0,0 +1,35 @@
+package cann
+
+import (
+	"context"
+	"time"
+)
+
+// An Export is the current Premier League standings and Cann table for the data export
+type Export struct {
+	FetchedAt time.Time  // when the standings were fetched from football-data.org
+	Table     []TableRow // the standard table
+	Rows      []Row      // the Cann table
+}
+
+// Snapshot returns the current standings from the cache, fetching them if they aren't cached
+func Snapshot(ctx context.Context) (Export, error) {
+	standings, _, err := getStandings(ctx, defaultCompetition)
+	if err != nil {
+		return Export{}, err
+	}
+
+	standingsTable, err := parseStandings(standings)
+	if err != nil {
+		return Export{}, err
+	}
+
+	fetchedAt := clk.Now()
+	if _, cachedAt, ok := standingsCache.GetStale(standingsURL(defaultCompetition)); ok {
+		fetchedAt = cachedAt
+	}
+
+	opts := options{adjustments: pointsAdjustments, europe: europeanPlaces(defaultCompetition)}
+
+	return Export{FetchedAt: fetchedAt, Table: standingsTable, Rows: buildCann(standingsTable, opts)}, nil
+}
@@ -0,0 +1,259 @@
+package cann
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/mick4711/moh/negotiate"
+)
+
+// match statuses of upcoming matches, TIMED matches have a confirmed kick off time
+const scheduledStatuses = "SCHEDULED,TIMED"
+
+// A Fixture is an upcoming match, or a finished one with its score for ?results=1
+type Fixture struct {
+	Kickoff  string `json:"kickoff"` // RFC 3339 UTC kick off time
+	Status   string `json:"status"`
+	Home     string `json:"home"`
+	HomeTLA  string `json:"homeTla"`
+	Away     string `json:"away"`
+	AwayTLA  string `json:"awayTla"`
+	Score    string `json:"score,omitempty"` // full time score e.g. "2-1", finished matches only
+	Matchday int    `json:"matchday"`
+}
+
+// A MatchdayFixtures is the fixtures of a matchday in kick off order
+type MatchdayFixtures struct {
+	Matchday int       `json:"matchday"`
+	Fixtures []Fixture `json:"fixtures"`
+}
+
+// A ProjectedPlace is a team's points and league position after a result
+type ProjectedPlace struct {
+	Points   Points `json:"points"`
+	Position int    `json:"position"`
+}
+
+// ordinal league position e.g. "43 pts (2nd)"
+func (p ProjectedPlace) String() string {
+	return fmt.Sprintf("%d pts (%s)", p.Points, ordinal(p.Position))
+}
+
+// A Projection is where a team would land in the Cann table if it wins, draws or loses its next game
+type Projection struct {
+	Team     string         `json:"team"`
+	TLA      string         `json:"tla"`
+	Opponent string         `json:"opponent"` // TLA, home or away
+	Home     bool           `json:"home"`
+	Now      ProjectedPlace `json:"now"`
+	Win      ProjectedPlace `json:"win"`
+	Draw     ProjectedPlace `json:"draw"`
+	Loss     ProjectedPlace `json:"loss"`
+}
+
+// data passed to the fixtures template
+type fixturesPage struct {
+	Competition string             `json:"competition"`
+	Results     bool               `json:"results,omitempty"` // finished matches with their scores
+	Matchdays   []MatchdayFixtures `json:"matchdays"`
+	Projections []Projection       `json:"projections,omitempty"` // ?projection=1 only
+}
+
+// Fixtures writes the competition's upcoming fixtures grouped by matchday as html, or json for ?format=json.
+// ?results=1 lists the finished matches with their scores instead, ?projection=1 adds where each team would land
+// in the Cann table if it wins, draws or loses its next game
+func Fixtures(w http.ResponseWriter, req *http.Request) {
+	comp, err := competition(req)
+	if err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	query := req.URL.Query()
+	page := fixturesPage{Competition: competitions[comp], Results: query.Get("results") == "1"}
+
+	status := scheduledStatuses
+	if page.Results {
+		status = "FINISHED"
+	}
+
+	matches, err := getMatches(req.Context(), comp, status)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	page.Matchdays = groupByMatchday(matches)
+
+	if query.Get("projection") == "1" {
+		upcoming := matches
+		if page.Results {
+			if upcoming, err = getMatches(req.Context(), comp, scheduledStatuses); err != nil {
+				returnError(w, req, err)
+				return
+			}
+		}
+
+		standings, _, err := getStandings(req.Context(), comp)
+		if err != nil {
+			returnError(w, req, err)
+			return
+		}
+
+		standingsTable, err := parseStandings(standings)
+		if err != nil {
+			returnError(w, req, err)
+			return
+		}
+
+		page.Projections = nextGameProjections(standingsTable, upcoming)
+	}
+
+	if negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		writeJSON(w, req, page)
+		return
+	}
+
+	body, err := renderTemplate(page, fixturesTemplate)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	writeCacheable(w, req, "text/html; charset=utf-8", body)
+}
+
+// fetch the competition's matches with the comma separated statuses, every match of the season when status is
+// empty, cached like the standings
+func getMatches(ctx context.Context, comp, status string) ([]Match, error) {
+	url := fmt.Sprintf(`%s/competitions/%s/matches`, baseURL, comp)
+	if status != "" {
+		url += "?status=" + status
+	}
+
+	body, _, err := getCached(ctx, "matches", url)
+	if err != nil {
+		return nil, err
+	}
+
+	var matchesResponse MatchesResponse
+	if err := json.Unmarshal(body, &matchesResponse); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json from matches response:%w", err)
+	}
+
+	return matchesResponse.Matches, nil
+}
+
+// the matches grouped by matchday in matchday order, each in kick off order
+func groupByMatchday(matches []Match) []MatchdayFixtures {
+	sorted := slices.Clone(matches)
+	slices.SortStableFunc(sorted, func(a, b Match) int {
+		return cmp.Or(cmp.Compare(a.Matchday, b.Matchday), cmp.Compare(a.UTCDate, b.UTCDate))
+	})
+
+	var matchdays []MatchdayFixtures
+
+	for _, match := range sorted {
+		if len(matchdays) == 0 || matchdays[len(matchdays)-1].Matchday != match.Matchday {
+			matchdays = append(matchdays, MatchdayFixtures{Matchday: match.Matchday})
+		}
+
+		fixture := Fixture{
+			Kickoff:  match.UTCDate,
+			Status:   match.Status,
+			Home:     match.HomeTeam.ShortName,
+			HomeTLA:  match.HomeTeam.TLA,
+			Away:     match.AwayTeam.ShortName,
+			AwayTLA:  match.AwayTeam.TLA,
+			Matchday: match.Matchday,
+		}
+
+		if match.Status == "FINISHED" {
+			fixture.Score = fmt.Sprintf("%d-%d", match.Score.FullTime.Home, match.Score.FullTime.Away)
+		}
+
+		last := &matchdays[len(matchdays)-1]
+		last.Fixtures = append(last.Fixtures, fixture)
+	}
+
+	return matchdays
+}
+
+// where each team with an upcoming match would land after a win, draw or loss in its next game, in league order.
+// Only the team and its opponent change, a win or loss also moves their goal differences by one
+func nextGameProjections(standingsTable []TableRow, upcoming []Match) []Projection {
+	sorted := slices.Clone(upcoming)
+	slices.SortStableFunc(sorted, func(a, b Match) int { return cmp.Compare(a.UTCDate, b.UTCDate) })
+
+	next := make(map[int]Match, len(standingsTable))
+	for _, match := range sorted {
+		for _, team := range []Team{match.HomeTeam, match.AwayTeam} {
+			if _, ok := next[team.ID]; !ok {
+				next[team.ID] = match
+			}
+		}
+	}
+
+	var projections []Projection
+
+	for _, row := range standingsTable {
+		match, ok := next[row.Team.ID]
+		if !ok {
+			continue
+		}
+
+		home := match.HomeTeam.ID == row.Team.ID
+
+		opponent := match.HomeTeam
+		if home {
+			opponent = match.AwayTeam
+		}
+
+		projections = append(projections, Projection{
+			Team:     row.Team.ShortName,
+			TLA:      row.Team.TLA,
+			Opponent: opponent.TLA,
+			Home:     home,
+			Now:      ProjectedPlace{Points: row.Points, Position: row.Position},
+			Win:      projectedPlace(standingsTable, row.Team.ID, opponent.ID, Points(pointsForWin), 0, 1),
+			Draw:     projectedPlace(standingsTable, row.Team.ID, opponent.ID, 1, 1, 0),
+			Loss:     projectedPlace(standingsTable, row.Team.ID, opponent.ID, 0, Points(pointsForWin), -1),
+		})
+	}
+
+	return projections
+}
+
+// the team's points and position after gaining points, and the opponent opponentPoints, with the goal difference
+// margin for the team and against the opponent. Teams level on points are split by goal difference
+func projectedPlace(standingsTable []TableRow, teamID, opponentID int, points, opponentPoints Points, margin int) ProjectedPlace {
+	projected := slices.Clone(standingsTable)
+
+	var team TableRow
+
+	for i := range projected {
+		switch projected[i].Team.ID {
+		case teamID:
+			projected[i].Points += points
+			projected[i].GoalDiff += margin
+			team = projected[i]
+		case opponentID:
+			projected[i].Points += opponentPoints
+			projected[i].GoalDiff -= margin
+		}
+	}
+
+	position := 1
+
+	for _, row := range projected {
+		if row.Team.ID != teamID && (row.Points > team.Points || row.Points == team.Points && row.GoalDiff > team.GoalDiff) {
+			position++
+		}
+	}
+
+	return ProjectedPlace{Points: team.Points, Position: position}
+}
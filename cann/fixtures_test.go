@@ -0,0 +1,123 @@
+package cann
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a match between two teams of testTable
+func testMatch(matchday int, kickoff string, home, away int) Match {
+	return Match{
+		Matchday: matchday,
+		UTCDate:  kickoff,
+		Status:   "TIMED",
+		HomeTeam: Team{ID: home, ShortName: "team" + string(rune('0'+home)), TLA: "T" + string(rune('0'+home))},
+		AwayTeam: Team{ID: away, ShortName: "team" + string(rune('0'+away)), TLA: "T" + string(rune('0'+away))},
+	}
+}
+
+func TestGroupByMatchday(t *testing.T) {
+	matches := []Match{
+		testMatch(12, "2024-11-30T15:00:00Z", 3, 4),
+		testMatch(11, "2024-11-24T16:30:00Z", 1, 2),
+		testMatch(12, "2024-11-30T12:30:00Z", 5, 6),
+		testMatch(11, "2024-11-23T15:00:00Z", 7, 8),
+	}
+
+	got := groupByMatchday(matches)
+
+	if len(got) != 2 || got[0].Matchday != 11 || got[1].Matchday != 12 {
+		t.Fatalf("groupByMatchday() = %+v, want matchdays 11 and 12", got)
+	}
+
+	if got[0].Fixtures[0].HomeTLA != "T7" || got[1].Fixtures[0].HomeTLA != "T5" {
+		t.Errorf("groupByMatchday() = %+v, want each matchday in kick off order", got)
+	}
+}
+
+func TestNextGameProjections(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	table := testTable(6) // 6, 5, 4, 3, 2 and 1 points
+	upcoming := []Match{
+		testMatch(11, "2024-11-30T15:00:00Z", 2, 1), // later, not team 2's next game
+		testMatch(10, "2024-11-23T15:00:00Z", 3, 2),
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := nextGameProjections(table, upcoming)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := []Projection{
+		{Team: "team1", TLA: "T1", Opponent: "T2", Home: false, Now: ProjectedPlace{6, 1},
+			Win: ProjectedPlace{9, 1}, Draw: ProjectedPlace{7, 1}, Loss: ProjectedPlace{6, 2}},
+		{Team: "team2", TLA: "T2", Opponent: "T3", Home: false, Now: ProjectedPlace{5, 2},
+			Win: ProjectedPlace{8, 1}, Draw: ProjectedPlace{6, 1}, Loss: ProjectedPlace{5, 3}}, // level with team1 on a draw
+		{Team: "team3", TLA: "T3", Opponent: "T2", Home: true, Now: ProjectedPlace{4, 3},
+			Win: ProjectedPlace{7, 1}, Draw: ProjectedPlace{5, 3}, Loss: ProjectedPlace{4, 3}},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nextGameProjections() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	liverpool, villa := Team{ID: 64, ShortName: "Liverpool", TLA: "LIV"}, Team{ID: 58, ShortName: "Aston Villa", TLA: "AVL"}
+	scheduled, err := json.Marshal(MatchesResponse{Matches: []Match{
+		{Matchday: 21, UTCDate: "2024-01-20T15:00:00Z", Status: "TIMED", HomeTeam: villa, AwayTeam: liverpool},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/matches") {
+			_, _ = w.Write(scheduled) //nolint:errcheck // test server
+			return
+		}
+
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url  string
+		want []string
+	}{
+		{"/fixtures", []string{"<h2>Matchday 21</h2>", "<td>Aston Villa</td>", "<td>Liverpool</td>"}},
+		{"/fixtures?projection=1", []string{"Next game projection", "@ AVL (A)", "48 pts (1st)", "42 pts (2nd)"}},
+		{"/fixtures?projection=1&format=json", []string{`"matchday":21`, `"tla":"LIV","opponent":"AVL"`}},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Fixtures(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != http.StatusOK {
+			t.Fatalf("Fixtures(%s) status = %d, want 200", test.url, w.Code)
+		}
+
+		for _, want := range test.want {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("Fixtures(%s) body doesn't contain %q\n%s", test.url, want, w.Body)
+			}
+		}
+	}
+}
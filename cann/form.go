@@ -0,0 +1,16 @@
+package cann
+
+import "strings"
+
+// the results of a form string e.g. "W,D,L,W,W" as W, D or L badges, at most the last five, nil when not reported
+func formResults(form string) []string {
+	var results []string
+
+	for _, result := range strings.FieldsFunc(form, func(r rune) bool { return r == ',' }) {
+		if result = strings.TrimSpace(result); result != "" {
+			results = append(results, result)
+		}
+	}
+
+	return results[:min(len(results), formGames)]
+}
@@ -0,0 +1,78 @@
+package cann
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFormResults(t *testing.T) {
+	tests := []struct {
+		form string
+		want []string
+	}{
+		{"", nil},
+		{"W,D,L", []string{"W", "D", "L"}},
+		{"W, D ,L,,W", []string{"W", "D", "L", "W"}},
+		{"W,W,W,D,L,L,L", []string{"W", "W", "W", "D", "L"}},
+	}
+
+	for _, test := range tests {
+		if got := formResults(test.form); !slices.Equal(got, test.want) {
+			t.Errorf("formResults(%q) = %q, want %q", test.form, got, test.want)
+		}
+	}
+}
+
+func TestRenderFormBadges(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	table := testTable(20)
+	table[0].Form = "W,D,L,W,W"
+
+	standings, err := json.Marshal(DataResponse{Standings: []Standings{{Table: table}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url      string
+		wantForm []string
+		wantHTML bool
+	}{
+		{"/debug/render?format=json", []string{"W", "D", "L", "W", "W"}, false},
+		{"/debug/render?format=json&form=0", nil, false},
+		{"/debug/render", nil, true},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Render(w, httptest.NewRequest(http.MethodPost, test.url, bytes.NewReader(standings)))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != http.StatusOK {
+			t.Fatalf("Render(%s) status = %d, want 200", test.url, w.Code)
+		}
+
+		if test.wantHTML {
+			if body := w.Body.String(); !strings.Contains(body, `<span class="form form-D">D</span>`) {
+				t.Errorf("Render(%s) body doesn't contain the form badges", test.url)
+			}
+
+			continue
+		}
+
+		var page cannPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("Render(%s) body isn't json: %v", test.url, err)
+		}
+
+		if got := page.Rows[0].TeamDetails[0].Form; !slices.Equal(got, test.wantForm) {
+			t.Errorf("Render(%s) leader form = %q, want %q", test.url, got, test.wantForm)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package cann
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+// note shown while football-data hasn't yet applied recently finished matches to the standings
+const updatingNote = "Standings updating: recently finished matches aren't reflected in the table yet"
+
+var (
+	freshnessCheck bool          // compare the standings with recently finished matches, set by Configure
+	updatingTTL    time.Duration // shorter standings cache lifetime while they are updating, off when 0
+)
+
+// fetch the matches finished in the last day for a competition code, cached like the standings
+func getFinishedMatches(ctx context.Context, comp string, now time.Time) ([]Match, error) {
+	url := fmt.Sprintf(`%s/competitions/%s/matches?status=FINISHED&dateFrom=%s&dateTo=%s`,
+		baseURL, comp, now.AddDate(0, 0, -1).Format(time.DateOnly), now.Format(time.DateOnly))
+
+	body, _, err := getCached(ctx, "matches", url)
+	if err != nil {
+		return nil, err
+	}
+
+	var matchesResponse MatchesResponse
+	if err := json.Unmarshal(body, &matchesResponse); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json from matches response:%w", err)
+	}
+
+	return matchesResponse.Matches, nil
+}
+
+// whether any finished match was updated after the standings were, i.e. its result isn't in the table yet.
+// Unknown or unparseable update times are treated as up to date
+func standingsUpdating(standingsUpdated string, finished []Match) bool {
+	updated, err := time.Parse(time.RFC3339, standingsUpdated)
+	if err != nil {
+		return false
+	}
+
+	for _, match := range finished {
+		if matchUpdated, err := time.Parse(time.RFC3339, match.LastUpdated); err == nil && matchUpdated.After(updated) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// reconcile the standings with the recently finished matches, returning the standings to render and the
+// updating note when they lag behind. With an updating TTL a cached copy older than it is refetched
+func reconcileFreshness(ctx context.Context, comp string, standings []byte) ([]byte, []string) {
+	if !freshnessCheck {
+		return standings, nil
+	}
+
+	response, err := parseResponse(standings)
+	if err != nil {
+		return standings, nil
+	}
+
+	finished, err := getFinishedMatches(ctx, comp, clk.Now())
+	if err != nil {
+		slog.WarnContext(ctx, "finished matches unavailable", "comp", comp, "err", err)
+		return standings, nil
+	}
+
+	if !standingsUpdating(response.LastUpdated, finished) {
+		return standings, nil
+	}
+
+	if updatingTTL > 0 {
+		url := standingsURL(comp)
+		if _, fetched, ok := standingsCache.GetStale(url); ok && clock.Since(clk, fetched) > updatingTTL {
+			refreshed, err := fetchInto(ctx, standingsCache, "standings", url)
+			if err != nil {
+				slog.WarnContext(ctx, "updating standings refresh failed", "comp", comp, "err", err)
+				return standings, []string{updatingNote}
+			}
+
+			return reconcileRefreshed(refreshed, finished)
+		}
+	}
+
+	return standings, []string{updatingNote}
+}
+
+// the refetched standings, still noted as updating if they haven't caught up with the finished matches
+func reconcileRefreshed(standings []byte, finished []Match) ([]byte, []string) {
+	response, err := parseResponse(standings)
+	if err != nil || !standingsUpdating(response.LastUpdated, finished) {
+		return standings, nil
+	}
+
+	return standings, []string{updatingNote}
+}
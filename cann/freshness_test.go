@@ -0,0 +1,86 @@
+package cann
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStandingsUpdating(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	tests := []struct {
+		name             string
+		standingsUpdated string
+		matchUpdated     string
+		want             bool
+	}{
+		{name: "finished after the standings update", standingsUpdated: "2024-03-02T17:00:00Z", matchUpdated: "2024-03-02T17:05:00Z", want: true},
+		{name: "finished before the standings update", standingsUpdated: "2024-03-02T17:00:00Z", matchUpdated: "2024-03-02T16:55:00Z", want: false},
+		{name: "standings update time unknown", standingsUpdated: "", matchUpdated: "2024-03-02T17:05:00Z", want: false},
+		{name: "match update time unknown", standingsUpdated: "2024-03-02T17:00:00Z", matchUpdated: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+			got := standingsUpdating(tt.standingsUpdated, []Match{{Status: "FINISHED", LastUpdated: tt.matchUpdated}})
+
+			// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+			if got != tt.want {
+				t.Errorf("standingsUpdating() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateTableUpdatingNote(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	standingsUpdated := time.Now().Add(-time.Hour).UTC()
+	standings := bytes.Replace(validStandings, []byte(`{`), []byte(`{"lastUpdated": "`+standingsUpdated.Format(time.RFC3339)+`",`), 1)
+
+	finished, err := json.Marshal(MatchesResponse{Matches: []Match{
+		{Status: "FINISHED", HomeTeam: Team{ID: 57}, AwayTeam: Team{ID: 73}, LastUpdated: standingsUpdated.Add(10 * time.Minute).Format(time.RFC3339)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.HasSuffix(req.URL.Path, "/matches") {
+			_, _ = w.Write(finished) //nolint:errcheck // test server
+			return
+		}
+
+		_, _ = w.Write(standings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: 5 * time.Second, FreshnessCheck: true})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	w := httptest.NewRecorder()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann?format=json", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var page cannPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Contains(page.Notes, updatingNote) {
+		t.Errorf("GenerateTable() notes = %q, want %q", page.Notes, updatingNote)
+	}
+}
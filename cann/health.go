@@ -0,0 +1,103 @@
+package cann
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	healthyMaxAge      = 15 * time.Minute // oldest successful fetch still reported as healthy
+	probeMinInterval   = time.Minute      // minimum time between upstream probes made by health checks
+	upstreamDependency = "football-data"
+)
+
+// Status reports a dependency's health from the most recent upstream fetches
+type Status struct {
+	Healthy        bool   `json:"healthy"`
+	LastSuccess    string `json:"lastSuccess,omitempty"`
+	LastSuccessAge string `json:"lastSuccessAge,omitempty"`
+	LastError      string `json:"lastError,omitempty"`
+	Circuit        string `json:"circuit"` // circuit breaker state, closed, open or half-open
+}
+
+// outcome of the most recent standings fetches, safe for concurrent use
+type upstreamHealth struct {
+	mu          sync.Mutex
+	lastAttempt time.Time
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+}
+
+var upstream = &upstreamHealth{}
+
+// record the outcome of an upstream fetch
+func (u *upstreamHealth) record(err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.lastAttempt = clk.Now()
+	if err != nil {
+		u.lastFailure = u.lastAttempt
+		u.lastError = err.Error()
+
+		return
+	}
+
+	u.lastSuccess = u.lastAttempt
+}
+
+// whether the most recent fetch failed, e.g. while the circuit is open after repeated failures
+func (u *upstreamHealth) failing() bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.lastFailure.After(u.lastSuccess)
+}
+
+// a probe is needed when there is no recent success and no upstream call was made within the probe interval
+func (u *upstreamHealth) needsProbe(now time.Time) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return now.Sub(u.lastSuccess) > healthyMaxAge && now.Sub(u.lastAttempt) > probeMinInterval
+}
+
+func (u *upstreamHealth) status(now time.Time) Status {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	status := Status{
+		Healthy:   !u.lastSuccess.IsZero() && !u.lastFailure.After(u.lastSuccess) && now.Sub(u.lastSuccess) <= healthyMaxAge,
+		LastError: u.lastError,
+		Circuit:   upstreamBreaker.State(),
+	}
+
+	if !u.lastSuccess.IsZero() {
+		status.LastSuccess = u.lastSuccess.Format(time.RFC3339)
+		status.LastSuccessAge = now.Sub(u.lastSuccess).Round(time.Second).String()
+	}
+
+	if status.Healthy {
+		status.LastError = ""
+	}
+
+	return status
+}
+
+// DeepHealth reports the football-data dependency status keyed by dependency name.
+// The recorded fetch outcomes are reused, the upstream is only probed (through the standings cache)
+// when there is no recent success and no call was made within the probe interval, so health checks can't breach the quota.
+func DeepHealth(ctx context.Context) map[string]Status {
+	if upstream.needsProbe(clk.Now()) {
+		_, _, _ = getStandings(ctx, defaultCompetition) //nolint:errcheck // outcome is recorded in upstream
+	}
+
+	return map[string]Status{upstreamDependency: upstream.status(clk.Now())}
+}
+
+// UpstreamStatus reports the football-data status from the recorded fetch outcomes without probing the upstream
+func UpstreamStatus() Status {
+	return upstream.status(clk.Now())
+}
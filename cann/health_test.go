@@ -0,0 +1,129 @@
+package cann
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeepHealthFailedDependency(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, MaxEntries: 1, UpstreamTimeout: time.Second, RetryAttempts: 1})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	upstream = &upstreamHealth{}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	first := DeepHealth(context.Background())[upstreamDependency]
+	second := DeepHealth(context.Background())[upstreamDependency]
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if first.Healthy || first.LastError == "" {
+		t.Errorf("DeepHealth(context.Background()) = %+v, want unhealthy with last error", first)
+	}
+
+	if second.Healthy {
+		t.Errorf("DeepHealth(context.Background()) repeated = %+v, want unhealthy", second)
+	}
+
+	if requests != 1 {
+		t.Errorf("upstream requests = %d, want 1, repeat checks should reuse the recorded status", requests)
+	}
+}
+
+func TestStatusHealthy(t *testing.T) {
+	now := time.Now()
+	u := &upstreamHealth{lastAttempt: now, lastSuccess: now, lastFailure: now.Add(-time.Hour), lastError: "old"}
+
+	if status := u.status(now); !status.Healthy || status.LastError != "" || u.needsProbe(now) {
+		t.Errorf("status() = %+v, want healthy without a probe after a recent success", status)
+	}
+
+	if status := u.status(now.Add(healthyMaxAge + time.Second)); status.Healthy {
+		t.Errorf("status() = %+v, want unhealthy when the last success is too old", status)
+	}
+}
+
+func TestGetStandingsUpstreamDeadline(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	cancelled := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+			cancelled <- struct{}{}
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: 0, MaxEntries: 1, UpstreamTimeout: 50 * time.Millisecond})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	upstream = &upstreamHealth{}
+	cached := []byte(`{"standings": [{"table": []}]}`)
+	standingsCache.Set(ts.URL+"/competitions/PL/standings", cached) // expired immediately with a zero ttl
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	start := time.Now()
+	got, _, err := getStandings(context.Background(), defaultCompetition)
+	elapsed := time.Since(start)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || string(got) != string(cached) {
+		t.Errorf("getStandings() = %q, %v, want the cached copy", got, err)
+	}
+
+	if elapsed > time.Second {
+		t.Errorf("getStandings() took %s, want it to give up at the upstream deadline", elapsed)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("upstream request was not cancelled at the deadline")
+	}
+
+	if status := upstream.status(time.Now()); status.LastError == "" {
+		t.Errorf("upstream status = %+v, want the deadline error recorded", status)
+	}
+}
+
+func TestGenerateTableUpstreamTimeout(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: 50 * time.Millisecond})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	start := time.Now()
+	w := httptest.NewRecorder()
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann", http.NoBody))
+	elapsed := time.Since(start)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "standings request timed out after 50ms") {
+		t.Errorf("GenerateTable() status = %d body = %q, want 500 with a timeout error", w.Code, w.Body)
+	}
+
+	if elapsed > time.Second {
+		t.Errorf("GenerateTable() took %s, want it to give up at the upstream deadline", elapsed)
+	}
+}
@@ -0,0 +1,191 @@
+package cann
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/snapshot"
+)
+
+// the movement indicator compares league positions with the standings this many days earlier
+const movementDays = 7
+
+// standings snapshots saved after every fetch, for the Cann table at an earlier date and the movement indicator.
+// Off when nil, set by Configure
+var snapshots *snapshot.Store
+
+var errNoSnapshots = errors.New("standings snapshots aren't enabled, set SNAPSHOT_DIR")
+
+// save freshly fetched current standings as the competition's snapshot for the day, best effort
+func saveSnapshot(url string, body []byte) {
+	if snapshots == nil {
+		return
+	}
+
+	for comp := range competitions {
+		if standingsURL(comp) == url {
+			if err := snapshots.Save(comp, clk.Now(), body); err != nil {
+				slog.Error("standings snapshot not saved", "comp", comp, "err", err)
+			}
+
+			return
+		}
+	}
+}
+
+// the competition's latest saved standings and when they were saved, false when there are none
+func latestSnapshot(comp string) ([]byte, time.Time, bool) {
+	if snapshots == nil {
+		return nil, time.Time{}, false
+	}
+
+	standings, saved, err := snapshots.Latest(comp)
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+
+	return standings, saved, true
+}
+
+// whether the request asks for the Cann table as it stood earlier with ?date= or ?matchday=
+func historical(req *http.Request) bool {
+	return req.URL.Query().Has("date") || req.URL.Query().Has("matchday")
+}
+
+// an earlier point in the season from ?date=YYYY-MM-DD or ?matchday=N, matchday is 0 when a date is given
+func historyQuery(req *http.Request) (time.Time, int, error) {
+	query := req.URL.Query()
+
+	if query.Has("date") {
+		date, err := time.Parse(time.DateOnly, query.Get("date"))
+		if err != nil {
+			return time.Time{}, 0, fmt.Errorf("invalid date %q, must be YYYY-MM-DD", query.Get("date"))
+		}
+
+		return date, 0, nil
+	}
+
+	matchday, err := strconv.Atoi(query.Get("matchday"))
+	if err != nil || matchday < 1 {
+		return time.Time{}, 0, fmt.Errorf("invalid matchday %q, must be a number >= 1", query.Get("matchday"))
+	}
+
+	return time.Time{}, matchday, nil
+}
+
+// the saved standings for the latest snapshot on or before the date, or the latest at the matchday, with a note
+// saying when they are from
+func snapshotStandings(comp string, date time.Time, matchday int) ([]byte, string, error) {
+	if snapshots == nil {
+		return nil, "", errNoSnapshots
+	}
+
+	if matchday == 0 {
+		standings, day, err := snapshots.OnOrBefore(comp, date)
+		if err != nil {
+			return nil, "", fmt.Errorf("standings on %s: %w", date.Format(time.DateOnly), err)
+		}
+
+		return standings, "Standings as of " + day.Format("2 Jan 2006"), nil
+	}
+
+	days, err := snapshots.Days(comp)
+	if err != nil {
+		return nil, "", err //nolint:wrapcheck // the store names the failure
+	}
+
+	for i := len(days) - 1; i >= 0; i-- {
+		standings, err := snapshots.Load(comp, days[i])
+		if err != nil {
+			continue
+		}
+
+		if response, err := parseResponse(standings); err == nil && currentMatchday(response) == matchday {
+			return standings, fmt.Sprintf("Standings at matchday %d, as of %s", matchday, days[i].Format("2 Jan 2006")), nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("standings at matchday %d: %w", matchday, snapshot.ErrNotFound)
+}
+
+// writes the Cann table from a saved snapshot, 404 when there is none for the requested point in the season
+func renderHistorical(w http.ResponseWriter, req *http.Request, comp string) {
+	date, matchday, err := historyQuery(req)
+	if err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	standings, note, err := snapshotStandings(comp, date, matchday)
+	if errors.Is(err, snapshot.ErrNotFound) || errors.Is(err, errNoSnapshots) {
+		errorpage.Write(w, req, http.StatusNotFound, err)
+		return
+	}
+
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	renderTable(w, req, comp, standings, note)
+}
+
+// league positions each team gained, or lost when negative, since the latest snapshot a week before the standings
+// were updated, keyed by team ID. nil without snapshots or an earlier snapshot
+func weeklyMovement(comp string, standings []byte) map[int]int {
+	if snapshots == nil {
+		return nil
+	}
+
+	response, err := parseResponse(standings)
+	if err != nil {
+		return nil
+	}
+
+	asOf, err := time.Parse(time.RFC3339, response.LastUpdated)
+	if err != nil {
+		asOf = clk.Now()
+	}
+
+	previous, _, err := snapshots.OnOrBefore(comp, asOf.AddDate(0, 0, -movementDays))
+	if err != nil {
+		return nil
+	}
+
+	previousTable, err := parseStandings(previous)
+	if err != nil {
+		return nil
+	}
+
+	previousPositions := make(map[int]int, len(previousTable))
+	for _, row := range previousTable {
+		previousPositions[row.Team.ID] = row.Position
+	}
+
+	movement := make(map[int]int, len(previousTable))
+
+	for _, row := range response.Standings[0].Table {
+		if position, ok := previousPositions[row.Team.ID]; ok {
+			movement[row.Team.ID] = position - row.Position
+		}
+	}
+
+	return movement
+}
+
+// label displayed next to a team that moved in the table over the last week, e.g. "▲2" or "▼1"
+func movementLabel(movement map[int]int, teamID int) string {
+	switch moved := movement[teamID]; {
+	case moved > 0:
+		return fmt.Sprintf("▲%d", moved)
+	case moved < 0:
+		return fmt.Sprintf("▼%d", -moved)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,252 @@
+package cann
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/snapshot"
+	"github.com/mick4711/moh/stale"
+)
+
+func TestSnapshotSavedOnFetch(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	standings := standingsWithTeamAt(t, 99, 18)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(standings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	now := time.Date(2024, time.March, 10, 15, 0, 0, 0, time.UTC)
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1, Clock: clock.NewFake(now), SnapshotDir: dir})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann?comp=SA&format=json", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK {
+		t.Fatalf("GenerateTable() status = %d, want 200", w.Code)
+	}
+
+	got, err := snapshot.New(dir).Load("SA", now)
+	if err != nil || !slices.Equal(got, standings) {
+		t.Errorf("snapshot for %s = %q err = %v, want the fetched standings", now.Format(time.DateOnly), got, err)
+	}
+}
+
+func TestSnapshotServedWhenUpstreamFails(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	now := time.Date(2024, time.March, 10, 15, 0, 0, 0, time.UTC)
+
+	if err := snapshot.New(dir).Save("PL", now.Add(-3*time.Hour), standingsWithTeamAt(t, 99, 18)); err != nil {
+		t.Fatal(err)
+	}
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1,
+		BreakerThreshold: 100, Clock: clock.NewFake(now), SnapshotDir: dir})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	wantStale := stale.Data{Fetched: "2024-03-10T12:00:00Z", Age: "3h", Note: "Data from 3h ago — live update failed"}
+
+	tests := []struct {
+		url        string
+		handler    http.HandlerFunc
+		wantStatus int
+	}{
+		{"/cann?format=json", GenerateTable, http.StatusOK},
+		{"/cann/gaps", Gaps, http.StatusOK},
+		{"/cann?comp=SA&format=json", GenerateTable, http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		test.handler(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("%s status = %d, want %d", test.url, w.Code, test.wantStatus)
+			continue
+		}
+
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+
+		var got struct {
+			Stale *stale.Data `json:"stale"`
+		}
+
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || got.Stale == nil || *got.Stale != wantStale {
+			t.Errorf("%s stale = %+v, %v, want %+v", test.url, got.Stale, err, wantStale)
+		}
+
+		if w.Header().Get("Warning") != stale.Warning || w.Header().Get("Age") != "10800" {
+			t.Errorf("%s Warning = %q Age = %q, want %q 10800", test.url, w.Header().Get("Warning"), w.Header().Get("Age"), stale.Warning)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann?lang=es", http.NoBody))
+
+	if want := "Datos de hace 3h — falló la actualización en directo"; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("GenerateTable() html from the snapshot is missing the banner %q\n%s", want, w.Body)
+	}
+}
+
+func TestHistoricalTable(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dir := t.TempDir()
+	store := snapshot.New(dir)
+
+	if err := store.Save("PL", time.Date(2024, time.March, 10, 15, 0, 0, 0, time.UTC), standingsWithTeamAt(t, 99, 18)); err != nil {
+		t.Fatal(err)
+	}
+
+	Configure(Settings{BaseURL: "http://127.0.0.1:0", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1, SnapshotDir: dir})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url        string
+		wantStatus int
+		wantNote   string
+	}{
+		{"/cann?date=2024-03-12&format=json", http.StatusOK, "Standings as of 10 Mar 2024"},
+		{"/cann?date=2024-03-10&format=json", http.StatusOK, "Standings as of 10 Mar 2024"},
+		{"/cann?matchday=30&format=json", http.StatusOK, "Standings at matchday 30, as of 10 Mar 2024"},
+		{"/cann?date=2024-03-09", http.StatusNotFound, ""},
+		{"/cann?matchday=29", http.StatusNotFound, ""},
+		{"/cann?comp=SA&date=2024-03-12", http.StatusNotFound, ""},
+		{"/cann?date=March", http.StatusBadRequest, ""},
+		{"/cann?matchday=0", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		GenerateTable(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("%s status = %d, want %d", test.url, w.Code, test.wantStatus)
+			continue
+		}
+
+		if test.wantNote == "" {
+			continue
+		}
+
+		var page cannPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s body isn't json: %v", test.url, err)
+		}
+
+		if !slices.Contains(page.Notes, test.wantNote) {
+			t.Errorf("%s notes = %q, want %q", test.url, page.Notes, test.wantNote)
+		}
+	}
+}
+
+func TestHistoricalTableSkipsLiveState(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dir := t.TempDir()
+	now := time.Date(2024, time.March, 12, 15, 0, 0, 0, time.UTC)
+
+	if err := snapshot.New(dir).Save("PL", now.Add(-48*time.Hour), standingsWithTeamAt(t, 99, 18)); err != nil {
+		t.Fatal(err)
+	}
+
+	Configure(Settings{BaseURL: "http://127.0.0.1:0", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1, Clock: clock.NewFake(now), SnapshotDir: dir})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	backgroundRefreshes.Lock()
+	backgroundRefreshes.at[standingsURL("PL")] = now
+	backgroundRefreshes.Unlock()
+
+	for _, url := range []string{"/cann?date=2024-03-12&live=1&format=json", "/cann?matchday=30&live=1&format=json"} {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		GenerateTable(w, httptest.NewRequest(http.MethodGet, url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		var page cannPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s body isn't json: %v", url, err)
+		}
+
+		if page.LastRefreshed != "" || page.Stale != nil {
+			t.Errorf("%s lastRefreshed = %q stale = %+v, want no freshness banners", url, page.LastRefreshed, page.Stale)
+		}
+
+		for _, note := range page.Notes {
+			if strings.Contains(note, "Live") {
+				t.Errorf("%s notes = %q, want no live overlay", url, page.Notes)
+			}
+		}
+	}
+}
+
+func TestHistoricalTableWithoutSnapshots(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	Configure(Settings{BaseURL: "http://127.0.0.1:0", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann?date=2024-03-12", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusNotFound {
+		t.Errorf("GenerateTable() without SNAPSHOT_DIR status = %d, want 404", w.Code)
+	}
+}
+
+func TestWeeklyMovement(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		savedDaysAgo int
+		was, now     int
+		wantLabel    string
+	}{
+		{8, 5, 3, "▲2"},
+		{7, 3, 6, "▼3"},
+		{7, 4, 4, ""},
+		{6, 5, 3, ""}, // less than a week old
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		dir := t.TempDir()
+		if err := snapshot.New(dir).Save("PL", now.AddDate(0, 0, -test.savedDaysAgo), standingsWithTeamAt(t, 99, test.was)); err != nil {
+			t.Fatal(err)
+		}
+
+		Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout, Clock: clock.NewFake(now), SnapshotDir: dir})
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		got := movementLabel(weeklyMovement("PL", standingsWithTeamAt(t, 99, test.now)), 99)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if got != test.wantLabel {
+			t.Errorf("from %d to %d saved %d days ago label = %q, want %q", test.was, test.now, test.savedDaysAgo, got, test.wantLabel)
+		}
+	}
+
+	Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+}
@@ -0,0 +1,158 @@
+package cann
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	matchLength     = 2 * time.Hour // calendar event length, kick off to final whistle with half time
+	icalTimeFormat  = "20060102T150405Z"
+	icalLineOctets  = 75 // longest content line before it's folded
+	calendarRefresh = "PT1H"
+)
+
+// Calendar writes the competition's fixtures for the season as an iCalendar feed for calendar apps to subscribe to,
+// ?team= with a team's three letter code only includes that team's matches. Times are UTC so no time zone
+// definitions are needed, finished matches have their scores and postponed ones are cancelled
+func Calendar(w http.ResponseWriter, req *http.Request) {
+	comp, err := competition(req)
+	if err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	matches, err := getMatches(req.Context(), comp, "")
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	name := competitions[comp]
+
+	if tla := strings.ToUpper(req.URL.Query().Get("team")); tla != "" {
+		if matches, name = teamMatches(matches, tla); matches == nil {
+			returnBadRequest(w, req, fmt.Errorf("unknown team %q", tla))
+			return
+		}
+	}
+
+	writeCacheable(w, req, "text/calendar; charset=utf-8", calendar(name+" fixtures", competitions[comp], matches, clk.Now()))
+}
+
+// the matches the team with the three letter code plays in with its name, nil when it has none
+func teamMatches(matches []Match, tla string) ([]Match, string) {
+	var teamMatches []Match
+
+	name := tla
+
+	for _, match := range matches {
+		switch tla {
+		case match.HomeTeam.TLA:
+			name = match.HomeTeam.ShortName
+		case match.AwayTeam.TLA:
+			name = match.AwayTeam.ShortName
+		default:
+			continue
+		}
+
+		teamMatches = append(teamMatches, match)
+	}
+
+	return teamMatches, name
+}
+
+// the iCalendar feed named name with an event for each match, now stamps matches football-data hasn't dated
+func calendar(name, competitionName string, matches []Match, now time.Time) []byte {
+	var ical bytes.Buffer
+
+	writeLine := func(line string) { ical.WriteString(foldLine(line) + "\r\n") }
+
+	writeLine("BEGIN:VCALENDAR")
+	writeLine("VERSION:2.0")
+	writeLine("PRODID:-//moh//Fixtures//EN")
+	writeLine("CALSCALE:GREGORIAN")
+	writeLine("METHOD:PUBLISH")
+	writeLine("X-WR-CALNAME:" + icalText(name))
+	writeLine("REFRESH-INTERVAL;VALUE=DURATION:" + calendarRefresh)
+	writeLine("X-PUBLISHED-TTL:" + calendarRefresh)
+
+	for _, match := range matches {
+		kickoff, err := time.Parse(time.RFC3339, match.UTCDate)
+		if err != nil {
+			continue // not scheduled yet
+		}
+
+		updated, err := time.Parse(time.RFC3339, match.LastUpdated)
+		if err != nil {
+			updated = now
+		}
+
+		writeLine("BEGIN:VEVENT")
+		writeLine(fmt.Sprintf("UID:match-%d@moh", match.ID))
+		writeLine("DTSTAMP:" + updated.UTC().Format(icalTimeFormat))
+		writeLine("LAST-MODIFIED:" + updated.UTC().Format(icalTimeFormat))
+		writeLine("DTSTART:" + kickoff.UTC().Format(icalTimeFormat))
+		writeLine("DTEND:" + kickoff.Add(matchLength).UTC().Format(icalTimeFormat))
+		writeLine("SUMMARY:" + icalText(matchSummary(match)))
+		writeLine("DESCRIPTION:" + icalText(fmt.Sprintf("%s matchday %d", competitionName, match.Matchday)))
+		writeLine("STATUS:" + eventStatus(match.Status))
+		writeLine("END:VEVENT")
+	}
+
+	writeLine("END:VCALENDAR")
+
+	return ical.Bytes()
+}
+
+// e.g. "Arsenal v Chelsea", "Arsenal 2-1 Chelsea" once finished
+func matchSummary(match Match) string {
+	switch match.Status {
+	case "FINISHED":
+		return fmt.Sprintf("%s %d-%d %s", match.HomeTeam.ShortName, match.Score.FullTime.Home, match.Score.FullTime.Away, match.AwayTeam.ShortName)
+	case "POSTPONED":
+		return fmt.Sprintf("%s v %s (postponed)", match.HomeTeam.ShortName, match.AwayTeam.ShortName)
+	default:
+		return fmt.Sprintf("%s v %s", match.HomeTeam.ShortName, match.AwayTeam.ShortName)
+	}
+}
+
+// the iCalendar event status of a football-data match status, SCHEDULED matches don't have a confirmed kick off time
+func eventStatus(status string) string {
+	switch status {
+	case "SCHEDULED":
+		return "TENTATIVE"
+	case "POSTPONED", "SUSPENDED", "CANCELLED":
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// text escaped for an iCalendar property value
+func icalText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
+
+// a content line folded onto continuation lines of at most 75 octets, without splitting a character
+func foldLine(line string) string {
+	var folded strings.Builder
+
+	for limit := icalLineOctets; len(line) > limit; limit = icalLineOctets - 1 { // continuations start with a space
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		folded.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+	}
+
+	folded.WriteString(line)
+
+	return folded.String()
+}
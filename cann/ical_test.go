@@ -0,0 +1,122 @@
+package cann
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFoldLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"SUMMARY:short", "SUMMARY:short"},
+		{strings.Repeat("a", 75), strings.Repeat("a", 75)},
+		{strings.Repeat("a", 80), strings.Repeat("a", 75) + "\r\n " + "aaaaa"},
+		{strings.Repeat("a", 74) + "éa", strings.Repeat("a", 74) + "\r\n éa"}, // é is 2 octets
+	}
+
+	for _, test := range tests {
+		if got := foldLine(test.line); got != test.want {
+			t.Errorf("foldLine(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestCalendar(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	finished := testMatch(1, "2024-08-17T14:00:00Z", 1, 2)
+	finished.ID, finished.Status, finished.LastUpdated = 101, "FINISHED", "2024-08-17T16:05:00Z"
+	finished.Score.FullTime.Home, finished.Score.FullTime.Away = 2, 1
+
+	postponed := testMatch(2, "2024-08-24T11:30:00+01:00", 3, 1)
+	postponed.ID, postponed.Status = 102, "POSTPONED"
+
+	unscheduled := testMatch(3, "", 1, 4)
+
+	now := time.Date(2024, 8, 20, 9, 0, 0, 0, time.UTC)
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := string(calendar("Fixtures, team1", "Premier League", []Match{finished, postponed, unscheduled}, now))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//moh//Fixtures//EN\r\nCALSCALE:GREGORIAN\r\nMETHOD:PUBLISH\r\n" +
+		"X-WR-CALNAME:Fixtures\\, team1\r\nREFRESH-INTERVAL;VALUE=DURATION:PT1H\r\nX-PUBLISHED-TTL:PT1H\r\n" +
+		"BEGIN:VEVENT\r\nUID:match-101@moh\r\nDTSTAMP:20240817T160500Z\r\nLAST-MODIFIED:20240817T160500Z\r\n" +
+		"DTSTART:20240817T140000Z\r\nDTEND:20240817T160000Z\r\nSUMMARY:team1 2-1 team2\r\n" +
+		"DESCRIPTION:Premier League matchday 1\r\nSTATUS:CONFIRMED\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nUID:match-102@moh\r\nDTSTAMP:20240820T090000Z\r\nLAST-MODIFIED:20240820T090000Z\r\n" +
+		"DTSTART:20240824T103000Z\r\nDTEND:20240824T123000Z\r\nSUMMARY:team3 v team1 (postponed)\r\n" +
+		"DESCRIPTION:Premier League matchday 2\r\nSTATUS:CANCELLED\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	if got != want {
+		t.Errorf("calendar() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCalendarHandler(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	liverpool, villa, spurs := Team{ID: 64, ShortName: "Liverpool", TLA: "LIV"}, Team{ID: 58, ShortName: "Aston Villa", TLA: "AVL"},
+		Team{ID: 73, ShortName: "Tottenham", TLA: "TOT"}
+	season, err := json.Marshal(MatchesResponse{Matches: []Match{
+		{ID: 1, Matchday: 21, UTCDate: "2024-01-20T15:00:00Z", Status: "TIMED", HomeTeam: villa, AwayTeam: liverpool},
+		{ID: 2, Matchday: 21, UTCDate: "2024-01-21T16:30:00Z", Status: "SCHEDULED", HomeTeam: spurs, AwayTeam: villa},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requested string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.RequestURI()
+		_, _ = w.Write(season) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url     string
+		status  int
+		want    []string
+		notWant []string
+	}{
+		{"/fixtures.ics", http.StatusOK, []string{"X-WR-CALNAME:Premier League fixtures", "UID:match-1@moh", "UID:match-2@moh"}, nil},
+		{"/fixtures.ics?team=liv", http.StatusOK, []string{"X-WR-CALNAME:Liverpool fixtures", "SUMMARY:Aston Villa v Liverpool"}, []string{"UID:match-2@moh"}},
+		{"/fixtures.ics?team=ARS", http.StatusBadRequest, []string{`unknown team &#34;ARS&#34;`}, nil},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Calendar(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.status {
+			t.Fatalf("Calendar(%s) status = %d, want %d", test.url, w.Code, test.status)
+		}
+
+		for _, want := range test.want {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("Calendar(%s) body doesn't contain %q\n%s", test.url, want, w.Body)
+			}
+		}
+
+		for _, notWant := range test.notWant {
+			if strings.Contains(w.Body.String(), notWant) {
+				t.Errorf("Calendar(%s) body contains %q\n%s", test.url, notWant, w.Body)
+			}
+		}
+	}
+
+	if requested != "/competitions/PL/matches" {
+		t.Errorf("Calendar() requested %s, want the whole season's matches", requested)
+	}
+}
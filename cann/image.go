@@ -0,0 +1,145 @@
+package cann
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Cann table image layout in pixels, text is monospace so its width is estimated from the character count
+const (
+	imageFontSize       = 14
+	imageFooterFontSize = 11
+	imageCharWidth      = 8.4 // advance of a monospace character at imageFontSize
+	imageMargin         = 12
+	imageRowHeight      = 26
+	imageTitleHeight    = 36
+	imageFooterHeight   = 30
+	imageTeamPadding    = 4 // around the team label inside its zone color
+)
+
+// layout of the Cann table image passed to the svg template
+type imagePage struct {
+	Width, Height  int
+	FontSize       int
+	FooterFontSize int
+	Margin         int
+	RowHeight      int
+	Theme          Theme
+
+	Title          string
+	TitleBaseline  int
+	Rows           []imageRow
+	Footer         string
+	FooterBaseline int
+}
+
+// a Cann table row in the image, rows without teams are drawn empty to show the gaps
+type imageRow struct {
+	Top, Baseline int
+	Stripe        bool
+	Points        Points
+	Teams         []imageTeam
+}
+
+// a team in an image row, drawn after a separator on its zone color
+type imageTeam struct {
+	SeparatorX      int
+	X, Top          int // of the zone color box
+	Width, Height   int
+	TextX, Baseline int
+	Color           string // zone color, empty for zones the theme draws plainly
+	Label           string
+}
+
+// Image writes the Cann table for ?comp= as an svg image for sharing, with the zone colors of ?theme= or ?a11y=1 and a
+// footer with when it was generated. ?teams= and ?rowsort= apply as for the Cann table
+func Image(w http.ResponseWriter, req *http.Request) {
+	comp, err := competition(req)
+	if err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	standings, status, err := requestStandings(req, comp)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	markCache(w, status)
+	markStale(w, comp)
+
+	standingsTable, err := parseStandings(standings)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	opts := options{adjustments: pointsAdjustments, teams: watchlist(w, req), europe: europeanPlaces(comp), movement: weeklyMovement(comp, standings),
+		hideForm: true}
+	if opts.rowSort, err = rowSortFor(req.URL.Query().Get("rowsort")); err != nil {
+		returnBadRequest(w, req, err)
+		return
+	}
+
+	page := layoutImage(competitions[comp], buildCann(standingsTable, opts), theme(w, req), clk.Now())
+
+	body, err := renderTemplate(page, imageTemplate)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	writeCacheable(w, req, "image/svg+xml", body)
+}
+
+// position the title, rows and footer of the Cann table image, it is as wide as the longest row
+func layoutImage(competition string, rows []Row, pageTheme Theme, generated time.Time) imagePage {
+	page := imagePage{FontSize: imageFontSize, FooterFontSize: imageFooterFontSize, Margin: imageMargin, RowHeight: imageRowHeight,
+		Theme: pageTheme, Title: competition + " Cann table", TitleBaseline: imageTitleHeight - imageRowHeight/2 + imageFontSize/2,
+		Footer: "Generated " + generated.UTC().Format("2 Jan 2006 15:04 MST") + " from football-data.org standings"}
+
+	pointsWidth := 0
+	for _, row := range rows {
+		pointsWidth = max(pointsWidth, textWidth(fmt.Sprint(row.Points)))
+	}
+
+	page.Width = max(textWidth(page.Title), textWidth(page.Footer)*imageFooterFontSize/imageFontSize)
+
+	for i, row := range rows {
+		top := imageTitleHeight + i*imageRowHeight
+		imgRow := imageRow{Top: top, Baseline: top + imageRowHeight/2 + imageFontSize*2/5, Stripe: i%2 == 1, Points: row.Points}
+
+		x := pointsWidth
+		for _, team := range row.TeamDetails {
+			label := team.String()
+			if indicator := pageTheme.Indicator(team.Zone); indicator != "" {
+				label = indicator + " " + label
+			}
+
+			separatorX := imageMargin + x + textWidth(" ")
+			boxX := separatorX + textWidth("- ")
+			width := textWidth(label) + 2*imageTeamPadding
+
+			imgRow.Teams = append(imgRow.Teams, imageTeam{SeparatorX: separatorX, X: boxX, Top: top + 3, Width: width, Height: imageRowHeight - 6,
+				TextX: boxX + imageTeamPadding, Baseline: imgRow.Baseline, Color: pageTheme.Zones[team.Zone].Color, Label: label})
+			x = boxX + width - imageMargin
+		}
+
+		page.Width = max(page.Width, x)
+		page.Rows = append(page.Rows, imgRow)
+	}
+
+	page.Width += 2 * imageMargin
+	page.Height = imageTitleHeight + len(rows)*imageRowHeight + imageFooterHeight
+	page.FooterBaseline = page.Height - imageFooterHeight/2 + imageFooterFontSize/2
+
+	return page
+}
+
+// estimated width of monospace text at the image font size, rounded up
+func textWidth(text string) int {
+	return int(float64(utf8.RuneCountInString(text))*imageCharWidth + 0.99)
+}
@@ -0,0 +1,106 @@
+package cann
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestImage(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second,
+		Clock: clock.NewFake(time.Date(2024, 1, 2, 18, 30, 0, 0, time.UTC))})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url        string
+		wantStatus int
+		wantBody   []string
+	}{
+		{"/cann.svg", http.StatusOK, []string{`<svg xmlns="http://www.w3.org/2000/svg"`, ">Premier League Cann table</text>",
+			`fill="#c8e6c9"/>`, ">[3]Man City(19, &#43;24)[CL]</text>", ">Generated 2 Jan 2024 18:30 UTC from football-data.org standings</text>"}},
+		{"/cann.svg?a11y=1", http.StatusOK, []string{`fill="#56b4e9"/>`, ">▲ [3]Man City(19, &#43;24)[CL]</text>"}},
+		{"/cann.svg?rowsort=bad", http.StatusBadRequest, nil},
+		{"/cann.svg?comp=XX", http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Image(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("Image(%s) status = %d body = %s, want %d", test.url, w.Code, w.Body, test.wantStatus)
+			continue
+		}
+
+		for _, want := range test.wantBody {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("Image(%s) body = %s, want %s", test.url, w.Body, want)
+			}
+		}
+
+		if test.wantStatus != http.StatusOK {
+			continue
+		}
+
+		if contentType := w.Header().Get("Content-Type"); contentType != "image/svg+xml" {
+			t.Errorf("Image(%s) Content-Type = %q, want image/svg+xml", test.url, contentType)
+		}
+
+		// the image must be well formed xml for image viewers
+		decoder := xml.NewDecoder(w.Body)
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Errorf("Image(%s) is not well formed xml: %v", test.url, err)
+				break
+			}
+		}
+	}
+}
+
+func TestLayoutImage(t *testing.T) {
+	rows := buildCann(testTable(3)[1:], options{tableSize: 3, europe: europeanPlaces("PL")})
+	rows = append(rows, Row{Points: 0}) // a gap
+
+	got := layoutImage("Premier League", rows, defaultTheme, time.Date(2024, 1, 2, 18, 30, 0, 0, time.UTC))
+
+	if len(got.Rows) != 3 || got.Height != imageTitleHeight+3*imageRowHeight+imageFooterHeight {
+		t.Fatalf("layoutImage() rows = %d height = %d, want 3 rows", len(got.Rows), got.Height)
+	}
+
+	if teams := got.Rows[2].Teams; len(teams) != 0 || !got.Rows[1].Stripe || got.Rows[0].Stripe {
+		t.Errorf("layoutImage() rows = %+v, want the gap row empty and every other row striped", got.Rows)
+	}
+
+	team := got.Rows[1].Teams[0]
+	if team.Label != "[3]team3(10, +0)[CL]" || team.Color != defaultTheme.Zones[ZoneChampionsLeague].Color || team.X <= team.SeparatorX {
+		t.Errorf("layoutImage() third placed team = %+v, want it after the separator in the champions league color", team)
+	}
+
+	if right := team.X + team.Width + imageMargin; got.Width < right {
+		t.Errorf("layoutImage() width = %d, want at least %d to fit the teams", got.Width, right)
+	}
+}
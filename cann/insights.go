@@ -0,0 +1,120 @@
+package cann
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// teams in a cluster are within this many points of each other
+const clusterPoints Points = 3
+
+// Insights are the tightest and loosest parts of the table
+type Insights struct {
+	Cluster    *Cluster    `json:"cluster,omitempty"`    // nil when no two teams are within clusterPoints
+	BiggestGap *BiggestGap `json:"biggestGap,omitempty"` // nil when no team is ahead of the next on points
+}
+
+// A Cluster is the most teams within clusterPoints of each other, between two league positions
+type Cluster struct {
+	Teams  int    `json:"teams"`
+	Spread Points `json:"spread"` // points between the top and bottom team of the cluster
+	From   int    `json:"from"`   // league position of the top team
+	To     int    `json:"to"`     // league position of the bottom team
+}
+
+// A BiggestGap is the largest points gap between consecutive league positions
+type BiggestGap struct {
+	Gap   Points `json:"gap"`
+	Above int    `json:"above"` // league position above the gap
+	Below int    `json:"below"`
+}
+
+// the densest cluster and biggest gap in the standings. Clusters of equal size go to the smaller spread
+// then the higher in the table, equal gaps go to the higher in the table
+func tableInsights(standingsTable []TableRow) Insights {
+	table := slices.Clone(standingsTable)
+	slices.SortStableFunc(table, func(a, b TableRow) int { return cmp.Compare(a.Position, b.Position) })
+
+	return Insights{Cluster: densestCluster(table), BiggestGap: biggestGap(table)}
+}
+
+// the most teams within clusterPoints of each other in a position ordered table
+func densestCluster(table []TableRow) *Cluster {
+	var best *Cluster
+
+	bottom := 0
+
+	for top := range table {
+		bottom = max(bottom, top)
+		for bottom+1 < len(table) && table[top].Points-table[bottom+1].Points <= clusterPoints {
+			bottom++
+		}
+
+		teams, spread := bottom-top+1, table[top].Points-table[bottom].Points
+		if teams < 2 {
+			continue
+		}
+
+		if best == nil || teams > best.Teams || (teams == best.Teams && spread < best.Spread) {
+			best = &Cluster{Teams: teams, Spread: spread, From: table[top].Position, To: table[bottom].Position}
+		}
+	}
+
+	return best
+}
+
+// the largest points gap between consecutive teams in a position ordered table
+func biggestGap(table []TableRow) *BiggestGap {
+	var best *BiggestGap
+
+	for i := 1; i < len(table); i++ {
+		gap := table[i-1].Points - table[i].Points
+		if gap > 0 && (best == nil || gap > best.Gap) {
+			best = &BiggestGap{Gap: gap, Above: table[i-1].Position, Below: table[i].Position}
+		}
+	}
+
+	return best
+}
+
+// e.g. "6 teams within 3 points (4th to 9th)" or "3 teams level on points (4th to 6th)"
+func (c Cluster) String() string {
+	if c.Spread == 0 {
+		return fmt.Sprintf("%d teams level on points (%s to %s)", c.Teams, ordinal(c.From), ordinal(c.To))
+	}
+
+	unit := "points"
+	if c.Spread == 1 {
+		unit = "point"
+	}
+
+	return fmt.Sprintf("%d teams within %d %s (%s to %s)", c.Teams, c.Spread, unit, ordinal(c.From), ordinal(c.To))
+}
+
+// e.g. "8-point gap between 6th and 7th"
+func (g BiggestGap) String() string {
+	return fmt.Sprintf("%d-point gap between %s and %s", g.Gap, ordinal(g.Above), ordinal(g.Below))
+}
+
+// league position as an ordinal e.g. 1st, 12th, 22nd
+//
+//nolint:gomnd // readability, the english ordinal rules read best as literals
+func ordinal(n int) string {
+	suffix := "th"
+
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+
+	return fmt.Sprintf("%d%s", n, suffix)
+}
@@ -0,0 +1,80 @@
+package cann
+
+import "testing"
+
+// table of teams in league position order with the points
+func pointsTable(points ...Points) []TableRow {
+	table := make([]TableRow, 0, len(points))
+	for i, p := range points {
+		table = append(table, TableRow{Team: Team{ID: i + 1}, Position: i + 1, Points: p})
+	}
+
+	return table
+}
+
+func TestTableInsights(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	tests := []struct {
+		name        string
+		table       []TableRow
+		wantCluster string
+		wantGap     string
+	}{
+		{
+			name:        "equal gaps go to the higher in the table",
+			table:       pointsTable(50, 42, 41, 40, 39, 30, 29, 29, 20),
+			wantCluster: "4 teams within 3 points (2nd to 5th)",
+			wantGap:     "9-point gap between 5th and 6th",
+		},
+		{
+			name:        "equal sized clusters go to the smaller spread",
+			table:       pointsTable(40, 38, 30, 30, 10),
+			wantCluster: "2 teams level on points (3rd to 4th)",
+			wantGap:     "20-point gap between 4th and 5th",
+		},
+		{
+			name:        "gap at the bottom of the table",
+			table:       pointsTable(22, 21, 21, 2),
+			wantCluster: "3 teams within 1 point (1st to 3rd)",
+			wantGap:     "19-point gap between 3rd and 4th",
+		},
+		{
+			name:        "all teams level",
+			table:       pointsTable(0, 0, 0),
+			wantCluster: "3 teams level on points (1st to 3rd)",
+		},
+		{
+			name:  "single team",
+			table: pointsTable(10),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+			got := tableInsights(tt.table)
+
+			// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+			var cluster, gap string
+			if got.Cluster != nil {
+				cluster = got.Cluster.String()
+			}
+
+			if got.BiggestGap != nil {
+				gap = got.BiggestGap.String()
+			}
+
+			if cluster != tt.wantCluster || gap != tt.wantGap {
+				t.Errorf("tableInsights() = %q, %q, want %q, %q", cluster, gap, tt.wantCluster, tt.wantGap)
+			}
+		})
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	for n, want := range map[int]string{1: "1st", 2: "2nd", 3: "3rd", 4: "4th", 11: "11th", 12: "12th", 13: "13th", 21: "21st", 22: "22nd", 111: "111th"} {
+		if got := ordinal(n); got != want {
+			t.Errorf("ordinal(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cann
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+)
+
+// past seasons' standings don't change, so the snapshots are kept much longer than the current standings
+const historyTTL = 24 * time.Hour
+
+var historyCache = cache.New(historyTTL, cache.DefaultMaxEntries)
+
+// fetch a competition's standings as they were at a matchday of a season, seasons are identified by their start year
+func getStandingsAt(ctx context.Context, comp string, season, matchday int) ([]byte, error) {
+	url := fmt.Sprintf(`%s/competitions/%s/standings?season=%d&matchday=%d`, baseURL, comp, season, matchday)
+
+	standings, _, err := getCachedIn(ctx, historyCache, "standings", url)
+
+	return standings, err
+}
+
+// each team's points compared with the same matchday last season and a note describing it,
+// nil when last season can't be fetched. Newly promoted teams have no comparison
+func lastSeasonComparison(ctx context.Context, comp string, standings []byte) (map[int]Points, string) {
+	const unavailable = "Last season comparison unavailable"
+
+	current, err := parseResponse(standings)
+	if err != nil {
+		return nil, unavailable
+	}
+
+	startDate, err := time.Parse(time.DateOnly, current.Season.StartDate)
+	if err != nil {
+		slog.WarnContext(ctx, "last season comparison unavailable, season start date", "err", err)
+		return nil, unavailable
+	}
+
+	matchday := currentMatchday(current)
+
+	previous, err := getStandingsAt(ctx, comp, startDate.Year()-1, matchday)
+	if err == nil {
+		var previousTable []TableRow
+		if previousTable, err = parseStandings(previous); err == nil {
+			return lastSeasonDeltas(current.Standings[0].Table, previousTable),
+				"Points compared with matchday " + strconv.Itoa(matchday) + " last season"
+		}
+	}
+
+	slog.WarnContext(ctx, "last season comparison unavailable", "err", err)
+
+	return nil, unavailable
+}
+
+// points difference for each team in both tables keyed by team ID
+func lastSeasonDeltas(current, previous []TableRow) map[int]Points {
+	previousPoints := make(map[int]Points, len(previous))
+	for _, row := range previous {
+		previousPoints[row.Team.ID] = row.Points
+	}
+
+	deltas := make(map[int]Points, len(current))
+
+	for _, row := range current {
+		if points, ok := previousPoints[row.Team.ID]; ok {
+			deltas[row.Team.ID] = row.Points - points
+		}
+	}
+
+	return deltas
+}
+
+// label displayed next to a team with a last season comparison, e.g. "(+4 vs last season)"
+func lastSeasonLabel(deltas map[int]Points, teamID int) string {
+	delta, ok := deltas[teamID]
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprintf("(%+d vs last season)", delta)
+}
@@ -0,0 +1,109 @@
+package cann
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLastSeasonDeltas(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	current, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previous, err := os.ReadFile("standings_lastseason_test.json") // Aston Villa weren't in it
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currentTable, err := parseStandings(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousTable, err := parseStandings(previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := lastSeasonDeltas(currentTable, previousTable)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := map[int]Points{64: 4, 65: -3, 57: 0, 73: 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("lastSeasonDeltas() = %v, want %v", got, want)
+	}
+
+	if label := lastSeasonLabel(got, 58); label != "" {
+		t.Errorf("lastSeasonLabel() promoted team = %q, want no comparison", label)
+	}
+}
+
+func TestRenderCompareLastSeason(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	current, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current = bytes.Replace(current, []byte(`"standings": [`), []byte(`"season": {"startDate": "2024-08-16", "currentMatchday": 20}, "standings": [`), 1)
+
+	previous, err := os.ReadFile("standings_lastseason_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.URL.Path != "/competitions/PL/standings" || r.URL.Query().Get("season") != "2023" || r.URL.Query().Get("matchday") != "20" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write(previous) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, MaxEntries: 4, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	var page cannPage
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?format=json&compareLastSeason=1", bytes.NewReader(current)))
+
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if requests != 1 {
+		t.Errorf("upstream requests = %d, want 1, the historical snapshot should be cached", requests)
+	}
+
+	if teams := page.Rows[0].Teams; !strings.HasSuffix(teams, "(+4 vs last season)") {
+		t.Errorf("Render() Liverpool = %q, want a +4 comparison", teams)
+	}
+
+	if teams := page.Rows[3].Teams; strings.Contains(teams, "vs last season") {
+		t.Errorf("Render() Aston Villa = %q, want no comparison for a promoted team", teams)
+	}
+
+	if !reflect.DeepEqual(page.Notes, []string{"Points compared with matchday 20 last season"}) {
+		t.Errorf("Render() notes = %q, want the comparison note", page.Notes)
+	}
+}
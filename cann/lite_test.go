@@ -0,0 +1,105 @@
+package cann
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderLite(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url       string
+		wantStyle bool
+	}{
+		{"/debug/render", true},
+		{"/debug/render?lite=1", false},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Render(w, httptest.NewRequest(http.MethodPost, test.url, bytes.NewReader(validStandings)))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		body := w.Body.String()
+		if w.Code != http.StatusOK || !strings.Contains(body, "[3]Man City(19, &#43;24)") {
+			t.Fatalf("Render(%s) status = %d body = %s, want the Cann table", test.url, w.Code, body)
+		}
+
+		if got := strings.Contains(body, "<style>"); got != test.wantStyle {
+			t.Errorf("Render(%s) has styling = %v, want %v", test.url, got, test.wantStyle)
+		}
+
+		if test.wantStyle {
+			if !strings.Contains(body, `<span class="europa">[5]Tottenham(20, &#43;13)[EL]</span>`) {
+				t.Errorf("Render(%s) body = %s, want the teams marked with their zone", test.url, body)
+			}
+
+			continue
+		}
+
+		for _, heavy := range []string{"<img", "<link", "<script"} {
+			if strings.Contains(body, heavy) {
+				t.Errorf("Render(%s) contains %s, want no heavy assets", test.url, heavy)
+			}
+		}
+	}
+}
+
+func TestRenderTemplateError(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func(templates *template.Template) { cannTemplates = templates }(cannTemplates)
+	cannTemplates = template.Must(template.New(fullTemplate).Parse("{{ .Missing }}"))
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Render(w, httptest.NewRequest(http.MethodPost, "/debug/render", bytes.NewReader(validStandings)))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "error executing cannTemplate") {
+		t.Errorf("Render() with a failing template status = %d body = %q, want a 500 error response", w.Code, w.Body)
+	}
+}
+
+func TestRenderTemplateDir(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for file, content := range map[string]string{fullTemplate: "edited {{ .Competition }}", liteTemplate: "lite"} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defer func(dir string) { templateDir = dir }(templateDir)
+	templateDir = dir
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Render(w, httptest.NewRequest(http.MethodPost, "/debug/render", bytes.NewReader(validStandings)))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK || w.Body.String() != "edited Premier League" {
+		t.Errorf("Render() with a template dir status = %d body = %q, want the template read from disk", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,116 @@
+package cann
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// match statuses for matches in progress
+var liveStatuses = []string{"IN_PLAY", "PAUSED"}
+
+// A Match contains the teams and current score of a match
+type Match struct {
+	ID       int    `json:"id"`
+	Matchday int    `json:"matchday"`
+	UTCDate  string `json:"utcDate"` // RFC 3339 kick off time
+	Status   string `json:"status"`
+	HomeTeam Team   `json:"homeTeam"`
+	AwayTeam Team   `json:"awayTeam"`
+	Score    Score  `json:"score"`
+
+	LastUpdated string `json:"lastUpdated"` // RFC 3339 time football-data last changed the match
+}
+
+// A Score contains the goals for each team, during a match the full time score is the current score
+type Score struct {
+	FullTime struct {
+		Home int `json:"home"`
+		Away int `json:"away"`
+	} `json:"fullTime"`
+}
+
+// MatchesResponse contains the Matches
+type MatchesResponse struct {
+	Matches []Match `json:"matches"`
+}
+
+// fetch the matches in progress for a competition code
+func getLiveMatches(ctx context.Context, comp string) ([]Match, error) {
+	url := fmt.Sprintf(`%s/competitions/%s/matches?status=IN_PLAY,PAUSED`, baseURL, comp)
+
+	body, _, err := getCached(ctx, "matches", url)
+	if err != nil {
+		return nil, err
+	}
+
+	var matchesResponse MatchesResponse
+	if err := json.Unmarshal(body, &matchesResponse); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json from matches response:%w", err)
+	}
+
+	return slices.DeleteFunc(matchesResponse.Matches, func(m Match) bool {
+		return !slices.Contains(liveStatuses, m.Status)
+	}), nil
+}
+
+// provisional table with the matches in progress applied, and a note describing it.
+// The official table is returned when there are no matches in progress or they can't be fetched.
+func liveTable(ctx context.Context, comp string, standingsTable []TableRow) ([]TableRow, string) {
+	matches, err := getLiveMatches(ctx, comp)
+	if err != nil {
+		slog.WarnContext(ctx, "live matches unavailable", "comp", comp, "err", err)
+		return standingsTable, "Live scores unavailable, showing the official table"
+	}
+
+	if len(matches) == 0 {
+		return standingsTable, "No matches in progress, showing the official table"
+	}
+
+	return overlayLive(standingsTable, matches), fmt.Sprintf("Unofficial live table: includes %d matches in progress", len(matches))
+}
+
+// apply in-play results to a copy of the standings, as if the matches finished with the current score
+func overlayLive(standingsTable []TableRow, matches []Match) []TableRow {
+	live := slices.Clone(standingsTable)
+
+	byTeam := make(map[int]*TableRow, len(live))
+	for i := range live {
+		byTeam[live[i].Team.ID] = &live[i]
+	}
+
+	for _, match := range matches {
+		home, away := match.Score.FullTime.Home, match.Score.FullTime.Away
+
+		if row, ok := byTeam[match.HomeTeam.ID]; ok {
+			row.applyResult(home, away)
+		}
+
+		if row, ok := byTeam[match.AwayTeam.ID]; ok {
+			row.applyResult(away, home)
+		}
+	}
+
+	return rerank(live)
+}
+
+// add a result to the team's record
+func (r *TableRow) applyResult(goalsFor, goalsAgainst int) {
+	r.Played++
+	r.GoalsFor += goalsFor
+	r.GoalsAgainst += goalsAgainst
+	r.GoalDiff += goalsFor - goalsAgainst
+
+	switch {
+	case goalsFor > goalsAgainst:
+		r.Won++
+		r.Points += pointsForWin
+	case goalsFor == goalsAgainst:
+		r.Draw++
+		r.Points++
+	default:
+		r.Lost++
+	}
+}
@@ -0,0 +1,56 @@
+package cann
+
+import "testing"
+
+func TestOverlayLive(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	table := []TableRow{
+		{Team: Team{ID: 1, TLA: "AAA"}, Position: 1, Played: 10, Points: 22, GoalDiff: 10, GoalsFor: 20, GoalsAgainst: 10},
+		{Team: Team{ID: 2, TLA: "BBB"}, Position: 2, Played: 10, Points: 21, GoalDiff: 8, GoalsFor: 15, GoalsAgainst: 7},
+		{Team: Team{ID: 3, TLA: "CCC"}, Position: 3, Played: 10, Points: 15, GoalDiff: 0, GoalsFor: 12, GoalsAgainst: 12},
+	}
+
+	match := Match{Status: "IN_PLAY", HomeTeam: Team{ID: 1}, AwayTeam: Team{ID: 2}}
+	match.Score.FullTime.Home, match.Score.FullTime.Away = 0, 2
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := overlayLive(table, []Match{match})
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := []TableRow{
+		{Team: Team{ID: 2, TLA: "BBB"}, Position: 1, Played: 11, Points: 24, GoalDiff: 10, GoalsFor: 17, GoalsAgainst: 7, Won: 1},
+		{Team: Team{ID: 1, TLA: "AAA"}, Position: 2, Played: 11, Points: 22, GoalDiff: 8, GoalsFor: 20, GoalsAgainst: 12, Lost: 1},
+		{Team: Team{ID: 3, TLA: "CCC"}, Position: 3, Played: 10, Points: 15, GoalDiff: 0, GoalsFor: 12, GoalsAgainst: 12},
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("overlayLive() position %d = %+v, want %+v", i+1, got[i], want[i])
+		}
+	}
+
+	if table[0].Points != 22 || table[0].Position != 1 {
+		t.Errorf("overlayLive() altered the official table, got %+v", table[0])
+	}
+}
+
+func TestOverlayLiveGoalsTiebreak(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	table := []TableRow{
+		{Team: Team{ID: 1, ShortName: "Alpha", TLA: "AAA"}, Position: 1, Played: 10, Points: 20, GoalDiff: 5, GoalsFor: 15, GoalsAgainst: 10},
+		{Team: Team{ID: 2, ShortName: "Bravo", TLA: "BBB"}, Position: 2, Played: 10, Points: 19, GoalDiff: 5, GoalsFor: 14, GoalsAgainst: 9},
+		{Team: Team{ID: 3, ShortName: "Charlie", TLA: "CCC"}, Position: 3, Played: 10, Points: 10, GoalDiff: 0, GoalsFor: 10, GoalsAgainst: 10},
+	}
+
+	match := Match{Status: "IN_PLAY", HomeTeam: Team{ID: 2}, AwayTeam: Team{ID: 3}}
+	match.Score.FullTime.Home, match.Score.FullTime.Away = 2, 2
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	rows := buildCann(overlayLive(table, []Match{match}), options{})
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	teams := rows[0].TeamDetails
+	if len(teams) != 2 || teams[0].TLA != "BBB" || teams[0].GoalsFor != 16 || teams[0].GoalsAgainst != 11 || teams[1].TLA != "AAA" {
+		t.Errorf("buildCann() live tied row = %+v, want Bravo's live goals counted and Bravo first on goals scored", teams)
+	}
+}
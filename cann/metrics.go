@@ -0,0 +1,45 @@
+package cann
+
+import "github.com/mick4711/moh/metrics"
+
+// latency and failures of the football-data fetches by resource including retries,
+// registered on the Settings registry by Configure
+var (
+	fetchDuration = newFetchDuration(nil)
+	fetchErrors   = newFetchErrors(nil)
+)
+
+func newFetchDuration(registry *metrics.Registry) *metrics.HistogramVec {
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+
+	return registry.Histogram("football_data_request_duration_seconds", "Duration of football-data.org fetches including retries.",
+		metrics.DefaultBuckets, "resource")
+}
+
+func newFetchErrors(registry *metrics.Registry) *metrics.CounterVec {
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+
+	return registry.Counter("football_data_request_errors_total", "football-data.org fetches that failed after retries.", "resource")
+}
+
+// register the standings and last season cache hit and miss counts, read from the current caches at each scrape
+func registerCacheMetrics(registry *metrics.Registry) {
+	if registry == nil {
+		return
+	}
+
+	registry.CounterFunc("football_data_cache_hits_total", "football-data.org responses served from the cache.", "cache",
+		func() map[string]float64 {
+			return map[string]float64{"standings": float64(standingsCache.Stats().Hits), "history": float64(historyCache.Stats().Hits),
+				"scorers": float64(scorersCache.Stats().Hits)}
+		})
+	registry.CounterFunc("football_data_cache_misses_total", "football-data.org lookups not in the cache.", "cache",
+		func() map[string]float64 {
+			return map[string]float64{"standings": float64(standingsCache.Stats().Misses), "history": float64(historyCache.Stats().Misses),
+				"scorers": float64(scorersCache.Stats().Misses)}
+		})
+}
@@ -0,0 +1,68 @@
+package cann
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/metrics"
+)
+
+func TestFetchDurationMetric(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(standingsWithTeamAt(t, 73, 1)) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	registry := metrics.NewRegistry()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second, Metrics: registry})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	for range 2 { // the second is served from the cache
+		if _, _, err := getStandings(context.Background(), defaultCompetition); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var scrape strings.Builder
+	if _, err := registry.WriteTo(&scrape); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{`football_data_request_duration_seconds_count{resource="standings"} 1`,
+		`football_data_cache_hits_total{cache="standings"} 1`, `football_data_cache_misses_total{cache="standings"} 1`} {
+		if !strings.Contains(scrape.String(), want) {
+			t.Errorf("registry scrape =\n%s\nwant %s", scrape.String(), want)
+		}
+	}
+}
+
+func TestFetchErrorsMetric(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	registry := metrics.NewRegistry()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second, RetryAttempts: 2, Metrics: registry})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	if _, _, err := getStandings(context.Background(), defaultCompetition); err == nil {
+		t.Fatal("getStandings() from a failing upstream succeeded")
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if got := fetchErrors.Value("standings"); got != 1 {
+		t.Errorf("football_data_request_errors_total{resource=\"standings\"} = %v, want 1 per failed fetch, not per attempt", got)
+	}
+}
@@ -0,0 +1,87 @@
+package cann
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+// Probabilities are a team's chances of winning the title and of relegation, from 0 to 1
+type Probabilities struct {
+	Title      float64 `json:"title"`
+	Relegation float64 `json:"relegation"`
+}
+
+// An OddsProvider supplies per-team probabilities for a competition keyed by football-data.org team ID,
+// teams without probabilities are left unannotated
+type OddsProvider interface {
+	Probabilities(ctx context.Context, comp string) (map[int]Probabilities, error)
+}
+
+// provider of the probabilities shown in the Cann table, nil when disabled
+var oddsProvider OddsProvider
+
+// NewHTTPOddsProvider returns a provider reading the json endpoint at sourceURL with the competition code
+// as the comp query parameter, e.g. {"64": {"title": 0.62, "relegation": 0}}
+func NewHTTPOddsProvider(sourceURL string) OddsProvider {
+	return httpOddsProvider{sourceURL: sourceURL}
+}
+
+type httpOddsProvider struct {
+	sourceURL string
+}
+
+func (p httpOddsProvider) Probabilities(ctx context.Context, comp string) (map[int]Probabilities, error) {
+	sourceURL, err := url.Parse(p.sourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid odds source url: %w", err)
+	}
+
+	query := sourceURL.Query()
+	query.Set("comp", comp)
+	sourceURL.RawQuery = query.Encode()
+
+	var probabilities map[int]Probabilities
+	if err := getSupplementary(ctx, "odds", sourceURL.String(), &probabilities); err != nil {
+		return nil, err
+	}
+
+	return probabilities, nil
+}
+
+// probabilities from the configured provider, nil when disabled or the provider fails
+func teamProbabilities(ctx context.Context, comp string) map[int]Probabilities {
+	if oddsProvider == nil {
+		return nil
+	}
+
+	probabilities, err := oddsProvider.Probabilities(ctx, comp)
+	if err != nil {
+		slog.WarnContext(ctx, "odds unavailable", "comp", comp, "err", err)
+		return nil
+	}
+
+	return probabilities
+}
+
+// label displayed next to a team with a chance of the title or relegation, e.g. "(title 62%)",
+// empty when both are zero
+func (p Probabilities) label() string {
+	var parts []string
+
+	if p.Title > 0 {
+		parts = append(parts, fmt.Sprintf("title %.0f%%", p.Title*100)) //nolint:gomnd // percentage
+	}
+
+	if p.Relegation > 0 {
+		parts = append(parts, fmt.Sprintf("relegation %.0f%%", p.Relegation*100)) //nolint:gomnd // percentage
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return "(" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,80 @@
+package cann
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+type stubOdds struct {
+	probabilities map[int]Probabilities
+	err           error
+}
+
+func (s stubOdds) Probabilities(context.Context, string) (map[int]Probabilities, error) {
+	return s.probabilities, s.err
+}
+
+func TestRenderOdds(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { oddsProvider = nil }()
+
+	tests := []struct {
+		name     string
+		provider OddsProvider
+		want     string // Liverpool and Tottenham rows
+	}{
+		{"enabled", stubOdds{probabilities: map[int]Probabilities{64: {Title: 0.62}, 73: {Relegation: 0.015}}},
+			" - [1]Liverpool(20, -25)[CL](title 62%) - [5]Tottenham(20, +13)[EL](relegation 2%)"},
+		{"disabled", nil, " - [1]Liverpool(20, -25)[CL] - [5]Tottenham(20, +13)[EL]"},
+		{"provider error", stubOdds{err: errors.New("unavailable")}, " - [1]Liverpool(20, -25)[CL] - [5]Tottenham(20, +13)[EL]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+			oddsProvider = test.provider
+
+			// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+			w := httptest.NewRecorder()
+			Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?format=json", bytes.NewReader(validStandings)))
+
+			// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+			var page cannPage
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := page.Rows[0].Teams + page.Rows[len(page.Rows)-1].Teams; got != test.want {
+				t.Errorf("Render() rows = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestHTTPOddsProvider(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"64": {"title": 0.5, "relegation": 0}, "%s": {"title": 0, "relegation": 0}}`, r.URL.Query().Get("comp"))
+	}))
+	defer ts.Close()
+
+	got, err := NewHTTPOddsProvider(ts.URL+"/odds?season=2024").Probabilities(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 || got[64].Title != 0.5 || !strings.Contains(got[64].label(), "title 50%") || got[1].label() != "" {
+		t.Errorf("Probabilities() = %v, want the endpoint's probabilities for the competition", got)
+	}
+}
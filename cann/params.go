@@ -0,0 +1,40 @@
+package cann
+
+// Query parameters are classified by whether they change the data fetched from the upstream api.
+// The data cache is keyed by the upstream url, which is built only from data parameters,
+// every other parameter is applied to the cached data at render time, so requests differing
+// only in those share a cache entry.
+const (
+	paramData     = "data"     // selects the upstream data and is part of the cache key
+	paramDerived  = "derived"  // recomputes or filters the cached data before rendering
+	paramCosmetic = "cosmetic" // presentation only
+)
+
+var queryParams = map[string]string{
+	"comp":              paramData,
+	"competition":       paramData, // long form of comp
+	"live":              paramData, // adds the matches in progress, cached under their own url
+	"xg":                paramData, // adds the xG source data, fetched from its own url
+	"compareLastSeason": paramData, // adds last season's standings, cached under their own url
+	"refresh":           paramData, // refetches the standings, replacing the cached copy
+	"date":              paramData, // the standings saved on or before the date instead of the current standings
+	"matchday":          paramData, // the standings saved at the matchday instead of the current standings
+	"season":            paramData, // a past season's final standings, cached under their own url
+	"grouped":           paramDerived,
+	"teams":             paramDerived,
+	"winpoints":         paramDerived,
+	"rowsort":           paramDerived,
+	"form":              paramDerived,
+	"stats":             paramDerived, // adds the points per game, projected points and games in hand
+	"format":            paramCosmetic,
+	"pretty":            paramCosmetic,
+	"lite":              paramCosmetic,
+	"a11y":              paramCosmetic,
+	"theme":             paramCosmetic,
+	"lang":              paramCosmetic, // page text language, en, es or pt
+}
+
+// QueryParams lists the query parameters the Cann table accepts, sorted
+func QueryParams() []string {
+	return sortedKeys(queryParams)
+}
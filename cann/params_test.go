@@ -0,0 +1,57 @@
+package cann
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCosmeticParamsShareCacheEntry(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, MaxEntries: 4, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	compact := httptest.NewRecorder()
+	Gaps(compact, httptest.NewRequest(http.MethodGet, "/cann/gaps?comp=PL", http.NoBody))
+
+	pretty := httptest.NewRecorder()
+	Gaps(pretty, httptest.NewRequest(http.MethodGet, "/cann/gaps?comp=PL&pretty=1", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if requests != 1 {
+		t.Errorf("upstream requests = %d, want 1 shared data cache entry", requests)
+	}
+
+	if stats := CacheStats(); stats.Entries != 1 || stats.Hits != 1 {
+		t.Errorf("CacheStats() = %+v, want 1 entry and 1 hit", stats)
+	}
+
+	if strings.Contains(compact.Body.String(), "\n  ") || !strings.Contains(pretty.Body.String(), "\n  ") {
+		t.Errorf("pretty json not applied at render time\ncompact: %s\npretty: %s", compact.Body, pretty.Body)
+	}
+}
+
+func TestQueryParamsClassified(t *testing.T) {
+	for param, kind := range queryParams {
+		if kind != paramData && kind != paramDerived && kind != paramCosmetic {
+			t.Errorf("query param %q has unknown classification %q", param, kind)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package cann
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// switches of the Cann table view, each is "1" when on
+var viewSwitches = []string{"compareLastSeason", "grouped", "lite", "live", "stats", "xg"}
+
+// absolute url reproducing the current view with every parameter explicit, defaults included,
+// so the link shows the same view if the defaults change. The effective watchlist, palette and accessibility theme,
+// from the query or their cookies, are included as teams and a11y
+func permalink(req *http.Request, teams map[string]bool, a11y bool) string {
+	query := req.URL.Query()
+	values := url.Values{}
+
+	comp, err := competition(req)
+	if err != nil {
+		comp = defaultCompetition
+	}
+
+	values.Set("comp", comp)
+	values.Set("format", "html")
+	values.Set("teams", strings.Join(sortedKeys(teams), ","))
+	values.Set("winpoints", strconv.Itoa(pointsForWin))
+	values.Set("rowsort", rowSortCriteria)
+	values.Set("a11y", "0")
+	values.Set("theme", "light")
+	values.Set("form", "1")
+
+	if a11y {
+		values.Set("a11y", "1")
+	}
+
+	if query.Get("form") == "0" {
+		values.Set("form", "0")
+	}
+
+	if query.Get("theme") == darkTheme.Name {
+		values.Set("theme", darkTheme.Name)
+	}
+
+	for _, name := range []string{"winpoints", "rowsort", "season", "lang"} {
+		if value := query.Get(name); value != "" {
+			values.Set(name, value)
+		}
+	}
+
+	for _, name := range viewSwitches {
+		values.Set(name, "0")
+
+		if query.Get(name) == "1" {
+			values.Set(name, "1")
+		}
+	}
+
+	scheme := "http"
+	if req.TLS != nil || req.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	link := url.URL{Scheme: scheme, Host: req.Host, Path: req.URL.Path, RawQuery: values.Encode()}
+
+	return link.String()
+}
@@ -0,0 +1,66 @@
+package cann
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPermalink(t *testing.T) {
+	tests := []struct {
+		url    string
+		cookie string
+		want   string
+	}{
+		{
+			"/cann",
+			"",
+			"http://example.com/cann?a11y=0&comp=PL&compareLastSeason=0&form=1&format=html&grouped=0&lite=0&live=0&rowsort=goalDifference%2CgoalsFor%2Cname&stats=0&teams=&theme=light&winpoints=3&xg=0",
+		},
+		{
+			"/cann?grouped=1&winpoints=2&rowsort=form&stats=0&teams=tot,liv&pretty=1",
+			"",
+			"http://example.com/cann?a11y=0&comp=PL&compareLastSeason=0&form=1&format=html&grouped=1&lite=0&live=0&rowsort=form&stats=0&teams=LIV%2CTOT&theme=light&winpoints=2&xg=0",
+		},
+		{
+			"/cann?live=1",
+			"ARS",
+			"http://example.com/cann?a11y=0&comp=PL&compareLastSeason=0&form=1&format=html&grouped=0&lite=0&live=1&rowsort=goalDifference%2CgoalsFor%2Cname&stats=0&teams=ARS&theme=light&winpoints=3&xg=0",
+		},
+		{
+			"/cann?theme=dark&form=0",
+			"",
+			"http://example.com/cann?a11y=0&comp=PL&compareLastSeason=0&form=0&format=html&grouped=0&lite=0&live=0&rowsort=goalDifference%2CgoalsFor%2Cname&stats=0&teams=&theme=dark&winpoints=3&xg=0",
+		},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+		if test.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: watchlistCookie, Value: test.cookie})
+		}
+
+		if got := permalink(req, watchlist(httptest.NewRecorder(), req), false); got != test.want {
+			t.Errorf("permalink(%s) =\n%s\nwant\n%s", test.url, got, test.want)
+		}
+	}
+}
+
+func TestRenderPermalink(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "https://moh.example/debug/render?grouped=1", bytes.NewReader(validStandings))
+
+	w := httptest.NewRecorder()
+	Render(w, req)
+
+	if want := `value="https://moh.example/debug/render?a11y=0&amp;comp=PL&amp;compareLastSeason=0&amp;form=1&amp;format=html&amp;grouped=1`; !strings.Contains(w.Body.String(), want) {
+		t.Errorf("Render() html = %s, want the permalink %s", w.Body, want)
+	}
+}
@@ -0,0 +1,36 @@
+package cann
+
+import (
+	"slices"
+	"strings"
+)
+
+// note displayed on the Cann table before any games have been played
+const preSeasonNote = "Season not started: no games have been played yet, teams are listed alphabetically"
+
+// true during the gap between the fixture list release and matchday 1, when the standings exist with all zeros
+func isPreSeason(standingsTable []TableRow) bool {
+	for _, row := range standingsTable {
+		if row.Played != 0 {
+			return false
+		}
+	}
+
+	return len(standingsTable) > 0
+}
+
+// a single Cann table row listing every team alphabetically by name
+func preSeasonRows(standingsTable []TableRow) []Row {
+	sorted := slices.Clone(standingsTable)
+	slices.SortStableFunc(sorted, func(a, b TableRow) int { return strings.Compare(a.Team.ShortName, b.Team.ShortName) })
+
+	names := make([]string, 0, len(sorted))
+	teams := make([]RowTeam, 0, len(sorted))
+
+	for _, row := range sorted {
+		names = append(names, row.Team.ShortName)
+		teams = append(teams, RowTeam{Position: row.Position, TeamID: row.Team.ID, Team: row.Team.ShortName, TLA: row.Team.TLA, CrestURL: row.Team.Crest})
+	}
+
+	return []Row{{Points: 0, Teams: " - " + strings.Join(names, " - "), TeamDetails: teams}}
+}
@@ -0,0 +1,71 @@
+package cann
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/mick4711/moh/conditional"
+)
+
+func TestRenderPreSeason(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	preSeasonStandings, err := os.ReadFile("standings_preseason_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?format=json&grouped=1", bytes.NewReader(preSeasonStandings)))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK {
+		t.Fatalf("Render() status = %d, want %d\n%s", w.Code, http.StatusOK, w.Body)
+	}
+
+	var got cannPage
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := cannPage{
+		Rows: []Row{{Points: 0, Teams: " - Arsenal - Aston Villa - Crystal Palace - Liverpool", TeamDetails: []RowTeam{
+			{Position: 2, TeamID: 57, Team: "Arsenal", TLA: "ARS", CrestURL: "https://crests.football-data.org/57.png"},
+			{Position: 3, TeamID: 58, Team: "Aston Villa", TLA: "AVL", CrestURL: "https://crests.football-data.org/58.png"},
+			{Position: 4, TeamID: 354, Team: "Crystal Palace", TLA: "CRY", CrestURL: "https://crests.football-data.org/354.png"},
+			{Position: 1, TeamID: 64, Team: "Liverpool", TLA: "LIV", CrestURL: "https://crests.football-data.org/64.png"},
+		}}},
+		Notes:     []string{preSeasonNote},
+		PreSeason: true,
+
+		DataVersion: conditional.DataVersion(preSeasonStandings),
+		Competition: "Premier League",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Render() pre-season = %#v, want %#v", got, want)
+	}
+}
+
+func TestIsPreSeason(t *testing.T) {
+	table := testTable(4)
+	if isPreSeason(table) {
+		t.Errorf("isPreSeason() = true, want false with games played")
+	}
+
+	for i := range table {
+		table[i].Played = 0
+	}
+
+	if !isPreSeason(table) {
+		t.Errorf("isPreSeason() = false, want true with no games played")
+	}
+
+	if isPreSeason(nil) {
+		t.Errorf("isPreSeason(nil) = true, want false")
+	}
+}
@@ -0,0 +1,51 @@
+package cann
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Quota is the football-data.org request allowance reported on the most recent upstream response
+type Quota struct {
+	Available int       `json:"available"` // requests left before the counter resets
+	ResetsAt  time.Time `json:"resetsAt"`
+	Reported  time.Time `json:"reported"` // when the response carrying it was received
+}
+
+// the last reported quota, nil until a response carries the quota headers
+var quota struct {
+	sync.Mutex
+	last *Quota
+}
+
+// record the allowance from the X-Requests-Available-Minute and X-RequestCounter-Reset (seconds) headers
+func recordQuota(header http.Header) {
+	available, err := strconv.Atoi(header.Get("X-Requests-Available-Minute"))
+	if err != nil {
+		return
+	}
+
+	now := clk.Now()
+	reset, _ := strconv.Atoi(header.Get("X-RequestCounter-Reset")) //nolint:errcheck // resets now when missing
+
+	quota.Lock()
+	quota.last = &Quota{Available: available, ResetsAt: now.Add(time.Duration(reset) * time.Second), Reported: now}
+	quota.Unlock()
+}
+
+// UpstreamQuota returns the football-data.org allowance from the most recent response that reported it, nil if
+// none has
+func UpstreamQuota() *Quota {
+	quota.Lock()
+	defer quota.Unlock()
+
+	if quota.last == nil {
+		return nil
+	}
+
+	last := *quota.last
+
+	return &last
+}
@@ -0,0 +1,30 @@
+package cann
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestRecordQuota(t *testing.T) {
+	defer func(c clock.Clock) { clk = c }(clk)
+
+	now := time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC)
+	clk = clock.NewFake(now)
+	defer func() { quota.last = nil }()
+
+	recordQuota(http.Header{})
+
+	if got := UpstreamQuota(); got != nil {
+		t.Errorf("UpstreamQuota() without the quota headers = %+v, want nil", got)
+	}
+
+	recordQuota(http.Header{"X-Requests-Available-Minute": {"7"}, "X-Requestcounter-Reset": {"30"}})
+
+	want := Quota{Available: 7, ResetsAt: now.Add(30 * time.Second), Reported: now}
+	if got := UpstreamQuota(); got == nil || *got != want {
+		t.Errorf("UpstreamQuota() = %+v, want %+v", got, want)
+	}
+}
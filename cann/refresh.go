@@ -0,0 +1,79 @@
+package cann
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/conditional"
+)
+
+// standings urls refreshed in the background and when they were last refreshed, their cached copies are served
+// for up to warmMaxAge instead of the cache TTL. Reset by Configure
+var (
+	backgroundRefreshes = struct {
+		sync.Mutex
+		at map[string]time.Time
+	}{at: make(map[string]time.Time)}
+	warmMaxAge time.Duration
+)
+
+// Refresh fetches the competition's standings into the cache, replacing any cached copy, and returns their data
+// version, for refreshing in the background ahead of requests
+func Refresh(ctx context.Context, comp string) (string, error) {
+	url := standingsURL(comp)
+
+	body, err := fetchInto(ctx, standingsCache, "standings", url)
+	if err != nil {
+		return "", err
+	}
+
+	backgroundRefreshes.Lock()
+	backgroundRefreshes.at[url] = clk.Now()
+	backgroundRefreshes.Unlock()
+
+	return conditional.DataVersion(body), nil
+}
+
+// whether the url is refreshed in the background and was fetched at most warmMaxAge ago
+func warm(url string, fetched time.Time) bool {
+	backgroundRefreshes.Lock()
+	_, ok := backgroundRefreshes.at[url]
+	backgroundRefreshes.Unlock()
+
+	return ok && clock.Since(clk, fetched) < warmMaxAge
+}
+
+// when the competition's standings were last refreshed in the background, empty if they never were
+func lastRefreshed(comp string) string {
+	backgroundRefreshes.Lock()
+	defer backgroundRefreshes.Unlock()
+
+	at, ok := backgroundRefreshes.at[standingsURL(comp)]
+	if !ok {
+		return ""
+	}
+
+	return at.UTC().Format(time.RFC3339)
+}
+
+// MatchDay reports whether the competition has a match today, for refreshing more often while results come in
+func MatchDay(ctx context.Context, comp string) (bool, error) {
+	today := clk.Now().UTC().Format(time.DateOnly)
+	url := fmt.Sprintf(`%s/competitions/%s/matches?dateFrom=%s&dateTo=%s`, baseURL, comp, today, today)
+
+	body, _, err := getCached(ctx, "matches", url)
+	if err != nil {
+		return false, err
+	}
+
+	var matchesResponse MatchesResponse
+	if err := json.Unmarshal(body, &matchesResponse); err != nil {
+		return false, fmt.Errorf("error unmarshalling json from matches response:%w", err)
+	}
+
+	return len(matchesResponse.Matches) > 0, nil
+}
@@ -0,0 +1,134 @@
+package cann
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/conditional"
+)
+
+func TestRefresh(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches++
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Hour, UpstreamTimeout: time.Second, RetryAttempts: 1})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	first, err := Refresh(context.Background(), "PL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := Refresh(context.Background(), "PL")
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || first != conditional.DataVersion(validStandings) || second != first {
+		t.Errorf("Refresh() = %q then %q, %v, want the standings data version %q", first, second, err, conditional.DataVersion(validStandings))
+	}
+
+	if fetches != 2 {
+		t.Errorf("Refresh() twice fetched %d times, want the cached copy replaced each time", fetches)
+	}
+
+	if cached, ok := standingsCache.Get(standingsURL("PL")); !ok || string(cached) != string(validStandings) {
+		t.Error("Refresh() didn't cache the standings")
+	}
+
+	if lastRefreshed("PL") == "" || lastRefreshed("ELC") != "" {
+		t.Errorf("lastRefreshed() = %q for PL and %q for ELC, want only PL refreshed", lastRefreshed("PL"), lastRefreshed("ELC"))
+	}
+}
+
+func TestWarmStandings(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fetches++
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	fake := clock.NewFake(time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC))
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second, RetryAttempts: 1, Clock: fake, WarmMaxAge: 20 * time.Minute})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	if _, err := Refresh(context.Background(), "PL"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		advance     time.Duration
+		comp        string
+		wantFetches int
+		wantStatus  cacheStatus
+	}{
+		{"expired but warm", 10 * time.Minute, "PL", 1, cacheHit},
+		{"not refreshed in the background", 0, "ELC", 2, cacheMiss},
+		{"refresh too old", 10 * time.Minute, "PL", 3, cacheMiss},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		fake.Advance(test.advance)
+
+		_, status, err := getStandings(context.Background(), test.comp)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if err != nil || status != test.wantStatus || fetches != test.wantFetches {
+			t.Errorf("%s: getStandings() status = %s, %v after %d fetches, want %s after %d", test.name, status, err, fetches, test.wantStatus, test.wantFetches)
+		}
+	}
+}
+
+func TestMatchDay(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	var requested string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.RequestURI()
+		_, _ = w.Write([]byte(`{"matches": [{"id": 1, "status": "TIMED"}]}`)) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	fake := clock.NewFake(time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC))
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second, RetryAttempts: 1, Clock: fake})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	matchDay, err := MatchDay(context.Background(), "PL")
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || !matchDay {
+		t.Errorf("MatchDay() = %t, %v, want true", matchDay, err)
+	}
+
+	if want := "/competitions/PL/matches?dateFrom=2024-03-02&dateTo=2024-03-02"; requested != want {
+		t.Errorf("MatchDay() requested %s, want %s", requested, want)
+	}
+}
@@ -0,0 +1,118 @@
+package cann
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mick4711/moh/breaker"
+)
+
+const (
+	defaultRetryAttempts = 3
+	defaultRetryDelay    = 200 * time.Millisecond
+)
+
+var (
+	retryAttempts = defaultRetryAttempts // attempts per upstream fetch including the first, set by Configure
+	retryDelay    = defaultRetryDelay    // backoff before the first retry, doubled for each further retry
+)
+
+// refuses football-data fetches for a cooldown after repeated failures, set by Configure
+var upstreamBreaker = breaker.New(breaker.DefaultThreshold, breaker.DefaultCooldown, nil)
+
+var errNoAPIToken = errors.New("API_TOKEN is not configured")
+
+// a non 200 upstream response, retryAfter is the wait asked for by a Retry-After header
+type statusError struct {
+	resource   string
+	status     int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s response status not OK: %v", e.resource, e.status)
+}
+
+// request a resource from the upstream api, retrying connection errors, 5xx and 429 responses with exponential
+// backoff and jitter, or after the Retry-After wait when it is longer.
+// Retries stop at retryAttempts or when the next wait would pass the context deadline
+func fetchWithRetry(ctx context.Context, resource, url string) ([]byte, error) {
+	delay := retryDelay
+
+	for attempt := 1; ; attempt++ {
+		body, err := fetch(ctx, resource, url)
+		if err == nil || attempt >= retryAttempts || !retryable(ctx, err) {
+			return body, err
+		}
+
+		wait := delay + rand.N(delay) //nolint:gosec // jitter doesn't need a secure source
+
+		var statusErr *statusError
+		if errors.As(err, &statusErr) && statusErr.retryAfter > wait {
+			wait = statusErr.retryAfter
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return nil, err
+		}
+
+		slog.InfoContext(ctx, "retrying upstream fetch", "resource", resource, "wait", wait.Round(time.Millisecond), "attempt", attempt, "err", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+
+		delay *= 2
+	}
+}
+
+// whether a failed fetch may succeed when retried, other 4xx responses and a missing token won't fix themselves
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, errNoAPIToken) {
+		return false
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status >= http.StatusInternalServerError || statusErr.status == http.StatusTooManyRequests
+	}
+
+	return true // connection errors and bodies cut off mid-read
+}
+
+// whether a failed fetch counts towards opening the circuit, requests the upstream rejects and requests
+// abandoned by the client don't say the upstream is down
+func upstreamFailure(err error) bool {
+	if err == nil || errors.Is(err, errNoAPIToken) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.status >= http.StatusInternalServerError || statusErr.status == http.StatusTooManyRequests
+	}
+
+	return true
+}
+
+// the wait asked for by a Retry-After header, in seconds or as an http date, 0 when absent or invalid
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,220 @@
+package cann
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/stale"
+)
+
+func TestFetchWithRetry(t *testing.T) {
+	defer func(token string) { apiToken = token }(apiToken)
+	apiToken = "test-token"
+
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Millisecond
+
+	tests := []struct {
+		name         string
+		failures     []int // status of each failed attempt before a 200, 0 drops the connection
+		wantRequests int32
+		wantErr      bool
+	}{
+		{"fails twice then succeeds", []int{http.StatusServiceUnavailable, 0}, 3, false},
+		{"rate limited then succeeds", []int{http.StatusTooManyRequests}, 2, false},
+		{"keeps failing", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, 3, true},
+		{"client error isn't retried", []int{http.StatusNotFound}, 1, true},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		var requests atomic.Int32
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			n := int(requests.Add(1))
+			if n > len(test.failures) {
+				_, _ = w.Write([]byte(`{"standings": []}`)) //nolint:errcheck // test server
+				return
+			}
+
+			if test.failures[n-1] == 0 {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				conn.Close()
+
+				return
+			}
+
+			w.WriteHeader(test.failures[n-1])
+		}))
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		body, err := fetchWithRetry(context.Background(), "standings", ts.URL)
+
+		ts.Close()
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if (err != nil) != test.wantErr || requests.Load() != test.wantRequests {
+			t.Errorf("fetchWithRetry() %s err = %v after %d requests, want error %v after %d", test.name, err, requests.Load(), test.wantErr, test.wantRequests)
+		}
+
+		if !test.wantErr && string(body) != `{"standings": []}` {
+			t.Errorf("fetchWithRetry() %s body = %s, want the successful response", test.name, body)
+		}
+	}
+}
+
+func TestFetchWithRetryDeadline(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	defer func(token string) { apiToken = token }(apiToken)
+	apiToken = "test-token"
+
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Second
+
+	var requests atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	start := time.Now()
+	_, err := fetchWithRetry(ctx, "standings", ts.URL)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err == nil || requests.Load() != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("fetchWithRetry() err = %v after %d requests in %s, want the error without a retry past the deadline", err, requests.Load(), time.Since(start))
+	}
+}
+
+func TestFetchWithRetryHonoursRetryAfter(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	defer func(token string) { apiToken = token }(apiToken)
+	apiToken = "test-token"
+
+	defer func(delay time.Duration) { retryDelay = delay }(retryDelay)
+	retryDelay = time.Millisecond
+
+	var requests atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"standings": []}`)) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	start := time.Now()
+	_, err := fetchWithRetry(ctx, "standings", ts.URL)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || requests.Load() != 2 || time.Since(start) < time.Second {
+		t.Errorf("fetchWithRetry() err = %v after %d requests in %s, want success on the retry after the 1s Retry-After", err, requests.Load(), time.Since(start))
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"-5", 0},
+		{"Sat, 02 Mar 2024 15:00:30 GMT", 30 * time.Second},
+		{"Sat, 02 Mar 2024 14:00:00 GMT", 0},
+		{"soon", 0},
+	}
+
+	for _, test := range tests {
+		if got := parseRetryAfter(test.value, now); got != test.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestCircuitBreakerServesStaleTable(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests, failing atomic.Int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+
+		if failing.Load() == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Millisecond, UpstreamTimeout: time.Second, RetryAttempts: 1,
+		BreakerThreshold: 2, BreakerCooldown: time.Hour})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	if _, _, err := getStandings(context.Background(), defaultCompetition); err != nil {
+		t.Fatal(err)
+	}
+
+	failing.Store(1)
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	var w *httptest.ResponseRecorder
+
+	for range 4 {
+		time.Sleep(5 * time.Millisecond) // expire the cached copy
+
+		w = httptest.NewRecorder()
+		GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann", http.NoBody))
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if got := requests.Load(); got != 3 {
+		t.Errorf("upstream requests = %d, want 3, the first fetch and 2 failures before the circuit opens", got)
+	}
+
+	if w.Code != http.StatusOK || w.Header().Get(cacheHeader) != string(cacheStale) {
+		t.Errorf("GenerateTable() with the circuit open status = %d %s = %q, want 200 stale", w.Code, cacheHeader, w.Header().Get(cacheHeader))
+	}
+
+	if body := w.Body.String(); !strings.Contains(body, `<p class="stale" role="alert"><strong>Data from 1m ago — live update failed</strong></p>`) {
+		t.Errorf("GenerateTable() with the circuit open body is missing the stale data banner\n%s", body)
+	}
+
+	if w.Header().Get("Warning") != stale.Warning || w.Header().Get("Age") != "0" {
+		t.Errorf("GenerateTable() with the circuit open Warning = %q Age = %q, want %q 0", w.Header().Get("Warning"), w.Header().Get("Age"), stale.Warning)
+	}
+}
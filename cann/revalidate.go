@@ -0,0 +1,45 @@
+package cann
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/mick4711/moh/cache"
+)
+
+// serve expired cached copies while refreshing them in the background, set by Configure
+var staleWhileRevalidate bool
+
+// urls being refreshed in the background, so concurrent stale hits share one refresh
+var refreshing = struct {
+	sync.Mutex
+	urls map[string]bool
+	wg   sync.WaitGroup
+}{urls: make(map[string]bool)}
+
+// refresh a cached resource in the background unless a refresh of it is already in flight.
+// The refresh isn't tied to the request that triggered it, which has already been served
+func revalidate(store *cache.Cache, resource, url string) {
+	refreshing.Lock()
+	defer refreshing.Unlock()
+
+	if refreshing.urls[url] {
+		return
+	}
+
+	refreshing.urls[url] = true
+	refreshing.wg.Add(1)
+
+	go func() {
+		defer refreshing.wg.Done()
+
+		if _, err := fetchInto(context.Background(), store, resource, url); err != nil {
+			slog.Warn("background refresh failed", "resource", resource, "err", err)
+		}
+
+		refreshing.Lock()
+		delete(refreshing.urls, url)
+		refreshing.Unlock()
+	}()
+}
@@ -0,0 +1,81 @@
+package cann
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requests atomic.Int32
+
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if requests.Add(1) > 1 {
+			<-release // hold the background refresh until the stale hits have been served
+		}
+
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: 50 * time.Millisecond, MaxEntries: 1, UpstreamTimeout: 5 * time.Second, StaleWhileRevalidate: true})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	if _, _, err := getStandings(context.Background(), defaultCompetition); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond) // expire the cached copy
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	var wg sync.WaitGroup
+
+	start := time.Now()
+
+	for range 5 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			w := httptest.NewRecorder()
+			GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann?format=json", http.NoBody))
+
+			if w.Code != http.StatusOK || w.Header().Get(cacheHeader) != "STALE" {
+				t.Errorf("GenerateTable() status = %d %s = %q, want 200 marked stale", w.Code, cacheHeader, w.Header().Get(cacheHeader))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	elapsed := time.Since(start)
+
+	close(release)
+	refreshing.wg.Wait()
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if elapsed > time.Second {
+		t.Errorf("stale hits took %s, want them served without waiting on the upstream", elapsed)
+	}
+
+	if got := requests.Load(); got != 2 {
+		t.Errorf("upstream requests = %d, want the initial fetch and exactly one refresh", got)
+	}
+
+	if _, status, err := getStandings(context.Background(), defaultCompetition); err != nil || status == cacheStale {
+		t.Errorf("getStandings() after refresh status = %v, err = %v, want fresh data", status, err)
+	}
+}
@@ -0,0 +1,117 @@
+package cann
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// a rowSort orders teams that share a Cann table row
+type rowSort func(a, b TableRow) int
+
+// number of recent results counted for form
+const formGames = 5
+
+// intra-row sort criteria selected with ?rowsort=, a comma separated list e.g. "goalDifference,goalsFor,name"
+// where each criterion settles the ties left by the ones before it and league position settles any left after them
+var rowSorts = map[string]rowSort{
+	"position":       byPosition,
+	"form":           byForm,
+	"goalDifference": byGoalDifference,
+	"goalsFor":       byGoalsFor,
+	"name":           byName,
+}
+
+// the criteria used without ?rowsort=
+const defaultRowSortCriteria = "goalDifference,goalsFor,name"
+
+// the criteria used without ?rowsort=, set by Configure from CANN_ROW_SORT
+var rowSortCriteria = defaultRowSortCriteria
+
+// the requested intra-row sort, the configured criteria when name is empty
+func rowSortFor(name string) (rowSort, error) {
+	if name == "" {
+		name = rowSortCriteria
+	}
+
+	var criteria []rowSort
+
+	for _, criterion := range strings.Split(name, ",") {
+		sort, ok := rowSorts[strings.TrimSpace(criterion)]
+		if !ok {
+			return nil, fmt.Errorf("invalid rowsort %q, must be a comma separated list of %s", name, strings.Join(sortedKeys(rowSorts), ", "))
+		}
+
+		criteria = append(criteria, sort)
+	}
+
+	return func(a, b TableRow) int {
+		for _, sort := range criteria {
+			if c := sort(a, b); c != 0 {
+				return c
+			}
+		}
+
+		return byPosition(a, b)
+	}, nil
+}
+
+// league position, which settles ties on points with goal difference
+func byPosition(a, b TableRow) int {
+	return cmp.Compare(a.Position, b.Position)
+}
+
+// goal difference, highest first
+func byGoalDifference(a, b TableRow) int {
+	return cmp.Compare(b.GoalDiff, a.GoalDiff)
+}
+
+// goals scored, most first
+func byGoalsFor(a, b TableRow) int {
+	return cmp.Compare(b.GoalsFor, a.GoalsFor)
+}
+
+// team name, alphabetical
+func byName(a, b TableRow) int {
+	return strings.Compare(a.Team.ShortName, b.Team.ShortName)
+}
+
+// points from recent form, best first, teams without form data last, then league position
+func byForm(a, b TableRow) int {
+	aPoints, aOK := formPoints(a.Form)
+	bPoints, bOK := formPoints(b.Form)
+
+	switch {
+	case aOK && !bOK:
+		return -1
+	case !aOK && bOK:
+		return 1
+	}
+
+	if c := cmp.Compare(bPoints, aPoints); c != 0 {
+		return c
+	}
+
+	return byPosition(a, b)
+}
+
+// points from the last five results of a form string e.g. "W,D,L,W,W", false when there are no results
+func formPoints(form string) (Points, bool) {
+	var points Points
+
+	results := formResults(form)
+	if len(results) == 0 {
+		return 0, false
+	}
+
+	for _, result := range results {
+		switch result {
+		case "W":
+			points += pointsForWin
+		case "D":
+			points++
+		}
+	}
+
+	return points, true
+}
@@ -0,0 +1,143 @@
+package cann
+
+import (
+	"testing"
+)
+
+func TestBuildCannRowSortForm(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	tied := []TableRow{
+		{Team: Team{ShortName: "AAA"}, Position: 1, Points: 40, Form: "L,L,D,W,L"},
+		{Team: Team{ShortName: "BBB"}, Position: 2, Points: 40},
+		{Team: Team{ShortName: "CCC"}, Position: 3, Points: 40, Form: "W,W,W,D,W"},
+		{Team: Team{ShortName: "DDD"}, Position: 4, Points: 40, Form: "W,D,W,L,L"},
+		{Team: Team{ShortName: "EEE"}, Position: 5, Points: 38, Form: "W,W,W,W,W"},
+	}
+
+	tests := []struct {
+		rowsort string
+		want    string
+	}{
+		{"", " - [1]AAA(0, +0) - [2]BBB(0, +0) - [3]CCC(0, +0) - [4]DDD(0, +0)"},
+		{"position", " - [1]AAA(0, +0) - [2]BBB(0, +0) - [3]CCC(0, +0) - [4]DDD(0, +0)"},
+		{"form", " - [3]CCC(0, +0) - [4]DDD(0, +0) - [1]AAA(0, +0) - [2]BBB(0, +0)"},
+	}
+
+	for _, test := range tests {
+		sort, err := rowSortFor(test.rowsort)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		rows := buildCann(tied, options{rowSort: sort})
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if rows[0].Teams != test.want {
+			t.Errorf("buildCann() rowsort=%q tied row = %q, want %q", test.rowsort, rows[0].Teams, test.want)
+		}
+
+		if rows[2].Teams != " - [5]EEE(0, +0)" {
+			t.Errorf("buildCann() rowsort=%q moved a team between rows %q", test.rowsort, rows[2].Teams)
+		}
+	}
+
+	if tied[0].Team.ShortName != "AAA" {
+		t.Error("buildCann() reordered the fetched table")
+	}
+}
+
+func TestRowSortForInvalid(t *testing.T) {
+	if _, err := rowSortFor("alphabetical"); err == nil {
+		t.Error("rowSortFor(alphabetical) err = nil, want an error")
+	}
+}
+
+func TestBuildCannTiedTeamsOrderedByPosition(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	shuffled := []TableRow{
+		{Team: Team{ShortName: "CCC"}, Position: 3, Points: 40, GoalDiff: 5},
+		{Team: Team{ShortName: "EEE"}, Position: 5, Points: 38},
+		{Team: Team{ShortName: "AAA"}, Position: 1, Points: 40, GoalDiff: 12},
+		{Team: Team{ShortName: "DDD"}, Position: 4, Points: 40, GoalDiff: 2},
+		{Team: Team{ShortName: "BBB"}, Position: 2, Points: 40, GoalDiff: 9},
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	rows := buildCann(shuffled, options{})
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if want := " - [1]AAA(0, +12) - [2]BBB(0, +9) - [3]CCC(0, +5) - [4]DDD(0, +2)"; rows[0].Teams != want {
+		t.Errorf("buildCann() tied row = %q, want %q", rows[0].Teams, want)
+	}
+
+	for i, team := range rows[0].TeamDetails {
+		if team.Position != i+1 {
+			t.Errorf("buildCann() tied row team %d at position %d, want %d", i, team.Position, i+1)
+		}
+	}
+
+	if shuffled[0].Team.ShortName != "CCC" {
+		t.Error("buildCann() reordered the fetched table")
+	}
+}
+
+func TestBuildCannRowSortTiebreak(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	tied := []TableRow{
+		{Team: Team{ShortName: "Wolves"}, Position: 1, Points: 40, GoalDiff: 5, GoalsFor: 30},
+		{Team: Team{ShortName: "Brighton"}, Position: 2, Points: 40, GoalDiff: 5, GoalsFor: 30},
+		{Team: Team{ShortName: "Everton"}, Position: 3, Points: 40, GoalDiff: 5, GoalsFor: 33},
+		{Team: Team{ShortName: "Fulham"}, Position: 4, Points: 40, GoalDiff: 8, GoalsFor: 25},
+	}
+
+	tests := []struct {
+		rowsort string
+		want    string
+	}{
+		{"", " - [4]Fulham(0, +8) - [3]Everton(0, +5) - [2]Brighton(0, +5) - [1]Wolves(0, +5)"},
+		{"name", " - [2]Brighton(0, +5) - [3]Everton(0, +5) - [4]Fulham(0, +8) - [1]Wolves(0, +5)"},
+		{"goalsFor, name", " - [3]Everton(0, +5) - [2]Brighton(0, +5) - [1]Wolves(0, +5) - [4]Fulham(0, +8)"},
+		{"goalDifference", " - [4]Fulham(0, +8) - [1]Wolves(0, +5) - [2]Brighton(0, +5) - [3]Everton(0, +5)"},
+	}
+
+	for _, test := range tests {
+		sort, err := rowSortFor(test.rowsort)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		rows := buildCann(tied, options{rowSort: sort})
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if rows[0].Teams != test.want {
+			t.Errorf("buildCann() rowsort=%q tied row = %q, want %q", test.rowsort, rows[0].Teams, test.want)
+		}
+	}
+}
+
+func TestConfigureRowSort(t *testing.T) {
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		setting string
+		want    string
+	}{
+		{"", defaultRowSortCriteria},
+		{"form,name", "form,name"},
+		{"form,alphabetical", defaultRowSortCriteria},
+	}
+
+	for _, test := range tests {
+		Configure(Settings{RowSort: test.setting})
+
+		if rowSortCriteria != test.want {
+			t.Errorf("Configure(RowSort: %q) criteria = %q, want %q", test.setting, rowSortCriteria, test.want)
+		}
+	}
+
+	if _, err := rowSortFor("goalDifference,alphabetical"); err == nil {
+		t.Error("rowSortFor(goalDifference,alphabetical) err = nil, want an error")
+	}
+}
@@ -0,0 +1,96 @@
+package cann
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// seasons offered by the Cann page's season selector, the current season and those before it
+const selectableSeasons = 5
+
+// the first season start year ?season= accepts
+const firstSeason = 1990
+
+// A SeasonOption is a season in the Cann page's season selector
+type SeasonOption struct {
+	Year     int    // start year, the ?season= value
+	Label    string // e.g. "2022/23"
+	Selected bool
+}
+
+// the start year of the season in progress, seasons run from August so they're taken to start in July
+func currentSeason() int {
+	now := clk.Now().UTC()
+	if now.Month() < time.July {
+		return now.Year() - 1
+	}
+
+	return now.Year()
+}
+
+// the season start year from ?season=YYYY, 0 for the current season
+func seasonQuery(req *http.Request) (int, error) {
+	if !req.URL.Query().Has("season") {
+		return 0, nil
+	}
+
+	value := req.URL.Query().Get("season")
+
+	season, err := strconv.Atoi(value)
+	if err != nil || season < firstSeason || season > currentSeason() {
+		return 0, fmt.Errorf("invalid season %q, must be a season start year from %d to %d", value, firstSeason, currentSeason())
+	}
+
+	if season == currentSeason() {
+		return 0, nil
+	}
+
+	return season, nil
+}
+
+// fetch a competition's final standings for a past season, kept in the history cache as they don't change
+func getSeasonStandings(ctx context.Context, comp string, season int) ([]byte, error) {
+	url := fmt.Sprintf(`%s/competitions/%s/standings?season=%d`, baseURL, comp, season)
+
+	standings, _, err := getCachedIn(ctx, historyCache, "standings", url)
+
+	return standings, err
+}
+
+// outputs the Cann table of a past season's final standings
+func renderSeason(w http.ResponseWriter, req *http.Request, comp string, season int) {
+	standings, err := getSeasonStandings(req.Context(), comp, season)
+	if err != nil {
+		returnError(w, req, err)
+		return
+	}
+
+	renderTable(w, req, comp, standings, "Final standings of the "+seasonLabel(season)+" season")
+}
+
+// the season selector options from the current season back, the selected season is added when it's older
+func seasonOptions(selected int) []SeasonOption {
+	current := currentSeason()
+	if selected == 0 {
+		selected = current
+	}
+
+	options := make([]SeasonOption, 0, selectableSeasons+1)
+	for year := current; year > current-selectableSeasons; year-- {
+		options = append(options, SeasonOption{Year: year, Label: seasonLabel(year), Selected: year == selected})
+	}
+
+	if selected <= current-selectableSeasons {
+		options = append(options, SeasonOption{Year: selected, Label: seasonLabel(selected), Selected: true})
+	}
+
+	return options
+}
+
+// e.g. "2022/23" for the season starting in 2022
+func seasonLabel(year int) string {
+	return fmt.Sprintf("%d/%02d", year, (year+1)%100)
+}
@@ -0,0 +1,135 @@
+package cann
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestGenerateTableSeason(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var requested []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requested = append(requested, req.URL.RequestURI())
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second,
+		Clock: clock.NewFake(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))}) // in the 2023/24 season
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url           string
+		wantStatus    int
+		wantBody      []string
+		wantRequested string // upstream request, empty when none is sent
+	}{
+		{"/cann?season=2022", http.StatusOK, []string{"Final standings of the 2022/23 season", `<option value="2022" selected>2022/23</option>`},
+			"/competitions/PL/standings?season=2022"},
+		{"/cann?comp=SA&season=2022", http.StatusOK, []string{`<input type="hidden" name="comp" value="SA">`},
+			"/competitions/SA/standings?season=2022"},
+		{"/cann?season=2022&format=json", http.StatusOK, []string{`"season":2022`}, ""}, // cached by competition and season
+		{"/cann?season=2023", http.StatusOK, []string{`<option value="2023" selected>2023/24</option>`}, "/competitions/PL/standings"},
+		{"/cann?season=1989", http.StatusBadRequest, []string{"invalid season"}, ""},
+		{"/cann?season=2024", http.StatusBadRequest, []string{"invalid season"}, ""},
+	}
+
+	for _, test := range tests {
+		requested = nil
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		GenerateTable(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("GenerateTable(%s) status = %d body = %s, want %d", test.url, w.Code, w.Body, test.wantStatus)
+			continue
+		}
+
+		for _, want := range test.wantBody {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("GenerateTable(%s) body = %s, want %s", test.url, w.Body, want)
+			}
+		}
+
+		if got := strings.Join(requested, " "); got != test.wantRequested {
+			t.Errorf("GenerateTable(%s) requested %q, want %q", test.url, got, test.wantRequested)
+		}
+	}
+}
+
+func TestPastSeasonSkipsFreshnessBanners(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	Configure(Settings{BaseURL: defaultBaseURL, TTL: time.Minute, UpstreamTimeout: time.Second, Clock: clock.NewFake(now)})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	// the current season's standings were refreshed and are now served stale
+	standingsCache.Set(standingsURL("PL"), validStandings)
+	upstream.record(errors.New("upstream unavailable"))
+
+	backgroundRefreshes.Lock()
+	backgroundRefreshes.at[standingsURL("PL")] = now
+	backgroundRefreshes.Unlock()
+
+	w := httptest.NewRecorder()
+	w.Header().Set(cacheHeader, string(cacheStale))
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	renderTable(w, httptest.NewRequest(http.MethodGet, "/cann?season=2022&format=json", http.NoBody), "PL", validStandings)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var page cannPage
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+
+	if page.LastRefreshed != "" || page.Stale != nil || w.Header().Get("Warning") != "" {
+		t.Errorf("renderTable() season 2022 lastRefreshed = %q stale = %+v Warning = %q, want no freshness banners", page.LastRefreshed, page.Stale,
+			w.Header().Get("Warning"))
+	}
+}
+
+func TestSeasonOptions(t *testing.T) {
+	defer func(c clock.Clock) { clk = c }(clk)
+	clk = clock.NewFake(time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)) // in the 2024/25 season
+
+	tests := []struct {
+		selected int
+		want     []SeasonOption
+	}{
+		{0, []SeasonOption{{2024, "2024/25", true}, {2023, "2023/24", false}, {2022, "2022/23", false}, {2021, "2021/22", false},
+			{2020, "2020/21", false}}},
+		{1999, []SeasonOption{{2024, "2024/25", false}, {2023, "2023/24", false}, {2022, "2022/23", false}, {2021, "2021/22", false},
+			{2020, "2020/21", false}, {1999, "1999/00", true}}},
+	}
+
+	for _, test := range tests {
+		if got := seasonOptions(test.selected); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("seasonOptions(%d) = %+v, want %+v", test.selected, got, test.want)
+		}
+	}
+}
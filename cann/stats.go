@@ -0,0 +1,67 @@
+package cann
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TeamStats are a team's pace analytics, shown in the Cann table with ?stats=1
+type TeamStats struct {
+	PointsPerGame float64 `json:"pointsPerGame"`       // rounded to 2 decimal places
+	Projected     *Points `json:"projected,omitempty"` // end of season points at the current pace, omitted before the minimum matchday
+	GamesInHand   int     `json:"gamesInHand"`         // games fewer played than the leader, negative when the leader has played fewer
+}
+
+// e.g. "1.85 ppg, 70 projected, 1 in hand"
+func (s TeamStats) String() string {
+	parts := []string{fmt.Sprintf("%.2f ppg", s.PointsPerGame)}
+
+	if s.Projected != nil {
+		parts = append(parts, fmt.Sprintf("%d projected", *s.Projected))
+	}
+
+	if s.GamesInHand != 0 {
+		parts = append(parts, fmt.Sprintf("%d in hand", s.GamesInHand))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// the pace analytics of every team in the standings keyed by team ID, relative to the team top of the table. The
+// projections need at least minMatchdays games played by the leader
+func teamStats(standingsTable []TableRow, totalGames, minMatchdays int) map[int]TeamStats {
+	if len(standingsTable) == 0 {
+		return nil
+	}
+
+	leader := standingsTable[0]
+	for _, row := range standingsTable {
+		if row.Position < leader.Position {
+			leader = row
+		}
+	}
+
+	stats := make(map[int]TeamStats, len(standingsTable))
+	for _, row := range standingsTable {
+		teamStats := TeamStats{PointsPerGame: pointsPerGame(row), GamesInHand: leader.Played - row.Played}
+
+		if leader.Played >= minMatchdays {
+			projected := projectedPoints(row, totalGames)
+			teamStats.Projected = &projected
+		}
+
+		stats[row.Team.ID] = teamStats
+	}
+
+	return stats
+}
+
+// the team's points per game played rounded to 2 decimal places, 0 before its first game
+func pointsPerGame(row TableRow) float64 {
+	if row.Played == 0 {
+		return 0
+	}
+
+	return math.Round(float64(row.Points)/float64(row.Played)*100) / 100 //nolint:gomnd // 2 decimal places
+}
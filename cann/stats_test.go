@@ -0,0 +1,121 @@
+package cann
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeamStats(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	standingsTable := []TableRow{
+		{Team: Team{ID: 2}, Position: 2, Played: 9, Points: 20},
+		{Team: Team{ID: 1}, Position: 1, Played: 10, Points: 23},
+		{Team: Team{ID: 3}, Position: 3, Played: 11, Points: 19},
+		{Team: Team{ID: 4}, Position: 4, Played: 0, Points: 0},
+	}
+
+	projected := func(points Points) *Points { return &points }
+
+	tests := []struct {
+		name         string
+		minMatchdays int
+		want         map[int]TeamStats
+	}{
+		{"projected", 5, map[int]TeamStats{
+			1: {2.3, projected(87), 0},
+			2: {2.22, projected(84), 1},
+			3: {1.73, projected(66), -1},
+			4: {0, projected(0), 10},
+		}},
+		{"before the minimum matchday", 11, map[int]TeamStats{
+			1: {2.3, nil, 0},
+			2: {2.22, nil, 1},
+			3: {1.73, nil, -1},
+			4: {0, nil, 10},
+		}},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		got := teamStats(standingsTable, 38, test.minMatchdays)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("teamStats() %s = %+v, want %+v", test.name, got, test.want)
+		}
+	}
+
+	if got := teamStats(nil, 38, 5); got != nil {
+		t.Errorf("teamStats(nil) = %+v, want nil", got)
+	}
+}
+
+func TestTeamStatsString(t *testing.T) {
+	projected := Points(70)
+
+	tests := []struct {
+		stats TeamStats
+		want  string
+	}{
+		{TeamStats{1.85, &projected, 1}, "1.85 ppg, 70 projected, 1 in hand"},
+		{TeamStats{2, &projected, 0}, "2.00 ppg, 70 projected"},
+		{TeamStats{0.5, nil, -2}, "0.50 ppg, -2 in hand"},
+	}
+
+	for _, test := range tests {
+		if got := test.stats.String(); got != test.want {
+			t.Errorf("%+v.String() = %q, want %q", test.stats, got, test.want)
+		}
+	}
+}
+
+func TestGenerateTableStats(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		url      string
+		wantBody string
+		noBody   string
+	}{
+		{"/cann?stats=1", `<span class="stats">(2.11 ppg, 80 projected, 1 in hand)</span>`, ""},
+		{"/cann?stats=1&format=json", `"stats":{"pointsPerGame":2.25,"projected":86,"gamesInHand":0}`, ""},
+		{"/cann", "", `class="stats"`},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		GenerateTable(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != http.StatusOK {
+			t.Errorf("GenerateTable(%s) status = %d, want %d", test.url, w.Code, http.StatusOK)
+			continue
+		}
+
+		if !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("GenerateTable(%s) body = %s, want %s", test.url, w.Body, test.wantBody)
+		}
+
+		if test.noBody != "" && strings.Contains(w.Body.String(), test.noBody) {
+			t.Errorf("GenerateTable(%s) body = %s, want no %s", test.url, w.Body, test.noBody)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package cann
+
+import "net/http"
+
+const (
+	a11yCookie = "a11y"
+	a11yMaxAge = 365 * 24 * 60 * 60 // seconds
+)
+
+// A ZoneStyle is how a zone's section header is drawn, the border and indicator mark the zone without relying on color
+type ZoneStyle struct {
+	Color     string // background color
+	Border    string // css left border style e.g. "solid", "dashed"
+	Indicator string // text shown before the section name, may be empty
+}
+
+// A Theme is a palette for the Cann page, zones without a style are drawn plainly
+type Theme struct {
+	Name       string
+	Background string // page background color
+	Text       string // text color
+	Grid       string // table border color
+	Stripe     string // background color of every other row
+	Zones      map[Zone]ZoneStyle
+}
+
+// the default palette, also ?theme=light
+var defaultTheme = Theme{
+	Name:       "default",
+	Background: "#ffffff",
+	Text:       "#000000",
+	Grid:       "#b3e5fc",
+	Stripe:     "#b3e5fc",
+	Zones: map[Zone]ZoneStyle{
+		ZoneChampionsLeague: {Color: "#c8e6c9", Border: "none"},
+		ZoneEuropa:          {Color: "#fff9c4", Border: "none"},
+		ZoneRelegation:      {Color: "#ffcdd2", Border: "none"},
+	},
+}
+
+// dark palette for ?theme=dark, the zone colors are darkened so the light text stays readable
+var darkTheme = Theme{
+	Name:       "dark",
+	Background: "#121212",
+	Text:       "#e0e0e0",
+	Grid:       "#37474f",
+	Stripe:     "#263238",
+	Zones: map[Zone]ZoneStyle{
+		ZoneChampionsLeague: {Color: "#1b5e20", Border: "none"},
+		ZoneEuropa:          {Color: "#827717", Border: "none"},
+		ZoneRelegation:      {Color: "#b71c1c", Border: "none"},
+	},
+}
+
+// color-blind-safe palette from the Okabe-Ito colors, each zone also has its own border pattern and text indicator
+var a11yTheme = Theme{
+	Name:       "a11y",
+	Background: "#ffffff",
+	Text:       "#000000",
+	Grid:       "#b3e5fc",
+	Stripe:     "#eeeeee",
+	Zones: map[Zone]ZoneStyle{
+		ZoneChampionsLeague: {Color: "#56b4e9", Border: "solid", Indicator: "▲"},
+		ZoneEuropa:          {Color: "#f0e442", Border: "dashed", Indicator: "◆"},
+		ZoneMidTable:        {Color: "#ffffff", Border: "double", Indicator: "●"},
+		ZoneRelegation:      {Color: "#e69f00", Border: "dotted", Indicator: "▼"},
+	},
+}
+
+// get the page theme, the accessibility theme for ?a11y=1 or the a11y cookie when the parameter is absent,
+// otherwise the palette from ?theme=dark|light. The a11y parameter is saved in the cookie, ?a11y=0 clears it
+func theme(w http.ResponseWriter, req *http.Request) Theme {
+	query := req.URL.Query()
+
+	if !query.Has("a11y") {
+		if cookie, err := req.Cookie(a11yCookie); err == nil && cookie.Value == "1" {
+			return a11yTheme
+		}
+
+		return palette(query.Get("theme"))
+	}
+
+	cookie := &http.Cookie{
+		Name:     a11yCookie,
+		Value:    "1",
+		Path:     "/cann",
+		MaxAge:   a11yMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if query.Get("a11y") != "1" {
+		cookie.MaxAge = -1 // reset
+		http.SetCookie(w, cookie)
+
+		return palette(query.Get("theme"))
+	}
+
+	http.SetCookie(w, cookie)
+
+	return a11yTheme
+}
+
+// the palette selected with ?theme=, dark or the default light palette for any other value
+func palette(name string) Theme {
+	if name == darkTheme.Name {
+		return darkTheme
+	}
+
+	return defaultTheme
+}
+
+// the indicator shown before a zone's section name, empty when the theme has none
+func (t Theme) Indicator(zone Zone) string {
+	return t.Zones[zone].Indicator
+}
@@ -0,0 +1,124 @@
+package cann
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRenderA11yTheme(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		url     string
+		cookie  bool
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "default palette",
+			url:     "/debug/render?grouped=1",
+			want:    []string{defaultTheme.Stripe, "<th colspan=\"2\">Champions League</th>"},
+			notWant: []string{a11yTheme.Stripe, "▲"},
+		},
+		{
+			name: "a11y parameter",
+			url:  "/debug/render?grouped=1&a11y=1",
+			want: []string{a11yTheme.Stripe, "#56b4e9", "6px dotted", "▲ Champions League", "◆ Europa"},
+		},
+		{
+			name: "a11y flat table",
+			url:  "/debug/render?a11y=1",
+			want: []string{`<span class="champions-league">▲ [1]Liverpool(20, -25)[CL]</span>`, `<span class="europa">◆ [5]Tottenham`,
+				"border-bottom: 3px solid #000000", "border-bottom: 3px dashed #000000"},
+		},
+		{
+			name:    "dark palette",
+			url:     "/debug/render?grouped=1&theme=dark",
+			want:    []string{darkTheme.Background, darkTheme.Stripe, darkTheme.Zones[ZoneRelegation].Color},
+			notWant: []string{defaultTheme.Stripe},
+		},
+		{
+			name:    "light palette",
+			url:     "/debug/render?theme=light",
+			want:    []string{defaultTheme.Stripe},
+			notWant: []string{darkTheme.Background},
+		},
+		{
+			name:    "a11y over dark",
+			url:     "/debug/render?grouped=1&theme=dark&a11y=1",
+			want:    []string{a11yTheme.Stripe, "▲ Champions League"},
+			notWant: []string{darkTheme.Background},
+		},
+		{
+			name:   "a11y cookie",
+			url:    "/debug/render?grouped=1",
+			cookie: true,
+			want:   []string{a11yTheme.Stripe, "◆ Europa"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, test.url, bytes.NewReader(validStandings))
+			if test.cookie {
+				req.AddCookie(&http.Cookie{Name: a11yCookie, Value: "1"})
+			}
+
+			w := httptest.NewRecorder()
+
+			// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+			Render(w, req)
+
+			// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+			body := w.Body.String()
+			if w.Code != http.StatusOK {
+				t.Fatalf("Render(%s) status = %d, want 200", test.url, w.Code)
+			}
+
+			for _, want := range test.want {
+				if !strings.Contains(body, want) {
+					t.Errorf("Render(%s) body doesn't contain %q", test.url, want)
+				}
+			}
+
+			for _, notWant := range test.notWant {
+				if strings.Contains(body, notWant) {
+					t.Errorf("Render(%s) body contains %q", test.url, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestA11yCookie(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := theme(w, httptest.NewRequest(http.MethodGet, "/cann?a11y=1", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	cookies := responseCookies(w)
+	if got.Name != a11yTheme.Name || len(cookies) != 1 || cookies[0].Name != a11yCookie || cookies[0].Value != "1" {
+		t.Fatalf("theme(?a11y=1) = %s with cookies %v, want %s saved in the %s cookie", got.Name, cookies, a11yTheme.Name, a11yCookie)
+	}
+
+	w = httptest.NewRecorder()
+
+	if got := theme(w, httptest.NewRequest(http.MethodGet, "/cann?a11y=0", http.NoBody)); got.Name != defaultTheme.Name {
+		t.Errorf("theme(?a11y=0) = %s, want %s", got.Name, defaultTheme.Name)
+	}
+
+	if cookies := responseCookies(w); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("theme(?a11y=0) set cookies %v, want the %s cookie cleared", cookies, a11yCookie)
+	}
+}
@@ -0,0 +1,138 @@
+package cann
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStandingsCacheTTL(t *testing.T) {
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		ttl          time.Duration
+		wantRequests int
+	}{
+		{time.Hour, 1},
+		{time.Millisecond, 2},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+
+			_, _ = w.Write(validStandings) //nolint:errcheck // test server
+		}))
+
+		Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: test.ttl, MaxEntries: 1, UpstreamTimeout: time.Second})
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		for range 2 {
+			if _, _, err := getStandings(context.Background(), defaultCompetition); err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		ts.Close()
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if requests != test.wantRequests {
+			t.Errorf("CANN_CACHE_TTL %s upstream requests = %d, want %d", test.ttl, requests, test.wantRequests)
+		}
+	}
+}
+
+func TestScorersCacheTTL(t *testing.T) {
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	tests := []struct {
+		ttl          time.Duration
+		scorersTTL   time.Duration
+		wantRequests int
+	}{
+		{time.Hour, time.Hour, 1},
+		{time.Hour, time.Millisecond, 2},
+		{time.Millisecond, time.Hour, 1},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+
+			_, _ = w.Write([]byte(`{"scorers": []}`)) //nolint:errcheck // test server
+		}))
+
+		Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: test.ttl, ScorersTTL: test.scorersTTL, MaxEntries: 1,
+			UpstreamTimeout: time.Second})
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		for range 2 {
+			if _, err := Get(context.Background(), "scorers", "/competitions/PL/scorers"); err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		ts.Close()
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if requests != test.wantRequests {
+			t.Errorf("CANN_CACHE_TTL %s SCORERS_CACHE_TTL %s upstream requests = %d, want %d", test.ttl, test.scorersTTL, requests, test.wantRequests)
+		}
+	}
+}
+
+func TestGenerateTableUpstreamRateLimited(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rateLimited := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if rateLimited {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		_, _ = w.Write(validStandings) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Millisecond, MaxEntries: 1, UpstreamTimeout: time.Second})
+	defer Configure(Settings{BaseURL: defaultBaseURL, TTL: defaultTTL, UpstreamTimeout: defaultUpstreamTimeout})
+
+	if _, _, err := getStandings(context.Background(), defaultCompetition); err != nil {
+		t.Fatal(err)
+	}
+
+	rateLimited = true
+
+	time.Sleep(5 * time.Millisecond) // expire the cached copy
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	GenerateTable(w, httptest.NewRequest(http.MethodGet, "/cann?format=json", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK || w.Header().Get(cacheHeader) != "STALE" {
+		t.Errorf("GenerateTable() with the upstream returning 429 status = %d %s = %q, want the stale copy served",
+			w.Code, cacheHeader, w.Header().Get(cacheHeader))
+	}
+}
@@ -0,0 +1,33 @@
+package cann
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+)
+
+// the scorers get new goals after matches like the standings, but nothing is lost showing them a little later
+const defaultScorersTTL = 10 * time.Minute
+
+// football-data.org responses fetched with Get keyed by request url, with their own lifetime
+var scorersCache = cache.New(defaultScorersTTL, cache.DefaultMaxEntries)
+
+// Get returns a football-data.org api path e.g. "/competitions/PL/scorers" from the scorers cache or the
+// upstream api, with the same retries, circuit breaker and expired copy fallback as the standings. resource
+// names it in the logs and metrics
+func Get(ctx context.Context, resource, path string) ([]byte, error) {
+	body, _, err := getCachedIn(ctx, scorersCache, resource, baseURL+path)
+
+	return body, err
+}
+
+// Competition returns the requested competition's code and name, from ?comp= as for the Cann table
+func Competition(req *http.Request) (code, name string, err error) {
+	if code, err = competition(req); err != nil {
+		return "", "", err
+	}
+
+	return code, competitions[code], nil
+}
@@ -0,0 +1,13 @@
+package cann
+
+import (
+	"net/http"
+
+	"github.com/mick4711/moh/conditional"
+)
+
+// sets the data version header to the version of the upstream standings body and returns it for the json body,
+// it only changes when the fetched data does
+func setDataVersion(w http.ResponseWriter, standings []byte) string {
+	return conditional.SetDataVersion(w.Header(), standings)
+}
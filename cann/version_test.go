@@ -0,0 +1,53 @@
+package cann
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mick4711/moh/conditional"
+)
+
+func TestDataVersion(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	validStandings, err := os.ReadFile("standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := []byte(strings.Replace(string(validStandings), `"goalDifference": 13`, `"goalDifference": 14`, 1))
+
+	render := func(standings []byte) (string, string) {
+		w := httptest.NewRecorder()
+		Render(w, httptest.NewRequest(http.MethodPost, "/debug/render?format=json", bytes.NewReader(standings)))
+
+		var page cannPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+
+		return w.Header().Get(conditional.DataVersionHeader), page.DataVersion
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	firstHeader, firstVersion := render(validStandings)
+	againHeader, againVersion := render(validStandings)
+	changedHeader, changedVersion := render(changed)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if firstHeader == "" || firstHeader != firstVersion {
+		t.Fatalf("Render() %s = %q, dataVersion = %q, want matching versions", conditional.DataVersionHeader, firstHeader, firstVersion)
+	}
+
+	if againHeader != firstHeader || againVersion != firstVersion {
+		t.Errorf("Render() unchanged data version = %q, want stable %q", againVersion, firstVersion)
+	}
+
+	if changedHeader == firstHeader || changedVersion == firstVersion {
+		t.Errorf("Render() changed data version = %q, want it to differ from %q", changedVersion, firstVersion)
+	}
+}
@@ -0,0 +1,62 @@
+package cann
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+var validTLA = regexp.MustCompile(`^[A-Z0-9]{2,4}$`)
+
+const (
+	watchlistCookie = "watchlist"
+	watchlistMaxAge = 365 * 24 * 60 * 60 // seconds
+)
+
+// get the selected team TLAs from the teams query parameter e.g. ?teams=LIV,ARS
+// or from the watchlist cookie when the parameter is absent.
+// A selection from the query parameter is saved in the cookie, an empty ?teams= clears the cookie.
+func watchlist(w http.ResponseWriter, req *http.Request) map[string]bool {
+	query := req.URL.Query()
+
+	if !query.Has("teams") {
+		cookie, err := req.Cookie(watchlistCookie)
+		if err != nil {
+			return nil
+		}
+
+		return parseTeams(cookie.Value)
+	}
+
+	teams := parseTeams(query.Get("teams"))
+
+	cookie := &http.Cookie{
+		Name:     watchlistCookie,
+		Value:    strings.Join(sortedKeys(teams), ","),
+		Path:     "/cann",
+		MaxAge:   watchlistMaxAge,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	if len(teams) == 0 {
+		cookie.MaxAge = -1 // reset
+	}
+
+	http.SetCookie(w, cookie)
+
+	return teams
+}
+
+// parse a comma separated list of team TLAs, ignoring case and anything that isn't a valid TLA
+func parseTeams(value string) map[string]bool {
+	teams := make(map[string]bool)
+
+	for _, tla := range strings.Split(value, ",") {
+		if tla = strings.ToUpper(strings.TrimSpace(tla)); validTLA.MatchString(tla) {
+			teams[tla] = true
+		}
+	}
+
+	return teams
+}
@@ -0,0 +1,78 @@
+package cann
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWatchlistCookie(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	want := map[string]bool{"ARS": true, "LIV": true}
+
+	// ACT - select teams via query param //////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	got := watchlist(w, httptest.NewRequest(http.MethodGet, "/cann?teams=liv,ARS,<bad>", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("watchlist() from query = %v, want %v", got, want)
+	}
+
+	cookies := responseCookies(w)
+	if len(cookies) != 1 || cookies[0].Name != watchlistCookie || cookies[0].Value != "ARS,LIV" {
+		t.Fatalf("watchlist() set cookies %v, want %s=ARS,LIV", cookies, watchlistCookie)
+	}
+
+	// ACT - repeat visit without query param ///////////////////////////////////////////////////////////
+	req := httptest.NewRequest(http.MethodGet, "/cann", http.NoBody)
+	req.AddCookie(cookies[0])
+
+	w = httptest.NewRecorder()
+	got = watchlist(w, req)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("watchlist() from cookie = %v, want %v", got, want)
+	}
+
+	if len(responseCookies(w)) != 0 {
+		t.Errorf("watchlist() from cookie set cookies %v, want none", responseCookies(w))
+	}
+}
+
+func TestWatchlistReset(t *testing.T) {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/cann?teams=", http.NoBody)
+	req.AddCookie(&http.Cookie{Name: watchlistCookie, Value: "LIV"})
+
+	if got := watchlist(w, req); len(got) != 0 {
+		t.Errorf("watchlist() = %v, want no teams after reset", got)
+	}
+
+	if cookies := responseCookies(w); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("watchlist() set cookies %v, want expired %s cookie", cookies, watchlistCookie)
+	}
+}
+
+func TestBuildCannSelectedTeams(t *testing.T) {
+	got := buildCann(testTable(5), options{teams: map[string]bool{"T2": true, "T4": true}, europe: europeanPlaces("PL")})
+
+	want := []Row{
+		{4, " - [2]team2(10, +0)[CL]", []RowTeam{{2, 2, "team2", "T2", 10, 0, 0, 0, ZoneChampionsLeague, 0, nil, "", "[CL]", nil}}},
+		{3, "", nil},
+		{2, " - [4]team4(10, +0)[CL]", []RowTeam{{4, 4, "team4", "T4", 10, 0, 0, 0, ZoneChampionsLeague, 0, nil, "", "[CL]", nil}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("buildCann() selected teams\ngot :%#v, \nwant:%#v", got, want)
+	}
+}
+
+// cookies set on a recorded response
+func responseCookies(w *httptest.ResponseRecorder) []*http.Cookie {
+	res := w.Result()
+	defer res.Body.Close()
+
+	return res.Cookies()
+}
@@ -0,0 +1,103 @@
+package cann
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// expected goals for a team from the supplementary data source
+type xgValues struct {
+	For     float64 `json:"xgFor"`
+	Against float64 `json:"xgAgainst"`
+}
+
+// the expected goals source url, set by Configure
+var xgSourceURL string
+
+// xG table variant and a note describing it, the table is unchanged when no source is configured or it fails
+func xgTable(ctx context.Context, standingsTable []TableRow) ([]TableRow, string) {
+	if xgSourceURL == "" {
+		return standingsTable, "xG unavailable, no xG data source is configured"
+	}
+
+	xg, err := getExpectedGoals(ctx, xgSourceURL)
+	if err != nil {
+		slog.WarnContext(ctx, "xG unavailable", "err", err)
+		return standingsTable, "xG unavailable, the xG data source could not be read"
+	}
+
+	logMissing(ctx, "xG", standingsTable, func(teamID int) bool { _, ok := xg[teamID]; return ok })
+
+	return applyExpectedGoals(standingsTable, xg), "xG table: expected goals for and against from a supplementary data source"
+}
+
+// fetch expected goals from the source url, a json map of football-data.org team ID to xgFor and xgAgainst
+// e.g. {"64": {"xgFor": 41.2, "xgAgainst": 17.9}}
+func getExpectedGoals(ctx context.Context, sourceURL string) (map[int]xgValues, error) {
+	var xg map[int]xgValues
+	if err := getSupplementary(ctx, "xG", sourceURL, &xg); err != nil {
+		return nil, err
+	}
+
+	return xg, nil
+}
+
+// fetch and decode json from a supplementary data source, these aren't cached
+func getSupplementary(ctx context.Context, resource, sourceURL string, value any) error {
+	ctx, cancel := context.WithTimeout(ctx, upstreamTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("error creating %s request: %w", resource, err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error requesting %s: %w", resource, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s response status not OK: %v", resource, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading %s response: %w", resource, err)
+	}
+
+	if err := json.Unmarshal(body, value); err != nil {
+		return fmt.Errorf("error unmarshalling json from %s response:%w", resource, err)
+	}
+
+	return nil
+}
+
+// copy of the standings with the expected goals set for the teams the source has values for
+func applyExpectedGoals(standingsTable []TableRow, xg map[int]xgValues) []TableRow {
+	withXG := slices.Clone(standingsTable)
+
+	for i := range withXG {
+		if values, ok := xg[withXG[i].Team.ID]; ok {
+			withXG[i].ExpectedGoalsFor = &values.For
+			withXG[i].ExpectedGoalsAgainst = &values.Against
+		}
+	}
+
+	return withXG
+}
+
+// label displayed next to a team with expected goals, e.g. "(xG 41.2-17.9)", empty when there are none
+func xgLabel(row TableRow) string {
+	if row.ExpectedGoalsFor == nil || row.ExpectedGoalsAgainst == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("(xG %.1f-%.1f)", *row.ExpectedGoalsFor, *row.ExpectedGoalsAgainst)
+}
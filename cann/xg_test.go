@@ -0,0 +1,49 @@
+package cann
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExpectedGoals(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	table := testTable(2)
+	xg := map[int]xgValues{1: {For: 41.23, Against: 17.9}}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	withXG := applyExpectedGoals(table, xg)
+	rows := buildCann(withXG, options{})
+
+	// ASSERT - populated ///////////////////////////////////////////////////////////////////////////////
+	if want := " - [1]team1(10, +0)(xG 41.2-17.9)"; rows[0].Teams != want {
+		t.Errorf("buildCann() with xG = %q, want %q", rows[0].Teams, want)
+	}
+
+	populated, err := json.Marshal(withXG[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(populated), `"expectedGoalsFor":41.23`) {
+		t.Errorf("json with xG = %s, want expectedGoalsFor", populated)
+	}
+
+	// ASSERT - nil, omitted ///////////////////////////////////////////////////////////////////////////
+	if want := " - [2]team2(10, +0)"; rows[1].Teams != want {
+		t.Errorf("buildCann() without xG = %q, want %q", rows[1].Teams, want)
+	}
+
+	omitted, err := json.Marshal(withXG[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(omitted), "expectedGoals") {
+		t.Errorf("json without xG = %s, want xG fields omitted", omitted)
+	}
+
+	if table[0].ExpectedGoalsFor != nil {
+		t.Error("applyExpectedGoals() altered the fetched table")
+	}
+}
@@ -0,0 +1,79 @@
+package cann
+
+// A Zone is a section of the league table determined by league position
+type Zone string
+
+const (
+	ZoneChampionsLeague Zone = "champions-league"
+	ZoneEuropa          Zone = "europa"
+	ZoneMidTable        Zone = "mid-table"
+	ZoneRelegation      Zone = "relegation"
+)
+
+// number of relegation places counted up from the bottom of the table, the European zones are the competition's
+// European places
+const relegationPlaces = 3
+
+// A Group contains the Cann table rows for the teams in a zone
+type Group struct {
+	Name string `json:"name"`
+	Zone Zone   `json:"zone"`
+	Rows []Row  `json:"rows"`
+}
+
+// zones in table order with their section header names
+var zones = []struct {
+	zone Zone
+	name string
+}{
+	{ZoneChampionsLeague, "Champions League"},
+	{ZoneEuropa, "Europa"},
+	{ZoneMidTable, "Mid-table"},
+	{ZoneRelegation, "Relegation"},
+}
+
+// get the zone for a league position in the competition, the bottom zone is computed from the table size
+// so it is correct for 18, 20 or 24 team leagues
+func zoneFor(comp string, position, tableSize int) Zone {
+	return placeZone(europeanPlaces(comp), position, tableSize)
+}
+
+// get the zone for a league position from the European competition each position qualifies for, positions
+// qualifying for the Conference League are mid-table
+func placeZone(places map[int]string, position, tableSize int) Zone {
+	switch {
+	case places[position] == "CL":
+		return ZoneChampionsLeague
+	case places[position] == "EL":
+		return ZoneEuropa
+	case position > tableSize-relegationPlaces:
+		return ZoneRelegation
+	default:
+		return ZoneMidTable
+	}
+}
+
+// split the competition's standings into zones and build a Cann table for each, zones without teams are omitted
+func groupByZone(comp string, standingsTable []TableRow, opts options) []Group {
+	groups := make([]Group, 0, len(zones))
+	opts.tableSize = len(standingsTable)
+
+	for _, z := range zones {
+		var zoneTable []TableRow
+
+		for _, row := range standingsTable {
+			if zoneFor(comp, row.Position, len(standingsTable)) == z.zone {
+				zoneTable = append(zoneTable, row)
+			}
+		}
+
+		rows := buildCann(zoneTable, opts)
+		if len(rows) == 0 {
+			continue
+		}
+
+		groups = append(groups, Group{Name: z.name, Zone: z.zone, Rows: rows})
+	}
+
+	return groups
+}
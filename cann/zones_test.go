@@ -0,0 +1,130 @@
+package cann
+
+import (
+	"fmt"
+	"testing"
+)
+
+// generate a standings table of size teams, each team one point behind the team above
+func testTable(size int) []TableRow {
+	table := make([]TableRow, size)
+	for i := range table {
+		table[i] = TableRow{
+			Team:     Team{ID: i + 1, ShortName: fmt.Sprintf("team%d", i+1), TLA: fmt.Sprintf("T%d", i+1)},
+			Position: i + 1,
+			Played:   10,
+			Points:   Points(size - i),
+		}
+	}
+
+	return table
+}
+
+func TestZoneFor(t *testing.T) {
+	tests := []struct {
+		comp      string
+		position  int
+		tableSize int
+		want      Zone
+	}{
+		{"PL", 1, 20, ZoneChampionsLeague},
+		{"PL", 4, 20, ZoneChampionsLeague},
+		{"PL", 5, 20, ZoneEuropa},
+		{"PL", 6, 20, ZoneMidTable}, // Conference League
+		{"PL", 10, 20, ZoneMidTable},
+		{"PL", 17, 20, ZoneMidTable},
+		{"PL", 18, 20, ZoneRelegation},
+		{"PL", 20, 20, ZoneRelegation},
+		{"ELC", 1, 24, ZoneMidTable},
+		{"ELC", 5, 24, ZoneMidTable},
+		{"ELC", 21, 24, ZoneMidTable},
+		{"ELC", 22, 24, ZoneRelegation},
+		{"FL1", 3, 18, ZoneChampionsLeague},
+		{"FL1", 4, 18, ZoneEuropa},
+		{"FL1", 16, 18, ZoneRelegation},
+		{"DED", 3, 18, ZoneEuropa},
+	}
+
+	for _, test := range tests {
+		if got := zoneFor(test.comp, test.position, test.tableSize); got != test.want {
+			t.Errorf("zoneFor(%s, %d, %d) = %v, want %v", test.comp, test.position, test.tableSize, got, test.want)
+		}
+	}
+}
+
+func TestGroupByZone(t *testing.T) {
+	groups := groupByZone("PL", testTable(20), options{europe: europeanPlaces("PL")})
+
+	want := []struct {
+		name     string
+		firstRow string
+		rows     int
+	}{
+		{"Champions League", " - [1]team1(10, +0)[CL]", 4},
+		{"Europa", " - [5]team5(10, +0)[EL]", 1},
+		{"Mid-table", " - [6]team6(10, +0)[ECL]", 12},
+		{"Relegation", " - [18]team18(10, +0)", 3},
+	}
+
+	if len(groups) != len(want) {
+		t.Fatalf("groupByZone() returned %d groups, want %d", len(groups), len(want))
+	}
+
+	for i, group := range groups {
+		if group.Name != want[i].name || len(group.Rows) != want[i].rows || group.Rows[0].Teams != want[i].firstRow {
+			t.Errorf("groupByZone() group %d = %+v, want %+v", i, group, want[i])
+		}
+	}
+}
+
+func TestGroupByZoneOmitsEmptyGroups(t *testing.T) {
+	groups := groupByZone("PL", testTable(4), options{europe: europeanPlaces("PL")})
+
+	if len(groups) != 1 || groups[0].Zone != ZoneChampionsLeague {
+		t.Errorf("groupByZone() = %+v, want only the champions league group", groups)
+	}
+
+	championship := groupByZone("ELC", testTable(24), options{europe: europeanPlaces("ELC")})
+
+	if len(championship) != 2 || championship[0].Zone != ZoneMidTable || championship[1].Zone != ZoneRelegation {
+		t.Errorf("groupByZone(ELC) = %+v, want only the mid-table and relegation groups", championship)
+	}
+}
+
+func TestBuildCannTeamZones(t *testing.T) {
+	tests := []struct {
+		comp      string
+		tableSize int
+		teams     map[string]bool
+		position  int
+		want      Zone
+	}{
+		{"PL", 24, nil, 1, ZoneChampionsLeague},
+		{"PL", 24, nil, 12, ZoneMidTable},
+		{"PL", 24, nil, 24, ZoneRelegation},
+		{"PL", 24, nil, 21, ZoneMidTable},
+		{"PL", 20, nil, 18, ZoneRelegation},
+		{"PL", 20, map[string]bool{"T18": true}, 18, ZoneRelegation}, // zone from the whole table, not the selected teams
+		{"ELC", 24, nil, 1, ZoneMidTable},
+		{"FL1", 18, nil, 4, ZoneEuropa}, // matches the [EL] label
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		rows := buildCann(testTable(test.tableSize), options{teams: test.teams, europe: europeanPlaces(test.comp)})
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		var got Zone
+		for _, row := range rows {
+			for _, team := range row.TeamDetails {
+				if team.Position == test.position {
+					got = team.Zone
+				}
+			}
+		}
+
+		if got != test.want {
+			t.Errorf("buildCann() %s %d team table position %d zone = %q, want %q", test.comp, test.tableSize, test.position, got, test.want)
+		}
+	}
+}
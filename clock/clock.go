@@ -0,0 +1,58 @@
+// the current time as a dependency, so time-dependent code can be tested with a fixed or advancing fake clock
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// A Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the system clock
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// A Fake is a clock that only moves when it is set or advanced, safe for concurrent use
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake returns a fake clock stopped at now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Set stops the clock at now
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+}
+
+// Advance moves the clock forward by d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+// Since is the time elapsed since t on the clock
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
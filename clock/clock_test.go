@@ -0,0 +1,26 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFake(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	start := time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	fake.Advance(90 * time.Minute)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if got := Since(fake, start); got != 90*time.Minute {
+		t.Errorf("Since() after Advance(90m) = %s, want 1h30m0s", got)
+	}
+
+	fake.Set(start)
+
+	if got := fake.Now(); !got.Equal(start) {
+		t.Errorf("Now() after Set() = %s, want %s", got, start)
+	}
+}
@@ -0,0 +1,84 @@
+// conditional GET support shared by the handlers, ETag and Last-Modified validators and 304 Not Modified
+package conditional
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NotModified reports whether the client's copy of the response is current, If-None-Match has the etag or, for a
+// GET or HEAD without If-None-Match, the response hasn't changed since If-Modified-Since
+func NotModified(req *http.Request, tag string, modified time.Time) bool {
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return ETagMatches(ifNoneMatch, tag)
+	}
+
+	if modified.IsZero() || (req.Method != http.MethodGet && req.Method != http.MethodHead) {
+		return false
+	}
+
+	since, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+
+	return err == nil && !modified.Truncate(time.Second).After(since)
+}
+
+// ETagMatches checks an If-None-Match header value against the current etag, weakly as the compression
+// middleware makes the etags of compressed responses weak
+func ETagMatches(ifNoneMatch, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetLastModified sets the Last-Modified header, nothing is set for a zero time
+func SetLastModified(h http.Header, modified time.Time) {
+	if !modified.IsZero() {
+		h.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// Changes records when the response for each key, e.g. a url, last changed from its etags. A response is
+// unchanged for as long as its etag stays the same, so this is its Last-Modified time. Up to max keys are
+// tracked, all are forgotten when it is exceeded and their times restart from the next response
+type Changes struct {
+	mu      sync.Mutex
+	max     int
+	changes map[string]change
+}
+
+// the etag of a key's response and when it was first served
+type change struct {
+	tag   string
+	since time.Time
+}
+
+func NewChanges(max int) *Changes {
+	return &Changes{max: max, changes: make(map[string]change)}
+}
+
+// Since returns when the key's response changed to the etag, now if it has just changed
+func (c *Changes) Since(key, tag string, now time.Time) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if current, ok := c.changes[key]; ok && current.tag == tag {
+		return current.since
+	}
+
+	if len(c.changes) >= c.max {
+		clear(c.changes)
+	}
+
+	c.changes[key] = change{tag: tag, since: now}
+
+	return now
+}
@@ -0,0 +1,115 @@
+package conditional
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		ifNoneMatch string
+		want        bool
+	}{
+		{"", false},
+		{`"abc"`, true},
+		{`W/"abc"`, true},
+		{`"xyz", "abc"`, true},
+		{`"xyz"`, false},
+		{"*", true},
+	}
+
+	for _, test := range tests {
+		if got := ETagMatches(test.ifNoneMatch, `"abc"`); got != test.want {
+			t.Errorf("ETagMatches(%q) = %v, want %v", test.ifNoneMatch, got, test.want)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, 8, 17, 16, 5, 30, 500, time.UTC)
+
+	tests := []struct {
+		name            string
+		method          string
+		ifNoneMatch     string
+		ifModifiedSince string
+		modified        time.Time
+		want            bool
+	}{
+		{"no validators", http.MethodGet, "", "", modified, false},
+		{"etag matches", http.MethodGet, `"abc"`, "", modified, true},
+		{"etag changed", http.MethodGet, `"xyz"`, "", modified, false},
+		{"etag wins over date", http.MethodGet, `"xyz"`, "Sat, 17 Aug 2024 16:05:30 GMT", modified, false},
+		{"unchanged since", http.MethodGet, "", "Sat, 17 Aug 2024 16:05:30 GMT", modified, true},
+		{"unchanged since later", http.MethodHead, "", "Sun, 18 Aug 2024 09:00:00 GMT", modified, true},
+		{"changed since", http.MethodGet, "", "Sat, 17 Aug 2024 16:05:29 GMT", modified, false},
+		{"invalid date", http.MethodGet, "", "yesterday", modified, false},
+		{"unknown modified time", http.MethodGet, "", "Sun, 18 Aug 2024 09:00:00 GMT", time.Time{}, false},
+		{"not a get", http.MethodPost, "", "Sun, 18 Aug 2024 09:00:00 GMT", modified, false},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, "/cann", http.NoBody)
+		if test.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+
+		if test.ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", test.ifModifiedSince)
+		}
+
+		if got := NotModified(req, `"abc"`, test.modified); got != test.want {
+			t.Errorf("%s: NotModified() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	changes := NewChanges(2)
+	start := time.Date(2024, 8, 17, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		key  string
+		tag  string
+		now  time.Time
+		want time.Time
+	}{
+		{"/cann", `"v1"`, start, start},
+		{"/cann", `"v1"`, start.Add(time.Hour), start}, // unchanged
+		{"/cann", `"v2"`, start.Add(2 * time.Hour), start.Add(2 * time.Hour)},
+		{"/fpl", `"v1"`, start.Add(3 * time.Hour), start.Add(3 * time.Hour)},
+		{"/fixtures", `"v1"`, start.Add(4 * time.Hour), start.Add(4 * time.Hour)}, // over max, all forgotten
+		{"/cann", `"v2"`, start.Add(5 * time.Hour), start.Add(5 * time.Hour)},
+	}
+
+	for _, test := range tests {
+		if got := changes.Since(test.key, test.tag, test.now); !got.Equal(test.want) {
+			t.Errorf("Since(%s, %s, %s) = %s, want %s", test.key, test.tag, test.now, got, test.want)
+		}
+	}
+}
+
+func TestDataVersion(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	header := http.Header{}
+	preset := http.Header{DataVersionHeader: []string{"upstream"}}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	version := SetDataVersion(header, []byte(`{"points": 45}`))
+	kept := SetDataVersion(preset, []byte(`{"points": 45}`))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if len(version) != 16 || header.Get(DataVersionHeader) != version || version != DataVersion([]byte(`{"points": 45}`)) {
+		t.Errorf("SetDataVersion() = %q header %q, want the 16 hex digit version of the data in the header", version, header.Get(DataVersionHeader))
+	}
+
+	if version == DataVersion([]byte(`{"points": 46}`)) {
+		t.Errorf("DataVersion() = %q for changed data, want a new version", version)
+	}
+
+	if kept != "upstream" || preset.Get(DataVersionHeader) != "upstream" {
+		t.Errorf("SetDataVersion() with a version already set = %q, want the handler's version kept", kept)
+	}
+}
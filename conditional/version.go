@@ -0,0 +1,30 @@
+package conditional
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+// DataVersionHeader is the response header carrying the version of the data a response was built from
+const DataVersionHeader = "X-Data-Version"
+
+// DataVersion is a short hash of the data, clients compare it with their last fetch to detect changes without
+// diffing. It only changes when the data does
+func DataVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SetDataVersion sets the data version header to the version of data, unless the handler already set the
+// version of the data the response was built from, and returns the version in the header
+func SetDataVersion(h http.Header, data []byte) string {
+	if version := h.Get(DataVersionHeader); version != "" {
+		return version
+	}
+
+	version := DataVersion(data)
+	h.Set(DataVersionHeader, version)
+
+	return version
+}
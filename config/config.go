@@ -0,0 +1,341 @@
+// loads the server configuration from environment variables, applying defaults for unset values
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAddr                  = ":8080"
+	DefaultReadTimeout           = 5 * time.Second
+	DefaultWriteTimeout          = 10 * time.Second
+	DefaultShutdownTimeout       = 15 * time.Second
+	DefaultUpstreamTimeout       = 5 * time.Second
+	DefaultRetryAttempts         = 3
+	DefaultBreakerThreshold      = 5
+	DefaultBreakerCooldown       = 30 * time.Second
+	DefaultStandingsTTL          = 60 * time.Second
+	DefaultFPLCacheTTL           = 60 * time.Second // the managers' points change throughout a gameweek
+	DefaultFPLBootstrapTTL       = 6 * time.Hour    // bootstrap-static reference data changes infrequently
+	DefaultScorersTTL            = 10 * time.Minute
+	DefaultHuxleyCacheTTL        = time.Hour // Huxley's details only change with his age and new entries
+	DefaultCacheMaxEntries       = 32
+	DefaultRefreshInterval       = 10 * time.Minute
+	DefaultMatchDayRefresh       = time.Minute
+	DefaultStandingsBaseURL      = "http://api.football-data.org/v4"
+	DefaultFPLBaseURL            = "https://fantasy.premierleague.com/api"
+	DefaultLogLevel              = "info"
+	DefaultLogFormat             = "text"
+	DefaultLogSampleRate         = 1
+	DefaultSlowRequest           = time.Second
+	DefaultContentSecurityPolicy = "default-src 'self'; style-src 'self' 'unsafe-inline'; img-src 'self' https:"
+	redacted                     = "[REDACTED]"
+)
+
+// Config contains the effective server configuration
+type Config struct {
+	Addr                  string
+	TLSCertFile           string // PEM certificate chain, HTTPS is served on Addr when set with TLSKeyFile
+	TLSKeyFile            string // PEM private key of the certificate
+	RedirectAddr          string // plain HTTP address redirecting to HTTPS, off when empty
+	ReadTimeout           time.Duration
+	WriteTimeout          time.Duration
+	ShutdownTimeout       time.Duration // in-flight requests are given this long to finish after a shutdown signal
+	UpstreamTimeout       time.Duration // deadline for each upstream fetch, shorter than WriteTimeout to leave time to serve a cached copy
+	RetryAttempts         int           // attempts per upstream fetch including the first, within UpstreamTimeout
+	BreakerThreshold      int           // consecutive failed upstream requests that open the circuit
+	BreakerCooldown       time.Duration // time the circuit stays open before a trial request
+	StandingsTTL          time.Duration // cache lifetime of the football-data responses behind the /cann routes
+	FPLCacheTTL           time.Duration // cache lifetime of the FPL managers' entries behind /fpl
+	FPLBootstrapTTL       time.Duration // cache lifetime of the FPL bootstrap-static reference data
+	ScorersTTL            time.Duration // cache lifetime of the football-data scorers behind /scorers
+	HuxleyCacheTTL        time.Duration // Cache-Control max-age of the /huxley responses
+	StaleWhileRevalidate  bool          // serve expired standings immediately and refresh them in the background
+	FreshnessCheck        bool          // compare the standings with recently finished matches
+	UpdatingTTL           time.Duration // shorter standings cache lifetime while they are updating, 0 when unset
+	CacheMaxEntries       int
+	SnapshotDir           string        // standings snapshots are saved here, off when empty
+	RefreshInterval       time.Duration // standings and FPL points are refreshed in the background this often
+	MatchDayRefresh       time.Duration // the shorter refresh interval on days with a Premier League match
+	StandingsBaseURL      string
+	FPLBaseURL            string
+	OddsSourceURL         string // optional title and relegation probabilities endpoint
+	CannRowSort           string // order of the teams within a Cann table row without ?rowsort=, the cann default when empty
+	LogLevel              string
+	LogFormat             string        // text or json log lines
+	LogSampleRate         int           // log 1 in N successful requests
+	SlowRequest           time.Duration // requests at least this slow are always logged
+	Debug                 bool
+	TemplateDir           string   // re-read the html templates from this directory on every request, for live editing
+	DisabledRoutes        []string // url paths that aren't served or linked from the home page
+	SecurityHeaders       bool     // set security headers on html responses
+	ContentSecurityPolicy string
+	EmbeddableRoutes      []string             // url paths other sites may frame, frame blocking headers aren't set on them
+	CORSOrigins           []string             // origins allowed to fetch the json routes cross-origin, CORS is off when empty
+	RateLimit             RateLimit            // requests allowed per client, off when its Limit is 0
+	RouteRateLimits       map[string]RateLimit // rate limits of the url paths with their own
+	RateLimitAllowlist    []netip.Prefix       // client addresses that aren't rate limited
+	TrustedProxies        []netip.Prefix       // proxies whose Cf-Connecting-Ip header gives the client address, none when empty
+	APIToken              string               // secret, never logged
+	ExportToken           string               // secret bearer token for /export, never logged
+	AdminUser             string               // basic auth user name for /admin, the dashboard is off without a password
+	AdminPassword         string               // secret basic auth password for /admin, never logged
+	HuxleyDataFile        string               // json file the weigh-ins and vet visits posted to /huxley are kept in
+	HuxleyToken           string               // secret bearer token for posting to /huxley, never logged
+	HuxleyPhotosDir       string               // directory the photos uploaded to /huxley/photos are kept in
+	Managers              string
+	PointsAdjustments     string   // json informational points deductions by team ID
+	MinMatchdays          string   // matchday the derived Cann metrics are shown from, the cann default when empty
+	SeasonGames           string   // json games per team by competition code, overriding the cann defaults
+	XGSourceURL           string   // optional expected goals endpoint for the xG Cann table
+	AlertTeams            string   // json teams and zones to alert on
+	AlertWebhooks         []string // secret webhook urls the zone alerts are posted to, never logged
+	DerbyPairs            string   // json rival team ID pairs
+	DerbyPoints           string   // points within which a derby pair is highlighted, the cann default when empty
+	EuropeanPlaces        string   // json European places by competition code, overriding the cann defaults
+	FPLAnonymize          bool     // replace the FPL manager names with placeholders
+	FPLNamesToken         string   // secret bearer token for the real names while anonymized, never logged
+	FPLFields             []string // manager entry fields returned without ?fields=, all of them when empty
+	FPLBootstrapSections  []string // bootstrap-static sections returned, the fpl defaults when empty
+	HuxleyWeights         string   // json weigh-ins shown with those posted to /huxley
+
+	loadErrs []error // CONFIG_FILE or values that couldn't be applied, reported by Validate
+}
+
+// Load reads the configuration from environment variables, and the file named by CONFIG_FILE for those unset.
+// Call Validate before using it
+func Load() Config {
+	var fileErr error
+	fileValues, fileErr = loadFile()
+	rateLimit, rateLimitErr := rateLimitEnv()
+	routeRateLimits, routeRateLimitsErr := routeRateLimitsEnv()
+	allowlist, allowlistErr := allowlistEnv()
+	trustedProxies, trustedProxiesErr := trustedProxiesEnv()
+
+	_, debug := lookupEnv("DEBUG")
+	_, noSecurityHeaders := lookupEnv("DISABLE_SECURITY_HEADERS")
+	_, staleWhileRevalidate := lookupEnv("STALE_WHILE_REVALIDATE")
+	_, freshnessCheck := lookupEnv("CHECK_STANDINGS_FRESHNESS")
+	_, fplAnonymize := lookupEnv("FPL_ANONYMIZE")
+
+	return Config{
+		Addr:                  listenAddr(),
+		TLSCertFile:           getenv("TLS_CERT_FILE"),
+		TLSKeyFile:            getenv("TLS_KEY_FILE"),
+		RedirectAddr:          getenv("HTTP_REDIRECT_ADDR"),
+		ReadTimeout:           DefaultReadTimeout,
+		WriteTimeout:          DefaultWriteTimeout,
+		ShutdownTimeout:       durationEnv("SHUTDOWN_TIMEOUT", DefaultShutdownTimeout),
+		UpstreamTimeout:       durationEnv("UPSTREAM_TIMEOUT", DefaultUpstreamTimeout),
+		RetryAttempts:         intEnv("UPSTREAM_RETRY_ATTEMPTS", DefaultRetryAttempts),
+		BreakerThreshold:      intEnv("UPSTREAM_BREAKER_THRESHOLD", DefaultBreakerThreshold),
+		BreakerCooldown:       durationEnv("UPSTREAM_BREAKER_COOLDOWN", DefaultBreakerCooldown),
+		StandingsTTL:          durationEnv("CANN_CACHE_TTL", DefaultStandingsTTL),
+		FPLCacheTTL:           durationEnv("FPL_CACHE_TTL", DefaultFPLCacheTTL),
+		FPLBootstrapTTL:       durationEnv("FPL_BOOTSTRAP_CACHE_TTL", DefaultFPLBootstrapTTL),
+		ScorersTTL:            durationEnv("SCORERS_CACHE_TTL", DefaultScorersTTL),
+		HuxleyCacheTTL:        durationEnv("HUXLEY_CACHE_TTL", DefaultHuxleyCacheTTL),
+		StaleWhileRevalidate:  staleWhileRevalidate,
+		FreshnessCheck:        freshnessCheck,
+		UpdatingTTL:           durationEnv("CANN_UPDATING_TTL", 0),
+		CacheMaxEntries:       intEnv("CACHE_MAX_ENTRIES", DefaultCacheMaxEntries),
+		SnapshotDir:           getenv("SNAPSHOT_DIR"),
+		RefreshInterval:       durationEnv("REFRESH_INTERVAL", DefaultRefreshInterval),
+		MatchDayRefresh:       durationEnv("MATCHDAY_REFRESH_INTERVAL", DefaultMatchDayRefresh),
+		StandingsBaseURL:      stringEnv("STANDINGS_BASE_URL", DefaultStandingsBaseURL),
+		FPLBaseURL:            stringEnv("FPL_BASE_URL", DefaultFPLBaseURL),
+		OddsSourceURL:         getenv("ODDS_SOURCE_URL"),
+		CannRowSort:           getenv("CANN_ROW_SORT"),
+		LogLevel:              strings.ToLower(stringEnv("LOG_LEVEL", DefaultLogLevel)),
+		LogFormat:             strings.ToLower(stringEnv("LOG_FORMAT", DefaultLogFormat)),
+		LogSampleRate:         intEnv("LOG_SAMPLE_RATE", DefaultLogSampleRate),
+		SlowRequest:           durationEnv("LOG_SLOW_REQUEST", DefaultSlowRequest),
+		Debug:                 debug,
+		TemplateDir:           getenv("TEMPLATE_DIR"),
+		DisabledRoutes:        listEnv("DISABLED_ROUTES"),
+		SecurityHeaders:       !noSecurityHeaders,
+		ContentSecurityPolicy: stringEnv("CONTENT_SECURITY_POLICY", DefaultContentSecurityPolicy),
+		EmbeddableRoutes:      embeddableRoutes(),
+		CORSOrigins:           listEnv("CORS_ALLOWED_ORIGINS"),
+		RateLimit:             rateLimit,
+		RouteRateLimits:       routeRateLimits,
+		RateLimitAllowlist:    allowlist,
+		TrustedProxies:        trustedProxies,
+		APIToken:              getenv("API_TOKEN"),
+		ExportToken:           getenv("EXPORT_TOKEN"),
+		AdminUser:             getenv("ADMIN_USER"),
+		AdminPassword:         getenv("ADMIN_PASSWORD"),
+		HuxleyDataFile:        getenv("HUXLEY_DATA_FILE"),
+		HuxleyToken:           getenv("HUXLEY_TOKEN"),
+		HuxleyPhotosDir:       getenv("HUXLEY_PHOTOS_DIR"),
+		Managers:              getenv("managers"),
+		PointsAdjustments:     getenv("POINTS_ADJUSTMENTS"),
+		MinMatchdays:          getenv("MIN_MATCHDAYS"),
+		SeasonGames:           getenv("SEASON_GAMES"),
+		XGSourceURL:           getenv("XG_SOURCE_URL"),
+		AlertTeams:            getenv("ALERT_TEAMS"),
+		AlertWebhooks:         listEnv("ALERT_WEBHOOKS"),
+		DerbyPairs:            getenv("DERBY_PAIRS"),
+		DerbyPoints:           getenv("DERBY_POINTS"),
+		EuropeanPlaces:        getenv("EUROPEAN_PLACES"),
+		FPLAnonymize:          fplAnonymize,
+		FPLNamesToken:         getenv("FPL_NAMES_TOKEN"),
+		FPLFields:             listEnv("FPL_FIELDS"),
+		FPLBootstrapSections:  listEnv("FPL_BOOTSTRAP_SECTIONS"),
+		HuxleyWeights:         getenv("HUXLEY_WEIGHTS"),
+
+		loadErrs: []error{fileErr, rateLimitErr, routeRateLimitsErr, allowlistErr, trustedProxiesErr},
+	}
+}
+
+// Validate reports every missing required value and invalid setting, for a clear startup error
+func (c Config) Validate() error {
+	errs := slices.Clone(c.loadErrs)
+
+	if c.APIToken == "" {
+		errs = append(errs, errors.New("API_TOKEN is required, the football-data.org api token"))
+	}
+
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, c.LogLevel) {
+		errs = append(errs, fmt.Errorf("invalid LOG_LEVEL %q, must be debug, info, warn or error", c.LogLevel))
+	}
+
+	if !slices.Contains([]string{"text", "json"}, c.LogFormat) {
+		errs = append(errs, fmt.Errorf("invalid LOG_FORMAT %q, must be text or json", c.LogFormat))
+	}
+
+	if c.UpstreamTimeout >= c.WriteTimeout {
+		errs = append(errs, fmt.Errorf("UPSTREAM_TIMEOUT %s must be shorter than the %s write timeout to leave time to serve a cached copy",
+			c.UpstreamTimeout, c.WriteTimeout))
+	}
+
+	if (c.AdminUser == "") != (c.AdminPassword == "") {
+		errs = append(errs, errors.New("ADMIN_USER and ADMIN_PASSWORD must be set together"))
+	}
+
+	if (c.AlertTeams == "") != (len(c.AlertWebhooks) == 0) {
+		errs = append(errs, errors.New("ALERT_TEAMS and ALERT_WEBHOOKS must be set together"))
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+
+	if c.RedirectAddr != "" && c.TLSCertFile == "" {
+		errs = append(errs, errors.New("HTTP_REDIRECT_ADDR needs TLS_CERT_FILE and TLS_KEY_FILE, there is no HTTPS to redirect to"))
+	}
+
+	if c.HuxleyToken != "" && c.HuxleyDataFile == "" {
+		errs = append(errs, errors.New("HUXLEY_DATA_FILE is required with HUXLEY_TOKEN, the file posted entries are kept in"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// String formats the configuration for the startup log, secrets are redacted
+func (c Config) String() string {
+	return fmt.Sprintf("addr=%s tlsCertFile=%q tlsKeyFile=%q redirectAddr=%q readTimeout=%s writeTimeout=%s shutdownTimeout=%s upstreamTimeout=%s retryAttempts=%d breakerThreshold=%d breakerCooldown=%s standingsTTL=%s fplCacheTTL=%s fplBootstrapTTL=%s scorersTTL=%s huxleyCacheTTL=%s "+
+		"staleWhileRevalidate=%t freshnessCheck=%t updatingTTL=%s cacheMaxEntries=%d snapshotDir=%q refreshInterval=%s matchDayRefresh=%s standingsBaseURL=%s fplBaseURL=%s oddsSourceURL=%s cannRowSort=%q logLevel=%s logFormat=%s logSampleRate=%d slowRequest=%s debug=%t templateDir=%q disabledRoutes=%q securityHeaders=%t embeddableRoutes=%q corsOrigins=%q rateLimit=%s routeRateLimits=%v rateLimitAllowlist=%v trustedProxies=%v apiToken=%s exportToken=%s adminUser=%q adminPassword=%s huxleyDataFile=%q huxleyToken=%s huxleyPhotosDir=%q managers=%q "+
+		"pointsAdjustments=%q minMatchdays=%q seasonGames=%q xgSourceURL=%s alertTeams=%q alertWebhooks=%s derbyPairs=%q derbyPoints=%q europeanPlaces=%q fplAnonymize=%t fplNamesToken=%s fplFields=%q fplBootstrapSections=%q huxleyWeights=%q",
+		c.Addr, c.TLSCertFile, c.TLSKeyFile, c.RedirectAddr, c.ReadTimeout, c.WriteTimeout, c.ShutdownTimeout, c.UpstreamTimeout, c.RetryAttempts, c.BreakerThreshold, c.BreakerCooldown, c.StandingsTTL, c.FPLCacheTTL, c.FPLBootstrapTTL, c.ScorersTTL, c.HuxleyCacheTTL, c.StaleWhileRevalidate, c.FreshnessCheck, c.UpdatingTTL, c.CacheMaxEntries, c.SnapshotDir, c.RefreshInterval, c.MatchDayRefresh,
+		c.StandingsBaseURL, c.FPLBaseURL, c.OddsSourceURL, c.CannRowSort, c.LogLevel, c.LogFormat, c.LogSampleRate, c.SlowRequest, c.Debug, c.TemplateDir, c.DisabledRoutes, c.SecurityHeaders, c.EmbeddableRoutes, c.CORSOrigins, c.RateLimit, c.RouteRateLimits, c.RateLimitAllowlist, c.TrustedProxies, redact(c.APIToken), redact(c.ExportToken), c.AdminUser, redact(c.AdminPassword), c.HuxleyDataFile, redact(c.HuxleyToken), c.HuxleyPhotosDir, c.Managers,
+		c.PointsAdjustments, c.MinMatchdays, c.SeasonGames, c.XGSourceURL, c.AlertTeams, redact(strings.Join(c.AlertWebhooks, ",")), c.DerbyPairs, c.DerbyPoints, c.EuropeanPlaces, c.FPLAnonymize, redact(c.FPLNamesToken), c.FPLFields, c.FPLBootstrapSections, c.HuxleyWeights)
+}
+
+// show whether a secret is set without revealing its value
+func redact(secret string) string {
+	if secret == "" {
+		return "[UNSET]"
+	}
+
+	return redacted
+}
+
+// read a string environment variable, falls back to def when unset or empty
+func stringEnv(key, def string) string {
+	if value := getenv(key); value != "" {
+		return value
+	}
+
+	return def
+}
+
+// read a comma separated list environment variable, blank items are ignored
+func listEnv(key string) []string {
+	var list []string
+
+	for _, item := range strings.Split(getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+// read a positive integer environment variable, falls back to def when unset or invalid
+func intEnv(key string, def int) int {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Printf("invalid %s [%s], using default %d\n", key, value, def)
+		return def
+	}
+
+	return n
+}
+
+// read a positive duration environment variable e.g. "500ms", falls back to def when unset or invalid
+func durationEnv(key string, def time.Duration) time.Duration {
+	value, ok := lookupEnv(key)
+	if !ok {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s [%s], using default %s\n", key, value, def)
+		return def
+	}
+
+	return d
+}
+
+// listen address from ADDR e.g. "127.0.0.1:3000", or PORT as injected by PaaS platforms, a bare port number
+// is normalized to ":3000". Falls back to DefaultAddr, ADDR wins when both are set
+func listenAddr() string {
+	if addr := getenv("ADDR"); addr != "" {
+		return addr
+	}
+
+	port := strings.TrimSpace(getenv("PORT"))
+	if port == "" {
+		return DefaultAddr
+	}
+
+	if _, err := strconv.Atoi(port); err == nil {
+		return ":" + port
+	}
+
+	return port
+}
+
+// url paths other sites may frame from EMBEDDABLE_ROUTES, by default the Cann table for its ?lite=1 embed
+func embeddableRoutes() []string {
+	if _, ok := lookupEnv("EMBEDDABLE_ROUTES"); !ok {
+		return []string{"/cann"}
+	}
+
+	return listEnv("EMBEDDABLE_ROUTES")
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStringRedactsToken(t *testing.T) {
+	t.Setenv("API_TOKEN", "super-secret-token")
+	t.Setenv("HUXLEY_TOKEN", "super-secret-token")
+	t.Setenv("ADMIN_PASSWORD", "super-secret-token")
+	t.Setenv("ALERT_WEBHOOKS", "https://example.com/super-secret-token")
+	t.Setenv("managers", "1, 2")
+	t.Setenv("CACHE_MAX_ENTRIES", "7")
+
+	got := Load().String()
+
+	if strings.Contains(got, "super-secret-token") {
+		t.Errorf("Config.String() = %q, want token redacted", got)
+	}
+
+	for _, want := range []string{"addr=:8080", "writeTimeout=10s", "shutdownTimeout=15s", "standingsTTL=1m0s", "cacheMaxEntries=7",
+		"standingsBaseURL=" + DefaultStandingsBaseURL, "logLevel=info", "apiToken=" + redacted, "huxleyToken=" + redacted, "adminPassword=" + redacted,
+		"alertWebhooks=" + redacted, `managers="1, 2"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Config.String() = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestIntEnvInvalid(t *testing.T) {
+	t.Setenv("CACHE_MAX_ENTRIES", "lots")
+
+	if got := Load().CacheMaxEntries; got != DefaultCacheMaxEntries {
+		t.Errorf("Load().CacheMaxEntries = %d, want default %d", got, DefaultCacheMaxEntries)
+	}
+}
+
+func TestPerSourceCacheTTL(t *testing.T) {
+	if cfg := Load(); cfg.StandingsTTL != DefaultStandingsTTL || cfg.FPLCacheTTL != DefaultFPLCacheTTL || cfg.FPLBootstrapTTL != DefaultFPLBootstrapTTL ||
+		cfg.ScorersTTL != DefaultScorersTTL || cfg.HuxleyCacheTTL != DefaultHuxleyCacheTTL {
+		t.Errorf("Load() TTLs = %s, %s, %s, %s, %s, want defaults %s, %s, %s, %s, %s", cfg.StandingsTTL, cfg.FPLCacheTTL, cfg.FPLBootstrapTTL, cfg.ScorersTTL,
+			cfg.HuxleyCacheTTL, DefaultStandingsTTL, DefaultFPLCacheTTL, DefaultFPLBootstrapTTL, DefaultScorersTTL, DefaultHuxleyCacheTTL)
+	}
+
+	t.Setenv("CANN_CACHE_TTL", "2m")
+	t.Setenv("FPL_CACHE_TTL", "15s")
+	t.Setenv("FPL_BOOTSTRAP_CACHE_TTL", "30m")
+	t.Setenv("SCORERS_CACHE_TTL", "5m")
+	t.Setenv("HUXLEY_CACHE_TTL", "12h")
+
+	if cfg := Load(); cfg.StandingsTTL != 2*time.Minute || cfg.FPLCacheTTL != 15*time.Second || cfg.FPLBootstrapTTL != 30*time.Minute ||
+		cfg.ScorersTTL != 5*time.Minute || cfg.HuxleyCacheTTL != 12*time.Hour {
+		t.Errorf("Load() TTLs = %s, %s, %s, %s, %s, want 2m0s, 15s, 30m0s, 5m0s, 12h0m0s", cfg.StandingsTTL, cfg.FPLCacheTTL, cfg.FPLBootstrapTTL,
+			cfg.ScorersTTL, cfg.HuxleyCacheTTL)
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30s", 30 * time.Second},
+		{"soon", DefaultShutdownTimeout},
+		{"-1s", DefaultShutdownTimeout},
+	}
+
+	for _, test := range tests {
+		t.Setenv("SHUTDOWN_TIMEOUT", test.value)
+
+		if got := Load().ShutdownTimeout; got != test.want {
+			t.Errorf("Load().ShutdownTimeout with SHUTDOWN_TIMEOUT=%q = %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestListenAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		port string
+		want string
+	}{
+		{want: DefaultAddr},
+		{port: "3000", want: ":3000"},
+		{port: ":3001", want: ":3001"},
+		{addr: "127.0.0.1:9000", port: "3000", want: "127.0.0.1:9000"},
+	}
+
+	for _, test := range tests {
+		t.Setenv("ADDR", test.addr)
+		t.Setenv("PORT", test.port)
+
+		if got := Load().Addr; got != test.want {
+			t.Errorf("Load().Addr with ADDR=%q PORT=%q = %q, want %q", test.addr, test.port, got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() Config {
+		return Config{APIToken: "token", LogLevel: "info", LogFormat: "text", UpstreamTimeout: time.Second, WriteTimeout: DefaultWriteTimeout}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr []string
+	}{
+		{"valid", func(*Config) {}, nil},
+		{"missing token", func(c *Config) { c.APIToken = "" }, []string{"API_TOKEN is required"}},
+		{"admin user without a password", func(c *Config) { c.AdminUser = "mick" }, []string{"ADMIN_USER and ADMIN_PASSWORD"}},
+		{"admin password without a user", func(c *Config) { c.AdminPassword = "secret" }, []string{"ADMIN_USER and ADMIN_PASSWORD"}},
+		{"tls cert without a key", func(c *Config) { c.TLSCertFile = "cert.pem" }, []string{"TLS_CERT_FILE and TLS_KEY_FILE"}},
+		{"redirect without tls", func(c *Config) { c.RedirectAddr = ":80" }, []string{"HTTP_REDIRECT_ADDR needs TLS_CERT_FILE"}},
+		{"tls with a redirect", func(c *Config) { c.TLSCertFile, c.TLSKeyFile, c.RedirectAddr = "cert.pem", "key.pem", ":80" }, nil},
+		{"alert teams without webhooks", func(c *Config) { c.AlertTeams = `[{"team": "TOT", "zone": "relegation"}]` }, []string{"ALERT_TEAMS and ALERT_WEBHOOKS"}},
+		{"alert teams and webhooks", func(c *Config) {
+			c.AlertTeams, c.AlertWebhooks = `[{"team": "TOT", "zone": "relegation"}]`, []string{"https://example.com/hook"}
+		}, nil},
+		{"huxley token without a data file", func(c *Config) { c.HuxleyToken = "secret" }, []string{"HUXLEY_DATA_FILE is required"}},
+		{"huxley token and data file", func(c *Config) { c.HuxleyToken, c.HuxleyDataFile = "secret", "huxley.json" }, nil},
+		{"every problem", func(c *Config) {
+			c.APIToken, c.LogLevel, c.LogFormat, c.UpstreamTimeout = "", "loud", "xml", time.Minute
+		},
+			[]string{"API_TOKEN is required", `invalid LOG_LEVEL "loud"`, `invalid LOG_FORMAT "xml"`, "UPSTREAM_TIMEOUT 1m0s must be shorter"}},
+	}
+
+	for _, test := range tests {
+		cfg := valid()
+		test.modify(&cfg)
+
+		err := cfg.Validate()
+		if (err != nil) != (test.wantErr != nil) {
+			t.Fatalf("%s: Validate() = %v, want errors %q", test.name, err, test.wantErr)
+		}
+
+		for _, want := range test.wantErr {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("%s: Validate() = %v, want it to contain %q", test.name, err, want)
+			}
+		}
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	path := filepath.Join(t.TempDir(), "moh.env")
+	content := "# moh settings\nAPI_TOKEN='file-token'\n\nLOG_LEVEL=debug\nCANN_CACHE_TTL = \"2m\"\nPOINTS_ADJUSTMENTS=[]\n"
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("LOG_LEVEL", "warn") // the environment wins
+
+	for _, key := range []string{"API_TOKEN", "CANN_CACHE_TTL", "POINTS_ADJUSTMENTS"} {
+		t.Setenv(key, "") // restored after the test
+		os.Unsetenv(key)  //nolint:errcheck // unset for the file to apply
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	cfg := Load()
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err := cfg.Validate(); err != nil || cfg.APIToken != "file-token" || cfg.LogLevel != "warn" || cfg.StandingsTTL != 2*time.Minute {
+		t.Errorf("Load() with CONFIG_FILE = apiToken %q logLevel %q standingsTTL %s, %v, want the file values under the environment",
+			cfg.APIToken, cfg.LogLevel, cfg.StandingsTTL, err)
+	}
+
+	if cfg.PointsAdjustments != "[]" {
+		t.Errorf("Load() with CONFIG_FILE pointsAdjustments = %q, want the file value for the packages", cfg.PointsAdjustments)
+	}
+
+	if _, exported := os.LookupEnv("POINTS_ADJUSTMENTS"); exported {
+		t.Error("POINTS_ADJUSTMENTS is in the environment, want the file values kept out of it")
+	}
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "moh.env")
+	if err := os.WriteFile(path, []byte("API_TOKEN=token\nnot a setting\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path, "line 2: want KEY=VALUE"},
+		{filepath.Join(t.TempDir(), "missing.env"), "error reading CONFIG_FILE"},
+	}
+
+	for _, test := range tests {
+		t.Setenv("CONFIG_FILE", test.path)
+		t.Setenv("API_TOKEN", "token")
+
+		if err := Load().Validate(); err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("Load().Validate() with CONFIG_FILE=%s = %v, want %q", test.path, err, test.wantErr)
+		}
+	}
+}
+
+func TestRateLimits(t *testing.T) {
+	tests := []struct {
+		rateLimit  string
+		routes     string
+		allowlist  string
+		proxies    string
+		want       string // rateLimit, routeRateLimits, rateLimitAllowlist and trustedProxies in the startup log
+		wantErrors []string
+	}{
+		{want: "rateLimit=120/1m0s routeRateLimits=map[] rateLimitAllowlist=[] trustedProxies=[]"},
+		{"30/10s", "/fpl/live=10/1m, /huxley=off", "203.0.113.7, 198.51.100.9/24", "173.245.48.0/20", "rateLimit=30/10s routeRateLimits=map[/fpl/live:10/1m0s /huxley:off] rateLimitAllowlist=[203.0.113.7/32 198.51.100.0/24] trustedProxies=[173.245.48.0/20]", nil},
+		{"0", "", "", "", "rateLimit=off", nil},
+		{"lots", "fpl=1/1m", "somewhere", "cloudflare", "rateLimit=120/1m0s", []string{`RATE_LIMIT: invalid rate limit "lots"`, `RATE_LIMIT_ROUTES: invalid item "fpl=1/1m"`, `RATE_LIMIT_ALLOWLIST: invalid address "somewhere"`, `TRUSTED_PROXIES: invalid address "cloudflare"`}},
+	}
+
+	for _, test := range tests {
+		t.Setenv("API_TOKEN", "token")
+		t.Setenv("RATE_LIMIT_ROUTES", test.routes)
+		t.Setenv("RATE_LIMIT_ALLOWLIST", test.allowlist)
+		t.Setenv("TRUSTED_PROXIES", test.proxies)
+
+		t.Setenv("RATE_LIMIT", test.rateLimit)
+		if test.rateLimit == "" {
+			os.Unsetenv("RATE_LIMIT") //nolint:errcheck // restored by t.Setenv
+		}
+
+		cfg := Load()
+
+		if got := cfg.String(); !strings.Contains(got, test.want) {
+			t.Errorf("Load() with RATE_LIMIT=%q = %q, want it to contain %q", test.rateLimit, got, test.want)
+		}
+
+		err := cfg.Validate()
+		if (err != nil) != (test.wantErrors != nil) {
+			t.Fatalf("Validate() with RATE_LIMIT=%q = %v, want errors %q", test.rateLimit, err, test.wantErrors)
+		}
+
+		for _, want := range test.wantErrors {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Validate() = %v, want it to contain %q", err, want)
+			}
+		}
+	}
+}
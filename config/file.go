@@ -0,0 +1,79 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// the settings of the file named by CONFIG_FILE, set by Load
+var fileValues map[string]string
+
+// reads the KEY=VALUE lines of the file named by CONFIG_FILE, none when it isn't set.
+// Blank lines and lines starting with # are ignored, quotes around a value are removed
+func loadFile() (map[string]string, error) {
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CONFIG_FILE: %w", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+
+	var errs []error
+
+	lines := bufio.NewScanner(file)
+	for number := 1; lines.Scan(); number++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if key = strings.TrimSpace(key); !ok || key == "" {
+			errs = append(errs, fmt.Errorf("%s line %d: want KEY=VALUE", path, number))
+			continue
+		}
+
+		values[key] = unquote(strings.TrimSpace(value))
+	}
+
+	if err := lines.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("error reading CONFIG_FILE: %w", err))
+	}
+
+	return values, errors.Join(errs...)
+}
+
+// the value of an environment variable, or of the CONFIG_FILE setting when it is unset
+func lookupEnv(key string) (string, bool) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true
+	}
+
+	value, ok := fileValues[key]
+
+	return value, ok
+}
+
+// the value of an environment variable or CONFIG_FILE setting, empty when unset in both
+func getenv(key string) string {
+	value, _ := lookupEnv(key)
+	return value
+}
+
+// the value without a matching pair of surrounding single or double quotes
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+
+	return value
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRateLimit is the requests allowed per client when RATE_LIMIT is unset
+var DefaultRateLimit = RateLimit{Limit: 120, Window: time.Minute}
+
+// A RateLimit allows Limit requests per client in each Window, rate limiting is off when Limit is 0
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+func (r RateLimit) String() string {
+	if r.Limit == 0 {
+		return "off"
+	}
+
+	return fmt.Sprintf("%d/%s", r.Limit, r.Window)
+}
+
+// a rate limit e.g. "120/1m", "0" or "off" turns it off
+func parseRateLimit(value string) (RateLimit, error) {
+	if value = strings.TrimSpace(value); value == "0" || value == "off" {
+		return RateLimit{}, nil
+	}
+
+	limit, window, ok := strings.Cut(value, "/")
+
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || !ok {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, want requests/window e.g. 120/1m", value)
+	}
+
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q, want requests/window e.g. 120/1m", value)
+	}
+
+	return RateLimit{Limit: n, Window: d}, nil
+}
+
+// the default rate limit from RATE_LIMIT
+func rateLimitEnv() (RateLimit, error) {
+	value, ok := lookupEnv("RATE_LIMIT")
+	if !ok {
+		return DefaultRateLimit, nil
+	}
+
+	limit, err := parseRateLimit(value)
+	if err != nil {
+		return DefaultRateLimit, fmt.Errorf("RATE_LIMIT: %w", err)
+	}
+
+	return limit, nil
+}
+
+// per route rate limits from RATE_LIMIT_ROUTES e.g. "/fpl/live=10/1m,/export=5/1m" keyed by url path
+func routeRateLimitsEnv() (map[string]RateLimit, error) {
+	limits := make(map[string]RateLimit)
+
+	for _, item := range listEnv("RATE_LIMIT_ROUTES") {
+		path, value, ok := strings.Cut(item, "=")
+		if !ok || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("RATE_LIMIT_ROUTES: invalid item %q, want /path=requests/window", item)
+		}
+
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("RATE_LIMIT_ROUTES: %w", err)
+		}
+
+		limits[strings.TrimSpace(path)] = limit
+	}
+
+	return limits, nil
+}
+
+// client addresses and networks that aren't rate limited from RATE_LIMIT_ALLOWLIST e.g. "203.0.113.7,198.51.100.0/24"
+func allowlistEnv() ([]netip.Prefix, error) {
+	return prefixesEnv("RATE_LIMIT_ALLOWLIST")
+}
+
+// addresses of the proxies trusted to set the Cf-Connecting-Ip client address from TRUSTED_PROXIES, e.g. the
+// Cloudflare ranges "173.245.48.0/20,2400:cb00::/32"
+func trustedProxiesEnv() ([]netip.Prefix, error) {
+	return prefixesEnv("TRUSTED_PROXIES")
+}
+
+// a comma separated list of addresses and networks, an address is a network of just that address
+func prefixesEnv(name string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+
+	for _, item := range listEnv(name) {
+		if addr, err := netip.ParseAddr(item); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid address %q", name, item)
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+)
+
+// json routes other sites may fetch cross-origin, as well as everything under /api/v1
+var corsRoutes = []string{"/fpl", "/fpl/bootstrap", "/fpl/live", "/fpl/summary", "/fpl/{leagueID}", "/cann", "/cann/{competition}"}
+
+// response headers cross-origin scripts may read besides the CORS safelisted ones, for conditional requests,
+// change detection, stale data and rate limits
+const corsExposedHeaders = "ETag, Last-Modified, X-Data-Version, Warning, Retry-After"
+
+// cross-origin access to the json routes for the allowed origins, "*" allows any origin
+type cors struct {
+	origins []string
+}
+
+func newCORS(origins []string) *cors {
+	return &cors{origins: origins}
+}
+
+func (c *cors) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		origin := req.Header.Get("Origin")
+		if origin == "" || (!slices.Contains(corsRoutes, routeLabel(req.URL.Path)) && !isAPIV1(req.URL.Path)) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+
+		if !c.allowed(origin) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+
+		// preflight requests are answered here, the routes only serve GET
+		if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type")
+			w.Header().Set("Access-Control-Max-Age", "3600")
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+func (c *cors) allowed(origin string) bool {
+	return slices.Contains(c.origins, "*") || slices.Contains(c.origins, origin)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORS(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /fpl", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+	})
+	mux.HandleFunc("GET /fpl/{leagueID}", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("GET /huxley", func(http.ResponseWriter, *http.Request) {})
+	mux.HandleFunc("GET /api/v1/scorers", func(http.ResponseWriter, *http.Request) {})
+
+	handler := newCORS([]string{"https://moh.vercel.app"}).middleware(mux)
+
+	tests := []struct {
+		method      string
+		path        string
+		origin      string
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+	}{
+		{http.MethodGet, "/fpl", "https://moh.vercel.app", http.StatusOK, "https://moh.vercel.app", ""},
+		{http.MethodOptions, "/fpl", "https://moh.vercel.app", http.StatusNoContent, "https://moh.vercel.app", "GET, OPTIONS"},
+		{http.MethodGet, "/fpl", "https://evil.example", http.StatusOK, "", ""},
+		{http.MethodOptions, "/fpl", "https://evil.example", http.StatusMethodNotAllowed, "", ""},
+		{http.MethodGet, "/fpl", "", http.StatusOK, "", ""},
+		{http.MethodGet, "/fpl/314159", "https://moh.vercel.app", http.StatusOK, "https://moh.vercel.app", ""},
+		{http.MethodGet, "/huxley", "https://moh.vercel.app", http.StatusOK, "", ""},
+		{http.MethodGet, "/api/v1/scorers", "https://moh.vercel.app", http.StatusOK, "https://moh.vercel.app", ""},
+		{http.MethodOptions, "/api/v1/scorers", "https://moh.vercel.app", http.StatusNoContent, "https://moh.vercel.app", "GET, OPTIONS"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, http.NoBody)
+		if test.origin != "" {
+			req.Header.Set("Origin", test.origin)
+		}
+
+		if test.method == http.MethodOptions {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("%s %s origin %q status = %d, want %d", test.method, test.path, test.origin, w.Code, test.wantStatus)
+		}
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != test.wantOrigin {
+			t.Errorf("%s %s origin %q Access-Control-Allow-Origin = %q, want %q", test.method, test.path, test.origin, got, test.wantOrigin)
+		}
+
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != test.wantMethods {
+			t.Errorf("%s %s origin %q Access-Control-Allow-Methods = %q, want %q", test.method, test.path, test.origin, got, test.wantMethods)
+		}
+
+		wantExposed := ""
+		if test.wantOrigin != "" {
+			wantExposed = "ETag, Last-Modified, X-Data-Version, Warning, Retry-After"
+		}
+
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != wantExposed {
+			t.Errorf("%s %s origin %q Access-Control-Expose-Headers = %q, want %q", test.method, test.path, test.origin, got, wantExposed)
+		}
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	handler := newCORS([]string{"*"}).middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/cann?format=json", http.NoBody)
+	req.Header.Set("Origin", "https://anywhere.example")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://anywhere.example" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the request origin", got)
+	}
+}
@@ -0,0 +1,79 @@
+// writes error responses for all the pages, a styled html page or json for json clients, and logs the error
+package errorpage
+
+import (
+	"embed"
+	"encoding/json"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"github.com/mick4711/moh/conditional"
+	"github.com/mick4711/moh/i18n"
+	"github.com/mick4711/moh/negotiate"
+)
+
+//go:embed ErrorTemplate.html
+var templateFS embed.FS
+
+var errorTemplate = template.Must(template.ParseFS(templateFS, "ErrorTemplate.html"))
+
+// the json body of an error response and the data of its html page
+type body struct {
+	Status  int    `json:"status"`
+	Title   string `json:"title"` // the status text e.g. Bad Request
+	Message string `json:"error"`
+
+	Lang string        `json:"-"` // language of the html page, the message itself isn't translated
+	Text i18n.Messages `json:"-"`
+}
+
+// Write logs err and responds with status and the error, as a styled html page or json when the client asks for it
+func Write(w http.ResponseWriter, req *http.Request, status int, err error) {
+	write(w, req, status, err, negotiate.HTML, negotiate.JSON)
+}
+
+// JSON logs err and responds with status and the error, as json or a styled html page when the client asks for it.
+// For the json apis
+func JSON(w http.ResponseWriter, req *http.Request, status int, err error) {
+	write(w, req, status, err, negotiate.JSON, negotiate.HTML)
+}
+
+// 4xx are logged at warn and 5xx at error. Error responses aren't cached
+func write(w http.ResponseWriter, req *http.Request, status int, err error, formats ...string) {
+	level := slog.LevelWarn
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	slog.Log(req.Context(), level, "error response", "path", req.URL.Path, "status", status, "err", err)
+
+	page := body{Status: status, Title: http.StatusText(status), Message: err.Error()}
+
+	// the validators set for the data that couldn't be served
+	w.Header().Del("ETag")
+	w.Header().Del("Last-Modified")
+	w.Header().Del(conditional.DataVersionHeader)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if negotiate.Format(w, req, formats...) == negotiate.JSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			slog.WarnContext(req.Context(), "error writing error response", "err", err)
+		}
+
+		return
+	}
+
+	page.Lang = i18n.Language(w, req)
+	page.Text = i18n.Text(page.Lang)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+
+	if err := errorTemplate.Execute(w, page); err != nil {
+		slog.WarnContext(req.Context(), "error writing error page", "err", err)
+	}
+}
@@ -0,0 +1,63 @@
+package errorpage
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name            string
+		write           func(http.ResponseWriter, *http.Request, int, error)
+		accept          string
+		acceptLanguage  string
+		status          int
+		wantContentType string
+		wantBody        string
+	}{
+		{"page", Write, "", "", http.StatusBadRequest, "text/html; charset=utf-8", `<p class="message">unknown team &#34;ARS&#34;</p>`},
+		{"page for a json client", Write, "application/json", "", http.StatusBadRequest, "application/json", `{"status":400,"title":"Bad Request","error":"unknown team \"ARS\""}`},
+		{"json api", JSON, "*/*", "", http.StatusBadGateway, "application/json", `"title":"Bad Gateway"`},
+		{"json api for a browser", JSON, "text/html", "", http.StatusNotFound, "text/html; charset=utf-8", "<title>404 Not Found</title>"},
+		{"page in Portuguese", Write, "text/html", "pt-BR", http.StatusNotFound, "text/html; charset=utf-8", "<h1> Não encontrado </h1>"},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		req := httptest.NewRequest(http.MethodGet, "/fixtures.ics?team=ARS", http.NoBody)
+		req.Header.Set("Accept", test.accept)
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+
+		w := httptest.NewRecorder()
+		w.Header().Set("ETag", `"stale"`)
+		w.Header().Set("Last-Modified", "Sat, 02 Mar 2024 12:00:00 GMT")
+		w.Header().Set("X-Data-Version", "0aa77d01c215e08b")
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		test.write(w, req, test.status, errors.New(`unknown team "ARS"`))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.status {
+			t.Errorf("%s: status = %d, want %d", test.name, w.Code, test.status)
+		}
+
+		if got := w.Header().Get("Content-Type"); got != test.wantContentType {
+			t.Errorf("%s: Content-Type = %q, want %q", test.name, got, test.wantContentType)
+		}
+
+		if etag, cacheControl := w.Header().Get("ETag"), w.Header().Get("Cache-Control"); etag != "" || cacheControl != "no-store" {
+			t.Errorf("%s: ETag = %q Cache-Control = %q, want no ETag and no-store", test.name, etag, cacheControl)
+		}
+
+		if modified, version := w.Header().Get("Last-Modified"), w.Header().Get("X-Data-Version"); modified != "" || version != "" {
+			t.Errorf("%s: Last-Modified = %q X-Data-Version = %q, want neither", test.name, modified, version)
+		}
+
+		if !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("%s: body = %s, want it to contain %s", test.name, w.Body, test.wantBody)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// reloads the page when the server pushes a change to the data it shows, named by the script's data-event
+const pageEvent = document.currentScript.dataset.event;
+
+new EventSource("/events").addEventListener(pageEvent, () => location.reload());
@@ -0,0 +1,143 @@
+// publishes server-sent events to the connected browsers, so pages update when the data they show changes
+// instead of polling
+package events
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultHeartbeat = 15 * time.Second // keeps idle connections open through proxies
+	clientBuffer     = 8                // events queued for a slow client before they are dropped
+)
+
+// An Event is a named message, Data is sent as a single line
+type Event struct {
+	Type string
+	Data string
+}
+
+// A Hub fans each published event out to every connected client, safe for concurrent use
+type Hub struct {
+	mu        sync.Mutex
+	clients   map[chan Event]struct{}
+	closed    bool
+	heartbeat time.Duration
+}
+
+// New returns a hub sending a heartbeat comment to idle clients every heartbeat, DefaultHeartbeat when 0
+func New(heartbeat time.Duration) *Hub {
+	if heartbeat <= 0 {
+		heartbeat = DefaultHeartbeat
+	}
+
+	return &Hub{clients: make(map[chan Event]struct{}), heartbeat: heartbeat}
+}
+
+// Subscribe returns a channel receiving the published events and a function to unsubscribe.
+// The channel is closed on unsubscribe or when the hub closes
+func (h *Hub) Subscribe() (<-chan Event, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	client := make(chan Event, clientBuffer)
+	if h.closed {
+		close(client)
+		return client, func() {}
+	}
+
+	h.clients[client] = struct{}{}
+
+	return client, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		if _, ok := h.clients[client]; ok {
+			delete(h.clients, client)
+			close(client)
+		}
+	}
+}
+
+// Publish sends the event to every client, a client whose buffer is full misses it rather than holding up the others
+func (h *Hub) Publish(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		select {
+		case client <- event:
+		default:
+		}
+	}
+}
+
+// Clients is the number of connected clients
+func (h *Hub) Clients() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.clients)
+}
+
+// Close disconnects every client and refuses new ones, for server shutdown
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+
+	for client := range h.clients {
+		delete(h.clients, client)
+		close(client)
+	}
+}
+
+// ServeHTTP streams the events to the client until it disconnects or the hub closes
+func (h *Hub) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// the stream outlives the server write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	events, unsubscribe := h.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx buffering the stream
+	w.WriteHeader(http.StatusOK)
+
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		}
+
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublish(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	hub := New(0)
+
+	first, unsubscribeFirst := hub.Subscribe()
+	second, unsubscribeSecond := hub.Subscribe()
+	defer unsubscribeSecond()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	hub.Publish(Event{Type: "standings", Data: "1"})
+	unsubscribeFirst()
+	hub.Publish(Event{Type: "standings", Data: "2"})
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if event := <-first; event.Data != "1" {
+		t.Errorf("first client received %+v, want event 1", event)
+	}
+
+	if _, ok := <-first; ok {
+		t.Error("unsubscribed client channel still open")
+	}
+
+	if got := []string{(<-second).Data, (<-second).Data}; got[0] != "1" || got[1] != "2" {
+		t.Errorf("second client received %v, want events 1 and 2", got)
+	}
+
+	if hub.Clients() != 1 {
+		t.Errorf("Clients() = %d, want 1", hub.Clients())
+	}
+}
+
+func TestPublishSlowClient(t *testing.T) {
+	hub := New(0)
+
+	slow, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	for range clientBuffer + 1 {
+		hub.Publish(Event{Type: "fpl"}) // doesn't block on the full buffer
+	}
+
+	if len(slow) != clientBuffer {
+		t.Errorf("slow client has %d events queued, want %d", len(slow), clientBuffer)
+	}
+}
+
+func TestClose(t *testing.T) {
+	hub := New(0)
+	client, _ := hub.Subscribe()
+
+	hub.Close()
+
+	if _, ok := <-client; ok {
+		t.Error("client channel open after Close()")
+	}
+
+	if late, _ := hub.Subscribe(); hub.Clients() != 0 {
+		t.Errorf("Subscribe() after Close() added a client, channel %v", late)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	hub := New(20 * time.Millisecond)
+
+	ts := httptest.NewServer(hub)
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, http.NoBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	for hub.Clients() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	hub.Publish(Event{Type: "standings", Data: `{"competition":"PL"}`})
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", contentType)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+
+	var stream []string
+	for len(stream) < 5 && lines.Scan() {
+		stream = append(stream, lines.Text())
+	}
+
+	want := "event: standings\ndata: {\"competition\":\"PL\"}\n\n: heartbeat\n"
+	if got := strings.Join(stream, "\n"); got != want {
+		t.Errorf("stream = %q, want %q", got, want)
+	}
+
+	cancel()
+
+	for hub.Clients() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/conditional"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/fpl"
+	"github.com/mick4711/moh/huxley"
+)
+
+// access to /export, set at startup. It is served when DEBUG is set or with Authorization: Bearer <EXPORT_TOKEN>
+var exportAccess struct {
+	debug bool
+	token string
+}
+
+// a section of the export bundle, Error is set instead of Data when the section failed
+type exportSection struct {
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// writes the current data of every page as one json bundle, each section from the cache where it is cached
+func exportHandler(w http.ResponseWriter, req *http.Request) {
+	if !exportAllowed(req) {
+		http.NotFound(w, req)
+		return
+	}
+
+	body, err := json.Marshal(exportBundle(req))
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusInternalServerError, fmt.Errorf("error encoding export bundle: %w", err))
+		return
+	}
+
+	body = append(body, '\n')
+
+	conditional.SetDataVersion(w.Header(), body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(body); err != nil {
+		slog.WarnContext(req.Context(), "error writing export bundle", "err", err)
+	}
+}
+
+// true when DEBUG is set or the request has the export bearer token
+func exportAllowed(req *http.Request) bool {
+	if exportAccess.debug {
+		return true
+	}
+
+	bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+	return exportAccess.token != "" && ok && subtle.ConstantTimeCompare([]byte(bearer), []byte(exportAccess.token)) == 1
+}
+
+// gather the export sections concurrently, a failed section has an error marker and the others are unaffected
+func exportBundle(req *http.Request) map[string]exportSection {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		bundle = make(map[string]exportSection)
+	)
+
+	add := func(name string, section exportSection) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		bundle[name] = section
+	}
+
+	failed := func(err error) exportSection {
+		return exportSection{Timestamp: clk.Now(), Error: err.Error()}
+	}
+
+	wg.Add(3) //nolint:gomnd // one per goroutine below
+
+	go func() {
+		defer wg.Done()
+
+		snapshot, err := cann.Snapshot(req.Context())
+		if err != nil {
+			add("cann", failed(err))
+			add("standings", failed(err))
+
+			return
+		}
+
+		add("cann", exportSection{Timestamp: snapshot.FetchedAt, Data: snapshot.Rows})
+		add("standings", exportSection{Timestamp: snapshot.FetchedAt, Data: snapshot.Table})
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		league, err := fpl.League(req.Context())
+		if err != nil {
+			add("fpl", failed(err))
+			return
+		}
+
+		add("fpl", exportSection{Timestamp: league.FetchedAt, Data: league})
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		add("huxley", exportSection{Timestamp: clk.Now(), Data: huxley.Stats()})
+	}()
+
+	wg.Wait()
+
+	return bundle
+}
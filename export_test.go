@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/fpl"
+)
+
+func TestExportBundle(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	standings, err := os.ReadFile("cann/standings_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/competitions/PL/standings":
+			_, _ = w.Write(standings) //nolint:errcheck // test server
+		case "/entry/1/":
+			fmt.Fprint(w, `{"current_event": 7, "id": 1, "player_first_name": "A", "player_last_name": "B", "name": "Team"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	cann.Configure(cann.Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, MaxEntries: 1, UpstreamTimeout: time.Second})
+	fpl.Configure(fpl.Settings{BaseURL: ts.URL, CacheTTL: time.Minute, Managers: "1"})
+
+	exportAccess.token = "export-token"
+	defer func() { exportAccess.token = "" }()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	denied := httptest.NewRecorder()
+	exportHandler(denied, httptest.NewRequest(http.MethodGet, "/export", http.NoBody))
+
+	req := httptest.NewRequest(http.MethodGet, "/export", http.NoBody)
+	req.Header.Set("Authorization", "Bearer export-token")
+
+	w := httptest.NewRecorder()
+	exportHandler(w, req)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if denied.Code != http.StatusNotFound {
+		t.Errorf("exportHandler() without token status = %d, want %d", denied.Code, http.StatusNotFound)
+	}
+
+	var bundle map[string]exportSection
+	if err := json.Unmarshal(w.Body.Bytes(), &bundle); err != nil {
+		t.Fatal(err)
+	}
+
+	sections := make([]string, 0, len(bundle))
+	for name, section := range bundle {
+		sections = append(sections, name)
+
+		if section.Error != "" || section.Data == nil || section.Timestamp.IsZero() {
+			t.Errorf("export section %s = %+v, want data and a timestamp", name, section)
+		}
+	}
+
+	slices.Sort(sections)
+
+	if want := []string{"cann", "fpl", "huxley", "standings"}; !reflect.DeepEqual(sections, want) {
+		t.Errorf("export sections = %v, want %v", sections, want)
+	}
+}
+
+func TestExportBundleFPLFetchedAt(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	failing := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		fmt.Fprint(w, `{"current_event": 7, "id": 1, "player_first_name": "A", "player_last_name": "B", "name": "Team"}`)
+	}))
+	defer ts.Close()
+
+	fetched := time.Date(2024, 9, 28, 15, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(fetched)
+
+	fpl.Configure(fpl.Settings{BaseURL: ts.URL, CacheTTL: time.Minute, Managers: "1", Clock: fake})
+	defer fpl.Configure(fpl.Settings{})
+
+	req := httptest.NewRequest(http.MethodGet, "/export", http.NoBody)
+	fresh := exportBundle(req)["fpl"]
+
+	fake.Advance(2 * time.Hour)
+	failing = true
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := exportBundle(req)["fpl"]
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if fresh.Error != "" || !fresh.Timestamp.Equal(fetched) {
+		t.Errorf("export fpl timestamp = %s, want the fetch time %s", fresh.Timestamp, fetched)
+	}
+
+	if got.Error != "" || !got.Timestamp.Equal(fetched) {
+		t.Errorf("export fpl served stale = %+v, want the timestamp of the last good fetch %s", got, fetched)
+	}
+}
+
+func TestExportBundleSectionError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	fpl.Configure(fpl.Settings{BaseURL: ts.URL, CacheTTL: time.Minute, Managers: "1"})
+	cann.Configure(cann.Settings{BaseURL: ts.URL, TTL: time.Minute, MaxEntries: 1, UpstreamTimeout: time.Second})
+
+	bundle := exportBundle(httptest.NewRequest(http.MethodGet, "/export", http.NoBody))
+
+	if bundle["cann"].Error == "" || bundle["standings"].Error == "" {
+		t.Errorf("export cann = %+v, want an error marker when the standings fetch fails", bundle["cann"])
+	}
+
+	if bundle["huxley"].Error != "" || bundle["huxley"].Data == nil {
+		t.Errorf("export huxley = %+v, want it unaffected by the failed sections", bundle["huxley"])
+	}
+}
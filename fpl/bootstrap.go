@@ -0,0 +1,94 @@
+package fpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/errorpage"
+)
+
+const defaultBootstrapTTL = 6 * time.Hour // reference data changes infrequently, override with FPL_BOOTSTRAP_CACHE_TTL
+
+// the configured sections and the players for the manager summaries
+const bootstrapCacheEntries = 2
+
+// sections of bootstrap-static returned by default, override with environment variable FPL_BOOTSTRAP_SECTIONS
+var defaultBootstrapSections = []string{"teams", "elements", "events"}
+
+var (
+	bootstrapURL   = "https://fantasy.premierleague.com/api/bootstrap-static/"
+	bootstrapCache = cache.New(defaultBootstrapTTL, bootstrapCacheEntries)
+)
+
+// Bootstrap writes the configured subset of the FPL bootstrap-static reference data as json
+func Bootstrap(w http.ResponseWriter, r *http.Request) {
+	body, err := getBootstrap(r.Context(), bootstrapSections)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadGateway, err)
+		return
+	}
+
+	writeConditionalJSON(w, r, body)
+}
+
+// bootstrap-static sections to return from FPL_BOOTSTRAP_SECTIONS e.g. "teams,events", or the defaults, set by Configure
+var bootstrapSections = defaultBootstrapSections
+
+// get the trimmed bootstrap-static response from the cache, or fetch it
+func getBootstrap(ctx context.Context, sections []string) ([]byte, error) {
+	key := strings.Join(sections, ",")
+	if body, ok := bootstrapCache.Get(key); ok {
+		return body, nil
+	}
+
+	resp, err := timedGet(ctx, "bootstrap", bootstrapURL)
+	if err != nil {
+		return nil, fmt.Errorf("error requesting bootstrap-static: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get bootstrap-static not OK, Status: %v", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading bootstrap-static response: %w", err)
+	}
+
+	trimmed, err := trimBootstrap(body, sections)
+	if err != nil {
+		return nil, err
+	}
+
+	bootstrapCache.Set(key, trimmed)
+
+	return trimmed, nil
+}
+
+// keep only the requested top level sections, each must be present in the response
+func trimBootstrap(body []byte, sections []string) ([]byte, error) {
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(body, &all); err != nil {
+		return nil, fmt.Errorf("error unmarshalling bootstrap-static response: %w", err)
+	}
+
+	trimmed := make(map[string]json.RawMessage, len(sections))
+
+	for _, section := range sections {
+		value, ok := all[section]
+		if !ok {
+			return nil, fmt.Errorf("bootstrap-static response has no %q section", section)
+		}
+
+		trimmed[section] = value
+	}
+
+	return json.Marshal(trimmed)
+}
@@ -0,0 +1,167 @@
+package fpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+)
+
+const mockBootstrap = `{
+	"events": [{"id": 1, "name": "Gameweek 1"}],
+	"teams": [{"id": 1, "name": "Arsenal"}],
+	"elements": [{"id": 7, "web_name": "Saka"}],
+	"element_stats": [{"label": "Minutes played"}],
+	"total_players": 11000000
+}`
+
+func TestBootstrap(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+
+		w.Header().Set(ContentType, ApplicationJSON)
+		fmt.Fprintln(w, mockBootstrap)
+	}))
+	defer ts.Close()
+
+	bootstrapURL = ts.URL
+	bootstrapCache = cache.New(time.Minute, 1)
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	for range 2 {
+		w := httptest.NewRecorder()
+		Bootstrap(w, httptest.NewRequest(http.MethodGet, "/fpl/bootstrap", http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != http.StatusOK {
+			t.Fatalf("Bootstrap() status = %d, want %d", w.Code, http.StatusOK)
+		}
+
+		var got map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		if keys := sortedKeys(got); !reflect.DeepEqual(keys, []string{"elements", "events", "teams"}) {
+			t.Errorf("Bootstrap() sections = %v, want only the default subset", keys)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("upstream requests = %d, want 1, the second response should be cached", requests)
+	}
+}
+
+func TestBootstrapConditional(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintln(w, mockBootstrap)
+	}))
+	defer ts.Close()
+
+	bootstrapURL = ts.URL
+	bootstrapCache = cache.New(time.Minute, 1)
+
+	first := httptest.NewRecorder()
+	Bootstrap(first, httptest.NewRequest(http.MethodGet, "/fpl/bootstrap", http.NoBody))
+
+	tag, modified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if tag == "" || modified == "" {
+		t.Fatalf("Bootstrap() ETag = %q Last-Modified = %q, want both", tag, modified)
+	}
+
+	tests := []struct {
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"If-None-Match", tag, http.StatusNotModified},
+		{"If-None-Match", `"other"`, http.StatusOK},
+		{"If-Modified-Since", modified, http.StatusNotModified},
+		{"If-Modified-Since", "Sat, 17 Aug 2024 16:05:00 GMT", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/fpl/bootstrap", http.NoBody)
+		req.Header.Set(test.header, test.value)
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Bootstrap(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("Bootstrap() %s: %s status = %d, want %d", test.header, test.value, w.Code, test.wantStatus)
+		}
+
+		if test.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("Bootstrap() %s: %s 304 body = %q, want empty", test.header, test.value, w.Body)
+		}
+	}
+}
+
+func TestTrimBootstrapMissingSection(t *testing.T) {
+	if _, err := trimBootstrap([]byte(mockBootstrap), []string{"teams", "fixtures"}); err == nil {
+		t.Error("trimBootstrap() with a missing section err = nil, want err")
+	}
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+func TestBootstrapCacheTTL(t *testing.T) {
+	defer Configure(Settings{BaseURL: "https://fantasy.premierleague.com/api", CacheTTL: defaultBootstrapTTL})
+
+	tests := []struct {
+		ttl          time.Duration
+		wantRequests int
+	}{
+		{time.Hour, 1},
+		{time.Millisecond, 2},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests++
+
+			fmt.Fprintln(w, mockBootstrap)
+		}))
+
+		Configure(Settings{BaseURL: ts.URL, CacheTTL: test.ttl})
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		for range 2 {
+			if _, err := getBootstrap(context.Background(), defaultBootstrapSections); err != nil {
+				t.Fatal(err)
+			}
+
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		ts.Close()
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if requests != test.wantRequests {
+			t.Errorf("FPL_BOOTSTRAP_CACHE_TTL %s upstream requests = %d, want %d", test.ttl, requests, test.wantRequests)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package fpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/mick4711/moh/breaker"
+	"github.com/mick4711/moh/clock"
+)
+
+// DefaultUpstreamTimeout is the deadline for each FPL api request when Settings.UpstreamTimeout is 0
+const DefaultUpstreamTimeout = 5 * time.Second
+
+// An HTTPClient sends requests, e.g. an *http.Client
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// sends the FPL api requests, set by Configure
+var httpClient HTTPClient = http.DefaultClient
+
+// deadline for each FPL api request including reading the body, set by Configure
+var upstreamTimeout = DefaultUpstreamTimeout
+
+// refuses FPL api requests for a cooldown after repeated failures, set by Configure
+var upstreamBreaker = breaker.New(breaker.DefaultThreshold, breaker.DefaultCooldown, nil)
+
+// GET the url with the configured client within the upstream deadline, recording the request duration and failures
+// for the resource and the outcome for UpstreamStatus. The request is abandoned when ctx is cancelled, e.g. the client
+// disconnected, which isn't counted as an upstream failure. Nothing is sent while the circuit is open after repeated
+// failures
+func timedGet(ctx context.Context, resource, url string) (*http.Response, error) {
+	if err := upstreamBreaker.Allow(); err != nil {
+		return nil, fmt.Errorf("%s request not sent: %w", resource, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, upstreamTimeout)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		cancel()
+		return nil, err //nolint:wrapcheck // callers add the context
+	}
+
+	start := clk.Now()
+	resp, err := httpClient.Do(req)
+
+	if errors.Is(err, context.Canceled) {
+		cancel()
+		return nil, fmt.Errorf("%s request cancelled: %w", resource, err)
+	}
+
+	requestDuration.Observe(clock.Since(clk, start).Seconds(), resource)
+	recordFetch(resp, err)
+
+	failed := err != nil || resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+	upstreamBreaker.Record(failed)
+
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		requestErrors.Inc(resource)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		cancel()
+		return nil, fmt.Errorf("%s request timed out after %s: %w", resource, upstreamTimeout, err)
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err //nolint:wrapcheck // callers add the context
+	}
+
+	resp.Body = cancelOnClose{resp.Body, cancel}
+
+	return resp, nil
+}
+
+// a response body that releases the request's deadline when closed, the body is read within it
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	defer b.cancel()
+
+	return b.ReadCloser.Close() //nolint:wrapcheck // the body's own error
+}
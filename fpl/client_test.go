@@ -0,0 +1,72 @@
+package fpl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBootstrapInjectedClient(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	// a TLS server only accepts its own client, so a successful fetch shows the injected client was used
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(ContentType, ApplicationJSON)
+		fmt.Fprintln(w, mockBootstrap)
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, CacheTTL: defaultBootstrapTTL, HTTPClient: ts.Client()})
+	defer Configure(Settings{BaseURL: "https://fantasy.premierleague.com/api", CacheTTL: defaultBootstrapTTL})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Bootstrap(w, httptest.NewRequest(http.MethodGet, "/fpl/bootstrap", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK {
+		t.Errorf("Bootstrap() with the TLS server's client status = %d, want %d\n%s", w.Code, http.StatusOK, w.Body.String())
+	}
+}
+
+func TestTimedGetAbandoned(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	// a hung upstream, it only returns once the outbound request is abandoned
+	ts := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	defer ts.Close()
+
+	Configure(Settings{BaseURL: ts.URL, CacheTTL: defaultBootstrapTTL, UpstreamTimeout: 50 * time.Millisecond, BreakerThreshold: 1})
+	defer Configure(Settings{BaseURL: "https://fantasy.premierleague.com/api", CacheTTL: defaultBootstrapTTL})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel() // the client disconnected
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		wantErr     string
+		wantBreaker bool // the circuit opens after the failure
+	}{
+		{"client disconnected", cancelled, "bootstrap request cancelled", false},
+		{"upstream timeout", context.Background(), "bootstrap request timed out after 50ms", true},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		_, err := timedGet(test.ctx, "bootstrap", ts.URL)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("timedGet() %s err = %v, want %q", test.name, err, test.wantErr)
+		}
+
+		if got := upstreamBreaker.Allow() != nil; got != test.wantBreaker {
+			t.Errorf("timedGet() %s circuit open = %t, want %t", test.name, got, test.wantBreaker)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package fpl
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mick4711/moh/conditional"
+)
+
+// data version of the gameweek and the manager entries, the response timestamp is excluded so the version only
+// changes when points or ranks change. Entries are sorted as they arrive in any order.
+func dataVersion(leagueResponse LeagueResponse) (string, error) {
+	league := slices.Clone(leagueResponse.League)
+	slices.SortFunc(league, func(a, b ManagerEntry) int { return cmp.Compare(a.ID, b.ID) })
+
+	data, err := json.Marshal(struct {
+		Gameweek   int
+		League     []ManagerEntry
+		Pagination *Pagination
+	}{leagueResponse.Gameweek, league, leagueResponse.Pagination})
+	if err != nil {
+		return "", fmt.Errorf("error computing data version: %w", err)
+	}
+
+	return conditional.DataVersion(data), nil
+}
+
+// strong ETag of a representation of the data version, the negotiated format and the selected fields are part of
+// the tag so the html page, the full json and each trimmed variant have their own validator
+func etag(version, format string, fields []string) string {
+	fields = slices.Clone(fields)
+	slices.Sort(fields)
+
+	representation := fmt.Sprintf("%s %s %s", version, format, strings.Join(slices.Compact(fields), ","))
+
+	return fmt.Sprintf(`"%s"`, conditional.DataVersion([]byte(representation)))
+}
+
+// when each url's response last changed, its Last-Modified time
+var responseChanges = conditional.NewChanges(maxTrackedResponses)
+
+// urls whose Last-Modified times are tracked
+const maxTrackedResponses = 1000
+
+// write a json body with an ETag hashed from it, its data version and a Last-Modified time of when the url's response
+// last changed, 304 Not Modified without a body when the client's copy is current
+func writeConditionalJSON(w http.ResponseWriter, r *http.Request, body []byte) {
+	tag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	modified := responseChanges.Since(r.URL.RequestURI(), tag, clk.Now())
+
+	w.Header().Set("ETag", tag)
+	conditional.SetDataVersion(w.Header(), body)
+	conditional.SetLastModified(w.Header(), modified)
+
+	if conditional.NotModified(r, tag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(body); err != nil {
+		slog.WarnContext(r.Context(), "error writing json response", "err", err)
+	}
+}
@@ -0,0 +1,150 @@
+package fpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mick4711/moh/conditional"
+)
+
+func TestPointsConditionalGet(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := setTestServer()
+	defer ts.Close()
+
+	fplURL = ts.URL + EntryPlaceholder
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+
+		w := httptest.NewRecorder()
+		Points(w, req)
+
+		return w
+	}
+
+	// ACT and ASSERT - unchanged data ///////////////////////////////////////////////////////////////
+	first := get("")
+	tag := first.Header().Get("ETag")
+
+	if first.Code != http.StatusOK || tag == "" {
+		t.Fatalf("Points() status = %d etag = %q, want 200 with an etag", first.Code, tag)
+	}
+
+	if second := get(tag); second.Code != http.StatusNotModified || second.Body.Len() != 0 {
+		t.Errorf("Points() matching If-None-Match status = %d body = %q, want 304 with no body", second.Code, second.Body)
+	}
+
+	// ACT and ASSERT - changed data ///////////////////////////////////////////////////////////////
+	original := mockFplResponse[0].SummaryEventPoints
+	mockFplResponse[0].SummaryEventPoints++
+
+	defer func() { mockFplResponse[0].SummaryEventPoints = original }()
+
+	lastPoints.Clear() // the cached entries expire
+
+	if changed := get(tag); changed.Code != http.StatusOK || changed.Header().Get("ETag") == tag {
+		t.Errorf("Points() changed data status = %d etag = %q, want 200 with a new etag", changed.Code, changed.Header().Get("ETag"))
+	}
+}
+
+func TestPointsDataVersion(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := setTestServer()
+	defer ts.Close()
+
+	fplURL = ts.URL + EntryPlaceholder
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	get := func() (string, string) {
+		w := httptest.NewRecorder()
+		Points(w, httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody))
+
+		var got LeagueResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+
+		return w.Header().Get(conditional.DataVersionHeader), got.DataVersion
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	firstHeader, firstVersion := get()
+	againHeader, _ := get()
+
+	original := mockFplResponse[0].SummaryOverallPoints
+	mockFplResponse[0].SummaryOverallPoints++
+
+	defer func() { mockFplResponse[0].SummaryOverallPoints = original }()
+
+	lastPoints.Clear() // the cached entries expire
+
+	changedHeader, changedVersion := get()
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if firstHeader == "" || firstHeader != firstVersion {
+		t.Fatalf("Points() %s = %q, dataVersion = %q, want matching versions", conditional.DataVersionHeader, firstHeader, firstVersion)
+	}
+
+	if againHeader != firstHeader {
+		t.Errorf("Points() unchanged data version = %q, want stable %q", againHeader, firstHeader)
+	}
+
+	if changedHeader == firstHeader || changedVersion == firstVersion {
+		t.Errorf("Points() changed data version = %q, want it to differ from %q", changedVersion, firstVersion)
+	}
+}
+
+func TestPointsRepresentationETags(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := setTestServer()
+	defer ts.Close()
+
+	fplURL = ts.URL + EntryPlaceholder
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	get := func(url, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, http.NoBody)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+
+		w := httptest.NewRecorder()
+		Points(w, req)
+
+		return w
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	jsonTag := get("/fpl", "").Header().Get("ETag")
+	htmlTag := get("/fpl?format=html", "").Header().Get("ETag")
+	trimmedTag := get("/fpl?fields=rank,name", "").Header().Get("ETag")
+	reorderedTag := get("/fpl?fields=name,rank,name", "").Header().Get("ETag")
+	crossed := get("/fpl?format=html", jsonTag)
+	notModified := get("/fpl?format=html", htmlTag)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if jsonTag == htmlTag || jsonTag == trimmedTag || htmlTag == trimmedTag {
+		t.Errorf("Points() etags json %s html %s fields %s, want one per representation", jsonTag, htmlTag, trimmedTag)
+	}
+
+	if trimmedTag != reorderedTag {
+		t.Errorf("Points() etags ?fields=rank,name %s ?fields=name,rank,name %s, want the same field list to match", trimmedTag, reorderedTag)
+	}
+
+	if crossed.Code != http.StatusOK || notModified.Code != http.StatusNotModified {
+		t.Errorf("Points() html with the json etag status = %d, with its own etag %d, want 200 and 304", crossed.Code, notModified.Code)
+	}
+
+	if vary := notModified.Header().Get("Vary"); vary != "Accept" {
+		t.Errorf("Points() 304 Vary = %q, want Accept", vary)
+	}
+}
@@ -0,0 +1,89 @@
+package fpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// json names of the ManagerEntry fields that can be selected
+var managerFields = []string{"id", "name", "team", "points", "rank", "gw_points", "gw_rank", "link"}
+
+// league response with each manager entry trimmed to the selected fields
+type trimmedLeagueResponse struct {
+	LeagueResponse
+	League []map[string]json.RawMessage `json:"league"`
+}
+
+// manager entry fields returned without ?fields=, nil for all fields, set by Configure
+var defaultFields []string
+
+// manager entry fields to return from ?fields=rank,name,points, or FPL_FIELDS when the parameter is absent.
+// nil means all fields, an unknown field in the parameter is an error
+func selectedFields(r *http.Request) ([]string, error) {
+	if r.URL.Query().Has("fields") {
+		return parseFields(r.URL.Query().Get("fields"))
+	}
+
+	return defaultFields, nil
+}
+
+// the configured FPL_FIELDS, an unknown field is logged and all fields are returned
+func configuredFields(fields []string) []string {
+	selected, err := parseFields(strings.Join(fields, ","))
+	if err != nil {
+		slog.Warn("invalid FPL_FIELDS ignored", "err", err)
+		return nil
+	}
+
+	return selected
+}
+
+// parse a comma separated list of manager entry field names, empty means all fields
+func parseFields(value string) ([]string, error) {
+	var fields []string
+
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if !slices.Contains(managerFields, field) {
+			return nil, fmt.Errorf("invalid field %q, must be one of %s", field, strings.Join(managerFields, ","))
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// the league response with only the selected fields in each manager entry
+func trimFields(leagueResponse LeagueResponse, fields []string) (trimmedLeagueResponse, error) {
+	trimmed := trimmedLeagueResponse{LeagueResponse: leagueResponse, League: make([]map[string]json.RawMessage, 0, len(leagueResponse.League))}
+
+	for _, entry := range leagueResponse.League {
+		body, err := json.Marshal(entry)
+		if err != nil {
+			return trimmedLeagueResponse{}, err
+		}
+
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(body, &all); err != nil {
+			return trimmedLeagueResponse{}, err
+		}
+
+		selected := make(map[string]json.RawMessage, len(fields))
+		for _, field := range fields {
+			selected[field] = all[field]
+		}
+
+		trimmed.League = append(trimmed.League, selected)
+	}
+
+	return trimmed, nil
+}
@@ -0,0 +1,86 @@
+package fpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestPointsFields(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := setTestServer()
+	defer ts.Close()
+
+	fplURL = ts.URL + EntryPlaceholder
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Points(w, httptest.NewRequest(http.MethodGet, "/fpl?fields=rank,name,points", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var got struct {
+		Gameweek int                          `json:"gameweek"`
+		League   []map[string]json.RawMessage `json:"league"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Points() response %q, err = %v", w.Body.String(), err)
+	}
+
+	if got.Gameweek != Gameweek || len(got.League) != 2 {
+		t.Fatalf("Points() gameweek = %d league = %v, want gameweek %d and 2 managers", got.Gameweek, got.League, Gameweek)
+	}
+
+	for _, entry := range got.League {
+		fields := make([]string, 0, len(entry))
+		for field := range entry {
+			fields = append(fields, field)
+		}
+
+		slices.Sort(fields)
+
+		if want := []string{"name", "points", "rank"}; !slices.Equal(fields, want) {
+			t.Errorf("Points() entry fields = %v, want %v", fields, want)
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		value    string
+		want     []string
+		hasError bool
+	}{
+		{value: "", want: nil},
+		{value: "rank, gw_points", want: []string{"rank", "gw_points"}},
+		{value: "rank,salary", hasError: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseFields(test.value)
+		if !slices.Equal(got, test.want) || (err != nil) != test.hasError {
+			t.Errorf("parseFields(%q) = %v err = %v, want %v hasError %v", test.value, got, err, test.want, test.hasError)
+		}
+	}
+}
+
+func TestConfiguredFields(t *testing.T) {
+	tests := []struct {
+		fields []string
+		want   []string
+	}{
+		{nil, nil},
+		{[]string{"rank", "name"}, []string{"rank", "name"}},
+		{[]string{"rank", "salary"}, nil},
+	}
+
+	for _, test := range tests {
+		if got := configuredFields(test.fields); !slices.Equal(got, test.want) {
+			t.Errorf("configuredFields(%q) = %v, want %v", test.fields, got, test.want)
+		}
+	}
+}
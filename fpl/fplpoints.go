@@ -1,16 +1,27 @@
-// reads a list of comma separated FPL manager ids from environment variable "managers"
+// takes a list of comma separated FPL manager ids, configured from environment variable "managers",
 // and retrieves the current gameweek scores for the managers.
 package fpl
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/mick4711/moh/breaker"
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/conditional"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/metrics"
+	"github.com/mick4711/moh/negotiate"
+	"github.com/mick4711/moh/stale"
 )
 
 type Response struct { // fields retrieved from FPL API
@@ -40,58 +51,213 @@ type ManagerEntryResult struct { // result wrapper for ManagerEntry, Gameweek, E
 	Error             error
 }
 type LeagueResponse struct { // response with array of manager entries
-	Gameweek  int            `json:"gameweek"`
-	Timestamp string         `json:"timestamp"`
-	League    []ManagerEntry `json:"league"`
+	Gameweek   int            `json:"gameweek"`
+	Timestamp  string         `json:"timestamp"`
+	League     []ManagerEntry `json:"league"`
+	Pagination *Pagination    `json:"pagination,omitempty"`
+
+	DataVersion string `json:"dataVersion,omitempty"` // hash of the entries, unchanged while points and ranks are
+
+	Stale *stale.Data `json:"stale,omitempty"` // set when the last good entries are served after a failed fetch
+
+	FetchedAt time.Time `json:"-"` // when the entries were fetched from the FPL api, for the data export
 }
+type Pagination struct { // page of the managers list, only set when a page is requested
+	Total    int  `json:"total"`
+	Page     int  `json:"page"`
+	PageSize int  `json:"pageSize"`
+	HasNext  bool `json:"hasNext"`
+}
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
 
 var fplURL = "https://fantasy.premierleague.com/api/entry/%v/"
 
+// Settings configures the FPL api source and its caches
+type Settings struct {
+	BaseURL    string
+	CacheTTL   time.Duration // bootstrap-static cache lifetime
+	PointsTTL  time.Duration // cache lifetime of the managers' gameweek entries, defaultPointsTTL when 0
+	Clock      clock.Clock   // the current time, the system clock when nil
+	HTTPClient HTTPClient    // sends the FPL api requests, http.DefaultClient when nil
+
+	BreakerThreshold int           // consecutive failed requests that open the circuit, breaker.DefaultThreshold when 0
+	BreakerCooldown  time.Duration // time the circuit stays open before a trial request, breaker.DefaultCooldown when 0
+
+	UpstreamTimeout time.Duration // deadline for each FPL api request, DefaultUpstreamTimeout when 0
+
+	WarmMaxAge time.Duration // the managers' points refreshed in the background are served for up to this long
+
+	Metrics *metrics.Registry // registry for the request and cache metrics, unregistered when nil
+
+	Managers          string   // comma separated manager ids of the league, /fpl fails without ?league= when empty
+	Anonymize         bool     // replace the names with placeholders unless the request has NamesToken
+	NamesToken        string   // bearer token for the real names while anonymized, none when empty
+	Fields            []string // manager entry fields returned without ?fields=, all of them when empty
+	BootstrapSections []string // bootstrap-static sections returned, defaultBootstrapSections when empty
+}
+
+// the current time, set by Configure
+var clk clock.Clock = clock.Real{}
+
+// the comma separated manager ids of the league, set by Configure
+var configuredManagers string
+
+// Configure applies settings, call before serving requests
+func Configure(settings Settings) {
+	clk = settings.Clock
+	if clk == nil {
+		clk = clock.Real{}
+	}
+
+	httpClient = settings.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	upstreamTimeout = settings.UpstreamTimeout
+	if upstreamTimeout == 0 {
+		upstreamTimeout = DefaultUpstreamTimeout
+	}
+
+	upstreamBreaker = breaker.New(settings.BreakerThreshold, settings.BreakerCooldown, clk)
+	warmMaxAge = settings.WarmMaxAge
+	configuredManagers = settings.Managers
+	anonymizeNames, namesToken = settings.Anonymize, settings.NamesToken
+	defaultFields = configuredFields(settings.Fields)
+
+	bootstrapSections = defaultBootstrapSections
+	if len(settings.BootstrapSections) > 0 {
+		bootstrapSections = settings.BootstrapSections
+	}
+	setRefreshed(refreshedLeague{})
+
+	fplURL = settings.BaseURL + "/entry/%v/"
+	leagueURL = settings.BaseURL + "/leagues-classic/%d/standings/"
+	bootstrapURL = settings.BaseURL + "/bootstrap-static/"
+	liveURL = settings.BaseURL + "/event/%d/live/"
+	picksURL = settings.BaseURL + "/entry/%v/event/%d/picks/"
+	historyURL = settings.BaseURL + "/entry/%v/history/"
+	bootstrapCache = cache.NewWithClock(settings.CacheTTL, bootstrapCacheEntries, clk)
+	leagueCache = cache.NewWithClock(leagueTTL, maxCachedLeagues, clk)
+	pointsTTL := settings.PointsTTL
+	if pointsTTL == 0 {
+		pointsTTL = defaultPointsTTL
+	}
+
+	lastPoints = cache.NewWithClock(pointsTTL, maxCachedLeagues, clk)
+	liveCache = cache.NewWithClock(liveTTL, 2, clk)
+	requestDuration = newRequestDuration(settings.Metrics)
+	requestErrors = newRequestErrors(settings.Metrics)
+	registerCacheMetrics(settings.Metrics)
+}
+
+// CacheEntries describes the cached bootstrap data, league managers, live points and the managers' last good points
+// keyed by cache name
+func CacheEntries() map[string][]cache.Entry {
+	return map[string][]cache.Entry{"bootstrap": bootstrapCache.Entries(), "league": leagueCache.Entries(), "live": liveCache.Entries(),
+		"points": lastPoints.Entries()}
+}
+
+// ClearCache empties the caches and drops the background refreshed league so the next requests fetch from FPL
+func ClearCache() {
+	bootstrapCache.Clear()
+	leagueCache.Clear()
+	liveCache.Clear()
+	lastPoints.Clear()
+	setRefreshed(refreshedLeague{})
+}
+
 // var fplURL = "http://MIKE-DEV.local:3001/api/entry/%v/"
 // var fplURL = "http://MIKE-ALT.local:3001/api/entry/%v/"
 
 func Points(w http.ResponseWriter, r *http.Request) {
-	// Set CORS headers for the preflight request
-	if r.Method == http.MethodOptions {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		w.Header().Set("Access-Control-Max-Age", "3600")
-		w.WriteHeader(http.StatusNoContent)
+	managers, ok := requestedManagers(w, r)
+	if !ok {
+		return
+	}
 
+	// select the requested page of the manager ids
+	page, pageSize, paginated, err := parsePagination(r)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadRequest, err)
 		return
 	}
 
-	// Set CORS headers for the main request.
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	fields, err := selectedFields(r)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadRequest, err)
+		return
+	}
 
-	managers, ok := os.LookupEnv("managers")
-	if !ok {
-		errMsg := "Environment variable -managers- can not be read"
-		log.Printf("\n*********** FATAL ERROR *********************** [%s]  **************\n", errMsg)
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprint(w, errMsg)
+	pageList := managers
 
-		return
+	var pagination Pagination
+	if paginated {
+		pageList, pagination = pageManagers(managers, page, pageSize)
 	}
 
 	// retrieve and filter data from FPL for the list of manager ids
-	leagueResponse, err := getData(managers)
+	leagueResponse, err := getData(r.Context(), pageList)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	if paginated {
+		leagueResponse.Pagination = &pagination
+	}
+
+	markStale(w, leagueResponse)
+
+	if anonymizeRequested(r) {
+		anonymize(leagueResponse.League, managers)
+	}
+
+	// conditional get, the polling app only needs a body when points change
+	version, err := dataVersion(leagueResponse)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "%+v\n", err)
+		errorpage.JSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	format := negotiate.Format(w, r, negotiate.JSON, negotiate.HTML)
+	tag := etag(version, format, fields)
+	modified := responseChanges.Since(r.URL.RequestURI(), tag, clk.Now())
+
+	w.Header().Set("ETag", tag)
+	conditional.SetLastModified(w.Header(), modified)
+
+	leagueResponse.DataVersion = version
+	w.Header().Set(conditional.DataVersionHeader, leagueResponse.DataVersion)
+
+	if conditional.NotModified(r, tag, modified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
+	if format == negotiate.HTML {
+		writeLeagueHTML(w, r, leagueResponse)
 		return
 	}
 
 	// convert response to json
 	w.Header().Set("Content-Type", "application/json")
 
-	response, err := json.MarshalIndent(leagueResponse, "", "  ")
-	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		fmt.Fprintf(w, "%+v\n", err)
+	var output any = leagueResponse
+	if len(fields) > 0 {
+		if output, err = trimFields(leagueResponse, fields); err != nil {
+			errorpage.JSON(w, r, http.StatusInternalServerError, err)
+			return
+		}
+	}
 
+	response, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusInternalServerError, err)
 		return
 	}
 
@@ -99,17 +265,115 @@ func Points(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "%+v\n", string(response))
 }
 
-func getData(managers string) (LeagueResponse, error) {
+// the comma separated manager ids for the request, the ?league= classic league's managers or the configured list.
+// Writes the error response and returns false when they can't be read
+func requestedManagers(w http.ResponseWriter, r *http.Request) (string, bool) {
+	league, err := parseLeague(r)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadRequest, err)
+		return "", false
+	}
+
+	managers := configuredManagers
+	if league > 0 {
+		// the managers of the requested league replace the configured list
+		if managers, err = leagueManagers(r.Context(), league); err != nil {
+			status := http.StatusBadGateway
+			if errors.Is(err, errLeagueNotFound) {
+				status = http.StatusNotFound
+			}
+
+			errorpage.JSON(w, r, status, err)
+			return "", false
+		}
+	} else if managers == "" {
+		errorpage.JSON(w, r, http.StatusInternalServerError, errors.New("environment variable managers is not set"))
+		return "", false
+	}
+
+	return managers, true
+}
+
+// read page and pageSize query params, paginated is false when neither is present
+func parsePagination(r *http.Request) (page, pageSize int, paginated bool, err error) {
+	query := r.URL.Query()
+	if !query.Has("page") && !query.Has("pageSize") {
+		return 0, 0, false, nil
+	}
+
+	page, pageSize = 1, defaultPageSize
+
+	if query.Has("page") {
+		if page, err = strconv.Atoi(query.Get("page")); err != nil || page < 1 {
+			return 0, 0, false, fmt.Errorf("invalid page %q, must be a number >= 1", query.Get("page"))
+		}
+	}
+
+	if query.Has("pageSize") {
+		if pageSize, err = strconv.Atoi(query.Get("pageSize")); err != nil || pageSize < 1 || pageSize > maxPageSize {
+			return 0, 0, false, fmt.Errorf("invalid pageSize %q, must be a number from 1 to %d", query.Get("pageSize"), maxPageSize)
+		}
+	}
+
+	return page, pageSize, true, nil
+}
+
+// select a page of the comma separated manager ids, a page past the end is empty
+func pageManagers(managers string, page, pageSize int) (string, Pagination) {
+	managerList := strings.Split(managers, ",")
+	total := len(managerList)
+	start := min((page-1)*pageSize, total)
+	end := min(start+pageSize, total)
+
+	pagination := Pagination{Total: total, Page: page, PageSize: pageSize, HasNext: end < total}
+
+	return strings.Join(managerList[start:end], ","), pagination
+}
+
+// the gameweek entries of the comma separated managers, from the last background refresh when it is warm or else
+// the cache. When the fetch fails the managers' last good entries are served marked stale
+func getData(ctx context.Context, managers string) (LeagueResponse, error) {
+	if leagueResponse, ok := warmLeague(managers); ok {
+		return leagueResponse, nil
+	}
+
+	if leagueResponse, ok := cachedPoints(managers); ok {
+		return leagueResponse, nil
+	}
+
+	leagueResponse, err := fetchData(ctx, managers)
+	if err != nil {
+		if last, ok := lastGoodPoints(managers); ok {
+			slog.WarnContext(ctx, "serving the managers' last good points, fetch failed", "fetched", last.Stale.Fetched, "err", err)
+			return last, nil
+		}
+
+		return LeagueResponse{}, err
+	}
+
+	return leagueResponse, nil
+}
+
+// fetch the gameweek entries of the comma separated managers from the FPL api, the requests still in flight are
+// cancelled when one fails
+func fetchData(ctx context.Context, managers string) (LeagueResponse, error) {
 	// initialise
 	managerList := strings.Split(managers, ",")
-	league := []ManagerEntry{}                        // slice of manager gameweek entries
-	chManagerEntries := make(chan ManagerEntryResult) // channel to gather manager entries
+	if strings.TrimSpace(managers) == "" {
+		managerList = nil // empty page
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	league := []ManagerEntry{}                                          // slice of manager gameweek entries
+	chManagerEntries := make(chan ManagerEntryResult, len(managerList)) // channel to gather manager entries, never blocks a sender
 
 	var gameweek int // var to hold the gameweek value
 
 	// loop thru manager list, fire off goroutines to get entries for each manager, results sent to channels
 	for _, manager := range managerList {
-		go getManagerEntries(strings.TrimSpace(manager), chManagerEntries)
+		go getManagerEntries(ctx, strings.TrimSpace(manager), chManagerEntries)
 	}
 
 	// receive results from channels, set gameweek once and build up league table
@@ -128,19 +392,23 @@ func getData(managers string) (LeagueResponse, error) {
 	}
 
 	// construct response
+	fetchedAt := clk.Now()
 	leagueResponse := LeagueResponse{
 		Gameweek:  gameweek,
-		Timestamp: time.Now().Format("Mon Jan _2 15:04:05 MST 2006"),
+		Timestamp: fetchedAt.Format("Mon Jan _2 15:04:05 MST 2006"),
 		League:    league,
+		FetchedAt: fetchedAt,
 	}
 
+	rememberPoints(managers, leagueResponse)
+
 	return leagueResponse, nil
 }
 
-func getManagerEntries(entry string, chManagerEntries chan<- ManagerEntryResult) {
+func getManagerEntries(ctx context.Context, entry string, chManagerEntries chan<- ManagerEntryResult) {
 	url := fmt.Sprintf(fplURL, entry)
 
-	resp, err := http.Get(url)
+	resp, err := timedGet(ctx, "entry", url)
 	if err != nil {
 		chManagerEntries <- ManagerEntryResult{Error: err}
 		return
@@ -189,3 +457,23 @@ func getManagerEntries(entry string, chManagerEntries chan<- ManagerEntryResult)
 	}
 	chManagerEntries <- managerEntryResult
 }
+
+// League returns the current gameweek entries for every configured manager, for the data export.
+// Names are replaced with placeholders when FPL_ANONYMIZE is set
+func League(ctx context.Context) (LeagueResponse, error) {
+	managers := configuredManagers
+	if managers == "" {
+		return LeagueResponse{}, fmt.Errorf("environment variable -managers- can not be read")
+	}
+
+	leagueResponse, err := getData(ctx, managers)
+	if err != nil {
+		return LeagueResponse{}, err
+	}
+
+	if anonymizeNames {
+		anonymize(leagueResponse.League, managers)
+	}
+
+	return leagueResponse, nil
+}
@@ -1,6 +1,7 @@
 package fpl
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -56,8 +57,10 @@ func TestGetData(t *testing.T) {
 	// overwrite fplURL to use httptest URL
 	fplURL = ts.URL + EntryPlaceholder
 
+	ClearCache() // no managers cached by the earlier tests
+
 	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
-	testResponse, err := getData("1, 2")
+	testResponse, err := getData(context.Background(), "1, 2")
 	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
 	// check err
 	if err != nil {
@@ -174,8 +177,10 @@ func TestFpl404(t *testing.T) {
 	// overwrite fplURL to use httptest URL
 	fplURL = ts.URL + EntryPlaceholder
 
+	ClearCache() // no managers cached by the earlier tests
+
 	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
-	testResponse, err := getData("1, 2")
+	testResponse, err := getData(context.Background(), "1, 2")
 	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
 	// check err
 	if err != nil {
@@ -203,8 +208,10 @@ func TestFpl5XX(t *testing.T) {
 	// overwrite fplURL to use httptest URL
 	fplURL = ts.URL + EntryPlaceholder
 
+	ClearCache() // no last good points from the earlier tests to fall back to
+
 	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
-	_, err := getData("1, 2")
+	_, err := getData(context.Background(), "1, 2")
 
 	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
 	if err == nil {
@@ -213,3 +220,78 @@ func TestFpl5XX(t *testing.T) {
 		t.Errorf(`getData server error (%v), want: "...not OK, Status:..."`, err)
 	}
 }
+
+func TestPageManagers(t *testing.T) {
+	tests := []struct {
+		page, pageSize int
+		want           string
+		wantPagination Pagination
+	}{
+		{1, 2, "1,2", Pagination{Total: 5, Page: 1, PageSize: 2, HasNext: true}},
+		{2, 2, "3,4", Pagination{Total: 5, Page: 2, PageSize: 2, HasNext: true}},
+		{3, 2, "5", Pagination{Total: 5, Page: 3, PageSize: 2, HasNext: false}},
+		{4, 2, "", Pagination{Total: 5, Page: 4, PageSize: 2, HasNext: false}},
+	}
+
+	for _, test := range tests {
+		got, pagination := pageManagers("1,2,3,4,5", test.page, test.pageSize)
+		if got != test.want || pagination != test.wantPagination {
+			t.Errorf("pageManagers(page %d, size %d) = %q %+v, want %q %+v",
+				test.page, test.pageSize, got, pagination, test.want, test.wantPagination)
+		}
+	}
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query     string
+		paginated bool
+		hasError  bool
+	}{
+		{"", false, false},
+		{"?page=2&pageSize=50", true, false},
+		{"?page=2", true, false},
+		{"?page=0", false, true},
+		{"?page=x", false, true},
+		{"?pageSize=101", false, true},
+		{"?pageSize=-1", false, true},
+	}
+
+	for _, test := range tests {
+		_, _, paginated, err := parsePagination(httptest.NewRequest(http.MethodGet, "/fpl"+test.query, http.NoBody))
+		if paginated != test.paginated || (err != nil) != test.hasError {
+			t.Errorf("parsePagination(%q) paginated = %v err = %v, want paginated %v hasError %v",
+				test.query, paginated, err, test.paginated, test.hasError)
+		}
+	}
+}
+
+func TestPointsPage(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := setTestServer()
+	defer ts.Close()
+
+	fplURL = ts.URL + EntryPlaceholder
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Points(w, httptest.NewRequest(http.MethodGet, "/fpl?page=2&pageSize=1", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var got LeagueResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Points() response %q, err = %v", w.Body.String(), err)
+	}
+
+	if len(got.League) != 1 || got.League[0].ID != 2 {
+		t.Errorf("Points() page 2 league = %+v, want only manager 2", got.League)
+	}
+
+	want := Pagination{Total: 2, Page: 2, PageSize: 1, HasNext: false}
+	if got.Pagination == nil || *got.Pagination != want {
+		t.Errorf("Points() pagination = %+v, want %+v", got.Pagination, want)
+	}
+}
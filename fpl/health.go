@@ -0,0 +1,59 @@
+package fpl
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status reports whether the most recent FPL api request succeeded
+type Status struct {
+	Healthy     bool   `json:"healthy"`
+	LastFetch   string `json:"lastFetch,omitempty"`   // RFC 3339 time of the most recent request, empty before the first
+	LastSuccess string `json:"lastSuccess,omitempty"` // RFC 3339 time of the most recent successful request
+	LastError   string `json:"lastError,omitempty"`
+	Circuit     string `json:"circuit"` // circuit breaker state, closed, open or half-open
+}
+
+// outcome of the most recent FPL api request, safe for concurrent use
+var lastFetch struct {
+	sync.Mutex
+	at      time.Time
+	success time.Time
+	err     string
+}
+
+// record the outcome of an FPL api request, a missing manager (404) is a successful request
+func recordFetch(resp *http.Response, err error) {
+	lastFetch.Lock()
+	defer lastFetch.Unlock()
+
+	lastFetch.at = clk.Now()
+
+	switch {
+	case err != nil:
+		lastFetch.err = err.Error()
+	case resp.StatusCode >= http.StatusInternalServerError:
+		lastFetch.err = resp.Status
+	default:
+		lastFetch.err, lastFetch.success = "", lastFetch.at
+	}
+}
+
+// UpstreamStatus reports the outcome of the most recent FPL api request, unhealthy before the first request
+func UpstreamStatus() Status {
+	lastFetch.Lock()
+	defer lastFetch.Unlock()
+
+	status := Status{Circuit: upstreamBreaker.State()}
+	if lastFetch.at.IsZero() {
+		return status
+	}
+
+	status.Healthy, status.LastFetch, status.LastError = lastFetch.err == "", lastFetch.at.Format(time.RFC3339), lastFetch.err
+	if !lastFetch.success.IsZero() {
+		status.LastSuccess = lastFetch.success.Format(time.RFC3339)
+	}
+
+	return status
+}
@@ -0,0 +1,72 @@
+package fpl
+
+import (
+	"bytes"
+	"cmp"
+	"embed"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/stale"
+)
+
+const leagueTemplate = "LeagueTemplate.html"
+
+//go:embed LeagueTemplate.html
+var templateFS embed.FS
+
+var leagueTemplates = template.Must(template.ParseFS(templateFS, leagueTemplate))
+
+// a manager in the html league table with their position in it
+type leagueRow struct {
+	Position int
+	ManagerEntry
+}
+
+// data passed to the league template
+type leaguePage struct {
+	Gameweek  int
+	Timestamp string
+	Rows      []leagueRow
+	Stale     *stale.Data // the banner when the last good entries are served after a failed fetch
+}
+
+// the managers ordered by total points, most first, then by gameweek points
+func leagueRows(league []ManagerEntry) []leagueRow {
+	sorted := slices.Clone(league)
+	slices.SortStableFunc(sorted, func(a, b ManagerEntry) int {
+		if c := cmp.Compare(b.Points, a.Points); c != 0 {
+			return c
+		}
+
+		return cmp.Compare(b.GwPoints, a.GwPoints)
+	})
+
+	rows := make([]leagueRow, 0, len(sorted))
+	for i, entry := range sorted {
+		rows = append(rows, leagueRow{Position: i + 1, ManagerEntry: entry})
+	}
+
+	return rows
+}
+
+// write the league as an html table with each manager's position, gameweek points and total points
+func writeLeagueHTML(w http.ResponseWriter, r *http.Request, leagueResponse LeagueResponse) {
+	page := leaguePage{Gameweek: leagueResponse.Gameweek, Timestamp: leagueResponse.Timestamp, Rows: leagueRows(leagueResponse.League),
+		Stale: leagueResponse.Stale}
+
+	var body bytes.Buffer
+	if err := leagueTemplates.ExecuteTemplate(&body, leagueTemplate, page); err != nil {
+		errorpage.Write(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if _, err := body.WriteTo(w); err != nil {
+		slog.WarnContext(r.Context(), "error writing league page", "err", err)
+	}
+}
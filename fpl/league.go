@@ -0,0 +1,94 @@
+package fpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+)
+
+const (
+	leagueTTL        = 10 * time.Minute // league membership rarely changes, the managers' points are always fetched fresh
+	maxCachedLeagues = 100
+)
+
+// FPL classic league standings, %d is the league id
+var leagueURL = "https://fantasy.premierleague.com/api/leagues-classic/%d/standings/"
+
+// manager ids of each requested league keyed by league id
+var leagueCache = cache.New(leagueTTL, maxCachedLeagues)
+
+var errLeagueNotFound = errors.New("league not found")
+
+// fields retrieved from the classic league standings
+type leagueStandingsResponse struct {
+	Standings struct {
+		Results []struct {
+			Entry int `json:"entry"`
+		} `json:"results"`
+	} `json:"standings"`
+}
+
+// read the classic league id from the /fpl/{leagueID} path or ?league=, 0 when absent so the configured managers are used
+func parseLeague(r *http.Request) (int, error) {
+	value := r.PathValue("leagueID")
+	if value == "" {
+		query := r.URL.Query()
+		if !query.Has("league") {
+			return 0, nil
+		}
+
+		value = query.Get("league")
+	}
+
+	league, err := strconv.Atoi(value)
+	if err != nil || league < 1 {
+		return 0, fmt.Errorf("invalid league %q, must be a number >= 1", value)
+	}
+
+	return league, nil
+}
+
+// comma separated manager ids in a classic league, from the first page of its standings (50 managers),
+// cached per league
+func leagueManagers(ctx context.Context, league int) (string, error) {
+	key := strconv.Itoa(league)
+	if managers, ok := leagueCache.Get(key); ok {
+		return string(managers), nil
+	}
+
+	resp, err := timedGet(ctx, "league", fmt.Sprintf(leagueURL, league))
+	if err != nil {
+		return "", fmt.Errorf("error requesting league %d: %w", league, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("league ID %d: %w", league, errLeagueNotFound)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("get league ID %d not OK, Status: %v", league, resp.Status)
+	}
+
+	var standings leagueStandingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&standings); err != nil {
+		return "", fmt.Errorf("error decoding league %d standings: %w", league, err)
+	}
+
+	entries := make([]string, 0, len(standings.Standings.Results))
+	for _, result := range standings.Standings.Results {
+		entries = append(entries, strconv.Itoa(result.Entry))
+	}
+
+	managers := strings.Join(entries, ",")
+	leagueCache.Set(key, []byte(managers))
+
+	return managers, nil
+}
@@ -0,0 +1,188 @@
+package fpl
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPointsLeague(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	entries := setTestServer()
+	defer entries.Close()
+
+	fplURL = entries.URL + EntryPlaceholder
+
+	var requested []string
+	leagues := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+
+		_, _ = w.Write([]byte(`{"standings": {"results": [{"entry": 2}]}}`)) //nolint:errcheck // test server
+	}))
+	defer leagues.Close()
+
+	defer func(url string) { leagueURL = url }(leagueURL)
+	leagueURL = leagues.URL + "/leagues-classic/%d/standings/"
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Points(w, httptest.NewRequest(http.MethodGet, "/fpl?league=314159", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if len(requested) != 1 || requested[0] != "/leagues-classic/314159/standings/" {
+		t.Errorf("Points() requested %q, want the standings of league 314159", requested)
+	}
+
+	var got LeagueResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Points() response %q, err = %v", w.Body.String(), err)
+	}
+
+	if len(got.League) != 1 || got.League[0].ID != 2 {
+		t.Errorf("Points() league = %+v, want only the league's manager 2", got.League)
+	}
+}
+
+func TestPointsLeagueCachedAndNotFound(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	entries := setTestServer()
+	defer entries.Close()
+
+	fplURL = entries.URL + EntryPlaceholder
+
+	requests := 0
+	leagues := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		if r.URL.Path != "/leagues-classic/271828/standings/" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"standings": {"results": [{"entry": 1}, {"entry": 2}]}}`)) //nolint:errcheck // test server
+	}))
+	defer leagues.Close()
+
+	defer func(url string) { leagueURL = url }(leagueURL)
+	leagueURL = leagues.URL + "/leagues-classic/%d/standings/"
+
+	tests := []struct {
+		url          string
+		wantStatus   int
+		wantRequests int
+	}{
+		{"/fpl?league=271828", http.StatusOK, 1},
+		{"/fpl?league=271828", http.StatusOK, 1},
+		{"/fpl?league=999999", http.StatusNotFound, 2},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Points(w, httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus || requests != test.wantRequests {
+			t.Errorf("Points(%s) status = %d after %d league requests, want %d after %d", test.url, w.Code, requests, test.wantStatus, test.wantRequests)
+		}
+	}
+}
+
+func TestPointsLeagueHTML(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	entries := setTestServer()
+	defer entries.Close()
+
+	fplURL = entries.URL + EntryPlaceholder
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	req := httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Points(w, req)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Points() status = %d content type %q, want 200 html", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	// manager 2 has the most total points so is ranked first
+	first, second := strings.Index(body, "first2 last2"), strings.Index(body, "first1 last1")
+	if first < 0 || second < 0 || first > second {
+		t.Errorf("Points() html = %s, want manager 2 ranked above manager 1", body)
+	}
+
+	for _, want := range []string{"<td>1</td>", "<td>155</td>", "<td>177</td>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Points() html doesn't contain %q", want)
+		}
+	}
+}
+
+func TestParseLeague(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    int
+		wantErr bool
+	}{
+		{"/fpl", 0, false},
+		{"/fpl?league=314159", 314159, false},
+		{"/fpl?league=abc", 0, true},
+		{"/fpl?league=-5", 0, true},
+		{"/fpl?league=0", 0, true},
+		{"/fpl?league=", 0, true},
+	}
+
+	for _, test := range tests {
+		got, err := parseLeague(httptest.NewRequest(http.MethodGet, test.url, http.NoBody))
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("parseLeague(%s) = %d, %v, want %d, error %v", test.url, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestParseLeaguePath(t *testing.T) {
+	tests := []struct {
+		leagueID string
+		want     int
+		wantErr  bool
+	}{
+		{"314159", 314159, false},
+		{"abc", 0, true},
+		{"0", 0, true},
+	}
+
+	for _, test := range tests {
+		// the path parameter wins over ?league=
+		req := httptest.NewRequest(http.MethodGet, "/fpl/"+test.leagueID+"?league=271828", http.NoBody)
+		req.SetPathValue("leagueID", test.leagueID)
+
+		got, err := parseLeague(req)
+		if got != test.want || (err != nil) != test.wantErr {
+			t.Errorf("parseLeague(/fpl/%s) = %d, %v, want %d, error %v", test.leagueID, got, err, test.want, test.wantErr)
+		}
+	}
+}
+
+func TestPointsInvalidLeague(t *testing.T) {
+	w := httptest.NewRecorder()
+	Points(w, httptest.NewRequest(http.MethodGet, "/fpl?league=-1", http.NoBody))
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Points() invalid league status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+
+	if !strings.Contains(w.Body.String(), `"error":"invalid league \"-1\", must be a number \u003e= 1"`) {
+		t.Errorf("Points() invalid league body = %s, want the json error", w.Body)
+	}
+}
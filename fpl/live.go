@@ -0,0 +1,280 @@
+package fpl
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/errorpage"
+)
+
+// live event data is refetched at most this often however often the app polls
+const liveTTL = 30 * time.Second
+
+// bonus points for the first, second and third highest bonus points system (bps) scores in a fixture
+var bonusForRank = map[int]int{1: 3, 2: 2, 3: 1}
+
+var (
+	liveURL   = "https://fantasy.premierleague.com/api/event/%d/live/"           // %d is the gameweek
+	picksURL  = "https://fantasy.premierleague.com/api/entry/%v/event/%d/picks/" // manager id and gameweek
+	liveCache = cache.New(liveTTL, 2)
+)
+
+// fields retrieved from the live event data, each player's stats for the gameweek so far
+type liveEventResponse struct {
+	Elements []liveElement `json:"elements"`
+}
+
+type liveElement struct {
+	ID    int `json:"id"`
+	Stats struct {
+		TotalPoints int `json:"total_points"`
+	} `json:"stats"`
+	Explain []struct { // per fixture breakdown, two fixtures in a double gameweek
+		Fixture int `json:"fixture"`
+		Stats   []struct {
+			Identifier string `json:"identifier"`
+			Value      int    `json:"value"`
+		} `json:"stats"`
+	} `json:"explain"`
+}
+
+// fields retrieved from a manager's picks for the gameweek
+type picksResponse struct {
+	ActiveChip   string `json:"active_chip"`
+	EntryHistory struct {
+		EventTransfers     int `json:"event_transfers"`
+		EventTransfersCost int `json:"event_transfers_cost"`
+	} `json:"entry_history"`
+	Picks []struct {
+		Element       int  `json:"element"`
+		Multiplier    int  `json:"multiplier"` // 0 on the bench, 2 for the captain, 3 with triple captain
+		IsCaptain     bool `json:"is_captain"`
+		IsViceCaptain bool `json:"is_vice_captain"`
+	} `json:"picks"`
+}
+
+// A LiveManager is a manager's provisional score for the gameweek in progress
+type LiveManager struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Team         string `json:"team"`
+	Points       int    `json:"points"`       // provisional gameweek points, projected bonus included and hits deducted
+	Bonus        int    `json:"bonus"`        // projected bonus points in Points, not yet awarded by FPL
+	TransferCost int    `json:"transferCost"` // points deducted for extra transfers
+}
+
+// A LiveResponse is the league's provisional gameweek scores, highest first
+type LiveResponse struct {
+	Gameweek    int           `json:"gameweek"`
+	LastUpdated string        `json:"lastUpdated"` // RFC 3339 time the scores were computed
+	Managers    []LiveManager `json:"managers"`
+}
+
+// Live writes provisional points for the current gameweek for each manager in the league as json, from the
+// live player scores with bonus points projected from bps for fixtures where they haven't been awarded yet
+func Live(w http.ResponseWriter, r *http.Request) {
+	managers, ok := requestedManagers(w, r)
+	if !ok {
+		return
+	}
+
+	leagueResponse, err := getData(r.Context(), managers)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadGateway, err)
+		return
+	}
+
+	markStale(w, leagueResponse)
+
+	if leagueResponse.Gameweek < 1 {
+		errorpage.JSON(w, r, http.StatusNotFound, errors.New("no gameweek in progress"))
+		return
+	}
+
+	liveResponse, err := liveScores(r.Context(), leagueResponse)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadGateway, err)
+		return
+	}
+
+	response, err := json.MarshalIndent(liveResponse, "", "  ")
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(liveTTL.Seconds())))
+
+	writeConditionalJSON(w, r, append(response, '\n'))
+}
+
+// the provisional scores of the league's managers for its gameweek, the picks are fetched concurrently by a bounded
+// pool of workers
+func liveScores(ctx context.Context, leagueResponse LeagueResponse) (LiveResponse, error) {
+	gameweek := leagueResponse.Gameweek
+
+	elements, err := getLiveEvent(ctx, gameweek)
+	if err != nil {
+		return LiveResponse{}, err
+	}
+
+	points := make(map[int]int, len(elements))
+	for _, element := range elements {
+		points[element.ID] = element.Stats.TotalPoints
+	}
+
+	bonus := projectedBonus(elements)
+
+	liveManagers, err := fanOut(knownManagers(leagueResponse.League), summaryWorkers, func(entry ManagerEntry) (LiveManager, error) {
+		picks, err := getPicks(ctx, entry.ID, gameweek)
+		if err != nil {
+			return LiveManager{}, err
+		}
+
+		manager := LiveManager{ID: entry.ID, Name: entry.Name, Team: entry.Team, TransferCost: picks.EntryHistory.EventTransfersCost}
+		for _, pick := range picks.Picks {
+			manager.Points += pick.Multiplier * (points[pick.Element] + bonus[pick.Element])
+			manager.Bonus += pick.Multiplier * bonus[pick.Element]
+		}
+
+		manager.Points -= manager.TransferCost
+
+		return manager, nil
+	})
+	if err != nil {
+		return LiveResponse{}, err
+	}
+
+	slices.SortFunc(liveManagers, func(a, b LiveManager) int {
+		return cmp.Or(cmp.Compare(b.Points, a.Points), cmp.Compare(a.ID, b.ID))
+	})
+
+	return LiveResponse{Gameweek: gameweek, LastUpdated: clk.Now().UTC().Format(time.RFC3339), Managers: liveManagers}, nil
+}
+
+// the league's managers without those FPL doesn't know, they have no picks
+func knownManagers(league []ManagerEntry) []ManagerEntry {
+	return slices.DeleteFunc(slices.Clone(league), func(entry ManagerEntry) bool { return entry.ID == 0 })
+}
+
+// projected bonus points keyed by player id for the fixtures where no bonus has been awarded yet. The three
+// highest bps scores in a fixture of players who have played get 3, 2 and 1, tied players share the higher award
+// and the next player drops a place, as FPL awards them
+func projectedBonus(elements []liveElement) map[int]int {
+	type score struct {
+		element, bps int
+	}
+
+	fixtures := make(map[int][]score)
+	awarded := make(map[int]bool)
+
+	for _, element := range elements {
+		for _, fixture := range element.Explain {
+			var bps, minutes int
+
+			for _, stat := range fixture.Stats {
+				switch stat.Identifier {
+				case "bps":
+					bps = stat.Value
+				case "minutes":
+					minutes = stat.Value
+				case "bonus":
+					awarded[fixture.Fixture] = awarded[fixture.Fixture] || stat.Value > 0
+				}
+			}
+
+			if minutes > 0 {
+				fixtures[fixture.Fixture] = append(fixtures[fixture.Fixture], score{element.ID, bps})
+			}
+		}
+	}
+
+	bonus := make(map[int]int)
+
+	for fixture, scores := range fixtures {
+		if awarded[fixture] {
+			continue
+		}
+
+		for _, player := range scores {
+			rank := 1
+			for _, other := range scores {
+				if other.bps > player.bps {
+					rank++
+				}
+			}
+
+			if award, ok := bonusForRank[rank]; ok {
+				bonus[player.element] += award
+			}
+		}
+	}
+
+	return bonus
+}
+
+// the live player scores for the gameweek, cached briefly as every manager's points are computed from them
+func getLiveEvent(ctx context.Context, gameweek int) ([]liveElement, error) {
+	key := strconv.Itoa(gameweek)
+
+	body, ok := liveCache.Get(key)
+	if !ok {
+		var err error
+		if body, err = getBody(ctx, "live", fmt.Sprintf(liveURL, gameweek)); err != nil {
+			return nil, fmt.Errorf("error requesting gameweek %d live data: %w", gameweek, err)
+		}
+
+		liveCache.Set(key, body)
+	}
+
+	var live liveEventResponse
+	if err := json.Unmarshal(body, &live); err != nil {
+		return nil, fmt.Errorf("error decoding gameweek %d live data: %w", gameweek, err)
+	}
+
+	return live.Elements, nil
+}
+
+// a manager's picks, chip and transfer cost for the gameweek
+func getPicks(ctx context.Context, manager, gameweek int) (picksResponse, error) {
+	body, err := getBody(ctx, "picks", fmt.Sprintf(picksURL, manager, gameweek))
+	if err != nil {
+		return picksResponse{}, fmt.Errorf("error requesting manager ID %d picks: %w", manager, err)
+	}
+
+	var picks picksResponse
+	if err := json.Unmarshal(body, &picks); err != nil {
+		return picksResponse{}, fmt.Errorf("error decoding manager ID %d picks: %w", manager, err)
+	}
+
+	return picks, nil
+}
+
+// the body of a successful FPL api response for the resource
+func getBody(ctx context.Context, resource, url string) ([]byte, error) {
+	resp, err := timedGet(ctx, resource, url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get %s not OK, Status: %v", resource, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s response: %w", resource, err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,143 @@
+package fpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/conditional"
+)
+
+// live json for a player's total points in a fixture with the minutes, bps and awarded bonus stats
+func liveElementJSON(id, totalPoints, fixture, minutes, bps, bonus int) string {
+	return fmt.Sprintf(`{"id": %d, "stats": {"total_points": %d}, "explain": [{"fixture": %d, "stats": [`+
+		`{"identifier": "minutes", "value": %d}, {"identifier": "bps", "value": %d}, {"identifier": "bonus", "value": %d}]}]}`,
+		id, totalPoints, fixture, minutes, bps, bonus)
+}
+
+func TestProjectedBonus(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements []string
+		want     map[int]int
+	}{
+		{
+			name:     "top three",
+			elements: []string{liveElementJSON(1, 0, 1, 90, 30, 0), liveElementJSON(2, 0, 1, 90, 20, 0), liveElementJSON(3, 0, 1, 90, 10, 0), liveElementJSON(4, 0, 1, 90, 5, 0)},
+			want:     map[int]int{1: 3, 2: 2, 3: 1},
+		},
+		{
+			name:     "tie for first",
+			elements: []string{liveElementJSON(1, 0, 1, 90, 30, 0), liveElementJSON(2, 0, 1, 90, 30, 0), liveElementJSON(3, 0, 1, 90, 10, 0), liveElementJSON(4, 0, 1, 90, 5, 0)},
+			want:     map[int]int{1: 3, 2: 3, 3: 1},
+		},
+		{
+			name:     "tie for second",
+			elements: []string{liveElementJSON(1, 0, 1, 90, 30, 0), liveElementJSON(2, 0, 1, 90, 20, 0), liveElementJSON(3, 0, 1, 90, 20, 0), liveElementJSON(4, 0, 1, 90, 5, 0)},
+			want:     map[int]int{1: 3, 2: 2, 3: 2},
+		},
+		{
+			name:     "already awarded",
+			elements: []string{liveElementJSON(1, 0, 1, 90, 30, 3), liveElementJSON(2, 0, 1, 90, 20, 2)},
+			want:     map[int]int{},
+		},
+		{
+			name:     "not played",
+			elements: []string{liveElementJSON(1, 0, 1, 0, 30, 0), liveElementJSON(2, 0, 1, 90, 20, 0)},
+			want:     map[int]int{2: 3},
+		},
+	}
+
+	for _, test := range tests {
+		var elements []liveElement
+		if err := json.Unmarshal([]byte("["+strings.Join(test.elements, ",")+"]"), &elements); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := projectedBonus(elements); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: projectedBonus() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLive(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	entries := setTestServer()
+	defer entries.Close()
+
+	fplURL = entries.URL + EntryPlaceholder
+
+	elements := []string{
+		liveElementJSON(10, 6, 1, 90, 30, 0), // projected 3 bonus
+		liveElementJSON(11, 2, 1, 90, 20, 0), // tied second, projected 2
+		liveElementJSON(12, 1, 1, 90, 20, 0), // tied second, projected 2
+		liveElementJSON(20, 9, 2, 90, 40, 3), // bonus awarded, in the total points
+	}
+
+	picks := map[string]string{
+		"/entry/1/event/99/picks/": `{"entry_history": {"event_transfers_cost": 4}, "picks": [` +
+			`{"element": 10, "multiplier": 2}, {"element": 20, "multiplier": 1}, {"element": 11, "multiplier": 0}]}`,
+		"/entry/2/event/99/picks/": `{"entry_history": {"event_transfers_cost": 0}, "picks": [` +
+			`{"element": 12, "multiplier": 1}, {"element": 11, "multiplier": 1}]}`,
+	}
+
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/event/99/live/" {
+			_, _ = w.Write([]byte(`{"elements": [` + strings.Join(elements, ",") + `]}`)) //nolint:errcheck // test server
+			return
+		}
+
+		body, ok := picks[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(body)) //nolint:errcheck // test server
+	}))
+	defer live.Close()
+
+	defer func(live, picks string) { liveURL, picksURL = live, picks }(liveURL, picksURL)
+	liveURL, picksURL = live.URL+"/event/%d/live/", live.URL+"/entry/%v/event/%d/picks/"
+	liveCache = cache.New(liveTTL, 2)
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1, 2"
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Live(w, httptest.NewRequest(http.MethodGet, "/fpl/live", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK {
+		t.Fatalf("Live() status = %d body %q, want 200", w.Code, w.Body.String())
+	}
+
+	var got LiveResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Live() response %q, err = %v", w.Body.String(), err)
+	}
+
+	// manager 1: captain 2 x (6 + 3 projected) + 9 - 4 for a hit, the bench doesn't count
+	want := []LiveManager{
+		{ID: 1, Name: "first1 last1", Team: "team1", Points: 23, Bonus: 6, TransferCost: 4},
+		{ID: 2, Name: "first2 last2", Team: "team2", Points: 7, Bonus: 4},
+	}
+
+	if got.Gameweek != Gameweek || got.LastUpdated == "" || !reflect.DeepEqual(got.Managers, want) {
+		t.Errorf("Live() = %+v, want gameweek %d with managers %+v", got, Gameweek, want)
+	}
+
+	if version := w.Header().Get(conditional.DataVersionHeader); version != conditional.DataVersion(w.Body.Bytes()) {
+		t.Errorf("Live() %s = %q, want the version of the json", conditional.DataVersionHeader, version)
+	}
+
+	if cacheControl := w.Header().Get("Cache-Control"); cacheControl != "max-age=30" {
+		t.Errorf("Live() Cache-Control = %q, want max-age=30", cacheControl)
+	}
+}
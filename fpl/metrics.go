@@ -0,0 +1,43 @@
+package fpl
+
+import "github.com/mick4711/moh/metrics"
+
+const requestDurationName = "fpl_request_duration_seconds"
+
+// latency and failures of the FPL api requests by resource, registered on the Settings registry by Configure
+var (
+	requestDuration = newRequestDuration(nil)
+	requestErrors   = newRequestErrors(nil)
+)
+
+func newRequestDuration(registry *metrics.Registry) *metrics.HistogramVec {
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+
+	return registry.Histogram(requestDurationName, "Duration of FPL api requests until the response headers.", metrics.DefaultBuckets, "resource")
+}
+
+func newRequestErrors(registry *metrics.Registry) *metrics.CounterVec {
+	if registry == nil {
+		registry = metrics.NewRegistry()
+	}
+
+	return registry.Counter("fpl_request_errors_total", "FPL api requests that failed or returned a 5xx status.", "resource")
+}
+
+// register the bootstrap-static and league cache hit and miss counts, read from the current cache at each scrape
+func registerCacheMetrics(registry *metrics.Registry) {
+	if registry == nil {
+		return
+	}
+
+	registry.CounterFunc("fpl_cache_hits_total", "FPL api responses served from the cache.", "cache",
+		func() map[string]float64 {
+			return map[string]float64{"bootstrap": float64(bootstrapCache.Stats().Hits), "league": float64(leagueCache.Stats().Hits)}
+		})
+	registry.CounterFunc("fpl_cache_misses_total", "FPL api lookups not in the cache.", "cache",
+		func() map[string]float64 {
+			return map[string]float64{"bootstrap": float64(bootstrapCache.Stats().Misses), "league": float64(leagueCache.Stats().Misses)}
+		})
+}
@@ -0,0 +1,53 @@
+package fpl
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// whether names are anonymized and the bearer token that shows them anyway, set by Configure
+var (
+	anonymizeNames bool
+	namesToken     string
+)
+
+// names are anonymized when FPL_ANONYMIZE is set, unless the request carries the FPL_NAMES_TOKEN value as a bearer token
+func anonymizeRequested(r *http.Request) bool {
+	if !anonymizeNames {
+		return false
+	}
+
+	bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	return namesToken == "" || !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(namesToken)) != 1
+}
+
+// replace manager names, team names, ids and links with numbered placeholders, points and ranks are kept.
+// Managers are numbered by their order in the managers list so the numbering is stable between requests.
+func anonymize(league []ManagerEntry, managers string) {
+	order := make(map[int]int)
+
+	for i, manager := range strings.Split(managers, ",") {
+		if id, err := strconv.Atoi(strings.TrimSpace(manager)); err == nil {
+			order[id] = i + 1
+		}
+	}
+
+	next := len(order) + 1 // for entries without a known id, e.g. not found
+
+	for i := range league {
+		number, ok := order[league[i].ID]
+		if !ok {
+			number = next
+			next++
+		}
+
+		league[i].ID = number
+		league[i].Name = fmt.Sprintf("Manager #%d", number)
+		league[i].Team = fmt.Sprintf("Team #%d", number)
+		league[i].Link = ""
+	}
+}
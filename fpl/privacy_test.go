@@ -0,0 +1,58 @@
+package fpl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAnonymize(t *testing.T) {
+	league := []ManagerEntry{
+		{ID: 20, Name: "first2 last2", Team: "team2", Points: 177, Rank: 166, Link: "https://fantasy.premierleague.com/entry/20/event/9"},
+		{ID: 10, Name: "first1 last1", Team: "team1", Points: 77, Rank: 66, Link: "https://fantasy.premierleague.com/entry/10/event/9"},
+		{ID: 0, Name: "ID 30 Not Found (404)"},
+	}
+
+	anonymize(league, "10, 20, 30")
+
+	want := []ManagerEntry{
+		{ID: 2, Name: "Manager #2", Team: "Team #2", Points: 177, Rank: 166},
+		{ID: 1, Name: "Manager #1", Team: "Team #1", Points: 77, Rank: 66},
+		{ID: 4, Name: "Manager #4", Team: "Team #4"},
+	}
+
+	for i := range league {
+		if league[i] != want[i] {
+			t.Errorf("anonymize() entry %d = %+v, want %+v", i, league[i], want[i])
+		}
+	}
+}
+
+func TestAnonymizeRequested(t *testing.T) {
+	tests := []struct {
+		scenario  string
+		anonymize bool
+		token     string
+		auth      string
+		want      bool
+	}{
+		{"names shown by default", false, "", "", false},
+		{"anonymized", true, "", "", true},
+		{"anonymized without token", true, "secret", "", true},
+		{"anonymized wrong token", true, "secret", "Bearer wrong", true},
+		{"names shown with token", true, "secret", "Bearer secret", false},
+	}
+
+	defer func() { anonymizeNames, namesToken = false, "" }()
+
+	for _, test := range tests {
+		anonymizeNames, namesToken = test.anonymize, test.token
+
+		req := httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody)
+		req.Header.Set("Authorization", test.auth)
+
+		if got := anonymizeRequested(req); got != test.want {
+			t.Errorf("%s: anonymizeRequested() = %v, want %v", test.scenario, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package fpl
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+// the configured managers' points from the last background refresh
+type refreshedLeague struct {
+	managers string
+	response LeagueResponse
+	fetched  time.Time
+}
+
+// the last background refresh, served for up to warmMaxAge. Reset by Configure
+var (
+	refreshed = struct {
+		sync.Mutex
+		league refreshedLeague
+	}{}
+	warmMaxAge time.Duration
+)
+
+// record the refreshed points
+func setRefreshed(league refreshedLeague) {
+	refreshed.Lock()
+	defer refreshed.Unlock()
+
+	refreshed.league = league
+}
+
+// Refresh fetches the gameweek points of the configured managers for requests to be served from, and returns their
+// data version, which only changes when points or ranks do
+func Refresh(ctx context.Context) (string, error) {
+	managers := configuredManagers
+	if managers == "" {
+		return "", errors.New("environment variable managers is not set")
+	}
+
+	leagueResponse, err := fetchData(ctx, managers)
+	if err != nil {
+		return "", err
+	}
+
+	setRefreshed(refreshedLeague{managers: managers, response: leagueResponse, fetched: clk.Now()})
+
+	return dataVersion(leagueResponse)
+}
+
+// the refreshed points when they are for the managers and at most warmMaxAge old, a copy the caller may modify
+func warmLeague(managers string) (LeagueResponse, bool) {
+	refreshed.Lock()
+	league := refreshed.league
+	refreshed.Unlock()
+
+	if league.managers == "" || league.managers != managers || clock.Since(clk, league.fetched) >= warmMaxAge {
+		return LeagueResponse{}, false
+	}
+
+	response := league.response
+	response.League = slices.Clone(response.League)
+
+	return response, true
+}
@@ -0,0 +1,70 @@
+package fpl
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestRefresh(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := setTestServer()
+	defer ts.Close()
+
+	fplURL = ts.URL + EntryPlaceholder
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1,2"
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	first, err := Refresh(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configuredManagers = "2,1"
+	second, err := Refresh(context.Background())
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || first == "" || strings.Contains(first, `"`) || second != first {
+		t.Errorf("Refresh() = %q then %q, %v, want the same unquoted data version for the same points", first, second, err)
+	}
+}
+
+func TestWarmLeague(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fake := clock.NewFake(time.Date(2024, 9, 28, 15, 0, 0, 0, time.UTC))
+
+	defer func(c clock.Clock, maxAge time.Duration) { clk, warmMaxAge = c, maxAge }(clk, warmMaxAge)
+	defer setRefreshed(refreshedLeague{})
+
+	clk, warmMaxAge = fake, 20*time.Minute
+	setRefreshed(refreshedLeague{managers: "1,2", response: LeagueResponse{Gameweek: 7, League: []ManagerEntry{{ID: 1}, {ID: 2}}},
+		fetched: fake.Now()})
+
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		managers string
+		want     bool
+	}{
+		{"refreshed", 19 * time.Minute, "1,2", true},
+		{"other managers", 0, "1", false},
+		{"too old", time.Minute, "1,2", false},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		fake.Advance(test.advance)
+
+		got, ok := warmLeague(test.managers)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if ok != test.want || (ok && (got.Gameweek != 7 || len(got.League) != 2)) {
+			t.Errorf("%s: warmLeague(%q) = %+v, %t, want %t", test.name, test.managers, got, ok, test.want)
+		}
+	}
+}
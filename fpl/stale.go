@@ -0,0 +1,76 @@
+package fpl
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/stale"
+)
+
+// the gameweek entries of each managers list with when they were fetched. Within the FPL_CACHE_TTL they are served
+// instead of fetching again, after it they are only served as stale copies when a fetch fails so they never expire.
+// Reset by Configure
+var lastPoints = cache.New(defaultPointsTTL, maxCachedLeagues)
+
+// the FPL api gameweek entries are cached briefly as the points change often during gameweeks, override with
+// FPL_CACHE_TTL
+const defaultPointsTTL = time.Minute
+
+// keep the fetched entries of the managers to serve until they expire and if a later fetch fails
+func rememberPoints(managers string, leagueResponse LeagueResponse) {
+	body, err := json.Marshal(leagueResponse)
+	if err != nil {
+		slog.Error("managers' points not kept", "err", err)
+		return
+	}
+
+	lastPoints.Set(managers, body)
+}
+
+// the entries of the managers fetched within the FPL_CACHE_TTL, false when there are none
+func cachedPoints(managers string) (LeagueResponse, bool) {
+	if _, ok := lastPoints.Get(managers); !ok {
+		return LeagueResponse{}, false
+	}
+
+	return storedPoints(managers)
+}
+
+// the last good entries of the managers described as stale, false when there are none
+func lastGoodPoints(managers string) (LeagueResponse, bool) {
+	leagueResponse, ok := storedPoints(managers)
+	if !ok {
+		return LeagueResponse{}, false
+	}
+
+	leagueResponse.Stale = stale.New(leagueResponse.FetchedAt, clk.Now())
+
+	return leagueResponse, true
+}
+
+// the kept entries of the managers with when they were fetched, false when there are none
+func storedPoints(managers string) (LeagueResponse, bool) {
+	body, fetched, ok := lastPoints.GetStale(managers)
+	if !ok {
+		return LeagueResponse{}, false
+	}
+
+	var leagueResponse LeagueResponse
+	if err := json.Unmarshal(body, &leagueResponse); err != nil {
+		return LeagueResponse{}, false
+	}
+
+	leagueResponse.FetchedAt = fetched
+
+	return leagueResponse, true
+}
+
+// mark a response built from stale entries with the Warning and Age headers
+func markStale(w http.ResponseWriter, leagueResponse LeagueResponse) {
+	if leagueResponse.Stale != nil {
+		leagueResponse.Stale.Mark(w.Header())
+	}
+}
@@ -0,0 +1,130 @@
+package fpl
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/stale"
+)
+
+func TestPointsServedStale(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fake := clock.NewFake(time.Date(2024, 9, 28, 15, 0, 0, 0, time.UTC))
+
+	defer func(c clock.Clock, points *cache.Cache) { clk, lastPoints = c, points }(clk, lastPoints)
+	defer ClearCache()
+
+	clk, lastPoints = fake, cache.NewWithClock(0, maxCachedLeagues, fake)
+
+	ts := setTestServer()
+	defer ts.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "1,2"
+	fplURL = ts.URL + EntryPlaceholder
+
+	w := httptest.NewRecorder()
+	Points(w, httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody))
+
+	if w.Code != http.StatusOK || w.Header().Get("Warning") != "" {
+		t.Fatalf("Points() = %d Warning %q, want 200 without a warning", w.Code, w.Header().Get("Warning"))
+	}
+
+	fake.Advance(3*time.Hour + 5*time.Minute)
+	fplURL = failing.URL + EntryPlaceholder
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w = httptest.NewRecorder()
+	Points(w, httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody))
+
+	page := httptest.NewRecorder()
+	pageRequest := httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody)
+	pageRequest.Header.Set("Accept", "text/html")
+	Points(page, pageRequest)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var got LeagueResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Points() with FPL failing response %q, err = %v", w.Body.String(), err)
+	}
+
+	want := stale.Data{Fetched: "2024-09-28T15:00:00Z", Age: "3h", Note: "Data from 3h ago — live update failed"}
+	if got.Stale == nil || *got.Stale != want || len(got.League) != 2 {
+		t.Errorf("Points() with FPL failing = %+v stale %+v, want both managers stale %+v", got.League, got.Stale, want)
+	}
+
+	if w.Header().Get("Warning") != stale.Warning || w.Header().Get("Age") != "11100" {
+		t.Errorf("Points() with FPL failing Warning = %q Age = %q, want %q 11100", w.Header().Get("Warning"), w.Header().Get("Age"), stale.Warning)
+	}
+
+	if body := page.Body.String(); !strings.Contains(body, `<p class="stale" role="alert"><strong>Data from 3h ago — live update failed</strong></p>`) {
+		t.Errorf("Points() html with FPL failing is missing the stale data banner\n%s", body)
+	}
+
+	w = httptest.NewRecorder()
+	configuredManagers = "1"
+	Points(w, httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Points() with FPL failing and no last good points = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestPointsCacheTTL(t *testing.T) {
+	defer func(c clock.Clock, points *cache.Cache) { clk, lastPoints = c, points }(clk, lastPoints)
+	defer ClearCache()
+
+	mock := setTestServer()
+	defer mock.Close()
+
+	tests := []struct {
+		ttl          time.Duration
+		wantRequests int
+	}{
+		{time.Hour, 2},
+		{time.Minute, 4},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		fake := clock.NewFake(time.Date(2024, 9, 28, 15, 0, 0, 0, time.UTC))
+		clk, lastPoints = fake, cache.NewWithClock(test.ttl, maxCachedLeagues, fake)
+
+		requests := 0
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+
+			mock.Config.Handler.ServeHTTP(w, r)
+		}))
+
+		fplURL = ts.URL + EntryPlaceholder
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		for range 2 {
+			if _, err := getData(context.Background(), "1,2"); err != nil {
+				t.Fatal(err)
+			}
+
+			fake.Advance(5 * time.Minute)
+		}
+
+		ts.Close()
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if requests != test.wantRequests {
+			t.Errorf("FPL_CACHE_TTL %s upstream requests = %d, want %d", test.ttl, requests, test.wantRequests)
+		}
+	}
+}
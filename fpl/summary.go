@@ -0,0 +1,217 @@
+package fpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/mick4711/moh/errorpage"
+)
+
+// managers fetched at once, the FPL api throttles bursts of requests
+const summaryWorkers = 5
+
+// FPL manager season history, %v is the manager id
+var historyURL = "https://fantasy.premierleague.com/api/entry/%v/history/"
+
+// fields retrieved from a manager's season history
+type historyResponse struct {
+	Chips []Chip `json:"chips"`
+}
+
+// fields retrieved from the bootstrap-static players
+type playersResponse struct {
+	Elements []struct {
+		ID      int    `json:"id"`
+		WebName string `json:"web_name"`
+	} `json:"elements"`
+}
+
+// A Chip is a chip a manager played and the gameweek it was played in
+type Chip struct {
+	Name     string `json:"name"`
+	Gameweek int    `json:"event"`
+}
+
+// A Player is a footballer picked by a manager
+type Player struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// A ManagerSummary is a manager's captaincy, chips and transfers for the gameweek
+type ManagerSummary struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	Team         string `json:"team"`
+	Captain      Player `json:"captain"`
+	ViceCaptain  Player `json:"viceCaptain"`
+	ActiveChip   string `json:"activeChip,omitempty"` // chip played this gameweek
+	ChipsPlayed  []Chip `json:"chipsPlayed"`          // every chip played this season
+	Transfers    int    `json:"transfers"`
+	TransferCost int    `json:"transferCost"` // points deducted for the hits taken
+}
+
+// A SummaryResponse has the gameweek summary of each manager in the league
+type SummaryResponse struct {
+	Gameweek int              `json:"gameweek"`
+	Managers []ManagerSummary `json:"managers"`
+}
+
+// Summary writes each manager's captain and vice-captain, chips played and transfers made for the current gameweek
+// as json. The managers' picks and histories are fetched concurrently by a bounded pool of workers
+func Summary(w http.ResponseWriter, r *http.Request) {
+	managers, ok := requestedManagers(w, r)
+	if !ok {
+		return
+	}
+
+	leagueResponse, err := getData(r.Context(), managers)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadGateway, err)
+		return
+	}
+
+	markStale(w, leagueResponse)
+
+	if leagueResponse.Gameweek < 1 {
+		errorpage.JSON(w, r, http.StatusNotFound, errors.New("no gameweek started"))
+		return
+	}
+
+	summaries, err := managerSummaries(r.Context(), leagueResponse)
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusBadGateway, err)
+		return
+	}
+
+	response, err := json.MarshalIndent(SummaryResponse{Gameweek: leagueResponse.Gameweek, Managers: summaries}, "", "  ")
+	if err != nil {
+		errorpage.JSON(w, r, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeConditionalJSON(w, r, append(response, '\n'))
+}
+
+// the gameweek summary of each known manager in the league, in league order, with player names from bootstrap-static
+func managerSummaries(ctx context.Context, leagueResponse LeagueResponse) ([]ManagerSummary, error) {
+	names, err := playerNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := knownManagers(leagueResponse.League)
+
+	return fanOut(entries, summaryWorkers, func(entry ManagerEntry) (ManagerSummary, error) {
+		picks, err := getPicks(ctx, entry.ID, leagueResponse.Gameweek)
+		if err != nil {
+			return ManagerSummary{}, err
+		}
+
+		history, err := getHistory(ctx, entry.ID)
+		if err != nil {
+			return ManagerSummary{}, err
+		}
+
+		summary := ManagerSummary{
+			ID:           entry.ID,
+			Name:         entry.Name,
+			Team:         entry.Team,
+			ActiveChip:   picks.ActiveChip,
+			ChipsPlayed:  history.Chips,
+			Transfers:    picks.EntryHistory.EventTransfers,
+			TransferCost: picks.EntryHistory.EventTransfersCost,
+		}
+
+		for _, pick := range picks.Picks {
+			switch {
+			case pick.IsCaptain:
+				summary.Captain = Player{ID: pick.Element, Name: names[pick.Element]}
+			case pick.IsViceCaptain:
+				summary.ViceCaptain = Player{ID: pick.Element, Name: names[pick.Element]}
+			}
+		}
+
+		if summary.ChipsPlayed == nil {
+			summary.ChipsPlayed = []Chip{}
+		}
+
+		return summary, nil
+	})
+}
+
+// apply fn to each item with at most workers calls in flight, the results are in the order of the items.
+// The first error is returned once every call has finished
+func fanOut[T, R any](items []T, workers int, fn func(T) (R, error)) ([]R, error) {
+	results := make([]R, len(items))
+	errs := make([]error, len(items))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(workers, len(items)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				results[i], errs[i] = fn(items[i])
+			}
+		}()
+	}
+
+	for i := range items {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// the player web names keyed by id from the cached bootstrap-static data
+func playerNames(ctx context.Context) (map[int]string, error) {
+	body, err := getBootstrap(ctx, []string{"elements"})
+	if err != nil {
+		return nil, err
+	}
+
+	var players playersResponse
+	if err := json.Unmarshal(body, &players); err != nil {
+		return nil, fmt.Errorf("error decoding bootstrap-static players: %w", err)
+	}
+
+	names := make(map[int]string, len(players.Elements))
+	for _, player := range players.Elements {
+		names[player.ID] = player.WebName
+	}
+
+	return names, nil
+}
+
+// a manager's chips played this season
+func getHistory(ctx context.Context, manager int) (historyResponse, error) {
+	body, err := getBody(ctx, "history", fmt.Sprintf(historyURL, manager))
+	if err != nil {
+		return historyResponse{}, fmt.Errorf("error requesting manager ID %d history: %w", manager, err)
+	}
+
+	var history historyResponse
+	if err := json.Unmarshal(body, &history); err != nil {
+		return historyResponse{}, fmt.Errorf("error decoding manager ID %d history: %w", manager, err)
+	}
+
+	return history, nil
+}
@@ -0,0 +1,131 @@
+package fpl
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/cache"
+)
+
+func TestFanOut(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	var inFlight, maxInFlight atomic.Int32
+
+	double := func(n int) (int, error) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond)
+
+		return 2 * n, nil
+	}
+
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got, err := fanOut(items, 3, double)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || !slices.Equal(got, []int{2, 4, 6, 8, 10, 12, 14, 16, 18, 20, 22, 24}) {
+		t.Errorf("fanOut() = %v, %v, want each item doubled in order", got, err)
+	}
+
+	if maxInFlight.Load() > 3 {
+		t.Errorf("fanOut() ran %d calls at once, want at most 3", maxInFlight.Load())
+	}
+
+	failure := errors.New("failed")
+	if _, err := fanOut(items, 3, func(n int) (int, error) {
+		if n == 7 {
+			return 0, failure
+		}
+
+		return n, nil
+	}); !errors.Is(err, failure) {
+		t.Errorf("fanOut() err = %v, want %v", err, failure)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	entries := setTestServer()
+	defer entries.Close()
+
+	fplURL = entries.URL + EntryPlaceholder
+
+	responses := map[string]string{
+		"/bootstrap-static/": `{"elements": [{"id": 10, "web_name": "Haaland"}, {"id": 20, "web_name": "Salah"}]}`,
+		"/entry/1/event/99/picks/": `{"active_chip": "3xc", "entry_history": {"event_transfers": 1, "event_transfers_cost": 0}, "picks": [` +
+			`{"element": 10, "multiplier": 3, "is_captain": true}, {"element": 20, "multiplier": 1, "is_vice_captain": true}]}`,
+		"/entry/2/event/99/picks/": `{"active_chip": null, "entry_history": {"event_transfers": 3, "event_transfers_cost": 8}, "picks": [` +
+			`{"element": 10, "multiplier": 1, "is_vice_captain": true}, {"element": 20, "multiplier": 2, "is_captain": true}]}`,
+		"/entry/1/history/": `{"chips": [{"name": "wildcard", "event": 3}, {"name": "3xc", "event": 99}]}`,
+		"/entry/2/history/": `{"chips": []}`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(body)) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	defer func(picks, history, bootstrap string) {
+		picksURL, historyURL, bootstrapURL = picks, history, bootstrap
+	}(picksURL, historyURL, bootstrapURL)
+
+	picksURL, historyURL, bootstrapURL = ts.URL+"/entry/%v/event/%d/picks/", ts.URL+"/entry/%v/history/", ts.URL+"/bootstrap-static/"
+	bootstrapCache = cache.New(defaultBootstrapTTL, bootstrapCacheEntries)
+
+	defer func(managers string) { configuredManagers = managers }(configuredManagers)
+	configuredManagers = "2, 1"
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	Summary(w, httptest.NewRequest(http.MethodGet, "/fpl/summary", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusOK {
+		t.Fatalf("Summary() status = %d body %q, want 200", w.Code, w.Body.String())
+	}
+
+	var got SummaryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Summary() response %q, err = %v", w.Body.String(), err)
+	}
+
+	want := map[int]ManagerSummary{
+		1: {ID: 1, Name: "first1 last1", Team: "team1", Captain: Player{10, "Haaland"}, ViceCaptain: Player{20, "Salah"},
+			ActiveChip: "3xc", ChipsPlayed: []Chip{{"wildcard", 3}, {"3xc", 99}}, Transfers: 1},
+		2: {ID: 2, Name: "first2 last2", Team: "team2", Captain: Player{20, "Salah"}, ViceCaptain: Player{10, "Haaland"},
+			ChipsPlayed: []Chip{}, Transfers: 3, TransferCost: 8},
+	}
+
+	if got.Gameweek != Gameweek || len(got.Managers) != len(want) {
+		t.Fatalf("Summary() = %+v, want gameweek %d with %d managers", got, Gameweek, len(want))
+	}
+
+	for _, manager := range got.Managers {
+		if !reflect.DeepEqual(manager, want[manager.ID]) {
+			t.Errorf("Summary() manager %d = %+v, want %+v", manager.ID, manager, want[manager.ID])
+		}
+	}
+}
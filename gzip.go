@@ -0,0 +1,158 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gzip writers are reused across responses
+var gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+// gzip compresses the responses of next for clients that accept it, others are written through uncompressed.
+// Only compressible content types are compressed, see compressible
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !slices.Contains(w.Header().Values("Vary"), "Accept-Encoding") {
+			w.Header().Add("Vary", "Accept-Encoding")
+		}
+
+		if !acceptsGzip(req.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		gw := &gzipWriter{ResponseWriter: w}
+		defer gw.close()
+
+		next.ServeHTTP(gw, req)
+	})
+}
+
+// whether an Accept-Encoding header allows gzip, "gzip;q=0" refuses it
+func acceptsGzip(header string) bool {
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		if name = strings.TrimSpace(name); name != "gzip" && name != "*" {
+			continue
+		}
+
+		value, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+
+		q, err := strconv.ParseFloat(value, 64)
+
+		return err != nil || q > 0
+	}
+
+	return false
+}
+
+// text, json, javascript, xml and svg compress well. Event streams are excluded as each event is flushed on its own,
+// images, archives and media are already compressed
+func compressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	switch {
+	case mediaType == "text/event-stream":
+		return false
+	case strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	default:
+		return slices.Contains([]string{"application/json", "application/javascript", "application/xml", "image/svg+xml"}, mediaType)
+	}
+}
+
+// a strong etag made weak for a compressed body, the bytes differ from the uncompressed representation.
+// The handlers compare If-None-Match weakly so conditional requests still match
+func weakETag(h http.Header) {
+	if tag := h.Get("ETag"); tag != "" && !strings.HasPrefix(tag, "W/") {
+		h.Set("ETag", "W/"+tag)
+	}
+}
+
+// compresses the body once the handler writes, responses without a body such as 304 and bodies that
+// aren't compressible are passed through
+type gzipWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer // nil until a compressed body is started
+	wroteHeader bool
+	passthrough bool
+}
+
+func (w *gzipWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.wroteHeader = true
+
+	switch {
+	case status == http.StatusNotModified:
+		// the client's cached copy was compressed, so it has the weak etag
+		weakETag(w.Header())
+
+		w.passthrough = true
+	case status == http.StatusNoContent || w.Header().Get("Content-Encoding") != "" || !compressible(w.Header().Get("Content-Type")):
+		w.passthrough = true
+	default:
+		weakETag(w.Header())
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Del("Content-Length")
+
+		w.gz = gzipWriters.Get().(*gzip.Writer) //nolint:forcetypeassert // the pool only holds gzip writers
+		w.gz.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// sniff from the uncompressed body, net/http would otherwise sniff the compressed bytes
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.passthrough {
+		return w.ResponseWriter.Write(b) //nolint:wrapcheck // pass through
+	}
+
+	return w.gz.Write(b) //nolint:wrapcheck // pass through
+}
+
+// flush the compressed bytes written so far to the client
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		_ = w.gz.Flush() //nolint:errcheck // the client sees a truncated body, nothing more to do
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// finish the gzip stream so the body isn't truncated
+func (w *gzipWriter) close() {
+	if w.gz == nil {
+		return
+	}
+
+	_ = w.gz.Close() //nolint:errcheck // the client sees a truncated body, nothing more to do
+
+	w.gz.Reset(nil)
+	gzipWriters.Put(w.gz)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	page := "<!DOCTYPE html><html><body>" + strings.Repeat("<p>Cann table</p>", 500) + "</body></html>"
+
+	handler := compress(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		_, _ = io.WriteString(w, page) //nolint:errcheck // test handler
+	}))
+
+	tests := []struct {
+		acceptEncoding string
+		ifNoneMatch    string
+		wantStatus     int
+		wantEncoding   string
+	}{
+		{"gzip, deflate, br", "", http.StatusOK, "gzip"},
+		{"br;q=1.0, gzip;q=0.8", "", http.StatusOK, "gzip"},
+		{"", "", http.StatusOK, ""},
+		{"deflate", "", http.StatusOK, ""},
+		{"gzip;q=0", "", http.StatusOK, ""},
+		{"gzip", `"v1"`, http.StatusNotModified, ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/cann", http.NoBody)
+		req.Header.Set("Accept-Encoding", test.acceptEncoding)
+
+		if test.ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", test.ifNoneMatch)
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus || w.Header().Get("Content-Encoding") != test.wantEncoding {
+			t.Errorf("Accept-Encoding %q = %d %q, want %d %q", test.acceptEncoding, w.Code, w.Header().Get("Content-Encoding"), test.wantStatus, test.wantEncoding)
+			continue
+		}
+
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Accept-Encoding %q Vary = %q, want Accept-Encoding", test.acceptEncoding, w.Header().Get("Vary"))
+		}
+
+		if test.wantStatus == http.StatusNotModified {
+			if w.Body.Len() != 0 {
+				t.Errorf("Accept-Encoding %q 304 body = %q, want empty", test.acceptEncoding, w.Body)
+			}
+
+			continue
+		}
+
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Errorf("Accept-Encoding %q Content-Type = %q, want it sniffed from the uncompressed body", test.acceptEncoding, contentType)
+		}
+
+		body := w.Body.String()
+		if test.wantEncoding == "gzip" {
+			if w.Body.Len() >= len(page) {
+				t.Errorf("Accept-Encoding %q body is %d bytes, want fewer than %d", test.acceptEncoding, w.Body.Len(), len(page))
+			}
+
+			body = gunzip(t, w.Body)
+		}
+
+		if body != page {
+			t.Errorf("Accept-Encoding %q body isn't the complete page, got %d bytes want %d", test.acceptEncoding, len(body), len(page))
+		}
+	}
+}
+
+// decompress a gzip body, failing the test if the stream is truncated
+func gunzip(t *testing.T, r io.Reader) string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(body)
+}
+
+func TestCompressContentTypes(t *testing.T) {
+	body := strings.Repeat(`{"team": "Liverpool", "points": 45}`, 100)
+
+	tests := []struct {
+		contentType  string
+		etag         string
+		wantEncoding string
+		wantETag     string
+	}{
+		{"application/json", `"v1"`, "gzip", `W/"v1"`},
+		{"text/html; charset=utf-8", `W/"v1"`, "gzip", `W/"v1"`},
+		{"text/calendar; charset=utf-8", "", "gzip", ""},
+		{"application/problem+json", "", "gzip", ""},
+		{"text/event-stream", "", "", ""},
+		{"image/png", `"v1"`, "", `"v1"`},
+		{"application/octet-stream", "", "", ""},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		handler := compress(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", test.contentType)
+
+			if test.etag != "" {
+				w.Header().Set("ETag", test.etag)
+			}
+
+			_, _ = io.WriteString(w, body) //nolint:errcheck // test handler
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/fpl", http.NoBody)
+		req.Header.Set("Accept-Encoding", "gzip, br")
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		w.Header().Set("Vary", "Accept-Encoding")
+		handler.ServeHTTP(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if got := w.Header().Get("Content-Encoding"); got != test.wantEncoding {
+			t.Errorf("%s Content-Encoding = %q, want %q", test.contentType, got, test.wantEncoding)
+			continue
+		}
+
+		if got := w.Header().Get("ETag"); got != test.wantETag {
+			t.Errorf("%s ETag = %q, want %q", test.contentType, got, test.wantETag)
+		}
+
+		if vary := w.Header().Values("Vary"); len(vary) != 1 {
+			t.Errorf("%s Vary = %q, want Accept-Encoding once", test.contentType, vary)
+		}
+
+		got := w.Body.String()
+		if test.wantEncoding == "gzip" {
+			got = gunzip(t, w.Body)
+		}
+
+		if got != body {
+			t.Errorf("%s body isn't the complete response, got %d bytes want %d", test.contentType, len(got), len(body))
+		}
+	}
+}
@@ -2,11 +2,20 @@
 package huxley
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
+	"slices"
 	"time"
+
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/conditional"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/negotiate"
 )
 
 var templ = template.Must(template.New("webpage").Parse(`
@@ -29,16 +38,75 @@ var templ = template.Must(template.New("webpage").Parse(`
 			<li>Months: {{.Age.Months}}</li>
 			<li>Weeks: {{.Age.Weeks}}</li>
 			<li>Days: {{.Age.Days}}</li>
+			{{with .Weight}}
+			<li>Weight: {{.Latest.Kg}}kg on {{.Latest.Date}}{{with .Previous}} ({{$.Weight.ChangeLabel}} since {{.Date}}) {{$.Weight.Sparkline}}{{end}}</li>
+			{{end}}
+		</ul>
+		{{with .Weight}}{{with .Chart}}
+		<svg width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" role="img" aria-label="Weight over time, {{.Lowest}}kg to {{.Highest}}kg">
+			<polyline points="{{.Points}}" fill="none" stroke="blue" stroke-width="4"/>
+		</svg>
+		{{end}}{{end}}
+		{{with .VetVisits}}
+		<h3>Vet visits</h3>
+		<ul>
+			{{range .}}<li>{{.Date}}: {{.Reason}}{{with .Notes}} ({{.}}){{end}}</li>{{end}}
 		</ul>
+		{{end}}
+		{{with .Photos}}
+		<h3><a href="/huxley/photos">Photos</a></h3>
+		{{range .}}<a href="{{.URL}}"><img src="{{.Thumbnail}}" alt="Huxley on {{.Date}}"></a>{{end}}
+		{{end}}
 	</body>
 </html>
 `))
 
+// Settings configures the age calculation and the posted records
+type Settings struct {
+	Clock     clock.Clock   // the current time, the system clock when nil
+	DataFile  string        // json file the posted weigh-ins and vet visits are kept in
+	Token     string        // bearer token for posting entries and photos, posting is off when empty
+	PhotosDir string        // directory the uploaded photos are kept in, the gallery is off when empty
+	Weights   string        // json list of weigh-ins e.g. [{"date": "2024-01-10", "kg": 30.5}], none when empty
+	CacheTTL  time.Duration // Cache-Control max-age of the /huxley responses, defaultCacheTTL when 0
+}
+
+// Huxley's details only change with his age and new entries, so browsers can keep them for a while
+const defaultCacheTTL = time.Hour
+
+// the Cache-Control max-age of the /huxley responses, set by Configure
+var cacheTTL = defaultCacheTTL
+
+// the current time, set by Configure
+var clk clock.Clock = clock.Real{}
+
+// Configure applies settings, call before serving requests
+func Configure(settings Settings) {
+	clk = settings.Clock
+	if clk == nil {
+		clk = clock.Real{}
+	}
+
+	store.Lock()
+	store.file, store.photos, store.token = settings.DataFile, settings.PhotosDir, settings.Token
+	store.Unlock()
+
+	configuredWeights = parseWeights(settings.Weights)
+
+	cacheTTL = settings.CacheTTL
+	if cacheTTL == 0 {
+		cacheTTL = defaultCacheTTL
+	}
+}
+
 type DogStat struct {
 	Name        string
 	DateOfBirth string
 	Breed       string
 	Age         Age
+	Weight      *WeightTrend // nil when no weigh-ins are configured
+	VetVisits   []VetVisit   // most recent first
+	Photos      []Photo      // the most recent few photos, newest first
 }
 
 type Age struct {
@@ -56,29 +124,48 @@ const (
 	monthsInYear = 12
 )
 
-// write http to http.ResponseWriter, this is like a main() function
-func DogStats(w http.ResponseWriter, _ *http.Request) {
-	dob := time.Date(2022, 7, 28, 12, 0, 0, 0, time.Local)
+// write http to http.ResponseWriter, this is like a main() function, ?format=json or an Accept header preferring json writes the stats as json
+func DogStats(w http.ResponseWriter, req *http.Request) {
+	result := Stats()
+	version := dataVersion(recentPhotos())
 
-	loc, err := time.LoadLocation("Europe/Dublin")
-	if err != nil {
-		loc = time.UTC
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(cacheTTL.Seconds())))
+
+	if negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		writeJSON(w, req, version, result)
+		return
+	}
+
+	// write result to ResponseWriter using html template, buffered so a template error is still a clean error page
+	var page bytes.Buffer
+	if err := templ.Execute(&page, result); err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("error executing huxley template: %w", err))
+		return
 	}
 
-	age := getAge(dob, time.Now().In(loc))
+	if _, err := page.WriteTo(w); err != nil {
+		slog.WarnContext(req.Context(), "error writing huxley page", "err", err)
+	}
+}
 
-	result := DogStat{
+// Huxley's birthday
+var dateOfBirth = time.Date(2022, 7, 28, 12, 0, 0, 0, time.Local)
+
+// Stats returns Huxley's details and current age
+func Stats() DogStat {
+	age := getAge(dateOfBirth, clk.Now().In(dublin()))
+
+	visits := storedRecords().VetVisits
+	slices.Reverse(visits)
+
+	return DogStat{
 		Name:        "Huxley",
-		DateOfBirth: dob.Format("2 January 2006"),
+		DateOfBirth: dateOfBirth.Format("2 January 2006"),
 		Breed:       "Golden Retriever",
 		Age:         age,
-	}
-
-	// write result to ResponseWriter using html template
-	if err := templ.Execute(w, result); err != nil {
-		// TODO send back an error page, test with invalid field in template
-		log.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
+		Weight:      weightTrend(weightSeries()),
+		VetVisits:   visits,
+		Photos:      recentPhotos(),
 	}
 }
 
@@ -144,3 +231,41 @@ func getAgeYearsFractional(ageMonths, ageYears float64) float64 {
 
 	return ageYears
 }
+
+// data version of Huxley's date of birth, his weigh-ins, vet visits and the photos, hashed from the records rather
+// than the response so it doesn't change as his age moves on
+func dataVersion(photos []Photo) string {
+	data, err := json.Marshal(struct {
+		DateOfBirth time.Time
+		Weights     []Measurement
+		VetVisits   []VetVisit
+		Photos      []Photo
+	}{dateOfBirth, weightSeries(), storedRecords().VetVisits, photos})
+	if err != nil {
+		slog.Error("huxley data version not set", "err", err)
+		return ""
+	}
+
+	return conditional.DataVersion(data)
+}
+
+// write a value as json with the data version it was built from
+func writeJSON(w http.ResponseWriter, req *http.Request, version string, value any) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusInternalServerError, fmt.Errorf("error encoding json: %w", err))
+		return
+	}
+
+	body = append(body, '\n')
+
+	if version != "" {
+		w.Header().Set(conditional.DataVersionHeader, version)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := w.Write(body); err != nil {
+		slog.WarnContext(req.Context(), "error writing json response", "err", err)
+	}
+}
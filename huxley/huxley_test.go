@@ -1,8 +1,15 @@
 package huxley
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/conditional"
 )
 
 func TestGetData(t *testing.T) {
@@ -164,3 +171,84 @@ func areSameAge(calcAge, expectedAge Age) bool {
 		calcAge.Weeks == expectedAge.Weeks &&
 		calcAge.Days == expectedAge.Days
 }
+
+func TestStatsOnFixedClock(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	Configure(Settings{Clock: clock.NewFake(time.Date(2024, 7, 28, 11, 0, 0, 0, time.UTC))}) // second birthday, noon in Dublin
+	defer Configure(Settings{})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := Stats().Age
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if got.Days != 731 || got.Years != 2 {
+		t.Errorf("Stats() on the second birthday age = %d days %v years, want 731 days 2 years", got.Days, got.Years)
+	}
+
+	if again := Stats().Age; again != got {
+		t.Errorf("Stats() on a stopped clock = %+v then %+v, want the same age", got, again)
+	}
+}
+
+func TestDogStatsCacheTTL(t *testing.T) {
+	defer Configure(Settings{})
+
+	tests := []struct {
+		ttl  time.Duration
+		want string
+	}{
+		{0, "max-age=3600"},
+		{12 * time.Hour, "max-age=43200"},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		Configure(Settings{CacheTTL: test.ttl})
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		DogStats(w, httptest.NewRequest(http.MethodGet, "/huxley?format=json", http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if got := w.Header().Get("Cache-Control"); got != test.want {
+			t.Errorf("HUXLEY_CACHE_TTL %s Cache-Control = %q, want %q", test.ttl, got, test.want)
+		}
+
+	}
+}
+
+func TestDogStatsDataVersion(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fake := clock.NewFake(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+
+	Configure(Settings{Clock: fake, DataFile: filepath.Join(t.TempDir(), "huxley.json"), Token: "secret"})
+	defer Configure(Settings{})
+
+	version := func() string {
+		w := httptest.NewRecorder()
+		DogStats(w, httptest.NewRequest(http.MethodGet, "/huxley?format=json", http.NoBody))
+
+		return w.Header().Get(conditional.DataVersionHeader)
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	first := version()
+
+	fake.Advance(90 * time.Second)
+	later := version()
+
+	req := httptest.NewRequest(http.MethodPost, "/huxley/weights", strings.NewReader(`{"kg": 31.4}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	AddWeight(httptest.NewRecorder(), req)
+
+	weighed := version()
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if first == "" || later != first {
+		t.Errorf("DogStats() %s = %q then %q, want it unchanged as his age moves on", conditional.DataVersionHeader, first, later)
+	}
+
+	if weighed == first {
+		t.Errorf("DogStats() %s = %q after a weigh-in, want a new version", conditional.DataVersionHeader, weighed)
+	}
+}
@@ -0,0 +1,343 @@
+package huxley
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	_ "image/gif" // decode uploaded gifs
+	"image/jpeg"
+	_ "image/png" // decode uploaded pngs
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/negotiate"
+)
+
+const (
+	maxPhotoBody   = 20 << 20 // largest upload accepted, in bytes
+	maxPhotoPixels = 50e6     // largest decoded image accepted, so a small file can't expand into a huge one
+	photoSize      = 1600     // longest side of a stored photo
+	thumbnailSize  = 320      // longest side of a thumbnail
+	photoQuality   = 85       // jpeg quality of stored photos and thumbnails
+	pagePhotos     = 4        // most recent photos shown on the /huxley page
+	thumbnailsDir  = "thumbs" // subdirectory of the photos directory the thumbnails are kept in
+)
+
+// stored photo names, the upload date and a hash of the uploaded file e.g. 2024-03-10-3f2a9c0b1d4e.jpg
+var photoName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-[0-9a-f]{12}\.jpg$`)
+
+var galleryTempl = template.Must(template.New("gallery").Parse(`
+<!DOCTYPE html>
+<html>
+	<head>
+		<meta charset="UTF-8">
+		<title>Huxley's Photos</title>
+		<style>
+			h1 {color:blue;}
+			img {margin: 4px;}
+		</style>
+	</head>
+	<body style="font-size: xxx-large;">
+		<h1>Huxley's Photos</h1>
+		{{range .}}<a href="{{.URL}}"><img src="{{.Thumbnail}}" alt="Huxley on {{.Date}}" loading="lazy"></a>{{else}}<p>No photos yet</p>{{end}}
+		<p><a href="/huxley">Huxley's Details</a></p>
+	</body>
+</html>
+`))
+
+// A Photo is a stored photo of Huxley and its thumbnail
+type Photo struct {
+	Name      string
+	Date      string // yyyy-mm-dd uploaded
+	URL       string
+	Thumbnail string
+}
+
+// the photo stored under name, false when it isn't a stored photo name
+func storedPhoto(name string) (Photo, bool) {
+	match := photoName.FindStringSubmatch(name)
+	if match == nil {
+		return Photo{}, false
+	}
+
+	return Photo{Name: name, Date: match[1], URL: "/huxley/photos/" + name, Thumbnail: "/huxley/photos/" + thumbnailsDir + "/" + name}, true
+}
+
+// the photos directory, empty when photos aren't configured
+func photosDir() string {
+	store.Lock()
+	defer store.Unlock()
+
+	return store.photos
+}
+
+// the stored photos, most recent first, none when the directory doesn't exist yet
+func listPhotos(dir string) ([]Photo, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading huxley photos: %w", err)
+	}
+
+	var photos []Photo
+
+	for _, entry := range entries {
+		if photo, ok := storedPhoto(entry.Name()); ok && entry.Type().IsRegular() {
+			photos = append(photos, photo)
+		}
+	}
+
+	slices.Reverse(photos) // ReadDir sorts by name, so oldest first
+
+	return photos, nil
+}
+
+// the most recent photos for the /huxley page, errors are logged and no photos are shown
+func recentPhotos() []Photo {
+	dir := photosDir()
+	if dir == "" {
+		return nil
+	}
+
+	photos, err := listPhotos(dir)
+	if err != nil {
+		slog.Error("error listing huxley photos", "err", err)
+		return nil
+	}
+
+	return photos[:min(len(photos), pagePhotos)]
+}
+
+// Photos writes the gallery of Huxley's photos, ?format=json or an Accept header preferring json lists them as json.
+// It is 404 when HUXLEY_PHOTOS_DIR isn't configured
+func Photos(w http.ResponseWriter, req *http.Request) {
+	dir := photosDir()
+	if dir == "" {
+		http.NotFound(w, req)
+		return
+	}
+
+	photos, err := listPhotos(dir)
+	if err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	if negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		writeJSON(w, req, dataVersion(photos), photos)
+		return
+	}
+
+	var page bytes.Buffer
+	if err := galleryTempl.Execute(&page, photos); err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("error executing huxley gallery template: %w", err))
+		return
+	}
+
+	if _, err := page.WriteTo(w); err != nil {
+		slog.WarnContext(req.Context(), "error writing photos page", "err", err)
+	}
+}
+
+// ServePhoto writes the photo named by the {name} path value
+func ServePhoto(w http.ResponseWriter, req *http.Request) {
+	servePhotoFile(w, req, "")
+}
+
+// ServeThumbnail writes the thumbnail of the photo named by the {name} path value
+func ServeThumbnail(w http.ResponseWriter, req *http.Request) {
+	servePhotoFile(w, req, thumbnailsDir)
+}
+
+// write a stored image from the subdirectory of the photos directory, the names are content hashed so they can be
+// cached for good
+func servePhotoFile(w http.ResponseWriter, req *http.Request, subdir string) {
+	dir, name := photosDir(), req.PathValue("name")
+	if _, ok := storedPhoto(name); dir == "" || !ok {
+		http.NotFound(w, req)
+		return
+	}
+
+	file, err := os.Open(filepath.Join(dir, subdir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		http.NotFound(w, req)
+		return
+	}
+
+	if err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("error reading huxley photo: %w", err))
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("error reading huxley photo: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, req, name, info.ModTime(), file)
+}
+
+// UploadPhoto stores a jpeg, png or gif posted as the "photo" field of a multipart form, resized to at most 1600px
+// with a 320px thumbnail. It needs Authorization: Bearer <HUXLEY_TOKEN> and is 404 when no token or photos
+// directory is configured
+func UploadPhoto(w http.ResponseWriter, req *http.Request) {
+	dir := photosDir()
+	if dir == "" {
+		http.NotFound(w, req)
+		return
+	}
+
+	if !authorized(w, req) {
+		return
+	}
+
+	req.Body = http.MaxBytesReader(w, req.Body, maxPhotoBody)
+
+	upload, _, err := req.FormFile("photo")
+	if tooLarge := new(http.MaxBytesError); errors.As(err, &tooLarge) {
+		errorpage.JSON(w, req, http.StatusRequestEntityTooLarge, fmt.Errorf("photo is too large, the limit is %d MB", maxPhotoBody>>20))
+		return
+	}
+
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusBadRequest, fmt.Errorf("error reading posted photo: %w", err))
+		return
+	}
+	defer upload.Close()
+
+	data, err := io.ReadAll(upload)
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusBadRequest, fmt.Errorf("error reading posted photo: %w", err))
+		return
+	}
+
+	photo, thumbnail, err := resizePhoto(data)
+	if err != nil {
+		errorpage.JSON(w, req, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	hash := sha256.Sum256(data)
+	name := fmt.Sprintf("%s-%s.jpg", entryDate(""), hex.EncodeToString(hash[:6]))
+
+	if err := savePhoto(dir, name, photo, thumbnail); err != nil {
+		errorpage.JSON(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/huxley/photos/"+name)
+	w.WriteHeader(http.StatusCreated)
+
+	stored, _ := storedPhoto(name)
+	if err := json.NewEncoder(w).Encode(stored); err != nil {
+		slog.WarnContext(req.Context(), "error writing uploaded photo", "err", err)
+	}
+}
+
+// the uploaded image re-encoded as a jpeg photo and thumbnail
+func resizePhoto(data []byte) (photo, thumbnail []byte, err error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unsupported photo, must be a jpeg, png or gif: %w", err)
+	}
+
+	if config.Width*config.Height > maxPhotoPixels {
+		return nil, nil, fmt.Errorf("photo is too large, %dx%d is over %d pixels", config.Width, config.Height, int(maxPhotoPixels))
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error decoding %s photo: %w", format, err)
+	}
+
+	if photo, err = encodeJPEG(fit(img, photoSize)); err != nil {
+		return nil, nil, err
+	}
+
+	if thumbnail, err = encodeJPEG(fit(img, thumbnailSize)); err != nil {
+		return nil, nil, err
+	}
+
+	return photo, thumbnail, nil
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, img, &jpeg.Options{Quality: photoQuality}); err != nil {
+		return nil, fmt.Errorf("error encoding huxley photo: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// img scaled to fit within size pixels on its longest side, each pixel the average of the source pixels it covers.
+// Smaller images keep their size, transparent pixels are drawn over white as jpegs have no transparency
+func fit(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if longest := max(width, height); longest > size {
+		width, height = max(width*size/longest, 1), max(height*size/longest, 1)
+	}
+
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := range height {
+		top, bottom := bounds.Min.Y+y*bounds.Dy()/height, bounds.Min.Y+(y+1)*bounds.Dy()/height
+
+		for x := range width {
+			left, right := bounds.Min.X+x*bounds.Dx()/width, bounds.Min.X+(x+1)*bounds.Dx()/width
+
+			var r, g, b, a, n uint64
+
+			for sy := top; sy < bottom; sy++ {
+				for sx := left; sx < right; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+
+			white := 0xffff - a/n
+			scaled.Set(x, y, color.RGBA64{uint16(r/n + white), uint16(g/n + white), uint16(b/n + white), 0xffff})
+		}
+	}
+
+	return scaled
+}
+
+// write the photo and its thumbnail, the photo last so the gallery never lists one without a thumbnail
+func savePhoto(dir, name string, photo, thumbnail []byte) error {
+	if err := os.MkdirAll(filepath.Join(dir, thumbnailsDir), 0o755); err != nil {
+		return fmt.Errorf("error saving huxley photo: %w", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, thumbnailsDir, name), thumbnail); err != nil {
+		return fmt.Errorf("error saving huxley photo thumbnail: %w", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, name), photo); err != nil {
+		return fmt.Errorf("error saving huxley photo: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package huxley
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+// a multipart upload of a width x height png as the photo field
+func photoUpload(t *testing.T, width, height int) (body *bytes.Buffer, contentType string) {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		img.Set(x, 0, color.RGBA{200, 150, 50, 255})
+	}
+
+	body = new(bytes.Buffer)
+	form := multipart.NewWriter(body)
+
+	part, err := form.CreateFormFile("photo", "huxley.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := png.Encode(part, img); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return body, form.FormDataContentType()
+}
+
+func TestUploadPhoto(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dir := t.TempDir()
+
+	Configure(Settings{Clock: clock.NewFake(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)), Token: "secret", PhotosDir: dir})
+	defer Configure(Settings{})
+
+	body, contentType := photoUpload(t, 2000, 1000)
+	req := httptest.NewRequest(http.MethodPost, "/huxley/photos", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	UploadPhoto(w, req)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusCreated {
+		t.Fatalf("UploadPhoto() status = %d body = %s, want %d", w.Code, w.Body, http.StatusCreated)
+	}
+
+	var photo Photo
+	if err := json.Unmarshal(w.Body.Bytes(), &photo); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(photo.Name, "2024-03-10-") || photo.Date != "2024-03-10" || photo.Thumbnail != "/huxley/photos/thumbs/"+photo.Name {
+		t.Errorf("UploadPhoto() = %+v, want a photo uploaded on 2024-03-10", photo)
+	}
+
+	for path, want := range map[string]image.Point{
+		filepath.Join(dir, photo.Name):                {1600, 800},
+		filepath.Join(dir, thumbnailsDir, photo.Name): {320, 160},
+	} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := jpeg.DecodeConfig(file)
+		file.Close()
+
+		if err != nil || config.Width != want.X || config.Height != want.Y {
+			t.Errorf("stored %s = %dx%d (%v), want a %dx%d jpeg", path, config.Width, config.Height, err, want.X, want.Y)
+		}
+	}
+}
+
+func TestUploadPhotoRejected(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	Configure(Settings{Token: "secret", PhotosDir: t.TempDir()})
+	defer Configure(Settings{})
+
+	upload, contentType := photoUpload(t, 10, 10)
+
+	notImage := new(bytes.Buffer)
+	form := multipart.NewWriter(notImage)
+
+	part, err := form.CreateFormFile("photo", "huxley.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = part.Write([]byte("not a photo")) //nolint:errcheck // bytes.Buffer
+	_ = form.Close()                         //nolint:errcheck // bytes.Buffer
+
+	tests := []struct {
+		name        string
+		token       string
+		body        []byte
+		contentType string
+		wantStatus  int
+	}{
+		{"wrong token", "guess", upload.Bytes(), contentType, http.StatusUnauthorized},
+		{"not a form", "secret", []byte(`{"photo": "huxley.png"}`), "application/json", http.StatusBadRequest},
+		{"not an image", "secret", notImage.Bytes(), form.FormDataContentType(), http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/huxley/photos", bytes.NewReader(test.body))
+		req.Header.Set("Content-Type", test.contentType)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		UploadPhoto(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: UploadPhoto() status = %d body = %s, want %d", test.name, w.Code, w.Body, test.wantStatus)
+		}
+	}
+}
+
+func TestPhotoGallery(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dir := t.TempDir()
+	older, newer := "2024-01-05-0123456789ab.jpg", "2024-03-10-ba9876543210.jpg"
+
+	if err := os.MkdirAll(filepath.Join(dir, thumbnailsDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{older, newer, filepath.Join(thumbnailsDir, newer), "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, path), []byte("jpeg "+path), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Configure(Settings{PhotosDir: dir})
+	defer Configure(Settings{})
+
+	tests := []struct {
+		url        string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   string
+	}{
+		{"/huxley/photos", Photos, http.StatusOK,
+			`<a href="/huxley/photos/` + newer + `"><img src="/huxley/photos/thumbs/` + newer + `" alt="Huxley on 2024-03-10" loading="lazy"></a>` +
+				`<a href="/huxley/photos/` + older + `">`},
+		{"/huxley/photos?format=json", Photos, http.StatusOK, `{"Name":"` + newer + `","Date":"2024-03-10"`},
+		{"/huxley", DogStats, http.StatusOK, `<h3><a href="/huxley/photos">Photos</a></h3>`},
+		{"/huxley/photos/" + newer, ServePhoto, http.StatusOK, "jpeg " + newer},
+		{"/huxley/photos/thumbs/" + newer, ServeThumbnail, http.StatusOK, "jpeg thumbs/" + newer},
+		{"/huxley/photos/thumbs/" + older, ServeThumbnail, http.StatusNotFound, ""},
+		{"/huxley/photos/notes.txt", ServePhoto, http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+		req.SetPathValue("name", filepath.Base(req.URL.Path))
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		test.handler(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus || !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("GET %s status = %d body = %s, want %d with %s", test.url, w.Code, w.Body, test.wantStatus, test.wantBody)
+		}
+	}
+}
+
+func TestPhotosOff(t *testing.T) {
+	Configure(Settings{Token: "secret"})
+	defer Configure(Settings{})
+
+	for _, handler := range []http.HandlerFunc{Photos, UploadPhoto} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/huxley/photos", http.NoBody))
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("without HUXLEY_PHOTOS_DIR status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.NRGBA{0, 0, 0, 255})
+	img.Set(1, 0, color.NRGBA{0, 0, 0, 255}) // the rest is transparent, drawn over white
+
+	got := fit(img, 2)
+	if want := []uint8{128, 128, 128, 255, 255, 255, 255, 255}; got.Bounds() != image.Rect(0, 0, 2, 1) || !bytes.Equal(got.Pix, want) {
+		t.Errorf("fit(4x2, 2) = %v %v, want 2x1 %v", got.Bounds(), got.Pix, want)
+	}
+
+	if got := fit(img, 8); got.Bounds() != img.Bounds() {
+		t.Errorf("fit(4x2, 8) = %v, want the size kept", got.Bounds())
+	}
+}
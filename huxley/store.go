@@ -0,0 +1,266 @@
+package huxley
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/errorpage"
+)
+
+// largest posted entry accepted, in bytes
+const maxEntryBody = 4 << 10
+
+// A VetVisit is a dated visit to the vet and what it was for
+type VetVisit struct {
+	Date   string `json:"date"` // yyyy-mm-dd
+	Reason string `json:"reason"`
+	Notes  string `json:"notes,omitempty"`
+}
+
+// the weigh-ins and vet visits posted to the page, kept in the data file so they survive restarts. The field names
+// are matched case-insensitively so files written before the camelCase tags still read
+type records struct {
+	Weights   []Measurement `json:"weights"`
+	VetVisits []VetVisit    `json:"vetVisits"`
+}
+
+// the data file, the photos directory and the bearer token for posting entries and photos, set by Configure.
+// Posting is off without a token, the mutex serialises the file updates
+var store struct {
+	sync.Mutex
+	file   string
+	photos string
+	token  string
+}
+
+// the stored records, empty when there is no data file or it hasn't been written yet
+func loadRecords() (records, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	return readRecords()
+}
+
+// read the data file, the caller holds the store lock
+func readRecords() (records, error) {
+	var stored records
+
+	if store.file == "" {
+		return stored, nil
+	}
+
+	data, err := os.ReadFile(store.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return stored, nil
+	}
+
+	if err != nil {
+		return stored, fmt.Errorf("error reading huxley data file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return stored, fmt.Errorf("error parsing huxley data file %s: %w", store.file, err)
+	}
+
+	return stored, nil
+}
+
+// apply an update to the stored records and save them, a failed write doesn't lose the earlier entries
+func updateRecords(update func(*records)) error {
+	store.Lock()
+	defer store.Unlock()
+
+	if store.file == "" {
+		return errors.New("HUXLEY_DATA_FILE is not configured")
+	}
+
+	stored, err := readRecords()
+	if err != nil {
+		return err
+	}
+
+	update(&stored)
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding huxley records: %w", err)
+	}
+
+	if err := writeFile(store.file, data); err != nil {
+		return fmt.Errorf("error saving huxley records: %w", err)
+	}
+
+	return nil
+}
+
+// write data to a temporary file in the same directory and rename it over path, so a failed write leaves
+// any earlier file as it was
+func writeFile(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name()) //nolint:errcheck // gone after the rename
+
+	if _, err := temp.Write(data); err != nil {
+		_ = temp.Close() //nolint:errcheck // the write error is reported
+		return err
+	}
+
+	if err := temp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(temp.Name(), path)
+}
+
+// AddWeight records a posted weigh-in e.g. {"date": "2024-03-01", "kg": 31.2}, the date defaults to today.
+// It needs Authorization: Bearer <HUXLEY_TOKEN> and is 404 when no token is configured
+func AddWeight(w http.ResponseWriter, req *http.Request) {
+	var m Measurement
+	if !readEntry(w, req, &m) {
+		return
+	}
+
+	m.Date = entryDate(m.Date)
+
+	if err := validDate(m.Date); err != nil {
+		errorpage.JSON(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	if m.Kg <= 0 || m.Kg > 100 {
+		errorpage.JSON(w, req, http.StatusBadRequest, fmt.Errorf("invalid weight %gkg, must be more than 0 and at most 100", m.Kg))
+		return
+	}
+
+	saveEntry(w, req, m, func(stored *records) {
+		stored.Weights = append(stored.Weights, m)
+		slices.SortStableFunc(stored.Weights, func(a, b Measurement) int { return strings.Compare(a.Date, b.Date) })
+	})
+}
+
+// AddVetVisit records a posted vet visit e.g. {"date": "2024-03-01", "reason": "vaccinations"}, the date defaults
+// to today. It needs Authorization: Bearer <HUXLEY_TOKEN> and is 404 when no token is configured
+func AddVetVisit(w http.ResponseWriter, req *http.Request) {
+	var visit VetVisit
+	if !readEntry(w, req, &visit) {
+		return
+	}
+
+	visit.Date, visit.Reason, visit.Notes = entryDate(visit.Date), strings.TrimSpace(visit.Reason), strings.TrimSpace(visit.Notes)
+
+	if err := validDate(visit.Date); err != nil {
+		errorpage.JSON(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	if visit.Reason == "" {
+		errorpage.JSON(w, req, http.StatusBadRequest, errors.New("a vet visit needs a reason"))
+		return
+	}
+
+	saveEntry(w, req, visit, func(stored *records) {
+		stored.VetVisits = append(stored.VetVisits, visit)
+		slices.SortStableFunc(stored.VetVisits, func(a, b VetVisit) int { return strings.Compare(a.Date, b.Date) })
+	})
+}
+
+// check the bearer token and decode the posted json entry, writes the error response and returns false when
+// the request isn't allowed or the entry can't be read
+func readEntry(w http.ResponseWriter, req *http.Request, entry any) bool {
+	if !authorized(w, req) {
+		return false
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxEntryBody))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(entry); err != nil {
+		errorpage.JSON(w, req, http.StatusBadRequest, fmt.Errorf("error reading posted entry: %w", err))
+		return false
+	}
+
+	return true
+}
+
+// check the request carries the HUXLEY_TOKEN bearer token, writes a 404 when posting is off or a 401 and
+// returns false when it isn't allowed
+func authorized(w http.ResponseWriter, req *http.Request) bool {
+	store.Lock()
+	token := store.token
+	store.Unlock()
+
+	if token == "" {
+		http.NotFound(w, req)
+		return false
+	}
+
+	bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		errorpage.JSON(w, req, http.StatusUnauthorized, errors.New("a valid HUXLEY_TOKEN bearer token is required"))
+
+		return false
+	}
+
+	return true
+}
+
+// save a validated entry and respond 201 with it as json
+func saveEntry(w http.ResponseWriter, req *http.Request, entry any, update func(*records)) {
+	if err := updateRecords(update); err != nil {
+		errorpage.JSON(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(entry); err != nil {
+		slog.WarnContext(req.Context(), "error writing saved entry", "err", err)
+	}
+}
+
+// the posted date, today in Dublin when it is empty
+func entryDate(date string) string {
+	if date = strings.TrimSpace(date); date != "" {
+		return date
+	}
+
+	return clk.Now().In(dublin()).Format(dateLayout)
+}
+
+// a yyyy-mm-dd date that isn't in the future
+func validDate(date string) error {
+	day, err := time.ParseInLocation(dateLayout, date, dublin())
+	if err != nil {
+		return fmt.Errorf("invalid date %q, must be YYYY-MM-DD", date)
+	}
+
+	if day.After(clk.Now()) {
+		return fmt.Errorf("invalid date %q, it is in the future", date)
+	}
+
+	return nil
+}
+
+// Huxley's time zone, UTC if it can't be loaded
+func dublin() *time.Location {
+	loc, err := time.LoadLocation("Europe/Dublin")
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
@@ -0,0 +1,128 @@
+package huxley
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestAddEntries(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dataFile := filepath.Join(t.TempDir(), "huxley.json")
+
+	Configure(Settings{Clock: clock.NewFake(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)), DataFile: dataFile, Token: "secret"})
+	defer Configure(Settings{})
+
+	tests := []struct {
+		name       string
+		add        http.HandlerFunc
+		token      string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"weight", AddWeight, "secret", `{"date": "2024-03-01", "kg": 31.2}`, http.StatusCreated, `{"date":"2024-03-01","kg":31.2}`},
+		{"earlier weight", AddWeight, "secret", `{"date": "2024-01-10", "kg": 30.5}`, http.StatusCreated, `{"date":"2024-01-10","kg":30.5}`},
+		{"weight today", AddWeight, "secret", `{"kg": 31.4}`, http.StatusCreated, `{"date":"2024-03-10","kg":31.4}`},
+		{"vet visit", AddVetVisit, "secret", `{"date": "2024-02-14", "reason": "vaccinations", "notes": " booster due 2025 "}`, http.StatusCreated,
+			`{"date":"2024-02-14","reason":"vaccinations","notes":"booster due 2025"}`},
+		{"wrong token", AddWeight, "guess", `{"kg": 31.4}`, http.StatusUnauthorized, "HUXLEY_TOKEN"},
+		{"no token", AddWeight, "", `{"kg": 31.4}`, http.StatusUnauthorized, "HUXLEY_TOKEN"},
+		{"invalid weight", AddWeight, "secret", `{"kg": -1}`, http.StatusBadRequest, "invalid weight"},
+		{"future date", AddWeight, "secret", `{"date": "2024-03-11", "kg": 31.4}`, http.StatusBadRequest, "in the future"},
+		{"invalid date", AddVetVisit, "secret", `{"date": "March", "reason": "checkup"}`, http.StatusBadRequest, "must be YYYY-MM-DD"},
+		{"no reason", AddVetVisit, "secret", `{"date": "2024-03-01"}`, http.StatusBadRequest, "needs a reason"},
+		{"unknown field", AddWeight, "secret", `{"kg": 31.4, "lbs": 69}`, http.StatusBadRequest, "unknown field"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/huxley/weights", strings.NewReader(test.body))
+		if test.token != "" {
+			req.Header.Set("Authorization", "Bearer "+test.token)
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		test.add(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus || !strings.Contains(w.Body.String(), test.wantBody) {
+			t.Errorf("%s: status = %d body = %s, want %d with %s", test.name, w.Code, w.Body, test.wantStatus, test.wantBody)
+		}
+	}
+
+	data, err := os.ReadFile(dataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored records
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+
+	want := records{
+		Weights:   []Measurement{{"2024-01-10", 30.5}, {"2024-03-01", 31.2}, {"2024-03-10", 31.4}},
+		VetVisits: []VetVisit{{Date: "2024-02-14", Reason: "vaccinations", Notes: "booster due 2025"}},
+	}
+	if !reflect.DeepEqual(stored, want) {
+		t.Errorf("data file = %+v, want %+v", stored, want)
+	}
+
+	if !strings.Contains(string(data), `"vetVisits": [`) || !strings.Contains(string(data), `"reason": "vaccinations"`) {
+		t.Errorf("data file = %s, want camelCase field names", data)
+	}
+}
+
+func TestAddEntryPostingOff(t *testing.T) {
+	Configure(Settings{DataFile: filepath.Join(t.TempDir(), "huxley.json")})
+	defer Configure(Settings{})
+
+	req := httptest.NewRequest(http.MethodPost, "/huxley/weights", strings.NewReader(`{"kg": 31.4}`))
+	req.Header.Set("Authorization", "Bearer ")
+
+	w := httptest.NewRecorder()
+	AddWeight(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("AddWeight() without HUXLEY_TOKEN status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestDogStatsRecords(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dataFile := filepath.Join(t.TempDir(), "huxley.json")
+
+	// written before the camelCase field names
+	data := `{"Weights": [{"Date": "2024-03-01", "Kg": 31.5}],
+		"VetVisits": [{"Date": "2024-01-05", "Reason": "checkup"}, {"Date": "2024-02-14", "Reason": "vaccinations", "Notes": "booster"}]}`
+	if err := os.WriteFile(dataFile, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	Configure(Settings{DataFile: dataFile, Weights: `[{"date": "2024-01-10", "kg": 30.0}]`})
+	defer Configure(Settings{})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	DogStats(w, httptest.NewRequest(http.MethodGet, "/huxley", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	for _, want := range []string{
+		"Weight: 31.5kg on 2024-03-01 (&#43;1.5kg since 2024-01-10)",
+		`<polyline points="10,190 590,10"`,
+		"<li>2024-02-14: vaccinations (booster)</li><li>2024-01-05: checkup</li>",
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("DogStats() html = %s, want it to contain %s", w.Body, want)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package huxley
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// sparkline bars from lowest to highest
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// A Measurement is a dated weigh-in
+type Measurement struct {
+	Date string  `json:"date"` // yyyy-mm-dd
+	Kg   float64 `json:"kg"`
+}
+
+// A WeightTrend is the latest weigh-in and its change from the one before,
+// Change and Sparkline are only set when there are at least two measurements
+type WeightTrend struct {
+	Latest    Measurement
+	Previous  *Measurement
+	Change    float64
+	Sparkline string
+	Series    []Measurement
+}
+
+// weigh-ins from HUXLEY_WEIGHTS, set by Configure
+var configuredWeights []Measurement
+
+// weigh-ins from environment variable HUXLEY_WEIGHTS e.g. [{"date": "2024-01-10", "kg": 30.5}] and those posted
+// to the data file, in date order
+func weightSeries() []Measurement {
+	series := slices.Concat(configuredWeights, storedRecords().Weights)
+
+	// iso dates sort chronologically as strings
+	slices.SortStableFunc(series, func(a, b Measurement) int { return strings.Compare(a.Date, b.Date) })
+
+	return series
+}
+
+// the records posted to the data file, none when it can't be read
+func storedRecords() records {
+	stored, err := loadRecords()
+	if err != nil {
+		slog.Error("huxley records ignored", "err", err)
+	}
+
+	return stored
+}
+
+// parse the weigh-ins from HUXLEY_WEIGHTS, none when invalid
+func parseWeights(value string) []Measurement {
+	if value == "" {
+		return nil
+	}
+
+	var series []Measurement
+	if err := json.Unmarshal([]byte(value), &series); err != nil {
+		slog.Warn("invalid HUXLEY_WEIGHTS ignored", "err", err)
+		return nil
+	}
+
+	for _, m := range series {
+		if _, err := time.Parse(dateLayout, m.Date); err != nil {
+			slog.Warn("invalid HUXLEY_WEIGHTS date ignored", "err", err)
+			return nil
+		}
+	}
+
+	return series
+}
+
+// latest value and change from a date ordered series, nil when there are no measurements
+func weightTrend(series []Measurement) *WeightTrend {
+	if len(series) == 0 {
+		return nil
+	}
+
+	trend := WeightTrend{Latest: series[len(series)-1], Series: series}
+	if len(series) == 1 {
+		return &trend
+	}
+
+	previous := series[len(series)-2]
+	trend.Previous = &previous
+	trend.Change = math.Round((trend.Latest.Kg-previous.Kg)*10) / 10 //nolint:gomnd // round to 0.1kg
+	trend.Sparkline = sparkline(series)
+
+	return &trend
+}
+
+// one bar per measurement scaled between the lowest and highest weights
+func sparkline(series []Measurement) string {
+	lowest, highest := series[0].Kg, series[0].Kg
+	for _, m := range series {
+		lowest = min(lowest, m.Kg)
+		highest = max(highest, m.Kg)
+	}
+
+	var line strings.Builder
+
+	for _, m := range series {
+		bar := 0
+		if highest > lowest {
+			bar = int(math.Round((m.Kg - lowest) / (highest - lowest) * float64(len(sparkBars)-1)))
+		}
+
+		line.WriteRune(sparkBars[bar])
+	}
+
+	return line.String()
+}
+
+// change formatted with its sign for the page, e.g. "+0.7kg"
+func (t WeightTrend) ChangeLabel() string {
+	return fmt.Sprintf("%+.1fkg", t.Change)
+}
+
+// chart dimensions in pixels
+const (
+	chartWidth   = 600
+	chartHeight  = 200
+	chartPadding = 10
+)
+
+// A Chart is the weight series drawn as an svg line, x is the date and y the weight
+type Chart struct {
+	Width, Height int
+	Points        string // svg polyline points e.g. "10,190 590,10"
+	Lowest        float64
+	Highest       float64
+}
+
+// the series scaled to the chart, nil for fewer than two measurements
+func (t WeightTrend) Chart() *Chart {
+	if len(t.Series) < 2 {
+		return nil
+	}
+
+	first, _ := time.Parse(dateLayout, t.Series[0].Date)
+	last, _ := time.Parse(dateLayout, t.Series[len(t.Series)-1].Date)
+	days := max(last.Sub(first).Hours()/hoursInDay, 1)
+
+	chart := Chart{Width: chartWidth, Height: chartHeight, Lowest: t.Series[0].Kg, Highest: t.Series[0].Kg}
+	for _, m := range t.Series {
+		chart.Lowest = min(chart.Lowest, m.Kg)
+		chart.Highest = max(chart.Highest, m.Kg)
+	}
+
+	spread := max(chart.Highest-chart.Lowest, 1) // a flat series is drawn across the middle
+	points := make([]string, 0, len(t.Series))
+
+	for _, m := range t.Series {
+		date, _ := time.Parse(dateLayout, m.Date)
+		x := chartPadding + date.Sub(first).Hours()/hoursInDay/days*(chartWidth-2*chartPadding)
+		y := chartHeight - chartPadding - (m.Kg-chart.Lowest)/spread*(chartHeight-2*chartPadding)
+
+		if chart.Highest == chart.Lowest {
+			y = chartHeight / 2
+		}
+
+		points = append(points, fmt.Sprintf("%.0f,%.0f", x, y))
+	}
+
+	chart.Points = strings.Join(points, " ")
+
+	return &chart
+}
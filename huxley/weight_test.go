@@ -0,0 +1,89 @@
+package huxley
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWeightTrend(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	Configure(Settings{Weights: `[{"date": "2024-03-01", "kg": 31.5}, {"date": "2024-01-10", "kg": 30.0}, {"date": "2024-05-20", "kg": 32.2}]`})
+	defer Configure(Settings{})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := weightTrend(weightSeries())
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := &WeightTrend{
+		Latest:    Measurement{"2024-05-20", 32.2},
+		Previous:  &Measurement{"2024-03-01", 31.5},
+		Change:    0.7,
+		Sparkline: "▁▆█",
+		Series:    []Measurement{{"2024-01-10", 30.0}, {"2024-03-01", 31.5}, {"2024-05-20", 32.2}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("weightTrend() = %+v, want %+v", got, want)
+	}
+
+	if label := got.ChangeLabel(); label != "+0.7kg" {
+		t.Errorf("ChangeLabel() = %q, want %q", label, "+0.7kg")
+	}
+}
+
+func TestWeightTrendSinglePoint(t *testing.T) {
+	got := weightTrend([]Measurement{{"2024-01-10", 30.0}})
+	if got == nil || got.Latest.Kg != 30.0 || got.Previous != nil || got.Sparkline != "" {
+		t.Errorf("weightTrend() single point = %+v, want latest only with no trend", got)
+	}
+
+	if got := weightTrend(nil); got != nil {
+		t.Errorf("weightTrend(nil) = %+v, want nil", got)
+	}
+}
+
+func TestDogStatsWeight(t *testing.T) {
+	Configure(Settings{Weights: `[{"date": "2024-01-10", "kg": 30.0}, {"date": "2024-03-01", "kg": 31.5}]`})
+	defer Configure(Settings{})
+
+	w := httptest.NewRecorder()
+	DogStats(w, httptest.NewRequest(http.MethodGet, "/huxley", http.NoBody))
+
+	if body := w.Body.String(); !strings.Contains(body, "Weight: 31.5kg on 2024-03-01 (&#43;1.5kg since 2024-01-10) ▁█") {
+		t.Errorf("DogStats() html = %s, want the weight trend", body)
+	}
+
+	w = httptest.NewRecorder()
+	DogStats(w, httptest.NewRequest(http.MethodGet, "/huxley?format=json", http.NoBody))
+
+	var got DogStat
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Weight == nil || len(got.Weight.Series) != 2 {
+		t.Errorf("DogStats() json weight = %+v, want the series", got.Weight)
+	}
+}
+
+func TestWeightTrendChart(t *testing.T) {
+	tests := []struct {
+		series []Measurement
+		want   *Chart
+	}{
+		{[]Measurement{{"2024-01-01", 30}}, nil},
+		{[]Measurement{{"2024-01-01", 30}, {"2024-01-11", 32}}, &Chart{600, 200, "10,190 590,10", 30, 32}},
+		{[]Measurement{{"2024-01-01", 30}, {"2024-01-06", 32}, {"2024-01-11", 31}}, &Chart{600, 200, "10,190 300,10 590,100", 30, 32}},
+		{[]Measurement{{"2024-01-01", 31}, {"2024-01-11", 31}}, &Chart{600, 200, "10,100 590,100", 31, 31}},
+	}
+
+	for _, test := range tests {
+		got := WeightTrend{Series: test.series}.Chart()
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Chart(%v) = %+v, want %+v", test.series, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// translates the text of the rendered pages, choosing the language from the ?lang= query parameter or the
+// Accept-Language header
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// supported languages, the default first as the other catalogs fall back to it
+var languages = []string{"en", "es", "pt"}
+
+// Default is the language used when the request doesn't ask for a supported one
+const Default = "en"
+
+//go:embed en.json es.json pt.json
+var catalogFS embed.FS
+
+// Messages are a language's page text keyed by message name, for templates e.g. {{ .Text.points }}
+type Messages map[string]string
+
+// message catalogs keyed by language, every catalog has every English message
+var catalogs = loadCatalogs()
+
+// parse the embedded catalogs, missing translations fall back to English
+func loadCatalogs() map[string]Messages {
+	loaded := make(map[string]Messages, len(languages))
+
+	for _, lang := range languages {
+		body, err := catalogFS.ReadFile(lang + ".json")
+		if err != nil {
+			panic(fmt.Sprintf("i18n: reading the %s catalog: %s", lang, err))
+		}
+
+		var messages Messages
+		if err := json.Unmarshal(body, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: parsing the %s catalog: %s", lang, err))
+		}
+
+		for name, text := range loaded[Default] {
+			if _, ok := messages[name]; !ok {
+				messages[name] = text
+			}
+		}
+
+		loaded[lang] = messages
+	}
+
+	return loaded
+}
+
+// Language returns the supported language for the request, from ?lang= or else the Accept-Language entry with the
+// highest quality, matched by its primary subtag so pt-BR is pt. Responses vary by Accept-Language
+func Language(w http.ResponseWriter, req *http.Request) string {
+	w.Header().Add("Vary", "Accept-Language")
+
+	if lang := strings.ToLower(req.URL.Query().Get("lang")); slices.Contains(languages, lang) {
+		return lang
+	}
+
+	best, bestQuality := Default, 0.0
+
+	for _, entry := range strings.Split(req.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+
+		if slices.Contains(languages, primary) && quality > bestQuality {
+			best, bestQuality = primary, quality
+		}
+	}
+
+	return best
+}
+
+// Text returns the page text in the language, English for an unsupported language
+func Text(lang string) Messages {
+	if messages, ok := catalogs[lang]; ok {
+		return messages
+	}
+
+	return catalogs[Default]
+}
+
+// T returns the named message, the name itself when there is no such message
+func (m Messages) T(name string) string {
+	if text, ok := m[name]; ok {
+		return text
+	}
+
+	return name
+}
+
+// Status returns the translated status text e.g. Not Found, http.StatusText when it isn't translated
+func (m Messages) Status(status int) string {
+	if text, ok := m["status"+strconv.Itoa(status)]; ok {
+		return text
+	}
+
+	return http.StatusText(status)
+}
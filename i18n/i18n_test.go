@@ -0,0 +1,108 @@
+package i18n
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		url            string
+		acceptLanguage string
+		want           string
+	}{
+		{"/cann", "", "en"},
+		{"/cann", "pt-BR,pt;q=0.9,en;q=0.8", "pt"},
+		{"/cann", "de-DE, es;q=0.5, en;q=0.4", "es"},
+		{"/cann", "en;q=0.3, ES-mx;q=0.7", "es"},
+		{"/cann", "es;q=0, fr", "en"},
+		{"/cann", "fr-FR", "en"},
+		{"/cann?lang=PT", "es", "pt"},
+		{"/cann?lang=fr", "es", "es"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+		req.Header.Set("Accept-Language", test.acceptLanguage)
+
+		w := httptest.NewRecorder()
+		if got := Language(w, req); got != test.want {
+			t.Errorf("Language(%s, Accept-Language %q) = %q, want %q", test.url, test.acceptLanguage, got, test.want)
+		}
+
+		if got := w.Header().Get("Vary"); got != "Accept-Language" {
+			t.Errorf("Language(%s) Vary = %q, want Accept-Language", test.url, got)
+		}
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	english := readCatalog(t, Default)
+
+	for _, lang := range languages {
+		catalog := readCatalog(t, lang)
+
+		for name := range english {
+			if catalog[name] == "" {
+				t.Errorf("%s catalog has no %q message", lang, name)
+			}
+		}
+
+		for name := range catalog {
+			if _, ok := english[name]; !ok {
+				t.Errorf("%s catalog has %q which isn't an English message", lang, name)
+			}
+		}
+	}
+}
+
+func readCatalog(t *testing.T, lang string) map[string]string {
+	t.Helper()
+
+	body, err := catalogFS.ReadFile(lang + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var catalog map[string]string
+	if err := json.Unmarshal(body, &catalog); err != nil {
+		t.Fatalf("%s catalog: %s", lang, err)
+	}
+
+	return catalog
+}
+
+func TestMessages(t *testing.T) {
+	tests := []struct {
+		lang       string
+		wantPoints string
+		wantStatus string
+	}{
+		{"en", "Points", "Not Found"},
+		{"es", "Puntos", "No encontrado"},
+		{"pt", "Pontos", "Não encontrado"},
+		{"fr", "Points", "Not Found"},
+	}
+
+	for _, test := range tests {
+		messages := Text(test.lang)
+
+		if got := messages.T("points"); got != test.wantPoints {
+			t.Errorf("Text(%q).T(points) = %q, want %q", test.lang, got, test.wantPoints)
+		}
+
+		if got := messages.Status(http.StatusNotFound); got != test.wantStatus {
+			t.Errorf("Text(%q).Status(404) = %q, want %q", test.lang, got, test.wantStatus)
+		}
+	}
+
+	if got := Text("pt").Status(http.StatusTeapot); got != "I'm a teapot" {
+		t.Errorf("Status(418) = %q, want the untranslated status text", got)
+	}
+
+	if got := Text("es").T("missing"); got != "missing" {
+		t.Errorf("T(missing) = %q, want the message name", got)
+	}
+}
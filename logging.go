@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// request header carrying the request id, an incoming one from a proxy is kept, otherwise one is generated
+const requestIDHeader = "X-Request-Id"
+
+// longest incoming request id kept, longer ones are replaced so a client can't flood the logs
+const maxRequestIDLength = 64
+
+type requestIDKey struct{}
+
+// a logger writing text, or json lines with format json, at level and above. log package output from the
+// handler packages goes through it too once it is the default. An unknown level logs info and above
+func newLogger(w io.Writer, level, format string) *slog.Logger {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		minLevel = slog.LevelInfo
+	}
+
+	options := &slog.HandlerOptions{Level: minLevel}
+
+	var handler slog.Handler = slog.NewTextHandler(w, options)
+	if format == "json" {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(requestIDHandler{handler})
+}
+
+// adds the request id from the context to every record logged with one
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := requestID(ctx); id != "" {
+		record.AddAttrs(slog.String("requestId", id))
+	}
+
+	return h.Handler.Handle(ctx, record) //nolint:wrapcheck // pass through
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
+
+// give each request an id in its context and the X-Request-Id response header
+func requestIDs(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id)))
+	})
+}
+
+// the request id set by the requestIDs middleware, empty outside a request
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// a random 16 byte hex request id
+func newRequestID() string {
+	id := make([]byte, 16) //nolint:gomnd // 128 bits
+	_, _ = rand.Read(id)   //nolint:errcheck // crypto/rand doesn't fail on supported platforms
+
+	return hex.EncodeToString(id)
+}
+
+// incoming ids are kept when they are short printable ascii without spaces
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestIDs(t *testing.T) {
+	tests := []struct {
+		incoming string
+		wantKept bool
+	}{
+		{"", false},
+		{"abc-123", true},
+		{"has space", false},
+		{strings.Repeat("x", maxRequestIDLength+1), false},
+	}
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		var seen string
+		handler := requestIDs(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			seen = requestID(req.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/cann", http.NoBody)
+		if test.incoming != "" {
+			req.Header.Set(requestIDHeader, test.incoming)
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		got := w.Header().Get(requestIDHeader)
+		if got == "" || got != seen {
+			t.Errorf("incoming %q: response id %q, context id %q, want the same non-empty id", test.incoming, got, seen)
+		}
+
+		if kept := got == test.incoming; kept != test.wantKept {
+			t.Errorf("incoming %q: response id %q, want kept %t", test.incoming, got, test.wantKept)
+		}
+	}
+}
+
+func TestJSONAccessLogHasRequestID(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	var buf bytes.Buffer
+
+	accessLog := newAccessLogger(newLogger(&buf, "info", "json"), 1, time.Hour)
+	handler := requestIDs(accessLog.middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/missing", http.NoBody)
+	req.Header.Set(requestIDHeader, "req-1")
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var got struct {
+		Level     string `json:"level"`
+		Msg       string `json:"msg"`
+		Path      string `json:"path"`
+		Status    int    `json:"status"`
+		RequestID string `json:"requestId"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("access log %q isn't a json line: %v", buf.String(), err)
+	}
+
+	if got.Level != "WARN" || got.Msg != "request" || got.Path != "/missing" || got.Status != http.StatusNotFound || got.RequestID != "req-1" {
+		t.Errorf("access log = %+v, want a WARN request line for /missing 404 with requestId req-1", got)
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	tests := []struct {
+		level     string
+		wantDebug bool
+		wantInfo  bool
+	}{
+		{"debug", true, true},
+		{"info", false, true},
+		{"warn", false, false},
+		{"loud", false, true},
+	}
+
+	for _, test := range tests {
+		logger := newLogger(&bytes.Buffer{}, test.level, "text")
+
+		if got := logger.Enabled(context.Background(), slog.LevelDebug); got != test.wantDebug {
+			t.Errorf("LOG_LEVEL=%s debug enabled = %t, want %t", test.level, got, test.wantDebug)
+		}
+
+		if got := logger.Enabled(context.Background(), slog.LevelInfo); got != test.wantInfo {
+			t.Errorf("LOG_LEVEL=%s info enabled = %t, want %t", test.level, got, test.wantInfo)
+		}
+	}
+}
@@ -2,85 +2,555 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
-	"time"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 
 	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/config"
+	"github.com/mick4711/moh/errorpage"
 	"github.com/mick4711/moh/fpl"
 	"github.com/mick4711/moh/huxley"
+	"github.com/mick4711/moh/scorers"
 )
 
-const (
-	ServerReadTimeout  = 5 * time.Second
-	ServerWriteTimeout = 10 * time.Second
-)
+// build version, set at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// the current time, shared with the handler packages
+var clk clock.Clock = clock.Real{}
+
+// a route served by the mux, debug routes are only registered when DEBUG is set
+type route struct {
+	pattern string
+	handler http.HandlerFunc
+	title   string // home page link text, routes without a title aren't linked
+	debug   bool
+}
+
+var routes = []route{
+	{pattern: "GET /{$}", handler: homeHandler},
+	{pattern: "GET /cann", handler: cannHandler, title: "Cann Table"},
+	{pattern: "GET /cann.svg", handler: cannImageHandler, title: "Cann Table Image"},
+	{pattern: "GET /cann/gaps", handler: cannGapsHandler},
+	{pattern: "GET /cann/context", handler: cannContextHandler},
+	{pattern: "GET /cann/{competition}", handler: cannHandler},
+	{pattern: "GET /fixtures", handler: fixturesHandler, title: "Fixtures"},
+	{pattern: "GET /fixtures.ics", handler: fixturesCalendarHandler, title: "Fixtures Calendar"},
+	{pattern: "GET /scorers", handler: scorersHandler, title: "Top Scorers"},
+	{pattern: "GET /compare", handler: compareHandler, title: "Head to Head"},
+	{pattern: "GET /huxley", handler: huxleyHandler, title: "Huxley's Details"},
+	{pattern: "POST /huxley/weights", handler: huxleyWeightsHandler},
+	{pattern: "POST /huxley/vet-visits", handler: huxleyVetVisitsHandler},
+	{pattern: "GET /huxley/photos", handler: huxleyPhotosHandler},
+	{pattern: "POST /huxley/photos", handler: huxleyUploadPhotoHandler},
+	{pattern: "GET /huxley/photos/{name}", handler: huxleyPhotoHandler},
+	{pattern: "GET /huxley/photos/thumbs/{name}", handler: huxleyThumbnailHandler},
+	{pattern: "GET /fpl", handler: fplHandler, title: "FPL JSON"},
+	{pattern: "GET /fpl/bootstrap", handler: fplBootstrapHandler},
+	{pattern: "GET /fpl/live", handler: fplLiveHandler, title: "FPL Live JSON"},
+	{pattern: "GET /fpl/summary", handler: fplSummaryHandler, title: "FPL Captains and Transfers"},
+	{pattern: "GET /fpl/{leagueID}", handler: fplHandler},
+	{pattern: "GET /events", handler: eventsHandler},
+	{pattern: "GET /events.js", handler: eventsScriptHandler},
+	{pattern: "GET /healthz", handler: healthzHandler},
+	{pattern: "GET /readyz", handler: readyzHandler},
+	{pattern: "GET /metrics", handler: metricsHandler},
+	{pattern: "GET /api", handler: apiHandler},
+	{pattern: "GET /api/v1/openapi.json", handler: openAPIHandler},
+	{pattern: "GET /api/v1/cann", handler: apiV1(cannHandler)},
+	{pattern: "GET /api/v1/cann/gaps", handler: apiV1(cannGapsHandler)},
+	{pattern: "GET /api/v1/compare", handler: apiV1(compareHandler)},
+	{pattern: "GET /api/v1/fixtures", handler: apiV1(fixturesHandler)},
+	{pattern: "GET /api/v1/scorers", handler: apiV1(scorersHandler)},
+	{pattern: "GET /api/v1/fpl", handler: apiV1(fplHandler)},
+	{pattern: "GET /api/v1/fpl/bootstrap", handler: apiV1(fplBootstrapHandler)},
+	{pattern: "GET /api/v1/fpl/live", handler: apiV1(fplLiveHandler)},
+	{pattern: "GET /api/v1/fpl/summary", handler: apiV1(fplSummaryHandler)},
+	{pattern: "GET /export", handler: exportHandler},
+	{pattern: "GET /admin", handler: adminHandler},
+	{pattern: "POST /admin/refresh", handler: adminRefreshHandler},
+	{pattern: "POST /admin/clear-cache", handler: adminClearCacheHandler},
+	{pattern: "GET /debug/cache", handler: debugCacheHandler, debug: true},
+	{pattern: "POST /debug/render", handler: debugRenderHandler, debug: true},
+	{pattern: "GET /debug/metrics", handler: debugMetricsHandler, debug: true},
+}
+
+// a link on the home page
+type homeLink struct {
+	Title string
+	URL   string
+}
+
+// links to the enabled routes, set at startup
+var homeLinks []homeLink
 
 // main entry point - http server
 func main() {
+	cfg := config.Load()
+
+	slog.SetDefault(newLogger(os.Stderr, cfg.LogLevel, cfg.LogFormat))
+
+	if configErr = cfg.Validate(); configErr != nil {
+		slog.Error("invalid configuration", "err", configErr)
+		os.Exit(1)
+	}
+
+	var odds cann.OddsProvider
+	if cfg.OddsSourceURL != "" {
+		odds = cann.NewHTTPOddsProvider(cfg.OddsSourceURL)
+	}
+
+	schedule := newRefreshSchedule(cfg.RefreshInterval, cfg.MatchDayRefresh)
+
+	cann.Configure(cann.Settings{
+		BaseURL:         cfg.StandingsBaseURL,
+		APIToken:        cfg.APIToken,
+		TTL:             cfg.StandingsTTL,
+		ScorersTTL:      cfg.ScorersTTL,
+		MaxEntries:      cfg.CacheMaxEntries,
+		UpstreamTimeout: cfg.UpstreamTimeout,
+		RetryAttempts:   cfg.RetryAttempts,
+		Odds:            odds,
+		RowSort:         cfg.CannRowSort,
+		Clock:           clk,
+
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+
+		StaleWhileRevalidate: cfg.StaleWhileRevalidate,
+		FreshnessCheck:       cfg.FreshnessCheck,
+		UpdatingTTL:          cfg.UpdatingTTL,
+
+		Metrics: metricsRegistry,
+
+		TemplateDir: cannTemplateDir(cfg.TemplateDir),
+		SnapshotDir: cfg.SnapshotDir,
+		WarmMaxAge:  2 * schedule.longest(),
+
+		PointsAdjustments: cfg.PointsAdjustments,
+		MinMatchdays:      cfg.MinMatchdays,
+		SeasonGames:       cfg.SeasonGames,
+		XGSourceURL:       cfg.XGSourceURL,
+		AlertTeams:        cfg.AlertTeams,
+		AlertWebhooks:     cfg.AlertWebhooks,
+		DerbyPairs:        cfg.DerbyPairs,
+		DerbyPoints:       cfg.DerbyPoints,
+		EuropeanPlaces:    cfg.EuropeanPlaces,
+	})
+	fpl.Configure(fpl.Settings{BaseURL: cfg.FPLBaseURL, CacheTTL: cfg.FPLBootstrapTTL, PointsTTL: cfg.FPLCacheTTL, Clock: clk,
+		UpstreamTimeout: cfg.UpstreamTimeout, BreakerThreshold: cfg.BreakerThreshold, BreakerCooldown: cfg.BreakerCooldown,
+		Metrics: metricsRegistry, WarmMaxAge: 2 * schedule.longest(),
+		Managers: cfg.Managers, Anonymize: cfg.FPLAnonymize, NamesToken: cfg.FPLNamesToken, Fields: cfg.FPLFields, BootstrapSections: cfg.FPLBootstrapSections})
+	huxley.Configure(huxley.Settings{Clock: clk, DataFile: cfg.HuxleyDataFile, Token: cfg.HuxleyToken, PhotosDir: cfg.HuxleyPhotosDir,
+		Weights: cfg.HuxleyWeights, CacheTTL: cfg.HuxleyCacheTTL})
+
+	exportAccess.debug, exportAccess.token = cfg.Debug, cfg.ExportToken
+	adminAccess.user, adminAccess.password, adminAccess.refresh = cfg.AdminUser, cfg.AdminPassword, refreshSources(cfg.Managers)
+	apiTokenSet = cfg.APIToken != ""
+	templateDir = cfg.TemplateDir
+
+	enabled := enabledRoutes(cfg)
+	homeLinks = linksFor(enabled)
+
+	document, err := newOpenAPIDocument(enabled)
+	if err != nil {
+		slog.Error("error generating the openapi document", "err", err)
+		os.Exit(1)
+	}
+
+	openAPIDocument = document
+
 	mux := http.NewServeMux()
-	mux.HandleFunc("GET /{$}", homeHandler)
-	mux.HandleFunc("GET /cann", cannHandler)
-	mux.HandleFunc("GET /huxley", huxleyHandler)
-	mux.HandleFunc("GET /fpl", fplHandler)
+	for _, r := range enabled {
+		mux.Handle(r.pattern, r.handler)
+	}
 
 	srv := http.Server{
-		ReadTimeout:  ServerReadTimeout,
-		WriteTimeout: ServerWriteTimeout,
-		Addr:         ":8080",
-		Handler:      mux,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		Addr:         cfg.Addr,
+		Handler:      chain(mux, middlewares(cfg)...),
+	}
+
+	srv.RegisterOnShutdown(eventsHub.Close) // the streams would hold up the shutdown
+
+	if cfg.TLSCertFile != "" {
+		cert, err := newCertificate(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			slog.Error("error loading tls certificate", "err", err)
+			os.Exit(1)
+		}
+
+		srv.TLSConfig = tlsConfig(cert)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go newRefresher(eventsHub, schedule, refreshSources(cfg.Managers)).run(ctx)
+
+	slog.Info(startupMessage(cfg))
+
+	listener, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		slog.Error("error listening", "err", err)
+		os.Exit(1)
+	}
+
+	slog.Info("listening", "addr", listener.Addr().String(), "tls", srv.TLSConfig != nil)
+
+	if cfg.RedirectAddr != "" {
+		if err := serveRedirects(&srv, cfg.RedirectAddr); err != nil {
+			slog.Error("error serving https redirects", "err", err)
+			os.Exit(1)
+		}
 	}
 
-	log.Println("Listening on port 8080")
-	log.Fatal(srv.ListenAndServe())
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	if err := serve(&srv, listener, stop, cfg.ShutdownTimeout); err != nil {
+		slog.Error("server stopped", "err", err)
+		os.Exit(1)
+	}
 }
 
-// log request details
-func logRequest(req *http.Request) {
-	if req.RequestURI == "/favicon.ico" {
-		return
+// the middleware chain around the mux in the order requests pass through it. Panics are recovered inside the
+// access log so they are logged and counted as 500s, compression is innermost so the other middlewares see the
+// uncompressed response
+func middlewares(cfg config.Config) []middleware {
+	routeQuotas := make(map[string]quota, len(cfg.RouteRateLimits))
+	for path, limit := range cfg.RouteRateLimits {
+		routeQuotas[path] = quota(limit)
+	}
+
+	chain := []middleware{
+		requestIDs,
+		newAccessLogger(slog.Default(), cfg.LogSampleRate, cfg.SlowRequest).middleware,
+		recoverPanics,
+		newRateLimiter(clk, quota(cfg.RateLimit), routeQuotas, cfg.RateLimitAllowlist, cfg.TrustedProxies).middleware,
+	}
+
+	if cfg.SecurityHeaders {
+		chain = append(chain, newSecurityHeaders(cfg.ContentSecurityPolicy, cfg.EmbeddableRoutes).middleware)
+	}
+
+	if len(cfg.CORSOrigins) > 0 {
+		chain = append(chain, newCORS(cfg.CORSOrigins).middleware)
+	}
+
+	return append(chain, compress)
+}
+
+// routes enabled by the configuration
+func enabledRoutes(cfg config.Config) []route {
+	enabled := make([]route, 0, len(routes))
+
+	for _, r := range routes {
+		if (r.debug && !cfg.Debug) || slices.Contains(cfg.DisabledRoutes, r.path()) {
+			continue
+		}
+
+		enabled = append(enabled, r)
 	}
 
-	log.Printf("\n============ route = [%s]  ===================\n", req.RequestURI)
-	log.Println("Method:", req.Method)
-	log.Println("User-Agent:", req.Header["User-Agent"])
-	log.Println("Cf-Ipcountry:", req.Header["Cf-Ipcountry"])
-	log.Println("Cf-Connecting-Ip:", req.Header["Cf-Connecting-Ip"])
-	log.Println("Sec-Ch-Ua-Platform:", req.Header["Sec-Ch-Ua-Platform"])
-	log.Println("Sec-Ch-Ua:", req.Header["Sec-Ch-Ua"])
+	return enabled
+}
+
+// the route's url path, i.e. the pattern without the method
+func (r route) path() string {
+	_, path, _ := strings.Cut(r.pattern, " ")
+	return path
+}
+
+// home page links for the routes with a title
+func linksFor(enabled []route) []homeLink {
+	var links []homeLink
+
+	for _, r := range enabled {
+		if r.title != "" {
+			links = append(links, homeLink{Title: r.title, URL: r.path()})
+		}
+	}
+
+	return links
+}
+
+// effective configuration logged at startup, secrets are redacted by config.String
+func startupMessage(cfg config.Config) string {
+	patterns := make([]string, 0, len(routes))
+	for _, r := range enabledRoutes(cfg) {
+		patterns = append(patterns, r.pattern)
+	}
+
+	return fmt.Sprintf("starting version=%s %s routes=%q", version, cfg, patterns)
+}
+
+//go:embed HomeTemplate.html TooManyRequestsTemplate.html AdminTemplate.html
+var templateFS embed.FS
+
+// page templates compiled into the binary and parsed once at startup
+var (
+	homeTemplate            = template.Must(template.ParseFS(templateFS, "HomeTemplate.html"))
+	tooManyRequestsTemplate = template.Must(template.ParseFS(templateFS, "TooManyRequestsTemplate.html"))
+	adminTemplate           = template.Must(template.ParseFS(templateFS, "AdminTemplate.html"))
+)
+
+// directory the page templates are re-read from on every request for live editing, set from TEMPLATE_DIR.
+// The compiled in templates are used when empty
+var templateDir string
+
+// the Cann table templates live in the cann package directory under the template directory
+func cannTemplateDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+
+	return filepath.Join(dir, "cann")
+}
+
+// the parsed template for a page, re-read from templateDir when it is set
+func pageTemplate(compiled *template.Template, file string) (*template.Template, error) {
+	if templateDir == "" {
+		return compiled, nil
+	}
+
+	return template.ParseFS(os.DirFS(templateDir), file) //nolint:wrapcheck // the parse error names the file
 }
 
 // displays landing page with links to other pages
 func homeHandler(w http.ResponseWriter, req *http.Request) {
-	logRequest(req)
+	// generate html output, buffered so a template error is still a clean 500
+	templ, err := pageTemplate(homeTemplate, "HomeTemplate.html")
+	if err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("home page unavailable, error reading home template: %w", err))
+		return
+	}
 
-	// generate html output
-	homeTemplate := template.Must(template.ParseFiles("HomeTemplate.html"))
-	if err := homeTemplate.Execute(w, nil); err != nil {
-		log.Fatal(err)
+	var page bytes.Buffer
+	if err := templ.Execute(&page, homeLinks); err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("home page unavailable, error executing home template: %w", err))
+		return
+	}
+
+	if _, err := page.WriteTo(w); err != nil {
+		slog.WarnContext(req.Context(), "error writing home page", "err", err)
 	}
 }
 
 // displays Huxley's personal details
 func huxleyHandler(w http.ResponseWriter, req *http.Request) {
-	logRequest(req)
-
 	// generate html output
 	huxley.DogStats(w, req)
 }
 
+// records a weigh-in posted with the HUXLEY_TOKEN bearer token
+func huxleyWeightsHandler(w http.ResponseWriter, req *http.Request) {
+	huxley.AddWeight(w, req)
+}
+
+// records a vet visit posted with the HUXLEY_TOKEN bearer token
+func huxleyVetVisitsHandler(w http.ResponseWriter, req *http.Request) {
+	huxley.AddVetVisit(w, req)
+}
+
+// displays the gallery of Huxley's photos
+func huxleyPhotosHandler(w http.ResponseWriter, req *http.Request) {
+	huxley.Photos(w, req)
+}
+
+// stores a photo uploaded with the HUXLEY_TOKEN bearer token
+func huxleyUploadPhotoHandler(w http.ResponseWriter, req *http.Request) {
+	huxley.UploadPhoto(w, req)
+}
+
+// serves one of Huxley's photos
+func huxleyPhotoHandler(w http.ResponseWriter, req *http.Request) {
+	huxley.ServePhoto(w, req)
+}
+
+// serves the thumbnail of one of Huxley's photos
+func huxleyThumbnailHandler(w http.ResponseWriter, req *http.Request) {
+	huxley.ServeThumbnail(w, req)
+}
+
 // displays FPL league table
 func fplHandler(w http.ResponseWriter, req *http.Request) {
-	logRequest(req)
-
 	// get json for consumption by vercel app
 	fpl.Points(w, req)
 }
 
+// FPL player, team and gameweek reference data for the vercel app
+func fplBootstrapHandler(w http.ResponseWriter, req *http.Request) {
+	fpl.Bootstrap(w, req)
+}
+
+// provisional points for the gameweek in progress, polled by the vercel app
+func fplLiveHandler(w http.ResponseWriter, req *http.Request) {
+	fpl.Live(w, req)
+}
+
+// each manager's captain, chips and transfers for the gameweek
+func fplSummaryHandler(w http.ResponseWriter, req *http.Request) {
+	fpl.Summary(w, req)
+}
+
 // fetches the standard table standings, generates and outputs the Cann table
 func cannHandler(w http.ResponseWriter, req *http.Request) {
-	logRequest(req)
-
 	cann.GenerateTable(w, req)
 }
+
+// fetches the standard table standings, outputs the Cann table as an svg image for sharing
+func cannImageHandler(w http.ResponseWriter, req *http.Request) {
+	cann.Image(w, req)
+}
+
+// fetches the standard table standings, outputs the points gaps between teams as json
+func cannGapsHandler(w http.ResponseWriter, req *http.Request) {
+	cann.Gaps(w, req)
+}
+
+// outputs static context for a competition as json, e.g. the reigning champion
+func cannContextHandler(w http.ResponseWriter, req *http.Request) {
+	cann.Context(w, req)
+}
+
+// fetches the upcoming fixtures, outputs them by matchday with the optional Cann table projection
+func fixturesHandler(w http.ResponseWriter, req *http.Request) {
+	cann.Fixtures(w, req)
+}
+
+// fetches the season's fixtures, outputs them as an iCalendar feed for calendar apps to subscribe to
+func fixturesCalendarHandler(w http.ResponseWriter, req *http.Request) {
+	cann.Calendar(w, req)
+}
+
+// fetches the competition's top scorers, outputs them as html or json
+func scorersHandler(w http.ResponseWriter, req *http.Request) {
+	scorers.Scorers(w, req)
+}
+
+// fetches the standings, matches and past meetings of two teams, outputs them side by side as html or json
+func compareHandler(w http.ResponseWriter, req *http.Request) {
+	cann.Compare(w, req)
+}
+
+// API_TOKEN is configured, set at startup
+var apiTokenSet bool
+
+// reports the server is ready, 503 when API_TOKEN is unset. With ?deep=1 also reports each upstream dependency's
+// status, 503 if any is unhealthy
+func healthzHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response := map[string]any{"status": "ok"}
+	status := http.StatusOK
+
+	if !apiTokenSet {
+		response["status"], response["error"] = "unavailable", "API_TOKEN is not set"
+		status = http.StatusServiceUnavailable
+	} else if req.URL.Query().Get("deep") == "1" {
+		dependencies := cann.DeepHealth(req.Context())
+		response["dependencies"] = dependencies
+
+		for _, dependency := range dependencies {
+			if !dependency.Healthy {
+				response["status"] = "unavailable"
+				status = http.StatusServiceUnavailable
+
+				break
+			}
+		}
+	}
+
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.WarnContext(req.Context(), "error writing health response", "err", err)
+	}
+}
+
+// the configuration validation result, the server only starts with a valid configuration
+var configErr error
+
+// a readiness report, ready unless the configuration is invalid or an upstream circuit is open
+type readiness struct {
+	Status       string         `json:"status"` // ready or unavailable
+	Config       configStatus   `json:"config"`
+	Dependencies map[string]any `json:"dependencies"` // each upstream's last fetches and circuit breaker state
+}
+
+type configStatus struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// reports whether the server should receive traffic for the load balancer as json, 503 when it shouldn't.
+// Upstream health comes from the recorded fetches, the upstreams aren't called. An unhealthy upstream with a
+// closed circuit is still ready as cached copies can be served
+func readyzHandler(w http.ResponseWriter, req *http.Request) {
+	footballData, fplStatus := cann.UpstreamStatus(), fpl.UpstreamStatus()
+
+	report := readiness{Status: "ready", Config: configStatus{Valid: configErr == nil},
+		Dependencies: map[string]any{sourceFootballData: footballData, sourceFPL: fplStatus}}
+
+	if configErr != nil {
+		report.Config.Error = configErr.Error()
+	}
+
+	status := http.StatusOK
+	if !report.Config.Valid || footballData.Circuit == "open" || fplStatus.Circuit == "open" {
+		report.Status, status = "unavailable", http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		slog.WarnContext(req.Context(), "error writing readiness response", "err", err)
+	}
+}
+
+// request counts and upstream latencies in the Prometheus text format
+func metricsHandler(w http.ResponseWriter, req *http.Request) {
+	metricsRegistry.ServeHTTP(w, req)
+}
+
+// displays cache counters, only routed when DEBUG environment variable is set
+func debugCacheHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	stats := map[string]any{"standings": cann.CacheStats()}
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		slog.WarnContext(req.Context(), "error writing cache stats", "err", err)
+	}
+}
+
+// renders a posted standings json body as a Cann table, only routed when DEBUG environment variable is set
+func debugRenderHandler(w http.ResponseWriter, req *http.Request) {
+	cann.Render(w, req)
+}
+
+// displays upstream schema drift counters in the Prometheus text format, only routed when DEBUG environment variable is set
+func debugMetricsHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	if _, err := fmt.Fprint(w, cann.SchemaDriftMetrics()); err != nil {
+		slog.WarnContext(req.Context(), "error writing schema drift metrics", "err", err)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/config"
+)
+
+func TestStartupMessage(t *testing.T) {
+	t.Setenv("API_TOKEN", "super-secret-token")
+
+	got := startupMessage(config.Load())
+
+	if strings.Contains(got, "super-secret-token") {
+		t.Errorf("startupMessage() = %q, want token redacted", got)
+	}
+
+	for _, want := range []string{"version=dev", "addr=:8080", "readTimeout=5s", "apiToken=[REDACTED]", `"GET /cann"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("startupMessage() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	if strings.Contains(got, "/debug/cache") {
+		t.Errorf("startupMessage() = %q, want debug routes omitted when DEBUG is unset", got)
+	}
+}
+
+func TestHomeLinksDisabledRoute(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	t.Setenv("DISABLED_ROUTES", "/huxley")
+
+	homeLinks = linksFor(enabledRoutes(config.Load()))
+	defer func() { homeLinks = nil }()
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	homeHandler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	body := w.Body.String()
+	if strings.Contains(body, `href="/huxley"`) {
+		t.Errorf("home page links to disabled route /huxley\n%s", body)
+	}
+
+	for _, want := range []string{`href="/cann"`, `href="/fpl"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("home page missing enabled route link %s", want)
+		}
+	}
+}
+
+func TestHomeHandlerTemplateError(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	defer func(templ *template.Template) { homeTemplate = templ }(homeTemplate)
+	homeTemplate = template.Must(template.New("HomeTemplate.html").Parse("{{ .Missing }}"))
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	w := httptest.NewRecorder()
+	homeHandler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "home page unavailable") {
+		t.Errorf("homeHandler() with a failing template status = %d body = %q, want a 500 error response", w.Code, w.Body)
+	}
+}
+
+func TestHomeHandlerTemplateDir(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dir := t.TempDir()
+
+	defer func(dir string) { templateDir = dir }(templateDir)
+	templateDir = dir
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	var bodies []string
+
+	for _, content := range []string{"first edit", "second edit"} {
+		if err := os.WriteFile(filepath.Join(dir, "HomeTemplate.html"), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		w := httptest.NewRecorder()
+		homeHandler(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+		bodies = append(bodies, w.Body.String())
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if bodies[0] != "first edit" || bodies[1] != "second edit" {
+		t.Errorf("homeHandler() with TEMPLATE_DIR bodies = %q, want each edit of the template on disk", bodies)
+	}
+}
+
+func TestHealthzAPIToken(t *testing.T) {
+	tests := []struct {
+		token      bool
+		wantStatus int
+		wantBody   string
+	}{
+		{true, http.StatusOK, `{"status":"ok"}`},
+		{false, http.StatusServiceUnavailable, `{"error":"API_TOKEN is not set","status":"unavailable"}`},
+	}
+
+	defer func(set bool) { apiTokenSet = set }(apiTokenSet)
+
+	for _, test := range tests {
+		// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+		apiTokenSet = test.token
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		healthzHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if body := strings.TrimSpace(w.Body.String()); w.Code != test.wantStatus || body != test.wantBody {
+			t.Errorf("healthzHandler() token set %v = %d %s, want %d %s", test.token, w.Code, body, test.wantStatus, test.wantBody)
+		}
+	}
+}
+
+func TestReadyz(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	defer func(err error) { configErr = err }(configErr)
+	defer cann.Configure(cann.Settings{BaseURL: config.DefaultStandingsBaseURL, TTL: time.Minute, UpstreamTimeout: time.Second})
+
+	tests := []struct {
+		name        string
+		configErr   error
+		openCircuit bool
+		wantStatus  int
+		want        []string
+	}{
+		{"ready", nil, false, http.StatusOK, []string{`"status":"ready"`, `"config":{"valid":true}`, `"circuit":"closed"`}},
+		{"invalid config", errors.New("API_TOKEN is required"), false, http.StatusServiceUnavailable,
+			[]string{`"status":"unavailable"`, `"config":{"valid":false,"error":"API_TOKEN is required"}`}},
+		{"circuit open", nil, true, http.StatusServiceUnavailable, []string{`"status":"unavailable"`, `"circuit":"open"`}},
+	}
+
+	for _, test := range tests {
+		configErr = test.configErr
+
+		cann.Configure(cann.Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second,
+			RetryAttempts: 1, BreakerThreshold: 1})
+
+		if test.openCircuit {
+			if _, err := cann.Refresh(context.Background(), "PL"); err == nil {
+				t.Fatal("Refresh() from a failing upstream succeeded")
+			}
+		}
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		readyzHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("%s: readyzHandler() status = %d, want %d", test.name, w.Code, test.wantStatus)
+		}
+
+		for _, want := range test.want {
+			if !strings.Contains(w.Body.String(), want) {
+				t.Errorf("%s: readyzHandler() body = %s, want it to contain %s", test.name, w.Body, want)
+			}
+		}
+	}
+}
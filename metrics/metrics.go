@@ -0,0 +1,274 @@
+// counters and histograms served in the Prometheus text exposition format, a minimal stdlib stand-in for
+// the Prometheus client so the server has no dependencies
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds for upstream request latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// A Registry holds the metrics served together, safe for concurrent use
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// a registered counter or histogram
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register a metric, panics on a duplicate name like the Prometheus client's MustRegister
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, registered := range r.metrics {
+		if registered.name() == m.name() {
+			panic(fmt.Sprintf("metrics: duplicate metric %q", m.name()))
+		}
+	}
+
+	r.metrics = append(r.metrics, m)
+}
+
+// Counter registers a counter with the label names
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{family: family{metricName: name, help: help, labels: labels}, values: map[string]float64{}}
+	r.register(c)
+
+	return c
+}
+
+// Histogram registers a histogram with the bucket upper bounds and label names
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{family: family{metricName: name, help: help, labels: labels}, buckets: slices.Clone(buckets), series: map[string]*histogram{}}
+	slices.Sort(h.buckets)
+	r.register(h)
+
+	return h
+}
+
+// WriteTo writes every metric in the text exposition format, metrics in registration order and series sorted
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		m.write(&buf)
+	}
+
+	return buf.WriteTo(w) //nolint:wrapcheck // pass through
+}
+
+// ServeHTTP serves the metrics for scraping
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	_, _ = r.WriteTo(w) //nolint:errcheck // the scrape fails, nothing more to do
+}
+
+// the name, help and label names shared by the series of a metric
+type family struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (f family) name() string {
+	return f.metricName
+}
+
+func (f family) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.metricName, f.help, f.metricName, kind)
+}
+
+// series key for label values, panics when the count doesn't match the label names
+func (f family) key(values []string) string {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", f.metricName, len(f.labels), len(values)))
+	}
+
+	return strings.Join(values, "\xff")
+}
+
+// label pairs for a series key with any extra pairs appended e.g. {route="/cann",status="200"}
+func (f family) labelPairs(key string, extra ...string) string {
+	var pairs []string
+
+	if len(f.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf("%s=%q", f.labels[i], value))
+		}
+	}
+
+	pairs = append(pairs, extra...)
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// A CounterVec is a counter with a series per combination of label values
+type CounterVec struct {
+	family
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// Inc adds one to the series for the label values
+func (c *CounterVec) Inc(values ...string) {
+	key := c.key(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key]++
+}
+
+// Value is the count of the series for the label values, 0 when it hasn't been incremented
+func (c *CounterVec) Value(values ...string) float64 {
+	key := c.key(values)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.values[key]
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.header(w, "counter")
+
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(key), formatFloat(c.values[key]))
+	}
+}
+
+// CounterFunc registers a counter kept elsewhere, e.g. cache hits, read from collect at each scrape.
+// collect returns the count for each value of the label
+func (r *Registry) CounterFunc(name, help, label string, collect func() map[string]float64) {
+	r.register(&counterFunc{family: family{metricName: name, help: help, labels: []string{label}}, collect: collect})
+}
+
+// a counter read from a callback
+type counterFunc struct {
+	family
+	collect func() map[string]float64
+}
+
+func (c *counterFunc) write(w io.Writer) {
+	values := c.collect()
+
+	c.header(w, "counter")
+
+	for _, key := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(key), formatFloat(values[key]))
+	}
+}
+
+// A HistogramVec is a histogram with a series per combination of label values
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogram
+}
+
+// observations of one histogram series, counts[i] is the observations no greater than buckets[i]
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records a value, e.g. a duration in seconds, in the series for the label values
+func (h *HistogramVec) Observe(value float64, values ...string) {
+	key := h.key(values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			s.counts[i]++
+		}
+	}
+
+	s.count++
+	s.sum += value
+}
+
+// Count is the number of observations in the series for the label values
+func (h *HistogramVec) Count(values ...string) uint64 {
+	key := h.key(values)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.series[key]; ok {
+		return s.count
+	}
+
+	return 0
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(key, fmt.Sprintf("le=%q", formatFloat(bound))), s.counts[i])
+		}
+
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(key, `le="+Inf"`), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelPairs(key), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelPairs(key), s.count)
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
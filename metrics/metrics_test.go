@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistryExposition(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	registry := NewRegistry()
+	requests := registry.Counter("http_requests_total", "Requests served.", "route", "status")
+	latency := registry.Histogram("upstream_request_duration_seconds", "Upstream latency.", []float64{0.5, 0.1}, "resource")
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	requests.Inc("/cann", "200")
+	requests.Inc("/cann", "200")
+	requests.Inc("/fpl", "500")
+	latency.Observe(0.05, "standings")
+	latency.Observe(0.3, "standings")
+
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := `# HELP http_requests_total Requests served.
+# TYPE http_requests_total counter
+http_requests_total{route="/cann",status="200"} 2
+http_requests_total{route="/fpl",status="500"} 1
+# HELP upstream_request_duration_seconds Upstream latency.
+# TYPE upstream_request_duration_seconds histogram
+upstream_request_duration_seconds_bucket{resource="standings",le="0.1"} 1
+upstream_request_duration_seconds_bucket{resource="standings",le="0.5"} 2
+upstream_request_duration_seconds_bucket{resource="standings",le="+Inf"} 2
+upstream_request_duration_seconds_sum{resource="standings"} 0.35
+upstream_request_duration_seconds_count{resource="standings"} 2
+`
+	if got := w.Body.String(); got != want {
+		t.Errorf("ServeHTTP() body =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain") {
+		t.Errorf("ServeHTTP() Content-Type = %q, want text/plain", got)
+	}
+
+	if requests.Value("/cann", "200") != 2 || requests.Value("/huxley", "200") != 0 || latency.Count("standings") != 2 {
+		t.Error("metric values don't match the recorded observations")
+	}
+}
+
+func TestRegistryDuplicate(t *testing.T) {
+	registry := NewRegistry()
+	registry.Counter("requests_total", "Requests.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Counter() with a duplicate name didn't panic")
+		}
+	}()
+
+	registry.Counter("requests_total", "Requests again.")
+}
+
+func TestCounterFunc(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	registry := NewRegistry()
+	hits := map[string]float64{"standings": 3, "history": 1}
+	registry.CounterFunc("cache_hits_total", "Cache hits.", "cache", func() map[string]float64 { return hits })
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	hits["standings"]++ // read at scrape time, not registration
+
+	var scrape strings.Builder
+	if _, err := registry.WriteTo(&scrape); err != nil {
+		t.Fatal(err)
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := `# HELP cache_hits_total Cache hits.
+# TYPE cache_hits_total counter
+cache_hits_total{cache="history"} 1
+cache_hits_total{cache="standings"} 4
+`
+	if got := scrape.String(); got != want {
+		t.Errorf("WriteTo() =\n%s\nwant\n%s", got, want)
+	}
+}
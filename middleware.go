@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/metrics"
+)
+
+// metrics served on /metrics, requests are counted by the access log middleware
+var (
+	metricsRegistry = metrics.NewRegistry()
+	requestsTotal   = metricsRegistry.Counter("http_requests_total", "Requests served by route and status code.", "route", "status")
+	requestDuration = metricsRegistry.Histogram("http_request_duration_seconds", "Time to serve requests by route.", metrics.DefaultBuckets, "route")
+)
+
+// a handler wrapped around the next handler in the chain
+type middleware func(http.Handler) http.Handler
+
+// the handler with the middlewares applied, the first middleware sees the request first
+func chain(handler http.Handler, middlewares ...middleware) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
+
+// records the status code written by a handler and whether the response was started
+type statusRecorder struct {
+	http.ResponseWriter
+	status  int
+	written bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status, r.written = status, true
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.written = true
+	return r.ResponseWriter.Write(b) //nolint:wrapcheck // the writer's error is returned as is
+}
+
+// the wrapped writer, for http.ResponseController to flush streamed responses
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// turns a panicking handler into a logged 500 so one bad request can't take down the server.
+// A response already started is left as it is, http.ErrAbortHandler is passed on to abort the response
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+
+			if p == http.ErrAbortHandler { //nolint:errorlint,err113 // the sentinel is panicked as is
+				panic(p)
+			}
+
+			slog.ErrorContext(req.Context(), "panic serving request", "path", req.URL.Path, "panic", p, "stack", string(debug.Stack()))
+
+			if !recorder.written {
+				errorpage.Write(w, req, http.StatusInternalServerError, errors.New("internal server error"))
+			}
+		}()
+
+		next.ServeHTTP(recorder, req)
+	})
+}
+
+// logs one line per request with its id, method, path, status and duration, at warn for 4xx and error for 5xx.
+// Browser favicon requests and successful health probes aren't logged, the others are also kept for the admin dashboard.
+// Successful requests are sampled, 1 in sampleRate is logged, errors and slow requests are always logged.
+type accessLogger struct {
+	logger     *slog.Logger
+	sampleRate int64
+	slow       time.Duration
+	successes  atomic.Int64
+}
+
+func newAccessLogger(logger *slog.Logger, sampleRate int, slow time.Duration) *accessLogger {
+	if sampleRate < 1 {
+		sampleRate = 1
+	}
+
+	return &accessLogger{logger: logger, sampleRate: int64(sampleRate), slow: slow}
+}
+
+func (a *accessLogger) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := clk.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, req)
+
+		duration := clock.Since(clk, start)
+
+		// scrapes aren't counted so the metrics only move with traffic
+		if req.URL.Path != "/metrics" {
+			route := routeLabel(req.URL.Path)
+			requestsTotal.Inc(route, strconv.Itoa(recorder.status))
+			requestDuration.Observe(duration.Seconds(), route)
+		}
+
+		if req.URL.Path == "/favicon.ico" || (isProbe(req.URL.Path) && recorder.status < http.StatusBadRequest) {
+			return
+		}
+
+		recentRequests.add(requestRecord{Time: start, ID: requestID(req.Context()), Method: req.Method, Path: req.URL.Path,
+			Status: recorder.status, Duration: duration})
+
+		if a.sampled(recorder.status, duration) {
+			a.logger.LogAttrs(req.Context(), statusLevel(recorder.status), "request", slog.String("method", req.Method),
+				slog.String("path", req.URL.Path), slog.Int("status", recorder.status), slog.Duration("duration", duration))
+		}
+	})
+}
+
+// load balancer and orchestrator probes, not logged while they succeed
+func isProbe(path string) bool {
+	return path == "/healthz" || path == "/readyz"
+}
+
+// the route serving a url path for the request counter, the route's pattern for paths with parameters and
+// "other" for paths without a route so unknown urls can't grow the number of series
+func routeLabel(path string) string {
+	for _, r := range routes {
+		if pattern := strings.TrimSuffix(r.path(), "{$}"); matchesPath(pattern, path) {
+			return pattern
+		}
+	}
+
+	return "other"
+}
+
+// whether the url path matches a route path, a {parameter} segment matches any one non-empty segment
+func matchesPath(pattern, path string) bool {
+	patternSegments, pathSegments := strings.Split(pattern, "/"), strings.Split(path, "/")
+	if len(patternSegments) != len(pathSegments) {
+		return false
+	}
+
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "{") {
+			if pathSegments[i] == "" {
+				return false
+			}
+
+			continue
+		}
+
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// request log level for a response status
+func statusLevel(status int) slog.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return slog.LevelError
+	case status >= http.StatusBadRequest:
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// errors and slow requests are always logged, successes are logged 1 in sampleRate
+func (a *accessLogger) sampled(status int, duration time.Duration) bool {
+	if status >= http.StatusBadRequest || duration >= a.slow {
+		return true
+	}
+
+	return (a.successes.Add(1)-1)%a.sampleRate == 0
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLogSampling(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	var buf bytes.Buffer
+
+	accessLog := newAccessLogger(testLogger(&buf), 3, time.Hour)
+	handler := accessLog.middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	for range 9 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", http.NoBody))
+	}
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/error", http.NoBody))
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	logs := buf.String()
+	if got := strings.Count(logs, "method=GET path=/ok status=200"); got != 3 {
+		t.Errorf("logged %d of 9 successes, want 3 with sample rate 3\n%s", got, logs)
+	}
+
+	if got := strings.Count(logs, "level=ERROR msg=request method=GET path=/error status=500"); got != 2 {
+		t.Errorf("logged %d of 2 errors, want all\n%s", got, logs)
+	}
+}
+
+func TestAccessLogSlowRequest(t *testing.T) {
+	var buf bytes.Buffer
+
+	accessLog := newAccessLogger(testLogger(&buf), 100, 0)
+	handler := accessLog.middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", http.NoBody))
+	}
+
+	if got := strings.Count(buf.String(), "method=GET path=/slow status=200"); got != 2 {
+		t.Errorf("logged %d of 2 slow requests, want all", got)
+	}
+}
+
+func TestAccessLogSkipsNoise(t *testing.T) {
+	var buf bytes.Buffer
+
+	accessLog := newAccessLogger(testLogger(&buf), 1, time.Hour)
+	handler := accessLog.middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("fail") == "1" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+
+	for _, url := range []string{"/favicon.ico", "/healthz", "/healthz?fail=1", "/cann"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, http.NoBody))
+	}
+
+	want := "level=ERROR msg=request method=GET path=/healthz status=503\nlevel=INFO msg=request method=GET path=/cann status=200\n"
+	if stripDurations(buf.String()) != want {
+		t.Errorf("access log = %q, want %q without the favicon or healthy probes", buf.String(), want)
+	}
+}
+
+// a text logger without timestamps, for comparing log lines
+func testLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{ReplaceAttr: func(_ []string, attr slog.Attr) slog.Attr {
+		if attr.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+
+		return attr
+	}}))
+}
+
+// access log lines without the trailing duration
+func stripDurations(logs string) string {
+	var stripped strings.Builder
+
+	for _, line := range strings.SplitAfter(logs, "\n") {
+		if i := strings.LastIndex(line, " "); i >= 0 {
+			stripped.WriteString(line[:i] + "\n")
+		}
+	}
+
+	return stripped.String()
+}
+
+func TestAccessLogCountsRequests(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	accessLog := newAccessLogger(testLogger(io.Discard), 1, time.Hour)
+	handler := accessLog.middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/metrics" {
+			metricsHandler(w, req)
+			return
+		}
+
+		if req.URL.Path == "/fpl" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	cannBefore, fplBefore, otherBefore := requestsTotal.Value("/cann", "200"), requestsTotal.Value("/fpl", "500"), requestsTotal.Value("other", "200")
+	cannTimed := requestDuration.Count("/cann")
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	for _, url := range []string{"/cann", "/cann?comp=BL1", "/fpl", "/wp-login.php", "/metrics"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, url, http.NoBody))
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if got := requestsTotal.Value("/cann", "200") - cannBefore; got != 2 {
+		t.Errorf("/cann 200 count moved by %v, want 2", got)
+	}
+
+	if got := requestsTotal.Value("/fpl", "500") - fplBefore; got != 1 {
+		t.Errorf("/fpl 500 count moved by %v, want 1", got)
+	}
+
+	if got := requestsTotal.Value("other", "200") - otherBefore; got != 1 {
+		t.Errorf("unrouted path count moved by %v, want 1", got)
+	}
+
+	if got := requestDuration.Count("/cann") - cannTimed; got != 2 {
+		t.Errorf("/cann duration observations moved by %d, want 2", got)
+	}
+
+	if requestsTotal.Value("/metrics", "200") != 0 || requestDuration.Count("/metrics") != 0 {
+		t.Error("/metrics scrapes were counted")
+	}
+
+	want := fmt.Sprintf(`http_requests_total{route="/cann",status="200"} %v`, requestsTotal.Value("/cann", "200"))
+	if body := w.Body.String(); !strings.Contains(body, want) {
+		t.Errorf("/metrics body =\n%s\nwant %s", body, want)
+	}
+}
+
+func TestRouteLabel(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", "/"},
+		{"/cann", "/cann"},
+		{"/cann/gaps", "/cann/gaps"},
+		{"/cann/BL1", "/cann/{competition}"},
+		{"/cann/", "other"},
+		{"/cann/BL1/extra", "other"},
+		{"/fpl/314159", "/fpl/{leagueID}"},
+		{"/fpl/live", "/fpl/live"},
+		{"/wp-login.php", "other"},
+	}
+
+	for _, test := range tests {
+		if got := routeLabel(test.path); got != test.want {
+			t.Errorf("routeLabel(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	var order []string
+
+	named := func(name string) middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, req)
+			})
+		}
+	}
+
+	handler := chain(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { order = append(order, "handler") }),
+		named("first"), named("second"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	if got := strings.Join(order, ","); got != "first,second,handler" {
+		t.Errorf("chain() served %s, want first,second,handler", got)
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	var buf bytes.Buffer
+
+	defer func(logger *slog.Logger) { slog.SetDefault(logger) }(slog.Default())
+	slog.SetDefault(testLogger(&buf))
+
+	handler := recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/started" {
+			w.WriteHeader(http.StatusAccepted)
+		}
+
+		panic("template error")
+	}))
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/cann", http.StatusInternalServerError},
+		{"/started", http.StatusAccepted}, // the response was already started
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("recoverPanics() %s status = %d, want %d", test.path, w.Code, test.wantStatus)
+		}
+	}
+
+	if logs := buf.String(); strings.Count(logs, `level=ERROR msg="panic serving request"`) != 2 || !strings.Contains(logs, "panic=\"template error\"") {
+		t.Errorf("recoverPanics() logged\n%s\nwant both panics", logs)
+	}
+}
+
+func TestRecoverPanicsAbortHandler(t *testing.T) {
+	handler := recoverPanics(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) }))
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler { //nolint:errorlint,err113 // the sentinel is panicked as is
+			t.Errorf("recoverPanics() recovered %v, want http.ErrAbortHandler passed on", p)
+		}
+	}()
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+}
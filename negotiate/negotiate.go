@@ -0,0 +1,111 @@
+// chooses a response format from the ?format= query parameter or the Accept header quality values
+package negotiate
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// response formats, the values of the ?format= query parameter
+const (
+	HTML = "html"
+	JSON = "json"
+	CSV  = "csv"
+	Text = "text"
+)
+
+// media type of each format
+var mediaTypes = map[string]string{
+	HTML: "text/html",
+	JSON: "application/json",
+	CSV:  "text/csv",
+	Text: "text/plain",
+}
+
+// Format returns the response format for the request from the formats a handler supports, the first is the default.
+// An explicit ?format= wins, otherwise the supported format with the highest Accept quality is chosen,
+// ties go to the earlier supported format, and the default is used when nothing acceptable is supported
+func Format(w http.ResponseWriter, req *http.Request, supported ...string) string {
+	w.Header().Add("Vary", "Accept")
+
+	if format := req.URL.Query().Get("format"); slices.Contains(supported, format) {
+		return format
+	}
+
+	accepted := parseAccept(req.Header.Get("Accept"))
+	best, bestQuality := supported[0], 0.0
+
+	for _, format := range supported {
+		if quality := accepted.quality(mediaTypes[format]); quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+
+	return best
+}
+
+// a media range from an Accept header, e.g. text/* with quality 0.8
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+type acceptHeader []mediaRange
+
+// parse the media ranges of an Accept header, a missing or invalid q parameter counts as 1
+func parseAccept(header string) acceptHeader {
+	var ranges acceptHeader
+
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, _ := strings.Cut(part, ";")
+
+		mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+		if mediaType == "" {
+			continue
+		}
+
+		quality := 1.0
+
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name != "q" {
+				continue
+			}
+
+			if q, err := strconv.ParseFloat(value, 64); err == nil && q >= 0 && q <= 1 {
+				quality = q
+			}
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+
+	return ranges
+}
+
+// quality of a media type from the most specific matching range, 0 when it isn't acceptable
+func (a acceptHeader) quality(mediaType string) float64 {
+	typ, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, 0
+
+	for _, r := range a {
+		var matches int
+
+		switch r.mediaType {
+		case mediaType:
+			matches = 3
+		case typ + "/*":
+			matches = 2
+		case "*/*":
+			matches = 1
+		}
+
+		if matches > specificity {
+			quality, specificity = r.quality, matches
+		}
+	}
+
+	return quality
+}
@@ -0,0 +1,43 @@
+package negotiate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		url    string
+		accept string
+		want   string
+	}{
+		{"/", "", HTML},
+		{"/", "*/*", HTML},
+		{"/", "application/json", JSON},
+		{"/", "application/json;q=0.9, text/html;q=1.0", HTML},
+		{"/", "text/html;q=0.5, application/json;q=0.9", JSON},
+		{"/", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", HTML},
+		{"/", "text/*;q=0.3, application/json;q=0.2", HTML},
+		{"/", "text/csv;q=1, application/json;q=0.4, text/html;q=0", CSV},
+		{"/", "image/png", HTML},
+		{"/", "application/json;q=invalid, text/html;q=0.9", JSON},
+		{"/?format=json", "text/html", JSON},
+		{"/?format=text", "application/json", Text},
+		{"/?format=xml", "application/json", JSON},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+		req.Header.Set("Accept", test.accept)
+
+		w := httptest.NewRecorder()
+		if got := Format(w, req, HTML, JSON, CSV, Text); got != test.want {
+			t.Errorf("Format(%s, Accept: %s) = %q, want %q", test.url, test.accept, got, test.want)
+		}
+
+		if vary := w.Header().Get("Vary"); vary != "Accept" {
+			t.Errorf("Format() Vary = %q, want Accept", vary)
+		}
+	}
+}
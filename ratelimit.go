@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+	"github.com/mick4711/moh/negotiate"
+)
+
+// buckets kept before the full ones are swept, bounds the memory a flood of addresses can use. When a flood keeps
+// them all busy the least recently used tenth is evicted
+const maxBuckets = 10000
+
+// a rate limit, Limit requests are allowed in each Window
+type quota struct {
+	Limit  int
+	Window time.Duration
+}
+
+// details of a tripped rate limit, the json body of a 429 response and the data of its html page
+type tooManyRequestsBody struct {
+	Error         string `json:"error"`
+	RetryAfter    int    `json:"retryAfter"` // seconds, also sent as the Retry-After header
+	Limit         int    `json:"limit"`
+	WindowSeconds int    `json:"windowSeconds"`
+}
+
+// responds 429 with a Retry-After header and the quota details, as json for json clients
+// and the /api/v1 routes, and a friendly html page otherwise. retryAfter is rounded up to whole seconds, at least 1
+func tooManyRequests(w http.ResponseWriter, req *http.Request, q quota, retryAfter time.Duration) {
+	body := tooManyRequestsBody{
+		Error:         "too many requests",
+		RetryAfter:    max(1, int(math.Ceil(retryAfter.Seconds()))),
+		Limit:         q.Limit,
+		WindowSeconds: int(q.Window.Seconds()),
+	}
+
+	w.Header().Set("Retry-After", strconv.Itoa(body.RetryAfter))
+
+	if isAPIV1(req.URL.Path) || negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			slog.WarnContext(req.Context(), "error writing too many requests response", "err", err)
+		}
+
+		return
+	}
+
+	templ, err := pageTemplate(tooManyRequestsTemplate, "TooManyRequestsTemplate.html")
+	if err != nil {
+		slog.ErrorContext(req.Context(), "error reading too many requests template", "err", err)
+		http.Error(w, body.Error, http.StatusTooManyRequests)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusTooManyRequests)
+
+	if err := templ.Execute(w, body); err != nil {
+		slog.ErrorContext(req.Context(), "error executing too many requests template", "err", err)
+	}
+}
+
+// a client's token bucket for a quota, refilled continuously at Limit tokens per Window
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// per client token bucket rate limiting, each route with its own quota has its own buckets and the other routes
+// share the default quota's
+type rateLimiter struct {
+	mu             sync.Mutex
+	clk            clock.Clock
+	quota          quota
+	routes         map[string]quota // quotas of the url paths with their own
+	allowlist      []netip.Prefix
+	trustedProxies []netip.Prefix     // proxies whose Cf-Connecting-Ip header is the client address
+	buckets        map[string]*bucket // keyed by client and route
+}
+
+func newRateLimiter(clk clock.Clock, defaultQuota quota, routes map[string]quota, allowlist, trustedProxies []netip.Prefix) *rateLimiter {
+	return &rateLimiter{clk: clk, quota: defaultQuota, routes: routes, allowlist: allowlist, trustedProxies: trustedProxies,
+		buckets: make(map[string]*bucket)}
+}
+
+func (l *rateLimiter) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if isProbe(req.URL.Path) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		client := clientAddr(req, l.trustedProxies)
+		if l.allowed(client) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		q, key := l.quota, client.String()
+		if routeQuota, ok := l.routes[req.URL.Path]; ok {
+			q, key = routeQuota, key+" "+req.URL.Path
+		}
+
+		if q.Limit == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		if retryAfter, ok := l.take(key, q); !ok {
+			tooManyRequests(w, req, q, retryAfter)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// whether the client is on the allowlist
+func (l *rateLimiter) allowed(client netip.Addr) bool {
+	return containsAddr(l.allowlist, client)
+}
+
+// whether the address is in one of the networks
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	return slices.ContainsFunc(prefixes, func(prefix netip.Prefix) bool { return prefix.Contains(addr) })
+}
+
+// take a token from the key's bucket, or the time until one is available when the bucket is empty
+func (l *rateLimiter) take(key string, q quota) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clk.Now()
+	perToken := q.Window / time.Duration(q.Limit)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= maxBuckets {
+			l.sweep(now)
+		}
+
+		if len(l.buckets) >= maxBuckets {
+			l.evict()
+		}
+
+		b = &bucket{tokens: float64(q.Limit), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(float64(q.Limit), b.tokens+float64(now.Sub(b.updated))/float64(perToken))
+	b.updated = now
+
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) * float64(perToken)), false
+	}
+
+	b.tokens--
+
+	return 0, true
+}
+
+// drop the buckets idle long enough to have refilled, a new bucket starts full so nothing is lost
+func (l *rateLimiter) sweep(now time.Time) {
+	window := l.quota.Window
+	for _, q := range l.routes {
+		window = max(window, q.Window)
+	}
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// drop the least recently used tenth of the buckets, when a flood keeps them all too busy to be swept
+func (l *rateLimiter) evict() {
+	keys := make([]string, 0, len(l.buckets))
+	for key := range l.buckets {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b string) int { return l.buckets[a].updated.Compare(l.buckets[b].updated) })
+
+	for _, key := range keys[:max(1, len(keys)/10)] {
+		delete(l.buckets, key)
+	}
+}
+
+// the client address, the connection's remote address or, when that is one of the trusted proxies, the
+// Cf-Connecting-Ip header Cloudflare sets. The header can't be spoofed to dodge the limit without a trusted proxy
+func clientAddr(req *http.Request, trustedProxies []netip.Prefix) netip.Addr {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+
+	remote, _ := netip.ParseAddr(host) //nolint:errcheck // an unparseable address is limited as the zero address
+	remote = remote.Unmap()
+
+	if containsAddr(trustedProxies, remote) {
+		if addr, err := netip.ParseAddr(req.Header.Get("Cf-Connecting-Ip")); err == nil {
+			return addr.Unmap()
+		}
+	}
+
+	return remote
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/clock"
+)
+
+func TestTooManyRequests(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+	}{
+		{name: "json client", accept: "application/json", want: "application/json"},
+		{name: "html client", accept: "text/html", want: "text/html; charset=utf-8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cann", http.NoBody)
+			req.Header.Set("Accept", test.accept)
+
+			w := httptest.NewRecorder()
+
+			// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+			tooManyRequests(w, req, quota{Limit: 10, Window: time.Minute}, 2500*time.Millisecond)
+
+			// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+			if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "3" {
+				t.Fatalf("tooManyRequests() status = %d Retry-After = %q, want 429 and 3", w.Code, w.Header().Get("Retry-After"))
+			}
+
+			if got := w.Header().Get("Content-Type"); got != test.want {
+				t.Errorf("tooManyRequests() Content-Type = %q, want %q", got, test.want)
+			}
+
+			if test.want != "application/json" {
+				if body := w.Body.String(); !strings.Contains(body, "10 requests every 60 seconds") || !strings.Contains(body, "try again in 3 seconds") {
+					t.Errorf("tooManyRequests() html = %s, want the quota and retry time", body)
+				}
+
+				return
+			}
+
+			var got tooManyRequestsBody
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			want := tooManyRequestsBody{Error: "too many requests", RetryAfter: 3, Limit: 10, WindowSeconds: 60}
+			if got != want {
+				t.Errorf("tooManyRequests() body = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fake := clock.NewFake(time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC))
+	limiter := newRateLimiter(fake, quota{Limit: 2, Window: time.Minute}, map[string]quota{"/fpl/live": {Limit: 1, Window: time.Minute}, "/huxley": {}},
+		[]netip.Prefix{netip.MustParsePrefix("198.51.100.0/24")}, []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")})
+
+	handler := limiter.middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }))
+
+	steps := []struct {
+		name           string
+		advance        time.Duration
+		path           string
+		client         string // Cf-Connecting-Ip from the trusted proxy, the remote address when empty
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{"first", 0, "/cann", "203.0.113.7", http.StatusOK, ""},
+		{"second", 0, "/fpl", "203.0.113.7", http.StatusOK, ""},
+		{"over the default quota", 0, "/cann", "203.0.113.7", http.StatusTooManyRequests, "30"},
+		{"route with its own quota", 0, "/fpl/live", "203.0.113.7", http.StatusOK, ""},
+		{"over the route quota", 0, "/fpl/live", "203.0.113.7", http.StatusTooManyRequests, "60"},
+		{"route without a limit", 0, "/huxley", "203.0.113.7", http.StatusOK, ""},
+		{"other client", 0, "/cann", "203.0.113.8", http.StatusOK, ""},
+		{"remote address", 0, "/cann", "", http.StatusOK, ""},
+		{"allowlisted", 0, "/fpl/live", "198.51.100.20", http.StatusOK, ""},
+		{"allowlisted again", 0, "/fpl/live", "198.51.100.20", http.StatusOK, ""},
+		{"probe", 0, "/healthz", "203.0.113.7", http.StatusOK, ""},
+		{"refilled a token", 30 * time.Second, "/cann", "203.0.113.7", http.StatusOK, ""},
+		{"empty again", 0, "/cann", "203.0.113.7", http.StatusTooManyRequests, "30"},
+	}
+
+	for _, step := range steps {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		fake.Advance(step.advance)
+
+		req := httptest.NewRequest(http.MethodGet, step.path, http.NoBody)
+		req.Header.Set("Accept", "application/json")
+
+		if step.client != "" {
+			req.Header.Set("Cf-Connecting-Ip", step.client)
+		}
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != step.wantStatus || w.Header().Get("Retry-After") != step.wantRetryAfter {
+			t.Errorf("%s: status = %d Retry-After = %q, want %d %q", step.name, w.Code, w.Header().Get("Retry-After"), step.wantStatus, step.wantRetryAfter)
+		}
+	}
+}
+
+func TestClientAddr(t *testing.T) {
+	trustedProxies := []netip.Prefix{netip.MustParsePrefix("192.0.2.0/24")}
+
+	tests := []struct {
+		remoteAddr string
+		header     string
+		want       string
+	}{
+		{"192.0.2.1:1234", "", "192.0.2.1"},
+		{"192.0.2.1:1234", "2001:db8::1", "2001:db8::1"},
+		{"192.0.2.1:1234", "not an address", "192.0.2.1"},
+		{"[::ffff:192.0.2.2]:1234", "", "192.0.2.2"},
+		{"[::ffff:192.0.2.2]:1234", "203.0.113.9", "203.0.113.9"},
+		{"203.0.113.7:1234", "198.51.100.20", "203.0.113.7"},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.RemoteAddr = test.remoteAddr
+
+		if test.header != "" {
+			req.Header.Set("Cf-Connecting-Ip", test.header)
+		}
+
+		if got := clientAddr(req, trustedProxies).String(); got != test.want {
+			t.Errorf("clientAddr() from %s and %q = %s, want %s", test.remoteAddr, test.header, got, test.want)
+		}
+	}
+}
+
+func TestRateLimiterBucketCap(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fake := clock.NewFake(time.Date(2024, 3, 2, 15, 0, 0, 0, time.UTC))
+	limiter := newRateLimiter(fake, quota{Limit: 2, Window: time.Minute}, nil, nil, nil)
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	for i := range maxBuckets + 100 {
+		fake.Advance(time.Millisecond) // a flood too busy for the idle buckets to be swept
+		limiter.take(strconv.Itoa(i), limiter.quota)
+	}
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if got := len(limiter.buckets); got > maxBuckets {
+		t.Errorf("buckets after a flood of %d clients = %d, want at most %d", maxBuckets+100, got, maxBuckets)
+	}
+
+	if _, ok := limiter.buckets[strconv.Itoa(maxBuckets+99)]; !ok {
+		t.Error("the latest client's bucket was evicted, want the least recently used evicted")
+	}
+}
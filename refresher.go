@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/events"
+	"github.com/mick4711/moh/fpl"
+)
+
+// the standings refreshed in the background, the competition /cann shows by default
+const refreshedCompetition = "PL"
+
+//go:embed events.js
+var eventsScript []byte
+
+// pushes events to the open Cann and FPL pages when the refresher sees their data change
+var eventsHub = events.New(events.DefaultHeartbeat)
+
+// an upstream source refreshed in the background, refresh returns the version of the fetched data
+type refreshSource struct {
+	event   string // event published when the version changes
+	refresh func(ctx context.Context) (string, error)
+}
+
+// refresh intervals, shorter on match days while results come in
+type refreshSchedule struct {
+	interval         time.Duration
+	matchDayInterval time.Duration
+	matchDay         func(ctx context.Context) (bool, error) // whether there is a match today
+}
+
+// the time until the next refresh, the normal interval when the match day check fails
+func (s refreshSchedule) next(ctx context.Context) time.Duration {
+	if s.matchDay == nil {
+		return s.interval
+	}
+
+	matchDay, err := s.matchDay(ctx)
+	if err != nil {
+		slog.Warn("match day check failed", "err", err)
+		return s.interval
+	}
+
+	if matchDay {
+		return s.matchDayInterval
+	}
+
+	return s.interval
+}
+
+// the longest time between refreshes, refreshed data is served for twice this so one failed refresh doesn't
+// send requests upstream
+func (s refreshSchedule) longest() time.Duration {
+	return max(s.interval, s.matchDayInterval)
+}
+
+// refreshes each source on the schedule and publishes an event when its data version changes
+type refresher struct {
+	hub      *events.Hub
+	schedule refreshSchedule
+	sources  []refreshSource
+	versions map[string]string // last version seen of each source by event
+}
+
+func newRefresher(hub *events.Hub, schedule refreshSchedule, sources []refreshSource) *refresher {
+	return &refresher{hub: hub, schedule: schedule, sources: sources, versions: make(map[string]string)}
+}
+
+// refresh until the context is cancelled, the first refresh only records the versions
+func (r *refresher) run(ctx context.Context) {
+	for {
+		r.refresh(ctx)
+
+		timer := time.NewTimer(r.schedule.next(ctx))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// refresh each source once, failures are logged and the source is tried again next time
+func (r *refresher) refresh(ctx context.Context) {
+	for _, source := range r.sources {
+		version, err := source.refresh(ctx)
+		if err != nil {
+			slog.Warn("background refresh failed", "source", source.event, "err", err)
+			continue
+		}
+
+		previous, seen := r.versions[source.event]
+		r.versions[source.event] = version
+
+		if seen && version != previous {
+			r.hub.Publish(events.Event{Type: source.event, Data: fmt.Sprintf(`{"dataVersion":%q}`, version)})
+		}
+	}
+}
+
+// the schedule from the configured intervals, by the Premier League fixtures
+func newRefreshSchedule(interval, matchDayInterval time.Duration) refreshSchedule {
+	return refreshSchedule{interval: interval, matchDayInterval: matchDayInterval, matchDay: func(ctx context.Context) (bool, error) {
+		return cann.MatchDay(ctx, refreshedCompetition)
+	}}
+}
+
+// the sources to refresh, FPL points only when managers are configured
+func refreshSources(managers string) []refreshSource {
+	sources := []refreshSource{{event: "standings", refresh: func(ctx context.Context) (string, error) {
+		return cann.Refresh(ctx, refreshedCompetition)
+	}}}
+
+	if managers != "" {
+		sources = append(sources, refreshSource{event: "fpl", refresh: func(ctx context.Context) (string, error) {
+			return fpl.Refresh(ctx)
+		}})
+	}
+
+	return sources
+}
+
+// streams the standings and fpl change events to the page, see events.js
+func eventsHandler(w http.ResponseWriter, req *http.Request) {
+	eventsHub.ServeHTTP(w, req)
+}
+
+// the script reloading a page when the data it shows changes, the Content-Security-Policy blocks inline scripts
+func eventsScriptHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", "max-age=3600")
+
+	if _, err := w.Write(eventsScript); err != nil {
+		slog.Error("error writing events script", "err", err)
+	}
+}
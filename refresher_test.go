@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/events"
+)
+
+func TestRefresherPublishesChanges(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	hub := events.New(0)
+
+	received, unsubscribe := hub.Subscribe()
+	defer unsubscribe()
+
+	standings := []string{"v1", "v1", "v2"}
+	fplVersions := []string{"a", "", "b"} // the second refresh fails
+
+	var refreshes int
+
+	r := newRefresher(hub, refreshSchedule{interval: time.Minute}, []refreshSource{
+		{event: "standings", refresh: func(context.Context) (string, error) { return standings[refreshes], nil }},
+		{event: "fpl", refresh: func(context.Context) (string, error) {
+			if fplVersions[refreshes] == "" {
+				return "", errors.New("unavailable")
+			}
+
+			return fplVersions[refreshes], nil
+		}},
+	})
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	for refreshes = range standings {
+		r.refresh(context.Background())
+	}
+
+	hub.Close()
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	var got []events.Event
+	for event := range received {
+		got = append(got, event)
+	}
+
+	want := []events.Event{{Type: "standings", Data: `{"dataVersion":"v2"}`}, {Type: "fpl", Data: `{"dataVersion":"b"}`}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("published %+v, want %+v", got, want)
+	}
+}
+
+func TestRefreshSources(t *testing.T) {
+	tests := []struct {
+		managers string
+		want     []string
+	}{
+		{"", []string{"standings"}},
+		{"1, 2", []string{"standings", "fpl"}},
+	}
+
+	for _, test := range tests {
+		var got []string
+		for _, source := range refreshSources(test.managers) {
+			got = append(got, source.event)
+		}
+
+		if strings.Join(got, ",") != strings.Join(test.want, ",") {
+			t.Errorf("refreshSources(%q) = %v, want %v", test.managers, got, test.want)
+		}
+	}
+}
+
+func TestEventsScriptHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	eventsScriptHandler(w, httptest.NewRequest(http.MethodGet, "/events.js", http.NoBody))
+
+	if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/javascript") || !strings.Contains(w.Body.String(), "EventSource") {
+		t.Errorf("eventsScriptHandler() Content-Type = %q body = %q, want the events script", contentType, w.Body)
+	}
+}
+
+func TestRefreshScheduleNext(t *testing.T) {
+	matchDay := func(today bool, err error) func(context.Context) (bool, error) {
+		return func(context.Context) (bool, error) { return today, err }
+	}
+
+	tests := []struct {
+		name     string
+		matchDay func(context.Context) (bool, error)
+		want     time.Duration
+	}{
+		{"no match day check", nil, 10 * time.Minute},
+		{"no match today", matchDay(false, nil), 10 * time.Minute},
+		{"match day", matchDay(true, nil), time.Minute},
+		{"check failed", matchDay(true, errors.New("unavailable")), 10 * time.Minute},
+	}
+
+	for _, test := range tests {
+		schedule := refreshSchedule{interval: 10 * time.Minute, matchDayInterval: time.Minute, matchDay: test.matchDay}
+
+		if got := schedule.next(context.Background()); got != test.want {
+			t.Errorf("%s: next() = %s, want %s", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// the top scorers of a football-data.org competition with their assists and penalties, fetched through the
+// scorers cache of the cann package
+package scorers
+
+import (
+	"bytes"
+	"cmp"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/conditional"
+	"github.com/mick4711/moh/errorpage"
+	"github.com/mick4711/moh/negotiate"
+)
+
+// scorers requested from football-data.org, it returns 10 by default
+const upstreamLimit = 20
+
+// A Scorer is a player in the top scorers table
+type Scorer struct {
+	Rank          int     `json:"rank"` // by goals, as football-data.org orders them
+	Player        string  `json:"player"`
+	Nationality   string  `json:"nationality,omitempty"`
+	Team          string  `json:"team"`
+	TLA           string  `json:"tla"`
+	PlayedMatches int     `json:"playedMatches"`
+	Goals         int     `json:"goals"`
+	Assists       int     `json:"assists"`
+	Penalties     int     `json:"penalties"` // goals scored from penalties
+	GoalsPerMatch float64 `json:"goalsPerMatch"`
+}
+
+// the football-data.org scorers response, assists and penalties are null when none are recorded and decode as 0
+type scorersResponse struct {
+	Scorers []struct {
+		Player struct {
+			Name        string `json:"name"`
+			Nationality string `json:"nationality"`
+		} `json:"player"`
+		Team struct {
+			ShortName string `json:"shortName"`
+			TLA       string `json:"tla"`
+		} `json:"team"`
+		PlayedMatches int `json:"playedMatches"`
+		Goals         int `json:"goals"`
+		Assists       int `json:"assists"`
+		Penalties     int `json:"penalties"`
+	} `json:"scorers"`
+}
+
+// data passed to the scorers template
+type scorersPage struct {
+	Competition string   `json:"competition"`
+	Code        string   `json:"-"`
+	Sort        string   `json:"sort"`
+	Scorers     []Scorer `json:"scorers"`
+}
+
+// the columns of ?sort=, highest first except name, ties keep the goals order
+var sorts = map[string]func(a, b Scorer) int{
+	"goals":         func(a, b Scorer) int { return cmp.Compare(b.Goals, a.Goals) },
+	"assists":       func(a, b Scorer) int { return cmp.Compare(b.Assists, a.Assists) },
+	"penalties":     func(a, b Scorer) int { return cmp.Compare(b.Penalties, a.Penalties) },
+	"goalsPerMatch": func(a, b Scorer) int { return cmp.Compare(b.GoalsPerMatch, a.GoalsPerMatch) },
+	"name":          func(a, b Scorer) int { return strings.Compare(a.Player, b.Player) },
+}
+
+const defaultSort = "goals"
+
+//go:embed ScorersTemplate.html
+var templateFS embed.FS
+
+var scorersTemplate = template.Must(template.ParseFS(templateFS, "ScorersTemplate.html"))
+
+// Scorers writes the competition's top scorers as html, or json for ?format=json. ?sort= orders them by goals
+// (default), assists, penalties, goalsPerMatch or name
+func Scorers(w http.ResponseWriter, req *http.Request) {
+	code, name, err := cann.Competition(req)
+	if err != nil {
+		errorpage.Write(w, req, http.StatusBadRequest, err)
+		return
+	}
+
+	page := scorersPage{Competition: name, Code: code, Sort: req.URL.Query().Get("sort")}
+	if page.Sort == "" {
+		page.Sort = defaultSort
+	}
+
+	sort, ok := sorts[page.Sort]
+	if !ok {
+		errorpage.Write(w, req, http.StatusBadRequest,
+			fmt.Errorf("invalid sort %q, must be one of %s", page.Sort, strings.Join(sortNames(), ", ")))
+
+		return
+	}
+
+	body, err := cann.Get(req.Context(), "scorers", fmt.Sprintf("/competitions/%s/scorers?limit=%d", code, upstreamLimit))
+	if err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	if page.Scorers, err = parseScorers(body); err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, err)
+		return
+	}
+
+	conditional.SetDataVersion(w.Header(), body)
+
+	slices.SortStableFunc(page.Scorers, sort)
+
+	if negotiate.Format(w, req, negotiate.HTML, negotiate.JSON) == negotiate.JSON {
+		w.Header().Set("Content-Type", "application/json")
+
+		if err := json.NewEncoder(w).Encode(page); err != nil {
+			slog.WarnContext(req.Context(), "error writing scorers json", "err", err)
+		}
+
+		return
+	}
+
+	// buffered so a template error is still a clean error page
+	var html bytes.Buffer
+	if err := scorersTemplate.Execute(&html, page); err != nil {
+		errorpage.Write(w, req, http.StatusInternalServerError, fmt.Errorf("error executing scorers template: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if _, err := html.WriteTo(w); err != nil {
+		slog.WarnContext(req.Context(), "error writing scorers page", "err", err)
+	}
+}
+
+// the scorers in the response, ranked in the order football-data.org returns them
+func parseScorers(body []byte) ([]Scorer, error) {
+	var response scorersResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error unmarshalling json from scorers response: %w", err)
+	}
+
+	scorers := make([]Scorer, 0, len(response.Scorers))
+
+	for i, s := range response.Scorers {
+		scorer := Scorer{Rank: i + 1, Player: s.Player.Name, Nationality: s.Player.Nationality, Team: s.Team.ShortName,
+			TLA: s.Team.TLA, PlayedMatches: s.PlayedMatches, Goals: s.Goals, Assists: s.Assists, Penalties: s.Penalties}
+
+		if s.PlayedMatches > 0 {
+			scorer.GoalsPerMatch = float64(s.Goals) / float64(s.PlayedMatches)
+		}
+
+		scorers = append(scorers, scorer)
+	}
+
+	return scorers, nil
+}
+
+// the ?sort= columns in alphabetical order
+func sortNames() []string {
+	names := make([]string, 0, len(sorts))
+	for name := range sorts {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
@@ -0,0 +1,83 @@
+package scorers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mick4711/moh/cann"
+	"github.com/mick4711/moh/conditional"
+)
+
+const scorersJSON = `{"scorers": [
+	{"player": {"name": "Erling Haaland", "nationality": "Norway"}, "team": {"shortName": "Man City", "tla": "MCI"},
+		"playedMatches": 10, "goals": 12, "assists": 1, "penalties": 3},
+	{"player": {"name": "Mohamed Salah", "nationality": "Egypt"}, "team": {"shortName": "Liverpool", "tla": "LIV"},
+		"playedMatches": 10, "goals": 8, "assists": 6, "penalties": 2},
+	{"player": {"name": "Cole Palmer", "nationality": "England"}, "team": {"shortName": "Chelsea", "tla": "CHE"},
+		"playedMatches": 4, "goals": 6, "assists": null, "penalties": null}
+]}`
+
+func TestScorers(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	var paths []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.RequestURI())
+		_, _ = w.Write([]byte(scorersJSON)) //nolint:errcheck // test server
+	}))
+	defer ts.Close()
+
+	cann.Configure(cann.Settings{BaseURL: ts.URL, APIToken: "test-token", TTL: time.Minute, UpstreamTimeout: time.Second})
+
+	tests := []struct {
+		url        string
+		wantStatus int
+		wantBody   []string // in order
+	}{
+		{"/scorers", http.StatusOK, []string{"<h1> Premier League top scorers </h1>", "Erling Haaland", "Mohamed Salah", "Cole Palmer"}},
+		{"/scorers?sort=assists", http.StatusOK, []string{"Mohamed Salah", "Erling Haaland", "Cole Palmer"}},
+		{"/scorers?sort=goalsPerMatch", http.StatusOK, []string{"Cole Palmer", "<td>1.50</td>", "Erling Haaland", "Mohamed Salah"}},
+		{"/scorers?sort=name", http.StatusOK, []string{"Cole Palmer", "Erling Haaland", "Mohamed Salah"}},
+		{"/scorers?format=json", http.StatusOK, []string{`"competition":"Premier League","sort":"goals"`,
+			`{"rank":1,"player":"Erling Haaland","nationality":"Norway","team":"Man City","tla":"MCI","playedMatches":10,"goals":12,"assists":1,"penalties":3,"goalsPerMatch":1.2}`,
+			`"assists":0,"penalties":0`}},
+		{"/scorers?sort=minutes", http.StatusBadRequest, []string{"invalid sort"}},
+		{"/scorers?comp=XX", http.StatusBadRequest, nil},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, test.url, http.NoBody)
+
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		Scorers(w, req)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if w.Code != test.wantStatus {
+			t.Errorf("GET %s status = %d body = %s, want %d", test.url, w.Code, w.Body, test.wantStatus)
+			continue
+		}
+
+		if version := w.Header().Get(conditional.DataVersionHeader); w.Code == http.StatusOK && version != conditional.DataVersion([]byte(scorersJSON)) {
+			t.Errorf("GET %s %s = %q, want the version of the upstream scorers", test.url, conditional.DataVersionHeader, version)
+		}
+
+		body := w.Body.String()
+		for _, want := range test.wantBody {
+			i := strings.Index(body, want)
+			if i < 0 {
+				t.Errorf("GET %s body = %s, want %s after the earlier expectations", test.url, w.Body, want)
+				break
+			}
+
+			body = body[i+len(want):]
+		}
+	}
+
+	if want := []string{"/competitions/PL/scorers?limit=20"}; len(paths) != 1 || paths[0] != want[0] {
+		t.Errorf("upstream requests = %v, want %v cached for every sort", paths, want)
+	}
+}
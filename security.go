@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// security headers set on html responses, frame blocking is left off the embeddable routes
+type securityHeaders struct {
+	policy     string   // Content-Security-Policy, frame-ancestors 'none' is appended for routes that aren't embeddable
+	embeddable []string // url paths that may be framed by other sites
+}
+
+func newSecurityHeaders(policy string, embeddable []string) *securityHeaders {
+	return &securityHeaders{policy: policy, embeddable: embeddable}
+}
+
+func (s *securityHeaders) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(&securityWriter{ResponseWriter: w, headers: s, embeddable: slices.Contains(s.embeddable, req.URL.Path)}, req)
+	})
+}
+
+// set the headers on h for an html response
+func (s *securityHeaders) apply(h http.Header, embeddable bool) {
+	policy := s.policy
+
+	if !embeddable {
+		h.Set("X-Frame-Options", "DENY")
+
+		policy = strings.TrimSuffix(strings.TrimSpace(policy), ";") + "; frame-ancestors 'none'"
+	}
+
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+
+	if policy = strings.TrimPrefix(policy, "; "); policy != "" {
+		h.Set("Content-Security-Policy", policy)
+	}
+}
+
+// adds the security headers when the response turns out to be html, the content type is only known
+// once the handler writes, and is sniffed from the body like net/http does when the handler doesn't set it
+type securityWriter struct {
+	http.ResponseWriter
+	headers     *securityHeaders
+	embeddable  bool
+	wroteHeader bool
+}
+
+func (w *securityWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		w.addIfHTML()
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *securityWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(b) //nolint:wrapcheck // pass through
+}
+
+func (w *securityWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *securityWriter) addIfHTML() {
+	if strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		w.headers.apply(w.Header(), w.embeddable)
+	}
+}
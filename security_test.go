@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mick4711/moh/config"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, "<!DOCTYPE html><html><body>page</body></html>") // content type sniffed
+	})
+	mux.HandleFunc("/cann", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html>embeddable</html>")
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"}) //nolint:errcheck // test handler
+	})
+
+	handler := newSecurityHeaders("default-src 'self'", []string{"/cann"}).middleware(mux)
+
+	tests := []struct {
+		path    string
+		want    map[string]string
+		notWant []string
+	}{
+		{"/page", map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "DENY",
+			"Referrer-Policy":         "strict-origin-when-cross-origin",
+			"Content-Security-Policy": "default-src 'self'; frame-ancestors 'none'",
+		}, nil},
+		{"/cann", map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"Content-Security-Policy": "default-src 'self'",
+		}, []string{"X-Frame-Options"}},
+		{"/json", nil, []string{"X-Frame-Options", "Content-Security-Policy", "X-Content-Type-Options"}},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, http.NoBody))
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		for header, want := range test.want {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s %s = %q, want %q", test.path, header, got, want)
+			}
+		}
+
+		for _, header := range test.notWant {
+			if got := w.Header().Get(header); got != "" {
+				t.Errorf("%s %s = %q, want it unset", test.path, header, got)
+			}
+		}
+	}
+}
+
+func TestSecurityHeadersConfigurablePolicy(t *testing.T) {
+	t.Setenv("CONTENT_SECURITY_POLICY", "default-src 'none'; style-src 'unsafe-inline';")
+
+	cfg := config.Load()
+	handler := newSecurityHeaders(cfg.ContentSecurityPolicy, cfg.EmbeddableRoutes).middleware(http.HandlerFunc(homeHandler))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+
+	if got, want := w.Header().Get("Content-Security-Policy"), "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'"; got != want {
+		t.Errorf("home page Content-Security-Policy = %q, want %q", got, want)
+	}
+}
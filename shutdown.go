@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+// serve on the listener until a signal arrives on stop, then stop accepting connections and let in-flight
+// requests finish within the timeout. HTTPS is served when srv has a TLS config. Returns nil after a clean shutdown
+func serve(srv *http.Server, listener net.Listener, stop <-chan os.Signal, timeout time.Duration) error {
+	served := make(chan error, 1)
+
+	go func() {
+		if srv.TLSConfig != nil {
+			served <- srv.ServeTLS(listener, "", "") // the certificate comes from the TLS config
+			return
+		}
+
+		served <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case sig := <-stop:
+		slog.Info("shutting down, waiting for in-flight requests", "signal", sig.String(), "timeout", timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		return fmt.Errorf("error shutting down: %w", err)
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("shutdown complete")
+
+	return nil
+}
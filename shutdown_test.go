@@ -0,0 +1,64 @@
+package main
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestServeDrainsInFlightRequests(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan struct{})
+	srv := &http.Server{ReadHeaderTimeout: time.Second, Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond) // e.g. an upstream fetch
+
+		_, _ = io.WriteString(w, "done") //nolint:errcheck // test server
+	})}
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+
+	go func() { served <- serve(srv, listener, stop, time.Second) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+
+	responses := make(chan result, 1)
+
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responses <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		responses <- result{body: string(body), err: err}
+	}()
+
+	<-started
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	stop <- os.Interrupt
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if got := <-responses; got.err != nil || got.body != "done" {
+		t.Errorf("in-flight request = %q, %v, want it completed during shutdown", got.body, got.err)
+	}
+
+	if err := <-served; err != nil {
+		t.Errorf("serve() = %v, want nil after a clean shutdown", err)
+	}
+}
@@ -0,0 +1,145 @@
+// flat json file store of upstream response snapshots, one file per key and day e.g. PL/2024-03-02.json.
+// A later snapshot on the same day replaces the earlier one, so each day keeps its final standings
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when there is no snapshot on or before the requested day
+var ErrNotFound = errors.New("no snapshot found")
+
+// A Store keeps snapshots under a directory, safe for concurrent use as each save is an atomic rename
+type Store struct {
+	dir string
+}
+
+// New returns a store writing under dir, created on the first save
+func New(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save writes the body as the snapshot of key for the day of at, in UTC, with at as its modification time
+func (s *Store) Save(key string, at time.Time, body []byte) error {
+	dir := filepath.Join(s.dir, key)
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // snapshots aren't secret
+		return fmt.Errorf("error creating snapshot directory: %w", err)
+	}
+
+	temp, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return fmt.Errorf("error creating snapshot: %w", err)
+	}
+	defer os.Remove(temp.Name()) // fails harmlessly once renamed
+
+	if _, err := temp.Write(body); err != nil {
+		temp.Close()
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+
+	if err := os.Chtimes(temp.Name(), at, at); err != nil {
+		return fmt.Errorf("error writing snapshot: %w", err)
+	}
+
+	if err := os.Rename(temp.Name(), filepath.Join(dir, day(at)+".json")); err != nil {
+		return fmt.Errorf("error saving snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Days lists the days with a snapshot of key, oldest first
+func (s *Store) Days(key string) ([]time.Time, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots: %w", err)
+	}
+
+	var days []time.Time
+
+	for _, entry := range entries {
+		if d, err := time.Parse(time.DateOnly, strings.TrimSuffix(entry.Name(), ".json")); err == nil && !entry.IsDir() {
+			days = append(days, d)
+		}
+	}
+
+	slices.SortFunc(days, time.Time.Compare)
+
+	return days, nil
+}
+
+// OnOrBefore returns the latest snapshot of key taken on or before the day of at, and its day
+func (s *Store) OnOrBefore(key string, at time.Time) ([]byte, time.Time, error) {
+	days, err := s.Days(key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	for i := len(days) - 1; i >= 0; i-- {
+		if day(days[i]) <= day(at) {
+			body, err := s.Load(key, days[i])
+			return body, days[i], err
+		}
+	}
+
+	return nil, time.Time{}, ErrNotFound
+}
+
+// Latest returns the most recent snapshot of key and when it was saved
+func (s *Store) Latest(key string) ([]byte, time.Time, error) {
+	days, err := s.Days(key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if len(days) == 0 {
+		return nil, time.Time{}, ErrNotFound
+	}
+
+	file := filepath.Join(s.dir, key, day(days[len(days)-1])+".json")
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	body, err := os.ReadFile(file)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	return body, info.ModTime(), nil
+}
+
+// Load returns the snapshot of key for a day
+func (s *Store) Load(key string, d time.Time) ([]byte, error) {
+	body, err := os.ReadFile(filepath.Join(s.dir, key, day(d)+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	return body, nil
+}
+
+// the snapshot file name for a time e.g. 2024-03-02
+func day(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
@@ -0,0 +1,78 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestOnOrBefore(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	store := New(t.TempDir())
+
+	saves := []struct {
+		at   time.Time
+		body string
+	}{
+		{time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC), "early on the 2nd"},
+		{time.Date(2024, 3, 2, 18, 0, 0, 0, time.UTC), "late on the 2nd"},
+		{time.Date(2024, 3, 9, 18, 0, 0, 0, time.UTC), "the 9th"},
+	}
+
+	for _, save := range saves {
+		if err := store.Save("PL", save.at, []byte(save.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		at      time.Time
+		want    string
+		wantDay string
+		wantErr error
+	}{
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), "", "", ErrNotFound},
+		{time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), "late on the 2nd", "2024-03-02", nil},
+		{time.Date(2024, 3, 8, 23, 0, 0, 0, time.UTC), "late on the 2nd", "2024-03-02", nil},
+		{time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), "the 9th", "2024-03-09", nil},
+	}
+
+	for _, test := range tests {
+		// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+		body, day, err := store.OnOrBefore("PL", test.at)
+
+		// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+		if !errors.Is(err, test.wantErr) || string(body) != test.want || (err == nil && day.Format(time.DateOnly) != test.wantDay) {
+			t.Errorf("OnOrBefore(%s) = %q, %s, %v, want %q, %s, %v", test.at, body, day, err, test.want, test.wantDay, test.wantErr)
+		}
+	}
+
+	if days, err := store.Days("BL1"); err != nil || len(days) != 0 {
+		t.Errorf("Days() without snapshots = %v, %v, want none", days, err)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	store := New(t.TempDir())
+
+	if _, _, err := store.Latest("PL"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Latest() without snapshots err = %v, want %v", err, ErrNotFound)
+	}
+
+	saved := time.Date(2024, 3, 9, 18, 30, 0, 0, time.UTC)
+
+	for at, body := range map[time.Time]string{saved.AddDate(0, 0, -7): "the 2nd", saved: "the 9th"} {
+		if err := store.Save("PL", at, []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	body, at, err := store.Latest("PL")
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || string(body) != "the 9th" || !at.Equal(saved) {
+		t.Errorf("Latest() = %q, %s, %v, want the 9th saved at %s", body, at, err, saved)
+	}
+}
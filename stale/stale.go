@@ -0,0 +1,48 @@
+// describes data served from an earlier fetch after a live update from the upstream failed, for the page banners
+// and the Warning header of the responses
+package stale
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Warning header value of a response served from an earlier fetch, RFC 7234 110 Response is Stale
+const Warning = `110 - "Response is Stale"`
+
+// Data describes stale data in a response
+type Data struct {
+	Fetched string `json:"fetched"` // RFC 3339 time the data was fetched
+	Age     string `json:"age"`     // how long before the response it was fetched e.g. 3h
+	Note    string `json:"note"`    // the banner e.g. data from 3h ago — live update failed
+
+	age time.Duration
+}
+
+// New describes data fetched at fetched and served at now
+func New(fetched, now time.Time) *Data {
+	age := now.Sub(fetched)
+
+	return &Data{Fetched: fetched.UTC().Format(time.RFC3339), Age: Age(age), Note: fmt.Sprintf("Data from %s ago — live update failed", Age(age)),
+		age: age}
+}
+
+// Age is a duration rounded down to whole minutes, hours within two days or else days e.g. 45m, 3h, 2d, at least 1m
+func Age(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", max(int(d/time.Minute), 1))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	default:
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+}
+
+// Mark sets the Warning header and the Age header in seconds on a response with the data
+func (d *Data) Mark(h http.Header) {
+	h.Set("Warning", Warning)
+	h.Set("Age", strconv.Itoa(max(int(d.age/time.Second), 0)))
+}
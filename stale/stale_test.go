@@ -0,0 +1,47 @@
+package stale
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{10 * time.Second, "1m"},
+		{45*time.Minute + 30*time.Second, "45m"},
+		{3*time.Hour + 59*time.Minute, "3h"},
+		{47 * time.Hour, "47h"},
+		{50 * time.Hour, "2d"},
+	}
+
+	for _, test := range tests {
+		if got := Age(test.age); got != test.want {
+			t.Errorf("Age(%s) = %q, want %q", test.age, got, test.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	fetched := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	now := fetched.Add(3*time.Hour + 20*time.Minute)
+	header := http.Header{}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	got := New(fetched, now)
+	got.Mark(header)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	want := Data{Fetched: "2024-03-02T12:00:00Z", Age: "3h", Note: "Data from 3h ago — live update failed", age: 200 * time.Minute}
+	if *got != want {
+		t.Errorf("New() = %+v, want %+v", *got, want)
+	}
+
+	if header.Get("Warning") != Warning || header.Get("Age") != "12000" {
+		t.Errorf("Mark() headers = %v, want Warning %s and Age 12000", header, Warning)
+	}
+}
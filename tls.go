@@ -0,0 +1,130 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a certificate served from PEM files renewed in place, e.g. by certbot, reloaded when either file changes
+type certificate struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // the later modification time of the files when last loaded
+}
+
+// the certificate from the files, an error when they can't be loaded at startup
+func newCertificate(certFile, keyFile string) (*certificate, error) {
+	c := &certificate{certFile: certFile, keyFile: keyFile}
+	if _, err := c.get(nil); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// the certificate for a handshake, reloaded when the files have changed since it was loaded. A failed reload, e.g. a
+// renewal half written, keeps serving the previous certificate until the files change again
+func (c *certificate) get(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	modTime, err := latestModTime(c.certFile, c.keyFile)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err == nil && modTime.Equal(c.modTime) {
+		return c.cert, nil
+	}
+
+	if err == nil {
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(c.certFile, c.keyFile); err == nil {
+			c.cert, c.modTime = &cert, modTime
+			slog.Info("tls certificate loaded", "file", c.certFile)
+
+			return c.cert, nil
+		}
+
+		c.modTime = modTime
+	}
+
+	if c.cert == nil {
+		return nil, fmt.Errorf("error loading tls certificate: %w", err)
+	}
+
+	slog.Warn("error reloading tls certificate, serving the previous one", "file", c.certFile, "err", err)
+
+	return c.cert, nil
+}
+
+// the later modification time of the files
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err //nolint:wrapcheck // the error names the file
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// HTTPS settings, HTTP/2 is negotiated by the server when the TLS config is set
+func tlsConfig(cert *certificate) *tls.Config {
+	return &tls.Config{MinVersion: tls.VersionTLS12, GetCertificate: cert.get}
+}
+
+// redirects plain HTTP requests to the same url over HTTPS, on the port of the HTTPS address unless it is 443
+func redirectToHTTPS(httpsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddr) //nolint:errcheck // an address without a port redirects to 443
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		host := req.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(strings.Trim(host, "[]"), port)
+		}
+
+		target := url.URL{Scheme: "https", Host: host, Path: req.URL.Path, RawQuery: req.URL.RawQuery}
+		http.Redirect(w, req, target.String(), http.StatusPermanentRedirect)
+	})
+}
+
+// listen on the redirect address and redirect to srv's HTTPS address until srv shuts down
+func serveRedirects(srv *http.Server, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error listening for https redirects: %w", err)
+	}
+
+	redirects := &http.Server{Handler: redirectToHTTPS(srv.Addr), ReadTimeout: srv.ReadTimeout, WriteTimeout: srv.WriteTimeout}
+	srv.RegisterOnShutdown(func() { _ = redirects.Close() }) //nolint:errcheck // closing on the way out
+
+	slog.Info("redirecting to https", "addr", listener.Addr().String())
+
+	go func() {
+		if err := redirects.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("error serving https redirects", "err", err)
+		}
+	}()
+
+	return nil
+}
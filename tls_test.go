@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// write a self-signed certificate for localhost and its key as PEM files
+func writeCertificate(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for file, block := range map[string]*pem.Block{certFile: {Type: "CERTIFICATE", Bytes: der}, keyFile: {Type: "EC PRIVATE KEY", Bytes: keyDER}} {
+		if err := os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// the common name of the certificate served
+func commonName(t *testing.T, cert *certificate) string {
+	t.Helper()
+
+	served, err := cert.get(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leaf, err := x509.ParseCertificate(served.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.Subject.CommonName
+}
+
+func TestCertificateReload(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	issued := time.Now().Add(-time.Hour)
+
+	if _, err := newCertificate(certFile, keyFile); err == nil {
+		t.Error("newCertificate() without the files succeeded, want an error")
+	}
+
+	writeCertificate(t, certFile, keyFile, "first", issued)
+
+	cert, err := newCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	first := commonName(t, cert)
+
+	writeCertificate(t, certFile, keyFile, "renewed", issued.Add(time.Minute))
+	renewed := commonName(t, cert)
+
+	if err := os.WriteFile(keyFile, []byte("half written"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	broken := commonName(t, cert)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if first != "first" || renewed != "renewed" || broken != "renewed" {
+		t.Errorf("certificates served = %q, %q, %q, want first, the renewal and the renewal kept after a broken renewal",
+			first, renewed, broken)
+	}
+}
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		httpsAddr string
+		url       string
+		want      string
+	}{
+		{":443", "http://moh.example/cann?comp=SA", "https://moh.example/cann?comp=SA"},
+		{":8443", "http://moh.example:8080/fpl", "https://moh.example:8443/fpl"},
+		{":8443", "http://[::1]:8080/", "https://[::1]:8443/"},
+		{"", "http://moh.example/", "https://moh.example/"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		redirectToHTTPS(test.httpsAddr).ServeHTTP(w, httptest.NewRequest(http.MethodPost, test.url, http.NoBody))
+
+		if w.Code != http.StatusPermanentRedirect || w.Header().Get("Location") != test.want {
+			t.Errorf("redirectToHTTPS(%q) %s = %d %q, want %d %q", test.httpsAddr, test.url, w.Code, w.Header().Get("Location"),
+				http.StatusPermanentRedirect, test.want)
+		}
+	}
+}
+
+func TestServeTLS(t *testing.T) {
+	// ARRANGE ///////////////////////////////////////////////////////////////////////////////////
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCertificate(t, certFile, keyFile, "moh", time.Now())
+
+	cert, err := newCertificate(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{ReadHeaderTimeout: time.Second, TLSConfig: tlsConfig(cert), Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, req.Proto) //nolint:errcheck // test server
+	})}
+
+	stop := make(chan os.Signal, 1)
+	served := make(chan error, 1)
+
+	go func() { served <- serve(srv, listener, stop, time.Second) }()
+
+	client := &http.Client{Transport: &http.Transport{ForceAttemptHTTP2: true,
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}} //nolint:gosec // the test certificate is self-signed
+
+	// ACT //////////////////////////////////////////////////////////////////////////////////////////////
+	resp, err := client.Get("https://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+
+	// ASSERT ///////////////////////////////////////////////////////////////////////////////////////////
+	if err != nil || string(body) != "HTTP/2.0" {
+		t.Errorf("https request served as %q, %v, want HTTP/2.0", body, err)
+	}
+
+	stop <- os.Interrupt
+
+	if err := <-served; err != nil {
+		t.Errorf("serve() = %v, want a clean shutdown", err)
+	}
+}